@@ -27,6 +27,7 @@ import (
 	cascfg "github.com/uber/jaeger/pkg/cassandra/config"
 	escfg "github.com/uber/jaeger/pkg/es/config"
 	influx "github.com/uber/jaeger/pkg/influxdb/config"
+	kafkacfg "github.com/uber/jaeger/pkg/kafka/config"
 	"github.com/uber/jaeger/storage/spanstore/memory"
 )
 
@@ -44,6 +45,8 @@ type BasicOptions struct {
 	ElasticSearch *escfg.Configuration
 
 	InfluxDB *influx.Configuration
+	// Kafka is the kafka configuration used by the collector to publish spans (if applicable)
+	Kafka *kafkacfg.Configuration
 }
 
 // Option is a function that sets some option on StorageBuilder.
@@ -93,6 +96,13 @@ func (BasicOptions) ElasticSearchOption(elastic *escfg.Configuration) Option {
 	}
 }
 
+// KafkaOption creates an Option that adds Kafka configuration.
+func (BasicOptions) KafkaOption(kafka *kafkacfg.Configuration) Option {
+	return func(b *BasicOptions) {
+		b.Kafka = kafka
+	}
+}
+
 // ApplyOptions takes a set of options and creates a populated BasicOptions struct
 func ApplyOptions(opts ...Option) BasicOptions {
 	o := BasicOptions{}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/uber/jaeger-lib/metrics"
 	escfg "github.com/uber/jaeger/pkg/es/config"
+	kafkacfg "github.com/uber/jaeger/pkg/kafka/config"
 	"github.com/uber/jaeger/storage/spanstore/memory"
 )
 
@@ -40,9 +41,13 @@ func TestApplyOptions(t *testing.T) {
 		Options.ElasticSearchOption(&escfg.Configuration{
 			Servers: []string{"127.0.0.1"},
 		}),
+		Options.KafkaOption(&kafkacfg.Configuration{
+			Brokers: []string{"127.0.0.1:9092"},
+		}),
 	)
 	assert.NotNil(t, opts.ElasticSearch)
 	assert.NotNil(t, opts.ElasticSearch.Servers)
+	assert.NotNil(t, opts.Kafka)
 	assert.NotNil(t, opts.Logger)
 	assert.NotNil(t, opts.MetricsFactory)
 }
@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package baggage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/tchannel-go/thrift"
+
+	"github.com/uber/jaeger/thrift-gen/baggage"
+)
+
+type mockRestrictionStore struct {
+	restrictions []*baggage.BaggageRestriction
+	err          error
+}
+
+func (s *mockRestrictionStore) GetBaggageRestrictions(serviceName string) ([]*baggage.BaggageRestriction, error) {
+	return s.restrictions, s.err
+}
+
+func TestGetBaggageRestrictions(t *testing.T) {
+	restrictions := []*baggage.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 10}}
+	handler := NewHandler(&mockRestrictionStore{restrictions: restrictions})
+	ctx, cancel := thrift.NewContext(time.Second)
+	defer cancel()
+	resp, err := handler.GetBaggageRestrictions(ctx, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, restrictions, resp)
+
+	handler = NewHandler(&mockRestrictionStore{err: errors.New("no restrictions")})
+	_, err = handler.GetBaggageRestrictions(ctx, "foo")
+	assert.EqualError(t, err, "no restrictions")
+}
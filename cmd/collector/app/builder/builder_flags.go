@@ -25,13 +25,32 @@ import (
 	"time"
 
 	"github.com/uber/jaeger/cmd/collector/app"
+	"github.com/uber/jaeger/plugin/sampling/strategystore/adaptive"
 )
 
+var adaptiveDefaults = adaptive.DefaultOptions()
+
 var (
 	// QueueSize is the size of collector's queue
 	QueueSize = flag.Int("collector.queue-size", app.DefaultQueueSize, "The queue size of the collector")
+	// QueueMaxBytes is the memory budget of the collector's queue
+	QueueMaxBytes = flag.Int64("collector.queue-max-bytes", 0, "The maximum memory in bytes used by the spans in the collector's queue, the queue size adapts to the size of the spans; 0 bounds the queue by collector.queue-size only")
+	// ReportBusy denotes whether clients are told to back off when the queue is full
+	ReportBusy = flag.Bool("collector.report-busy", false, "Whether to reject batches with a busy error, or HTTP 429, when the collector's queue is full rather than silently dropping spans")
 	// NumWorkers is the number of internal workers in a collector
 	NumWorkers = flag.Int("collector.num-workers", app.DefaultNumWorkers, "The number of workers pulling items from the queue")
+	// MaxRetries is the number of times a span that failed to be saved is retried
+	MaxRetries = flag.Int("collector.max-retries", 3, "The number of times a span that failed to be saved is retried before it is spilled to disk or discarded")
+	// RetryBackoff is the delay before the first retry of a span that failed to be saved
+	RetryBackoff = flag.Duration("collector.retry-backoff", app.DefaultRetryBackoff, "The delay before the first retry of a span that failed to be saved, doubled with every retry")
+	// MaxRetryBackoff is the upper bound of the delay between retries
+	MaxRetryBackoff = flag.Duration("collector.max-retry-backoff", app.DefaultMaxRetryBackoff, "The maximum delay between retries of a span that failed to be saved")
+	// MaxReplayAttempts is the number of times a spilled span is replayed before it is discarded
+	MaxReplayAttempts = flag.Int("collector.max-replay-attempts", app.DefaultMaxReplayAttempts, "The number of times a span spilled to disk is replayed before it is discarded")
+	// SpillDirectory is the directory of the on-disk queue the spans that failed to be saved are spilled to
+	SpillDirectory = flag.String("collector.spill-dir", "", "The directory spans that failed to be saved are spilled to and replayed from once the storage recovers, spilling is disabled if empty")
+	// SpillMaxBytes is the maximum disk usage of the spilled spans
+	SpillMaxBytes = flag.Int64("collector.spill-max-bytes", 1<<30, "The maximum disk usage in bytes of the spilled spans, 0 means no limit")
 	// WriteCacheTTL denotes how often to check and re-write a service or operation name
 	WriteCacheTTL = flag.Duration("collector.write-cache-ttl", time.Hour*12, "The duration to wait before rewriting an existing service or operation name")
 	// CollectorPort is the port that the collector service listens in on for tchannel requests
@@ -40,4 +59,26 @@ var (
 	CollectorHTTPPort = flag.Int("collector.http-port", 14268, "The http port for the collector service")
 	// CollectorZipkinHTTPPort is the port that the Zipkin collector service listens in on for http requests
 	CollectorZipkinHTTPPort = flag.Int("collector.zipkin.http-port", 0, "The http port for the Zipkin collector service e.g. 9411")
+	// SamplingStrategiesFile is the path to the JSON file with the sampling strategies served to clients
+	SamplingStrategiesFile = flag.String("sampling.strategies-file", "", "The path for the sampling strategies file in JSON format")
+	// SamplingStrategiesReloadInterval denotes how often the sampling strategies file is checked for changes
+	SamplingStrategiesReloadInterval = flag.Duration("sampling.strategies-reload-interval", time.Minute, "How often to check the sampling strategies file for changes, 0 disables reloading")
+	// SamplingStrategyStoreType is the type of the sampling strategy store, static or adaptive
+	SamplingStrategyStoreType = flag.String("sampling.strategy-store", staticStrategyStoreType, "The type of sampling strategy store, static or adaptive (requires cassandra or memory span storage)")
+	// SamplingTargetTracesPerSecond is the number of traces per second adaptive sampling aims for per operation
+	SamplingTargetTracesPerSecond = flag.Float64("sampling.target-traces-per-second", adaptiveDefaults.TargetTracesPerSecond, "The number of traces per second adaptive sampling aims to sample for each operation")
+	// SamplingCalculationInterval denotes how often adaptive sampling flushes throughput and recalculates probabilities
+	SamplingCalculationInterval = flag.Duration("sampling.calculation-interval", adaptiveDefaults.CalculationInterval, "How often adaptive sampling flushes throughput and recalculates the sampling probabilities")
+	// SamplingAggregationBuckets is the number of calculation intervals adaptive sampling averages the throughput over
+	SamplingAggregationBuckets = flag.Int("sampling.aggregation-buckets", adaptiveDefaults.AggregationBuckets, "The number of calculation intervals adaptive sampling averages the throughput over")
+	// SamplingDefaultProbability is the sampling probability adaptive sampling uses for new operations
+	SamplingDefaultProbability = flag.Float64("sampling.default-sampling-probability", adaptiveDefaults.DefaultSamplingProbability, "The sampling probability adaptive sampling uses for operations without throughput")
+	// SamplingMinProbability is the lowest sampling probability adaptive sampling assigns to an operation
+	SamplingMinProbability = flag.Float64("sampling.min-sampling-probability", adaptiveDefaults.MinSamplingProbability, "The lowest sampling probability adaptive sampling assigns to an operation")
+	// SamplingLowerBoundTracesPerSecond is the minimum rate at which every operation is sampled
+	SamplingLowerBoundTracesPerSecond = flag.Float64("sampling.lower-bound-traces-per-second", adaptiveDefaults.LowerBoundTracesPerSecond, "The minimum number of traces per second sampled for every operation")
+	// BaggageRestrictionsFile is the path to the JSON file with the baggage restrictions served to clients
+	BaggageRestrictionsFile = flag.String("baggage.restrictions-file", "", "The path for the baggage restrictions file in JSON format, baggage restrictions are not served without it")
+	// BaggageRestrictionsReloadInterval denotes how often the baggage restrictions file is checked for changes
+	BaggageRestrictionsReloadInterval = flag.Duration("baggage.restrictions-reload-interval", time.Minute, "How often to check the baggage restrictions file for changes, 0 disables reloading")
 )
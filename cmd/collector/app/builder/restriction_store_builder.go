@@ -0,0 +1,54 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package builder
+
+import (
+	"io"
+
+	basicB "github.com/uber/jaeger/cmd/builder"
+	"github.com/uber/jaeger/cmd/collector/app/baggage/restrictionstore"
+	"github.com/uber/jaeger/plugin/baggage/restrictionstore/static"
+)
+
+// RestrictionStore is a baggage restriction store that keeps running in the background until it is closed.
+type RestrictionStore interface {
+	restrictionstore.RestrictionStore
+	io.Closer
+}
+
+// NewRestrictionStore creates the baggage restriction store configured by --baggage.restrictions-file.
+// It returns a nil store if no file is configured, in which case the baggage restriction manager must
+// not be served, so that clients keep their default behaviour instead of dropping all baggage.
+func NewRestrictionStore(opts ...basicB.Option) (RestrictionStore, error) {
+	options := basicB.ApplyOptions(opts...)
+	if *BaggageRestrictionsFile == "" {
+		options.Logger.Info("No baggage restrictions file provided, baggage restrictions are not served")
+		return nil, nil
+	}
+	store, err := static.NewRestrictionStore(static.Options{
+		RestrictionsFile: *BaggageRestrictionsFile,
+		ReloadInterval:   *BaggageRestrictionsReloadInterval,
+	}, options.Logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
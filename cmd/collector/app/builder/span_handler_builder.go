@@ -22,6 +22,8 @@ package builder
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
 
 	"go.uber.org/zap"
@@ -38,12 +40,15 @@ import (
 	escfg "github.com/uber/jaeger/pkg/es/config"
 	"github.com/uber/jaeger/pkg/influxdb"
 	infcfg "github.com/uber/jaeger/pkg/influxdb/config"
+	kafkacfg "github.com/uber/jaeger/pkg/kafka/config"
+	"github.com/uber/jaeger/pkg/queue"
 	casSpanstore "github.com/uber/jaeger/plugin/storage/cassandra/spanstore"
 	esSpanstore "github.com/uber/jaeger/plugin/storage/es/spanstore"
 	influxstore "github.com/uber/jaeger/plugin/storage/influxdb/spanstore"
-	"github.com/uber/jaeger/storage/dependencystore"
+	kafkaSpanstore "github.com/uber/jaeger/plugin/storage/kafka/spanstore"
 	"github.com/uber/jaeger/storage/spanstore"
 	"github.com/uber/jaeger/storage/spanstore/memory"
+	storageMetrics "github.com/uber/jaeger/storage/spanstore/metrics"
 )
 
 var (
@@ -51,37 +56,71 @@ var (
 	errMissingMemoryStore         = errors.New("MemoryStore is not provided")
 	errMissingElasticSearchConfig = errors.New("ElasticSearch not configured")
 	errMissingInfluxDBConfig      = errors.New("InfluxDB not configured")
+	errMissingKafkaConfig         = errors.New("Kafka not configured")
 )
 
 // SpanHandlerBuilder builds span (Jaeger and zipkin) handlers
 type SpanHandlerBuilder interface {
-	BuildHandlers() (app.ZipkinSpansHandler, app.JaegerBatchesHandler, error)
+	// BuildHandlers builds the handlers, passing the given options to the span processor. The returned
+	// closer stops the span processor and closes the span writer.
+	BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error)
+	// BuildSpanWriter builds the span writer of the configured storage backend
+	BuildSpanWriter() (spanstore.Writer, error)
 }
 
-// NewSpanHandlerBuilder returns a span handler
+// NewSpanHandlerBuilder returns a span handler. When several span storage types are configured the
+// handlers write every span to each of the backends.
 func NewSpanHandlerBuilder(opts ...basicB.Option) (SpanHandlerBuilder, error) {
 	options := basicB.ApplyOptions(opts...)
-	if flags.SpanStorage.Type == flags.CassandraStorageType {
+	storageTypes := flags.SpanStorage.Types()
+	if len(storageTypes) == 1 {
+		return newSpanHandlerBuilder(storageTypes[0], options)
+	}
+	if len(storageTypes) == 0 {
+		return nil, flags.ErrUnsupportedStorageType
+	}
+	seen := make(map[string]bool, len(storageTypes))
+	builders := make([]SpanHandlerBuilder, 0, len(storageTypes))
+	for _, storageType := range storageTypes {
+		if seen[storageType] {
+			return nil, fmt.Errorf("Span storage type %s is listed more than once", storageType)
+		}
+		seen[storageType] = true
+		builder, err := newSpanHandlerBuilder(storageType, options)
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, builder)
+	}
+	return newFanOutSpanHandlerBuilder(storageTypes, builders, options.Logger, options.MetricsFactory), nil
+}
+
+func newSpanHandlerBuilder(storageType string, options basicB.BasicOptions) (SpanHandlerBuilder, error) {
+	if storageType == flags.CassandraStorageType {
 		if options.Cassandra == nil {
 			return nil, errMissingCassandraConfig
 		}
 		return newCassandraBuilder(options.Cassandra, options.Logger, options.MetricsFactory), nil
-	} else if flags.SpanStorage.Type == flags.MemoryStorageType {
+	} else if storageType == flags.MemoryStorageType {
 		if options.MemoryStore == nil {
 			return nil, errMissingMemoryStore
 		}
 		return newMemoryStoreBuilder(options.MemoryStore, options.Logger, options.MetricsFactory), nil
-	} else if flags.SpanStorage.Type == flags.ESStorageType {
+	} else if storageType == flags.ESStorageType {
 		if options.ElasticSearch == nil {
 			return nil, errMissingElasticSearchConfig
 		}
 		return newESBuilder(options.ElasticSearch, options.Logger, options.MetricsFactory), nil
-	} else if flags.SpanStorage.Type == flags.InfluxDBStorageType {
+	} else if storageType == flags.InfluxDBStorageType {
 		if options.InfluxDB == nil {
 			return nil, errMissingInfluxDBConfig
 		}
-
-		return newInfluxDBStoreBuilder(options.InfluxDB, options.Logger, options.MetricsFactory), nil
+		return newInfluxDBBuilder(options.InfluxDB, options.Logger, options.MetricsFactory), nil
+	} else if storageType == flags.KafkaStorageType {
+		if options.Kafka == nil {
+			return nil, errMissingKafkaConfig
+		}
+		return newKafkaBuilder(options.Kafka, options.Logger, options.MetricsFactory), nil
 	}
 	return nil, flags.ErrUnsupportedStorageType
 }
@@ -100,8 +139,12 @@ func newMemoryStoreBuilder(memStore *memory.Store, logger *zap.Logger, metricsFa
 	}
 }
 
-func (m *memoryStoreBuilder) BuildHandlers() (app.ZipkinSpansHandler, app.JaegerBatchesHandler, error) {
-	return buildHandlers(m.memStore, m.logger, m.metricsFactory)
+func (m *memoryStoreBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return buildHandlers(m, m.logger, m.metricsFactory, opts...)
+}
+
+func (m *memoryStoreBuilder) BuildSpanWriter() (spanstore.Writer, error) {
+	return m.memStore, nil
 }
 
 type cassandraSpanHandlerBuilder struct {
@@ -119,19 +162,21 @@ func newCassandraBuilder(config *cascfg.Configuration, logger *zap.Logger, metri
 	}
 }
 
-func (c *cassandraSpanHandlerBuilder) BuildHandlers() (app.ZipkinSpansHandler, app.JaegerBatchesHandler, error) {
+func (c *cassandraSpanHandlerBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return buildHandlers(c, c.logger, c.metricsFactory, opts...)
+}
+
+func (c *cassandraSpanHandlerBuilder) BuildSpanWriter() (spanstore.Writer, error) {
 	session, err := c.getSession()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	spanStore := casSpanstore.NewSpanWriter(
+	return casSpanstore.NewSpanWriter(
 		session,
 		*WriteCacheTTL,
 		c.metricsFactory,
 		c.logger,
-	)
-
-	return buildHandlers(spanStore, c.logger, c.metricsFactory)
+	), nil
 }
 
 func defaultSpanFilter(*model.Span) bool {
@@ -167,14 +212,16 @@ func newESBuilder(config *escfg.Configuration, logger *zap.Logger, metricsFactor
 	}
 }
 
-func (e *esSpanHandlerBuilder) BuildHandlers() (app.ZipkinSpansHandler, app.JaegerBatchesHandler, error) {
+func (e *esSpanHandlerBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return buildHandlers(e, e.logger, e.metricsFactory, opts...)
+}
+
+func (e *esSpanHandlerBuilder) BuildSpanWriter() (spanstore.Writer, error) {
 	client, err := e.getClient()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	spanStore := esSpanstore.NewSpanWriter(client, e.logger, e.metricsFactory)
-
-	return buildHandlers(spanStore, e.logger, e.metricsFactory)
+	return esSpanstore.NewSpanWriter(client, e.logger, e.metricsFactory), nil
 }
 
 func (e *esSpanHandlerBuilder) getClient() (es.Client, error) {
@@ -186,59 +233,142 @@ func (e *esSpanHandlerBuilder) getClient() (es.Client, error) {
 	return e.client, nil
 }
 
-type influxDBStoreBuilder struct {
+type influxDBSpanHandlerBuilder struct {
 	logger         *zap.Logger
 	metricsFactory metrics.Factory
-	conf           *infcfg.Configuration
-	store          *influxstore.SpanReader
+	configuration  infcfg.Configuration
 	client         influxdb.Client
 }
 
-func newInfluxDBStoreBuilder(conf *infcfg.Configuration, logger *zap.Logger, metricsFactory metrics.Factory) *influxDBStoreBuilder {
-	return &influxDBStoreBuilder{
+func newInfluxDBBuilder(config *infcfg.Configuration, logger *zap.Logger, metricsFactory metrics.Factory) *influxDBSpanHandlerBuilder {
+	return &influxDBSpanHandlerBuilder{
 		logger:         logger,
 		metricsFactory: metricsFactory,
-		conf:           conf,
+		configuration:  *config,
 	}
+}
 
+func (b *influxDBSpanHandlerBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return buildHandlers(b, b.logger, b.metricsFactory, opts...)
 }
 
-func (b *influxDBStoreBuilder) NewSpanReader() (spanstore.Reader, error) {
-	//return c.store, nil
+func (b *influxDBSpanHandlerBuilder) BuildSpanWriter() (spanstore.Writer, error) {
 	client, err := b.getClient()
 	if err != nil {
 		return nil, err
 	}
-	// TODO: Change this to the writer when we have it
-	return influxstore.NewSpanReader(client, b.conf), nil
-
-}
-
-func (b *influxDBStoreBuilder) NewDependencyReader() (dependencystore.Reader, error) {
-	return nil, nil
+	if err := b.configuration.CreateSchema(client); err != nil {
+		return nil, err
+	}
+	return influxstore.NewSpanWriter(client, *WriteCacheTTL, b.logger, b.metricsFactory), nil
 }
 
-func (b *influxDBStoreBuilder) getClient() (influxdb.Client, error) {
+func (b *influxDBSpanHandlerBuilder) getClient() (influxdb.Client, error) {
 	if b.client == nil {
-		client, err := b.conf.NewClient()
+		client, err := b.configuration.NewClient()
 		b.client = client
 		return b.client, err
 	}
 	return b.client, nil
 }
 
-func (b *influxDBStoreBuilder) BuildHandlers() (app.ZipkinSpansHandler, app.JaegerBatchesHandler, error) {
-	return buildHandlers(b.store, b.logger, b.metricsFactory)
+type kafkaSpanHandlerBuilder struct {
+	logger         *zap.Logger
+	metricsFactory metrics.Factory
+	configuration  kafkacfg.Configuration
+}
+
+func newKafkaBuilder(config *kafkacfg.Configuration, logger *zap.Logger, metricsFactory metrics.Factory) *kafkaSpanHandlerBuilder {
+	return &kafkaSpanHandlerBuilder{
+		logger:         logger,
+		metricsFactory: metricsFactory,
+		configuration:  *config,
+	}
+}
+
+func (k *kafkaSpanHandlerBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return buildHandlers(k, k.logger, k.metricsFactory, opts...)
+}
+
+func (k *kafkaSpanHandlerBuilder) BuildSpanWriter() (spanstore.Writer, error) {
+	marshaller, err := kafkaSpanstore.NewMarshaller(k.configuration.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := k.configuration.NewProducer()
+	if err != nil {
+		return nil, err
+	}
+	return kafkaSpanstore.NewSpanWriter(producer, marshaller, k.configuration.Topic, k.logger, k.metricsFactory), nil
+}
+
+type fanOutSpanHandlerBuilder struct {
+	logger         *zap.Logger
+	metricsFactory metrics.Factory
+	storageTypes   []string
+	builders       []SpanHandlerBuilder
+}
+
+func newFanOutSpanHandlerBuilder(
+	storageTypes []string,
+	builders []SpanHandlerBuilder,
+	logger *zap.Logger,
+	metricsFactory metrics.Factory,
+) *fanOutSpanHandlerBuilder {
+	return &fanOutSpanHandlerBuilder{
+		logger:         logger,
+		metricsFactory: metricsFactory,
+		storageTypes:   storageTypes,
+		builders:       builders,
+	}
+}
+
+func (f *fanOutSpanHandlerBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return buildHandlers(f, f.logger, f.metricsFactory, opts...)
+}
+
+// BuildSpanWriter builds the span writers of all the backends, in the order they are listed, and reports the
+// latency and failures of each of them under the span-storage metrics tagged with the backend type. If a
+// backend fails to build, the span writers of the backends built before it are closed.
+func (f *fanOutSpanHandlerBuilder) BuildSpanWriter() (spanstore.Writer, error) {
+	spanWriters := make([]spanstore.Writer, 0, len(f.storageTypes))
+	for i, storageType := range f.storageTypes {
+		spanWriter, err := f.builders[i].BuildSpanWriter()
+		if err != nil {
+			for _, built := range spanWriters {
+				if closeErr := spanstore.CloseWriter(built); closeErr != nil {
+					f.logger.Error("Failed to close span writer", zap.Error(closeErr))
+				}
+			}
+			return nil, err
+		}
+		backendMetrics := f.metricsFactory.Namespace("span-storage", map[string]string{"backend": storageType})
+		spanWriters = append(spanWriters, storageMetrics.NewWriteMetricsDecorator(spanWriter, backendMetrics))
+	}
+	return spanstore.NewNamedMultiplexWriter(f.storageTypes, spanWriters), nil
 }
 
 func buildHandlers(
-	spanStore spanstore.Writer,
+	builder SpanHandlerBuilder,
 	logger *zap.Logger,
 	metricsFactory metrics.Factory,
-) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, error) {
+	opts ...app.Option,
+) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	spanStore, err := builder.BuildSpanWriter()
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	hostname, _ := os.Hostname()
 	hostMetrics := metricsFactory.Namespace(hostname, nil)
 
+	if *SpillDirectory != "" {
+		spillQueue, err := queue.NewDiskQueue(*SpillDirectory, *SpillMaxBytes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		opts = append([]app.Option{app.Options.SpillQueue(spillQueue)}, opts...)
+	}
+
 	zSanitizer := zs.NewChainedSanitizer(
 		zs.NewSpanDurationSanitizer(logger),
 		zs.NewParentIDSanitizer(logger),
@@ -246,15 +376,24 @@ func buildHandlers(
 
 	spanProcessor := app.NewSpanProcessor(
 		spanStore,
-		app.Options.ServiceMetrics(metricsFactory),
-		app.Options.HostMetrics(hostMetrics),
-		app.Options.Logger(logger),
-		app.Options.SpanFilter(defaultSpanFilter),
-		app.Options.NumWorkers(*NumWorkers),
-		app.Options.QueueSize(*QueueSize),
+		append([]app.Option{
+			app.Options.ServiceMetrics(metricsFactory),
+			app.Options.HostMetrics(hostMetrics),
+			app.Options.Logger(logger),
+			app.Options.SpanFilter(defaultSpanFilter),
+			app.Options.NumWorkers(*NumWorkers),
+			app.Options.QueueSize(*QueueSize),
+			app.Options.QueueMaxBytes(*QueueMaxBytes),
+			app.Options.ReportBusy(*ReportBusy),
+			app.Options.MaxRetries(*MaxRetries),
+			app.Options.RetryBackoff(*RetryBackoff),
+			app.Options.MaxRetryBackoff(*MaxRetryBackoff),
+			app.Options.MaxReplayAttempts(*MaxReplayAttempts),
+		}, opts...)...,
 	)
 
 	return app.NewZipkinSpanHandler(logger, spanProcessor, zSanitizer),
 		app.NewJaegerSpanHandler(logger, spanProcessor),
+		spanProcessor,
 		nil
 }
@@ -21,20 +21,37 @@
 package builder
 
 import (
+	"errors"
 	"flag"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Shopify/sarama"
+	"github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger/cmd/builder"
+	"github.com/uber/jaeger/cmd/collector/app"
+	"github.com/uber/jaeger/model"
 	cascfg "github.com/uber/jaeger/pkg/cassandra/config"
 	"github.com/uber/jaeger/pkg/cassandra/mocks"
 	escfg "github.com/uber/jaeger/pkg/es/config"
 	esMocks "github.com/uber/jaeger/pkg/es/mocks"
+	infcfg "github.com/uber/jaeger/pkg/influxdb/config"
+	influxMocks "github.com/uber/jaeger/pkg/influxdb/mocks"
+	kafkacfg "github.com/uber/jaeger/pkg/kafka/config"
+	"github.com/uber/jaeger/storage/spanstore"
 	"github.com/uber/jaeger/storage/spanstore/memory"
+	spanstoreMocks "github.com/uber/jaeger/storage/spanstore/mocks"
 )
 
 func TestNewSpanHandlerBuilder(t *testing.T) {
@@ -88,13 +105,17 @@ func TestNewSpanHandlerBuilderMemorySet(t *testing.T) {
 	}()
 	os.Args = []string{"test", "--span-storage.type=memory"}
 	flag.Parse()
-	handler, err := NewSpanHandlerBuilder(builder.Options.MemoryStoreOption(memory.NewStore()))
+	memStore := memory.NewStore()
+	handler, err := NewSpanHandlerBuilder(builder.Options.MemoryStoreOption(memStore))
 	assert.NoError(t, err)
 	assert.NotNil(t, handler)
-	jHandler, zHandler, err := handler.BuildHandlers()
+	jHandler, zHandler, _, err := handler.BuildHandlers()
 	assert.NoError(t, err)
 	assert.NotNil(t, jHandler)
 	assert.NotNil(t, zHandler)
+	spanWriter, err := handler.BuildSpanWriter()
+	assert.NoError(t, err)
+	assert.Equal(t, memStore, spanWriter)
 }
 
 func TestNewSpanHandlerBuilderElasticSearch(t *testing.T) {
@@ -138,7 +159,7 @@ func TestBuildHandlersCassandra(t *testing.T) {
 	withCassandraBuilder(func(cBuilder *cassandraSpanHandlerBuilder) {
 		mockSession := mocks.Session{}
 		cBuilder.session = &mockSession
-		zHandler, jHandler, err := cBuilder.BuildHandlers()
+		zHandler, jHandler, _, err := cBuilder.BuildHandlers()
 		assert.NoError(t, err)
 		assert.NotNil(t, zHandler)
 		assert.NotNil(t, jHandler)
@@ -148,7 +169,7 @@ func TestBuildHandlersCassandra(t *testing.T) {
 func TestBuildHandlersCassandraFailure(t *testing.T) {
 	withCassandraBuilder(func(cBuilder *cassandraSpanHandlerBuilder) {
 		cBuilder.configuration.Servers = []string{"badhostname"}
-		zHandler, jHandler, err := cBuilder.BuildHandlers()
+		zHandler, jHandler, _, err := cBuilder.BuildHandlers()
 		assert.Error(t, err)
 		assert.Nil(t, zHandler)
 		assert.Nil(t, jHandler)
@@ -171,7 +192,7 @@ func TestBuildHandlersElasticSearch(t *testing.T) {
 	withElasticSearchBuilder(func(builder *esSpanHandlerBuilder) {
 		mockClient := esMocks.Client{}
 		builder.client = &mockClient
-		zHandler, jHandler, err := builder.BuildHandlers()
+		zHandler, jHandler, _, err := builder.BuildHandlers()
 		assert.NoError(t, err)
 		assert.NotNil(t, zHandler)
 		assert.NotNil(t, jHandler)
@@ -181,9 +202,310 @@ func TestBuildHandlersElasticSearch(t *testing.T) {
 func TestBuildHandlersElasticSearchFailure(t *testing.T) {
 	withElasticSearchBuilder(func(builder *esSpanHandlerBuilder) {
 		builder.configuration.Servers = []string{}
-		zHandler, jHandler, err := builder.BuildHandlers()
+		zHandler, jHandler, _, err := builder.BuildHandlers()
 		assert.Error(t, err)
 		assert.Nil(t, zHandler)
 		assert.Nil(t, jHandler)
 	})
 }
+
+func TestNewSpanHandlerBuilderInfluxDBNotSet(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+	os.Args = []string{"test", "--span-storage.type=influxdb"}
+	flag.Parse()
+	handler, err := NewSpanHandlerBuilder()
+	assert.EqualError(t, err, "InfluxDB not configured")
+	assert.Nil(t, handler)
+}
+
+func withInfluxDBBuilder(f func(builder *influxDBSpanHandlerBuilder)) {
+	cfg := &infcfg.Configuration{
+		Server:         "http://127.0.0.1:8086",
+		Database:       "jaeger",
+		ConnectionType: "http",
+	}
+	iBuilder := newInfluxDBBuilder(cfg, zap.NewNop(), metrics.NullFactory)
+	f(iBuilder)
+}
+
+func TestBuildHandlersInfluxDB(t *testing.T) {
+	withInfluxDBBuilder(func(builder *influxDBSpanHandlerBuilder) {
+		mockClient := &influxMocks.Client{}
+		mockClient.On("QuerySpans", "SHOW DATABASES", "").Return(&client.Response{
+			Results: []client.Result{{
+				Series: []models.Row{{
+					Name:    "databases",
+					Columns: []string{"name"},
+					Values:  [][]interface{}{{"_internal"}, {"jaeger"}},
+				}},
+			}},
+		}, nil)
+		builder.client = mockClient
+		zHandler, jHandler, _, err := builder.BuildHandlers()
+		assert.NoError(t, err)
+		assert.NotNil(t, zHandler)
+		assert.NotNil(t, jHandler)
+	})
+}
+
+func TestBuildHandlersInfluxDBFailure(t *testing.T) {
+	withInfluxDBBuilder(func(builder *influxDBSpanHandlerBuilder) {
+		mockClient := &influxMocks.Client{}
+		mockClient.On("QuerySpans", "SHOW DATABASES", "").Return(nil, errors.New("connection refused"))
+		builder.client = mockClient
+		zHandler, jHandler, _, err := builder.BuildHandlers()
+		assert.EqualError(t, err, "connection refused")
+		assert.Nil(t, zHandler)
+		assert.Nil(t, jHandler)
+	})
+}
+
+func TestBuildHandlersInfluxDBCreatesSchema(t *testing.T) {
+	withInfluxDBBuilder(func(builder *influxDBSpanHandlerBuilder) {
+		builder.configuration.RetentionPolicy = "jaeger"
+		builder.configuration.Retention = 72 * time.Hour
+		mockClient := &influxMocks.Client{}
+		mockClient.On("QuerySpans", "SHOW DATABASES", "").Return(&client.Response{}, nil)
+		mockClient.On("QuerySpans", `CREATE DATABASE "jaeger"`, "").Return(&client.Response{}, nil)
+		mockClient.On("QuerySpans", `SHOW RETENTION POLICIES ON "jaeger"`, "").Return(&client.Response{}, nil)
+		mockClient.On("QuerySpans", `CREATE RETENTION POLICY "jaeger" ON "jaeger" DURATION 72h REPLICATION 1 DEFAULT`, "").
+			Return(&client.Response{}, nil)
+		builder.client = mockClient
+		zHandler, jHandler, _, err := builder.BuildHandlers()
+		assert.NoError(t, err)
+		assert.NotNil(t, zHandler)
+		assert.NotNil(t, jHandler)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestBuildHandlersInfluxDBSchemaFailure(t *testing.T) {
+	withInfluxDBBuilder(func(builder *influxDBSpanHandlerBuilder) {
+		mockClient := &influxMocks.Client{}
+		mockClient.On("QuerySpans", "SHOW DATABASES", "").Return(&client.Response{}, nil)
+		mockClient.On("QuerySpans", `CREATE DATABASE "jaeger"`, "").Return(&client.Response{Err: "forbidden"}, nil)
+		builder.client = mockClient
+		zHandler, jHandler, _, err := builder.BuildHandlers()
+		assert.EqualError(t, err, "forbidden")
+		assert.Nil(t, zHandler)
+		assert.Nil(t, jHandler)
+	})
+}
+
+func TestNewSpanHandlerBuilderKafka(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+	os.Args = []string{"test", "--span-storage.type=kafka"}
+	flag.Parse()
+	handler, err := NewSpanHandlerBuilder()
+	assert.EqualError(t, err, "Kafka not configured")
+	assert.Nil(t, handler)
+
+	handler, err = NewSpanHandlerBuilder(builder.Options.KafkaOption(&kafkacfg.Configuration{
+		Brokers: []string{"127.0.0.1:9092"},
+	}))
+	assert.NoError(t, err)
+	assert.NotNil(t, handler)
+}
+
+func TestBuildHandlersKafka(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("jaeger-spans", 0, broker.BrokerID()),
+	})
+	kBuilder := newKafkaBuilder(&kafkacfg.Configuration{
+		Brokers:  []string{broker.Addr()},
+		Topic:    "jaeger-spans",
+		Encoding: "thrift",
+	}, zap.NewNop(), metrics.NullFactory)
+	zHandler, jHandler, _, err := kBuilder.BuildHandlers()
+	assert.NoError(t, err)
+	assert.NotNil(t, zHandler)
+	assert.NotNil(t, jHandler)
+}
+
+func TestBuildHandlersKafkaUnsupportedEncoding(t *testing.T) {
+	kBuilder := newKafkaBuilder(&kafkacfg.Configuration{
+		Brokers:  []string{"127.0.0.1:9092"},
+		Topic:    "jaeger-spans",
+		Encoding: "protobuf",
+	}, zap.NewNop(), metrics.NullFactory)
+	zHandler, jHandler, _, err := kBuilder.BuildHandlers()
+	assert.EqualError(t, err, `unsupported span encoding "protobuf"`)
+	assert.Nil(t, zHandler)
+	assert.Nil(t, jHandler)
+}
+
+func TestNewSpanHandlerBuilderFanOut(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+	os.Args = []string{"test", "--span-storage.type=memory,kafka"}
+	flag.Parse()
+	handler, err := NewSpanHandlerBuilder(builder.Options.MemoryStoreOption(memory.NewStore()))
+	assert.EqualError(t, err, "Kafka not configured")
+	assert.Nil(t, handler)
+
+	handler, err = NewSpanHandlerBuilder(
+		builder.Options.MemoryStoreOption(memory.NewStore()),
+		builder.Options.KafkaOption(&kafkacfg.Configuration{
+			Brokers: []string{"127.0.0.1:9092"},
+		}),
+	)
+	assert.NoError(t, err)
+	assert.IsType(t, &fanOutSpanHandlerBuilder{}, handler)
+}
+
+func TestNewSpanHandlerBuilderFanOutDuplicateType(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+	}()
+	os.Args = []string{"test", "--span-storage.type=memory,memory"}
+	flag.Parse()
+	handler, err := NewSpanHandlerBuilder(builder.Options.MemoryStoreOption(memory.NewStore()))
+	assert.EqualError(t, err, "Span storage type memory is listed more than once")
+	assert.Nil(t, handler)
+}
+
+type fakeSpanHandlerBuilder struct {
+	spanWriter spanstore.Writer
+	err        error
+}
+
+func (f *fakeSpanHandlerBuilder) BuildHandlers(opts ...app.Option) (app.ZipkinSpansHandler, app.JaegerBatchesHandler, io.Closer, error) {
+	return nil, nil, nil, errors.New("not implemented")
+}
+
+type closingSpanWriter struct {
+	spanstoreMocks.Writer
+	closed bool
+}
+
+func (w *closingSpanWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func (f *fakeSpanHandlerBuilder) BuildSpanWriter() (spanstore.Writer, error) {
+	return f.spanWriter, f.err
+}
+
+func TestBuildHandlersFanOut(t *testing.T) {
+	memStore := memory.NewStore()
+	failingWriter := &spanstoreMocks.Writer{}
+	failingWriter.On("WriteSpan", mock.Anything).Return(errors.New("no space left"))
+	metricsFactory := metrics.NewLocalFactory(0)
+	fBuilder := newFanOutSpanHandlerBuilder(
+		[]string{"memory", "elasticsearch"},
+		[]SpanHandlerBuilder{
+			newMemoryStoreBuilder(memStore, zap.NewNop(), metricsFactory),
+			&fakeSpanHandlerBuilder{spanWriter: failingWriter},
+		},
+		zap.NewNop(),
+		metricsFactory,
+	)
+	zHandler, jHandler, _, err := fBuilder.BuildHandlers()
+	assert.NoError(t, err)
+	assert.NotNil(t, zHandler)
+	assert.NotNil(t, jHandler)
+
+	spanWriter, err := fBuilder.BuildSpanWriter()
+	assert.NoError(t, err)
+	span := &model.Span{
+		TraceID: model.TraceID{Low: 1},
+		SpanID:  model.SpanID(1),
+		Process: model.NewProcess("svc", nil),
+	}
+	assert.EqualError(t, spanWriter.WriteSpan(span), "no space left")
+	trace, err := memStore.GetTrace(span.TraceID)
+	assert.NoError(t, err)
+	assert.Len(t, trace.Spans, 1)
+
+	counters, _ := metricsFactory.Snapshot()
+	assert.EqualValues(t, 1, counters["span-storage.WriteSpan.inserts|backend=memory"])
+	assert.EqualValues(t, 0, counters["span-storage.WriteSpan.errors|backend=memory"])
+	assert.EqualValues(t, 0, counters["span-storage.WriteSpan.inserts|backend=elasticsearch"])
+	assert.EqualValues(t, 1, counters["span-storage.WriteSpan.errors|backend=elasticsearch"])
+}
+
+func TestBuildHandlersClosesSpanWriters(t *testing.T) {
+	spanWriter := &closingSpanWriter{}
+	fBuilder := newFanOutSpanHandlerBuilder(
+		[]string{"memory", "kafka"},
+		[]SpanHandlerBuilder{
+			newMemoryStoreBuilder(memory.NewStore(), zap.NewNop(), metrics.NullFactory),
+			&fakeSpanHandlerBuilder{spanWriter: spanWriter},
+		},
+		zap.NewNop(),
+		metrics.NullFactory,
+	)
+	_, _, closer, err := fBuilder.BuildHandlers()
+	require.NoError(t, err)
+	assert.False(t, spanWriter.closed)
+	assert.NoError(t, closer.Close())
+	assert.True(t, spanWriter.closed)
+}
+
+func TestBuildHandlersFanOutFailure(t *testing.T) {
+	fBuilder := newFanOutSpanHandlerBuilder(
+		[]string{"memory", "elasticsearch"},
+		[]SpanHandlerBuilder{
+			newMemoryStoreBuilder(memory.NewStore(), zap.NewNop(), metrics.NullFactory),
+			&fakeSpanHandlerBuilder{err: errors.New("no available connection")},
+		},
+		zap.NewNop(),
+		metrics.NullFactory,
+	)
+	zHandler, jHandler, _, err := fBuilder.BuildHandlers()
+	assert.EqualError(t, err, "no available connection")
+	assert.Nil(t, zHandler)
+	assert.Nil(t, jHandler)
+}
+
+func TestBuildSpanWriterFanOutFailureClosesBuiltWriters(t *testing.T) {
+	spanWriter := &closingSpanWriter{}
+	fBuilder := newFanOutSpanHandlerBuilder(
+		[]string{"kafka", "elasticsearch"},
+		[]SpanHandlerBuilder{
+			&fakeSpanHandlerBuilder{spanWriter: spanWriter},
+			&fakeSpanHandlerBuilder{err: errors.New("no available connection")},
+		},
+		zap.NewNop(),
+		metrics.NullFactory,
+	)
+	_, err := fBuilder.BuildSpanWriter()
+	assert.EqualError(t, err, "no available connection")
+	assert.True(t, spanWriter.closed)
+}
+
+func TestBuildHandlersSpillDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(spillDirectory string) { *SpillDirectory = spillDirectory }(*SpillDirectory)
+
+	mBuilder := newMemoryStoreBuilder(memory.NewStore(), zap.NewNop(), metrics.NullFactory)
+	*SpillDirectory = filepath.Join(dir, "spill")
+	zHandler, jHandler, _, err := mBuilder.BuildHandlers()
+	assert.NoError(t, err)
+	assert.NotNil(t, zHandler)
+	assert.NotNil(t, jHandler)
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0644))
+	*SpillDirectory = file
+	zHandler, jHandler, _, err = mBuilder.BuildHandlers()
+	assert.Error(t, err)
+	assert.Nil(t, zHandler)
+	assert.Nil(t, jHandler)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package builder
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	basicB "github.com/uber/jaeger/cmd/builder"
+	"github.com/uber/jaeger/cmd/collector/app"
+	"github.com/uber/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/uber/jaeger/cmd/flags"
+	"github.com/uber/jaeger/pkg/distributedlock"
+	casLock "github.com/uber/jaeger/plugin/pkg/distributedlock/cassandra"
+	localLock "github.com/uber/jaeger/plugin/pkg/distributedlock/local"
+	"github.com/uber/jaeger/plugin/sampling/strategystore/adaptive"
+	"github.com/uber/jaeger/plugin/sampling/strategystore/static"
+	casSamplingstore "github.com/uber/jaeger/plugin/storage/cassandra/samplingstore"
+	"github.com/uber/jaeger/storage/samplingstore"
+	memorySamplingstore "github.com/uber/jaeger/storage/samplingstore/memory"
+)
+
+const (
+	staticStrategyStoreType   = "static"
+	adaptiveStrategyStoreType = "adaptive"
+)
+
+var (
+	errUnsupportedStrategyStoreType = errors.New("Sampling strategy store type is not supported")
+	errAdaptiveSamplingStorage      = errors.New("Adaptive sampling requires Cassandra or memory span storage")
+	errAdaptiveSamplingESLock       = errors.New("Adaptive sampling is not supported with ElasticSearch span storage, which provides no distributed lock to elect the collector that calculates the probabilities")
+)
+
+// StrategyStore is a sampling strategy store that keeps running in the background until it is closed.
+type StrategyStore interface {
+	strategystore.StrategyStore
+	io.Closer
+}
+
+// NewStrategyStore creates the sampling strategy store selected by --sampling.strategy-store. The returned
+// span processor options must be passed to BuildHandlers so that the store observes the incoming spans.
+func NewStrategyStore(opts ...basicB.Option) (StrategyStore, []app.Option, error) {
+	options := basicB.ApplyOptions(opts...)
+	switch *SamplingStrategyStoreType {
+	case staticStrategyStoreType:
+		store, err := static.NewStrategyStore(static.Options{
+			StrategiesFile: *SamplingStrategiesFile,
+			ReloadInterval: *SamplingStrategiesReloadInterval,
+		}, options.Logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, nil, nil
+	case adaptiveStrategyStoreType:
+		hostname, _ := os.Hostname()
+		storage, lock, err := newAdaptiveSamplingStorage(options, hostname)
+		if err != nil {
+			return nil, nil, err
+		}
+		processor := adaptive.NewProcessor(
+			adaptive.Options{
+				TargetTracesPerSecond:      *SamplingTargetTracesPerSecond,
+				CalculationInterval:        *SamplingCalculationInterval,
+				AggregationBuckets:         *SamplingAggregationBuckets,
+				DefaultSamplingProbability: *SamplingDefaultProbability,
+				MinSamplingProbability:     *SamplingMinProbability,
+				LowerBoundTracesPerSecond:  *SamplingLowerBoundTracesPerSecond,
+			},
+			hostname,
+			storage,
+			lock,
+			options.Logger,
+		)
+		if err := processor.Start(); err != nil {
+			return nil, nil, err
+		}
+		aggregator := adaptive.NewAggregator(storage, *SamplingCalculationInterval, options.Logger)
+		store := &adaptiveStrategyStore{Processor: processor, aggregator: aggregator}
+		return store, []app.Option{app.Options.PreProcessSpans(aggregator.HandleSpans)}, nil
+	}
+	return nil, nil, errUnsupportedStrategyStoreType
+}
+
+// newAdaptiveSamplingStorage returns the storage the adaptive sampling data is shared through, and the lock
+// the collectors elect the leader that calculates the probabilities with. Cassandra is preferred when the
+// spans are written to several backends. The memory storage is local to the collector, which is then
+// always the leader.
+func newAdaptiveSamplingStorage(options basicB.BasicOptions, hostname string) (samplingstore.Store, distributedlock.Lock, error) {
+	switch {
+	case flags.SpanStorage.Includes(flags.CassandraStorageType):
+		if options.Cassandra == nil {
+			return nil, nil, errMissingCassandraConfig
+		}
+		config := fixConfiguration(*options.Cassandra)
+		session, err := config.NewSession()
+		if err != nil {
+			return nil, nil, err
+		}
+		return casSamplingstore.New(session, options.MetricsFactory, options.Logger), casLock.NewLock(session, hostname), nil
+	case flags.SpanStorage.Includes(flags.MemoryStorageType):
+		// keep the throughput for at least as long as the processor looks back
+		retention := time.Duration(*SamplingAggregationBuckets+2) * *SamplingCalculationInterval
+		if retention < memorySamplingstore.DefaultRetention {
+			retention = memorySamplingstore.DefaultRetention
+		}
+		return memorySamplingstore.NewStoreWithRetention(retention), localLock.NewLock(), nil
+	case flags.SpanStorage.Includes(flags.ESStorageType):
+		return nil, nil, errAdaptiveSamplingESLock
+	}
+	return nil, nil, errAdaptiveSamplingStorage
+}
+
+type adaptiveStrategyStore struct {
+	*adaptive.Processor
+	aggregator *adaptive.Aggregator
+}
+
+// Close stops both the aggregator and the processor.
+func (s *adaptiveStrategyStore) Close() error {
+	s.aggregator.Close()
+	return s.Processor.Close()
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/cmd/flags"
+	"github.com/uber/jaeger/plugin/sampling/strategystore/static"
+)
+
+func withStrategyStoreType(storeType, storageType string, f func()) {
+	originalStoreType, originalStorageType := *SamplingStrategyStoreType, flags.SpanStorage.Type
+	defer func() {
+		*SamplingStrategyStoreType, flags.SpanStorage.Type = originalStoreType, originalStorageType
+	}()
+	*SamplingStrategyStoreType, flags.SpanStorage.Type = storeType, storageType
+	f()
+}
+
+func TestNewStrategyStoreStatic(t *testing.T) {
+	withStrategyStoreType(staticStrategyStoreType, flags.MemoryStorageType, func() {
+		store, opts, err := NewStrategyStore()
+		require.NoError(t, err)
+		defer store.Close()
+		assert.IsType(t, &static.Store{}, store)
+		assert.Empty(t, opts)
+	})
+}
+
+func TestNewStrategyStoreAdaptiveMemory(t *testing.T) {
+	withStrategyStoreType(adaptiveStrategyStoreType, flags.MemoryStorageType, func() {
+		store, opts, err := NewStrategyStore()
+		require.NoError(t, err)
+		defer store.Close()
+		assert.IsType(t, &adaptiveStrategyStore{}, store)
+		assert.Len(t, opts, 1)
+	})
+}
+
+func TestNewStrategyStoreErrors(t *testing.T) {
+	testCases := []struct {
+		storeType   string
+		storageType string
+		expectedErr error
+	}{
+		{storeType: "sneh", storageType: flags.CassandraStorageType, expectedErr: errUnsupportedStrategyStoreType},
+		{storeType: adaptiveStrategyStoreType, storageType: flags.InfluxDBStorageType, expectedErr: errAdaptiveSamplingStorage},
+		{storeType: adaptiveStrategyStoreType, storageType: flags.ESStorageType, expectedErr: errAdaptiveSamplingESLock},
+		{storeType: adaptiveStrategyStoreType, storageType: flags.CassandraStorageType, expectedErr: errMissingCassandraConfig},
+	}
+	for _, testCase := range testCases {
+		withStrategyStoreType(testCase.storeType, testCase.storageType, func() {
+			store, opts, err := NewStrategyStore()
+			assert.Equal(t, testCase.expectedErr, err)
+			assert.Nil(t, store)
+			assert.Nil(t, opts)
+		})
+	}
+
+	withStrategyStoreType(staticStrategyStoreType, flags.MemoryStorageType, func() {
+		original := *SamplingStrategiesFile
+		defer func() { *SamplingStrategiesFile = original }()
+		*SamplingStrategiesFile = "missing.json"
+		_, _, err := NewStrategyStore()
+		assert.Error(t, err)
+	})
+}
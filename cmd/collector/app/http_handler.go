@@ -29,8 +29,10 @@ import (
 
 	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/gorilla/mux"
+	"github.com/uber/tchannel-go"
 	tchanThrift "github.com/uber/tchannel-go/thrift"
 
+	zipkinConverter "github.com/uber/jaeger/model/converter/thrift/zipkin"
 	tJaeger "github.com/uber/jaeger/thrift-gen/jaeger"
 )
 
@@ -40,23 +42,36 @@ const (
 	UnableToReadBodyErrFormat = "Unable to process request body: %v"
 )
 
+// SubmitErrorStatusCode returns the HTTP status code for an error of submitting spans, 429 Too Many Requests
+// if the collector is busy so that clients back off, 500 otherwise
+func SubmitErrorStatusCode(err error) int {
+	if err == tchannel.ErrServerBusy {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
 // APIHandler handles all HTTP calls to the collector
 type APIHandler struct {
 	jaegerBatchesHandler JaegerBatchesHandler
+	zipkinSpansHandler   ZipkinSpansHandler
 }
 
 // NewAPIHandler returns a new APIHandler
 func NewAPIHandler(
 	jaegerBatchesHandler JaegerBatchesHandler,
+	zipkinSpansHandler ZipkinSpansHandler,
 ) *APIHandler {
 	return &APIHandler{
 		jaegerBatchesHandler: jaegerBatchesHandler,
+		zipkinSpansHandler:   zipkinSpansHandler,
 	}
 }
 
 // RegisterRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/traces", aH.saveSpan).Methods(http.MethodPost)
+	router.HandleFunc("/api/import", aH.importTrace).Methods(http.MethodPost)
 }
 
 func (aH *APIHandler) saveSpan(w http.ResponseWriter, r *http.Request) {
@@ -81,7 +96,7 @@ func (aH *APIHandler) saveSpan(w http.ResponseWriter, r *http.Request) {
 		defer cancel()
 		batches := []*tJaeger.Batch{batch}
 		if _, err = aH.jaegerBatchesHandler.SubmitBatches(ctx, batches); err != nil {
-			http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), http.StatusInternalServerError)
+			http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), SubmitErrorStatusCode(err))
 			return
 		}
 
@@ -92,3 +107,70 @@ func (aH *APIHandler) saveSpan(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusAccepted)
 }
+
+// importTrace replays a trace exported by the query service through the span processor
+func (aH *APIHandler) importTrace(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := tchanThrift.NewContext(time.Minute)
+	defer cancel()
+	format := r.FormValue(formatParam)
+	switch strings.ToLower(format) {
+	case "zipkin-json":
+		spans, err := zipkinConverter.DeserializeJSON(bodyBytes)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusBadRequest)
+			return
+		}
+		if _, err = aH.zipkinSpansHandler.SubmitZipkinBatch(ctx, spans); err != nil {
+			http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), SubmitErrorStatusCode(err))
+			return
+		}
+
+	case "jaeger-thrift":
+		batches, err := deserializeJaegerBatches(bodyBytes)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusBadRequest)
+			return
+		}
+		if _, err = aH.jaegerBatchesHandler.SubmitBatches(ctx, batches); err != nil {
+			http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), SubmitErrorStatusCode(err))
+			return
+		}
+
+	default:
+		http.Error(w, fmt.Sprintf("Unsupported format type: %v", format), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// deserializeJaegerBatches reads a list of batches serialized with the binary protocol
+func deserializeJaegerBatches(b []byte) ([]*tJaeger.Batch, error) {
+	buffer := thrift.NewTMemoryBuffer()
+	buffer.Write(b)
+
+	transport := thrift.NewTBinaryProtocolTransport(buffer)
+	_, size, err := transport.ReadListBegin() // Ignore the returned element type
+	if err != nil {
+		return nil, err
+	}
+
+	// Like for Zipkin spans, do not trust the size to preallocate the slice
+	var batches []*tJaeger.Batch
+	for i := 0; i < size; i++ {
+		batch := &tJaeger.Batch{}
+		if err = batch.Read(transport); err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
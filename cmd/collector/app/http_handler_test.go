@@ -35,9 +35,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	jaegerClient "github.com/uber/jaeger-client-go"
 	"github.com/uber/jaeger-client-go/transport"
+	"github.com/uber/tchannel-go"
 	tchanThrift "github.com/uber/tchannel-go/thrift"
 
 	"github.com/uber/jaeger/thrift-gen/jaeger"
+	"github.com/uber/jaeger/thrift-gen/zipkincore"
 )
 
 var httpClient = &http.Client{Timeout: 2 * time.Second}
@@ -61,9 +63,28 @@ func (p *mockJaegerHandler) getBatches() []*jaeger.Batch {
 	return p.batches
 }
 
+type mockZipkinHandler struct {
+	err   error
+	mux   sync.Mutex
+	spans []*zipkincore.Span
+}
+
+func (p *mockZipkinHandler) SubmitZipkinBatch(ctx tchanThrift.Context, spans []*zipkincore.Span) ([]*zipkincore.Response, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.spans = append(p.spans, spans...)
+	return nil, p.err
+}
+
+func (p *mockZipkinHandler) getSpans() []*zipkincore.Span {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.spans
+}
+
 func initializeTestServer(err error) (*httptest.Server, *APIHandler) {
 	r := mux.NewRouter()
-	handler := NewAPIHandler(&mockJaegerHandler{err: err})
+	handler := NewAPIHandler(&mockJaegerHandler{err: err}, &mockZipkinHandler{err: err})
 	handler.RegisterRoutes(r)
 	return httptest.NewServer(r), handler
 }
@@ -94,6 +115,11 @@ func TestThriftFormat(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, http.StatusInternalServerError, statusCode)
 	assert.EqualValues(t, "Cannot submit Jaeger batch: Bad times ahead\n", resBodyStr)
+
+	handler.jaegerBatchesHandler.(*mockJaegerHandler).err = tchannel.ErrServerBusy
+	statusCode, _, err = postBytes(server.URL+`/api/traces?format=jaeger.thrift`, someBytes)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusTooManyRequests, statusCode)
 }
 
 func TestViaClient(t *testing.T) {
@@ -150,13 +176,89 @@ func TestWrongFormat(t *testing.T) {
 }
 
 func TestCannotReadBodyFromRequest(t *testing.T) {
-	handler := NewAPIHandler(&mockJaegerHandler{})
+	handler := NewAPIHandler(&mockJaegerHandler{}, &mockZipkinHandler{})
 	req, err := http.NewRequest(http.MethodPost, "whatever", &errReader{})
 	assert.NoError(t, err)
 	rw := dummyResponseWriter{}
 	handler.saveSpan(&rw, req)
 	assert.EqualValues(t, http.StatusInternalServerError, rw.myStatusCode)
 	assert.EqualValues(t, "Unable to process request body: Simulated error reading body\n", rw.myBody)
+
+	req, err = http.NewRequest(http.MethodPost, "whatever", &errReader{})
+	assert.NoError(t, err)
+	rw = dummyResponseWriter{}
+	handler.importTrace(&rw, req)
+	assert.EqualValues(t, http.StatusInternalServerError, rw.myStatusCode)
+	assert.EqualValues(t, "Unable to process request body: Simulated error reading body\n", rw.myBody)
+}
+
+func TestImportJaegerThrift(t *testing.T) {
+	batches := []*jaeger.Batch{
+		{Process: &jaeger.Process{ServiceName: "a"}, Spans: []*jaeger.Span{{OperationName: "x"}, {OperationName: "y"}}},
+		{Process: &jaeger.Process{ServiceName: "b"}, Spans: []*jaeger.Span{{OperationName: "z"}}},
+	}
+	buffer := thrift.NewTMemoryBuffer()
+	protocol := thrift.NewTBinaryProtocolTransport(buffer)
+	protocol.WriteListBegin(thrift.STRUCT, len(batches))
+	for _, batch := range batches {
+		batch.Write(protocol)
+	}
+	protocol.WriteListEnd()
+	server, handler := initializeTestServer(nil)
+	defer server.Close()
+
+	statusCode, resBodyStr, err := postBytes(server.URL+`/api/import?format=jaeger-thrift`, buffer.Bytes())
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	assert.EqualValues(t, "", resBodyStr)
+	assert.Equal(t, batches, handler.jaegerBatchesHandler.(*mockJaegerHandler).getBatches())
+
+	handler.jaegerBatchesHandler.(*mockJaegerHandler).err = tchannel.ErrServerBusy
+	statusCode, _, err = postBytes(server.URL+`/api/import?format=jaeger-thrift`, buffer.Bytes())
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusTooManyRequests, statusCode)
+
+	statusCode, resBodyStr, err = postBytes(server.URL+`/api/import?format=jaeger-thrift`, []byte("not good"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, resBodyStr, "Unable to process request body")
+
+	statusCode, resBodyStr, err = postBytes(server.URL+`/api/import?format=jaeger-thrift`, []byte{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, resBodyStr, "Unable to process request body")
+}
+
+func TestImportZipkinJSON(t *testing.T) {
+	server, handler := initializeTestServer(nil)
+	defer server.Close()
+	body := []byte(`[{"traceId": "0000000000000001", "id": "0000000000000002", "name": "x"}]`)
+
+	statusCode, resBodyStr, err := postBytes(server.URL+`/api/import?format=zipkin-json`, body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	assert.EqualValues(t, "", resBodyStr)
+	assert.Equal(t, []*zipkincore.Span{{TraceID: 1, ID: 2, Name: "x"}}, handler.zipkinSpansHandler.(*mockZipkinHandler).getSpans())
+
+	handler.zipkinSpansHandler.(*mockZipkinHandler).err = fmt.Errorf("Bad times ahead")
+	statusCode, resBodyStr, err = postBytes(server.URL+`/api/import?format=zipkin-json`, body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusInternalServerError, statusCode)
+	assert.EqualValues(t, "Cannot submit Zipkin batch: Bad times ahead\n", resBodyStr)
+
+	statusCode, resBodyStr, err = postBytes(server.URL+`/api/import?format=zipkin-json`, []byte("{"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "Unable to process request body: unexpected end of JSON input\n", resBodyStr)
+}
+
+func TestImportWrongFormat(t *testing.T) {
+	server, _ := initializeTestServer(nil)
+	defer server.Close()
+	statusCode, resBodyStr, err := postBytes(server.URL+`/api/import?format=jaeger.thrift`, []byte{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, statusCode)
+	assert.EqualValues(t, "Unsupported format type: jaeger.thrift\n", resBodyStr)
 }
 
 type errReader struct{}
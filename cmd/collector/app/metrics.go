@@ -43,8 +43,25 @@ type SpanProcessorMetrics struct { //TODO - initialize metrics in the traditiona
 	BatchSize metrics.Gauge // size of span batch
 	// QueueLength measures the size of the internal span queue
 	QueueLength metrics.Gauge
+	// QueueCapacity measures the capacity of the internal span queue, which changes with the size of
+	// the spans when the queue is bounded by memory
+	QueueCapacity metrics.Gauge
 	// ErrorBusy counts number of return ErrServerBusy
 	ErrorBusy metrics.Counter
+	// SpansRetried counts the retries of spans that failed to be saved
+	SpansRetried metrics.Counter
+	// SpansFailed counts the spans that could not be saved, even after retries, and were discarded
+	SpansFailed metrics.Counter
+	// SpansSpilled counts the spans that failed to be saved and were spilled to the on-disk queue
+	SpansSpilled metrics.Counter
+	// SpansReplayed counts the spilled spans that were later saved
+	SpansReplayed metrics.Counter
+	// SpillDropped counts the spans discarded because the on-disk queue was full
+	SpillDropped metrics.Counter
+	// SpillLength measures the number of spans in the on-disk queue
+	SpillLength metrics.Gauge
+	// SpillBytes measures the disk usage of the on-disk queue
+	SpillBytes metrics.Gauge
 	// SavedBySvc contains span and trace counts by service
 	SavedBySvc   metricsBySvc  // spans actually saved
 	serviceNames metrics.Gauge // total number of unique service name metrics reported by this collector
@@ -89,7 +106,15 @@ func NewSpanProcessorMetrics(serviceMetrics metrics.Factory, hostMetrics metrics
 		SpansDropped:   hostMetrics.Counter("spans.dropped", nil),
 		BatchSize:      hostMetrics.Gauge("batch-size", nil),
 		QueueLength:    hostMetrics.Gauge("queue-length", nil),
+		QueueCapacity:  hostMetrics.Gauge("queue-capacity", nil),
 		ErrorBusy:      hostMetrics.Counter("error.busy", nil),
+		SpansRetried:   hostMetrics.Counter("spans.retried", nil),
+		SpansFailed:    hostMetrics.Counter("spans.failed", nil),
+		SpansSpilled:   hostMetrics.Counter("spans.spilled", nil),
+		SpansReplayed:  hostMetrics.Counter("spans.replayed", nil),
+		SpillDropped:   hostMetrics.Counter("spill.dropped", nil),
+		SpillLength:    hostMetrics.Gauge("spill.length", nil),
+		SpillBytes:     hostMetrics.Gauge("spill.bytes", nil),
 		SavedBySvc:     newMetricsBySvc(serviceMetrics, "saved-by-svc"),
 		spanCounts:     spanCounts,
 		serviceNames:   hostMetrics.Gauge("spans.serviceNames", nil),
@@ -21,12 +21,15 @@
 package app
 
 import (
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger/model"
 
 	"github.com/uber/jaeger/cmd/collector/app/sanitizer"
+	"github.com/uber/jaeger/pkg/queue"
 )
 
 const (
@@ -34,6 +37,12 @@ const (
 	DefaultNumWorkers = 50
 	// DefaultQueueSize is the size of the processor's queue
 	DefaultQueueSize = 2000
+	// DefaultRetryBackoff is the default delay before the first retry of a span that failed to be saved
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultMaxRetryBackoff is the default upper bound of the delay between retries
+	DefaultMaxRetryBackoff = 30 * time.Second
+	// DefaultMaxReplayAttempts is the default number of times a spilled span is replayed before it is discarded
+	DefaultMaxReplayAttempts = 100
 )
 
 type options struct {
@@ -49,6 +58,12 @@ type options struct {
 	queueSize        int
 	reportBusy       bool
 	extraFormatTypes []string
+	maxRetries       int
+	retryBackoff     time.Duration
+	maxRetryBackoff  time.Duration
+	maxReplays       int
+	spillQueue       *queue.DiskQueue
+	queueMaxBytes    int64
 }
 
 // Option is a function that sets some option on StorageBuilder.
@@ -141,6 +156,52 @@ func (options) ExtraFormatTypes(extraFormatTypes []string) Option {
 	}
 }
 
+// MaxRetries creates an Option that initializes the number of times a span that failed to be saved is retried
+func (options) MaxRetries(maxRetries int) Option {
+	return func(b *options) {
+		b.maxRetries = maxRetries
+	}
+}
+
+// RetryBackoff creates an Option that initializes the delay before the first retry, the delay doubles
+// with every retry
+func (options) RetryBackoff(retryBackoff time.Duration) Option {
+	return func(b *options) {
+		b.retryBackoff = retryBackoff
+	}
+}
+
+// MaxRetryBackoff creates an Option that initializes the upper bound of the delay between retries
+func (options) MaxRetryBackoff(maxRetryBackoff time.Duration) Option {
+	return func(b *options) {
+		b.maxRetryBackoff = maxRetryBackoff
+	}
+}
+
+// MaxReplayAttempts creates an Option that initializes the number of times a spilled span is replayed before
+// it is discarded, so that a span a storage keeps rejecting does not block the spans spilled after it
+func (options) MaxReplayAttempts(maxReplays int) Option {
+	return func(b *options) {
+		b.maxReplays = maxReplays
+	}
+}
+
+// SpillQueue creates an Option that initializes the on-disk queue the spans that failed to be saved are
+// spilled to, to be replayed once the storage recovers
+func (options) SpillQueue(spillQueue *queue.DiskQueue) Option {
+	return func(b *options) {
+		b.spillQueue = spillQueue
+	}
+}
+
+// QueueMaxBytes creates an Option that initializes the memory budget of the queue in bytes, the capacity
+// of the queue is adjusted at runtime to the average size of the spans so that they fit in the budget
+func (options) QueueMaxBytes(queueMaxBytes int64) Option {
+	return func(b *options) {
+		b.queueMaxBytes = queueMaxBytes
+	}
+}
+
 func (o options) apply(opts ...Option) options {
 	ret := options{}
 	for _, opt := range opts {
@@ -170,5 +231,14 @@ func (o options) apply(opts ...Option) options {
 	if ret.numWorkers == 0 {
 		ret.numWorkers = DefaultNumWorkers
 	}
+	if ret.retryBackoff == 0 {
+		ret.retryBackoff = DefaultRetryBackoff
+	}
+	if ret.maxRetryBackoff == 0 {
+		ret.maxRetryBackoff = DefaultMaxRetryBackoff
+	}
+	if ret.maxReplays == 0 {
+		ret.maxReplays = DefaultMaxReplayAttempts
+	}
 	return ret
 }
@@ -22,12 +22,14 @@ package app
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/queue"
 )
 
 func TestAllOptionSet(t *testing.T) {
@@ -45,15 +47,33 @@ func TestAllOptionSet(t *testing.T) {
 		Options.Sanitizer(func(span *model.Span) *model.Span { return span }),
 		Options.QueueSize(10),
 		Options.PreSave(func(span *model.Span) {}),
+		Options.MaxRetries(3),
+		Options.RetryBackoff(time.Second),
+		Options.MaxRetryBackoff(time.Minute),
+		Options.MaxReplayAttempts(7),
+		Options.SpillQueue(&queue.DiskQueue{}),
+		Options.QueueMaxBytes(1024),
 	)
 	assert.EqualValues(t, 5, opts.numWorkers)
 	assert.EqualValues(t, 10, opts.queueSize)
+	assert.EqualValues(t, 3, opts.maxRetries)
+	assert.Equal(t, time.Second, opts.retryBackoff)
+	assert.Equal(t, time.Minute, opts.maxRetryBackoff)
+	assert.EqualValues(t, 7, opts.maxReplays)
+	assert.NotNil(t, opts.spillQueue)
+	assert.EqualValues(t, 1024, opts.queueMaxBytes)
 }
 
 func TestNoOptionsSet(t *testing.T) {
 	opts := Options.apply()
 	assert.EqualValues(t, DefaultNumWorkers, opts.numWorkers)
 	assert.EqualValues(t, 0, opts.queueSize)
+	assert.EqualValues(t, 0, opts.maxRetries)
+	assert.Equal(t, DefaultRetryBackoff, opts.retryBackoff)
+	assert.Equal(t, DefaultMaxRetryBackoff, opts.maxRetryBackoff)
+	assert.EqualValues(t, DefaultMaxReplayAttempts, opts.maxReplays)
+	assert.Nil(t, opts.spillQueue)
+	assert.EqualValues(t, 0, opts.queueMaxBytes)
 	assert.False(t, opts.reportBusy)
 	assert.False(t, opts.blockingSubmit)
 	assert.NotPanics(t, func() { opts.preProcessSpans(nil) })
@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sampling
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/tchannel-go/thrift"
+
+	"github.com/uber/jaeger/thrift-gen/sampling"
+)
+
+type mockStrategyStore struct {
+	response *sampling.SamplingStrategyResponse
+	err      error
+}
+
+func (s *mockStrategyStore) GetSamplingStrategy(serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	return s.response, s.err
+}
+
+func TestGetSamplingStrategy(t *testing.T) {
+	response := &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.5},
+	}
+	handler := NewHandler(&mockStrategyStore{response: response})
+	ctx, cancel := thrift.NewContext(time.Second)
+	defer cancel()
+	resp, err := handler.GetSamplingStrategy(ctx, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, response, resp)
+
+	handler = NewHandler(&mockStrategyStore{err: errors.New("no strategy")})
+	_, err = handler.GetSamplingStrategy(ctx, "foo")
+	assert.EqualError(t, err, "no strategy")
+}
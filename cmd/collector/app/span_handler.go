@@ -21,6 +21,8 @@
 package app
 
 import (
+	"io"
+
 	"github.com/uber/tchannel-go/thrift"
 	"go.uber.org/zap"
 
@@ -58,6 +60,8 @@ type JaegerBatchesHandler interface {
 type SpanProcessor interface {
 	// ProcessSpans processes model spans and return with either a list of true/false success or an error
 	ProcessSpans(mSpans []*model.Span, spanFormat string) ([]bool, error)
+	// Close stops processing spans and closes the span writer
+	io.Closer
 }
 
 type jaegerBatchesHandler struct {
@@ -85,6 +85,10 @@ func (s *shouldIErrorProcessor) ProcessSpans(mSpans []*model.Span, format string
 	return retMe, nil
 }
 
+func (s *shouldIErrorProcessor) Close() error {
+	return nil
+}
+
 func TestZipkinSpanHandler(t *testing.T) {
 	testChunks := []struct {
 		expectedErr error
@@ -21,19 +21,39 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/uber/tchannel-go"
 	"go.uber.org/zap"
 
 	"github.com/uber/jaeger/model"
+	jConverter "github.com/uber/jaeger/model/converter/json"
+	jModel "github.com/uber/jaeger/model/json"
 	"github.com/uber/jaeger/storage/spanstore"
 
 	"github.com/uber/jaeger/cmd/collector/app/sanitizer"
 	"github.com/uber/jaeger/pkg/queue"
 )
 
+var errEmptySpilledSpan = errors.New("spilled span is empty")
+
+// maxSpillReadAttempts is how many times in a row a spilled span that cannot be read is tried before it is discarded
+const maxSpillReadAttempts = 3
+
+// queueResizeInterval is how often the capacity of a queue bounded by memory is adjusted to the size of the spans
+const queueResizeInterval = time.Minute
+
 type spanProcessor struct {
+	// enqueuedSpans and enqueuedBytes measure the spans enqueued since the last resize of the queue,
+	// they come first to be 64-bit aligned for atomic operations
+	enqueuedSpans   int64
+	enqueuedBytes   int64
 	queue           *queue.BoundedQueue
 	metrics         *SpanProcessorMetrics
 	preProcessSpans ProcessSpans
@@ -44,11 +64,20 @@ type spanProcessor struct {
 	spanWriter      spanstore.Writer
 	reportBusy      bool
 	numWorkers      int
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	maxReplays      int
+	spillQueue      *queue.DiskQueue
+	queueMaxBytes   int64
+	stopCh          chan struct{}
+	stopWG          sync.WaitGroup
 }
 
 type queueItem struct {
 	queuedTime time.Time
 	span       *model.Span
+	size       int64
 }
 
 // NewSpanProcessor returns a SpanProcessor that preProcesses, filters, queues, sanitizes, and processes spans
@@ -64,6 +93,18 @@ func NewSpanProcessor(
 	})
 
 	sp.queue.StartLengthReporting(1*time.Second, sp.metrics.QueueLength)
+	sp.metrics.QueueCapacity.Update(int64(sp.queue.Capacity()))
+
+	if sp.queueMaxBytes > 0 {
+		sp.stopWG.Add(1)
+		go sp.resizeQueuePeriodically()
+	}
+
+	if sp.spillQueue != nil {
+		sp.reportSpillSize()
+		sp.stopWG.Add(1)
+		go sp.replaySpilledSpans()
+	}
 
 	return sp
 }
@@ -77,7 +118,14 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 	droppedItemHandler := func(item interface{}) {
 		handlerMetrics.SpansDropped.Inc(1)
 	}
-	boundedQueue := queue.NewBoundedQueue(options.queueSize, droppedItemHandler)
+	var boundedQueue *queue.BoundedQueue
+	if options.queueMaxBytes > 0 {
+		boundedQueue = queue.NewByteBoundedQueue(options.queueSize, options.queueMaxBytes, func(item interface{}) int64 {
+			return item.(*queueItem).size
+		}, droppedItemHandler)
+	} else {
+		boundedQueue = queue.NewBoundedQueue(options.queueSize, droppedItemHandler)
+	}
 
 	sp := spanProcessor{
 		queue:           boundedQueue,
@@ -89,6 +137,13 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		reportBusy:      options.reportBusy,
 		numWorkers:      options.numWorkers,
 		spanWriter:      spanWriter,
+		maxRetries:      options.maxRetries,
+		retryBackoff:    options.retryBackoff,
+		maxRetryBackoff: options.maxRetryBackoff,
+		maxReplays:      options.maxReplays,
+		spillQueue:      options.spillQueue,
+		queueMaxBytes:   options.queueMaxBytes,
+		stopCh:          make(chan struct{}),
 	}
 	sp.processSpan = ChainedProcessSpan(
 		options.preSave,
@@ -98,19 +153,202 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 	return &sp
 }
 
-// Stop halts the span processor and all its go-routines.
+// Stop halts the span processor and all its go-routines. Spans being retried are spilled to the
+// on-disk queue, if there is one, or discarded.
 func (sp *spanProcessor) Stop() {
+	close(sp.stopCh)
 	sp.queue.Stop()
+	sp.stopWG.Wait()
+}
+
+// Close stops the span processor, then closes the span writer if it is an io.Closer so that
+// the spans it buffers are flushed.
+func (sp *spanProcessor) Close() error {
+	sp.Stop()
+	return spanstore.CloseWriter(sp.spanWriter)
 }
 
+// saveSpan writes the span to storage, retrying with exponential backoff if the write fails. When the span
+// is written to several backends only the ones that failed are retried. Spans that cannot be saved are
+// spilled to the on-disk queue, if there is one, or discarded.
 func (sp *spanProcessor) saveSpan(span *model.Span) {
+	failed, err := sp.writeSpan(span, nil)
+	backoff := sp.retryBackoff
+	for retry := 0; err != nil && retry < sp.maxRetries; retry++ {
+		if !sp.wait(backoff) {
+			break
+		}
+		backoff = sp.nextBackoff(backoff)
+		sp.metrics.SpansRetried.Inc(1)
+		failed, err = sp.writeSpan(span, failed)
+	}
+	if err == nil {
+		return
+	}
+	sp.logger.Error("Failed to save span", zap.Error(err))
+	if sp.spillQueue != nil {
+		sp.spillSpan(span, failed)
+	} else {
+		sp.metrics.SpansFailed.Inc(1)
+	}
+}
+
+// writeSpan writes the span with the writers of the multiplex span writer with the given names, or with the
+// span writer if it is not a multiplex one, and returns the names of the writers that failed
+func (sp *spanProcessor) writeSpan(span *model.Span, writers []string) ([]string, error) {
 	startTime := time.Now()
-	if err := sp.spanWriter.WriteSpan(span); err != nil {
-		sp.logger.Error("Failed to save span", zap.Error(err))
+	var failed []string
+	var err error
+	if multiplexWriter, ok := sp.spanWriter.(*spanstore.MultiplexWriter); ok {
+		failed, err = multiplexWriter.WriteSpanTo(context.Background(), span, writers)
 	} else {
+		err = sp.spanWriter.WriteSpan(span)
+	}
+	if err == nil {
 		sp.metrics.SavedBySvc.ReportServiceNameForSpan(span)
 	}
 	sp.metrics.SaveLatency.Record(time.Now().Sub(startTime))
+	return failed, err
+}
+
+func (sp *spanProcessor) nextBackoff(backoff time.Duration) time.Duration {
+	if backoff *= 2; backoff > sp.maxRetryBackoff {
+		return sp.maxRetryBackoff
+	}
+	return backoff
+}
+
+// wait sleeps for the given duration and returns false if the processor was stopped in the meantime
+func (sp *spanProcessor) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-sp.stopCh:
+		return false
+	}
+}
+
+// spilledSpan is the on-disk record of a spilled span
+type spilledSpan struct {
+	// Writers are the names of the writers of the multiplex span writer the span still has to be written
+	// with, all of them if empty
+	Writers []string     `json:"writers,omitempty"`
+	Span    *jModel.Span `json:"span"`
+}
+
+func (sp *spanProcessor) spillSpan(span *model.Span, writers []string) {
+	data, err := json.Marshal(&spilledSpan{
+		Writers: writers,
+		Span:    jConverter.FromDomainEmbedProcess(span),
+	})
+	if err == nil {
+		err = sp.spillQueue.Put(data)
+	}
+	if err == queue.ErrDiskQueueFull {
+		sp.metrics.SpillDropped.Inc(1)
+		return
+	}
+	if err != nil {
+		sp.logger.Error("Failed to spill span", zap.Error(err))
+		sp.metrics.SpansFailed.Inc(1)
+		return
+	}
+	sp.metrics.SpansSpilled.Inc(1)
+	sp.reportSpillSize()
+}
+
+// replaySpilledSpans saves the spilled spans, oldest first, until the processor is stopped. The spans
+// stay on disk until they are saved, so they survive restarts. While the storage keeps failing the
+// replay backs off, to not overload a storage that is still recovering. A span is only retried with the
+// writers that failed, which are recorded by name so that they are still found after a restart. A span
+// that still cannot be saved after maxReplays attempts is discarded, so that a span a storage rejects
+// for good does not block the spans spilled after it.
+func (sp *spanProcessor) replaySpilledSpans() {
+	defer sp.stopWG.Done()
+	backoff := sp.retryBackoff
+	readFailures := 0
+	for {
+		select {
+		case <-sp.stopCh:
+			return
+		default:
+		}
+		data, err := sp.spillQueue.Front()
+		if err != nil && err != queue.ErrDiskQueueEmpty {
+			readFailures++
+			if os.IsNotExist(err) || readFailures >= maxSpillReadAttempts {
+				sp.logger.Error("Discarding spilled span that cannot be read", zap.Error(err))
+				readFailures = 0
+				sp.removeSpilledSpan()
+				sp.metrics.SpansFailed.Inc(1)
+				continue
+			}
+			sp.logger.Error("Failed to read spilled span", zap.Error(err))
+		}
+		if err != nil {
+			if !sp.wait(sp.retryBackoff) {
+				return
+			}
+			continue
+		}
+		readFailures = 0
+		span, writers, err := decodeSpilledSpan(data)
+		if err != nil {
+			sp.logger.Error("Discarding spilled span that cannot be decoded", zap.Error(err))
+			sp.removeSpilledSpan()
+			sp.metrics.SpansFailed.Inc(1)
+			continue
+		}
+		for attempt := 1; ; attempt++ {
+			failed, err := sp.writeSpan(span, writers)
+			if err == nil {
+				sp.metrics.SpansReplayed.Inc(1)
+				break
+			}
+			if attempt >= sp.maxReplays {
+				sp.logger.Error("Discarding spilled span that cannot be saved", zap.Error(err))
+				sp.metrics.SpansFailed.Inc(1)
+				break
+			}
+			if failed != nil {
+				// only the writers that failed are retried
+				writers = failed
+			}
+			if !sp.wait(backoff) {
+				return
+			}
+			backoff = sp.nextBackoff(backoff)
+		}
+		backoff = sp.retryBackoff
+		sp.removeSpilledSpan()
+	}
+}
+
+func decodeSpilledSpan(data []byte) (*model.Span, []string, error) {
+	var spilled spilledSpan
+	if err := json.Unmarshal(data, &spilled); err != nil {
+		return nil, nil, err
+	}
+	if spilled.Span == nil {
+		return nil, nil, errEmptySpilledSpan
+	}
+	span, err := jConverter.SpanToDomain(spilled.Span)
+	return span, spilled.Writers, err
+}
+
+func (sp *spanProcessor) removeSpilledSpan() {
+	if err := sp.spillQueue.Remove(); err != nil {
+		sp.logger.Error("Failed to remove spilled span", zap.Error(err))
+		sp.wait(sp.retryBackoff)
+	}
+	sp.reportSpillSize()
+}
+
+func (sp *spanProcessor) reportSpillSize() {
+	sp.metrics.SpillLength.Update(int64(sp.spillQueue.Size()))
+	sp.metrics.SpillBytes.Update(sp.spillQueue.Bytes())
 }
 
 func (sp *spanProcessor) ProcessSpans(mSpans []*model.Span, spanFormat string) ([]bool, error) {
@@ -145,9 +383,52 @@ func (sp *spanProcessor) enqueueSpan(span *model.Span, originalFormat string) bo
 		queuedTime: time.Now(),
 		span:       span,
 	}
+	if sp.queueMaxBytes > 0 {
+		item.size = estimateSpanSize(span)
+		atomic.AddInt64(&sp.enqueuedSpans, 1)
+		atomic.AddInt64(&sp.enqueuedBytes, item.size)
+	}
 	addedToQueue := sp.queue.Produce(item)
 	if !addedToQueue {
 		sp.metrics.ErrorBusy.Inc(1)
 	}
 	return addedToQueue
 }
+
+func (sp *spanProcessor) resizeQueuePeriodically() {
+	defer sp.stopWG.Done()
+	ticker := time.NewTicker(queueResizeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sp.resizeQueue()
+		case <-sp.stopCh:
+			return
+		}
+	}
+}
+
+// resizeQueue changes the capacity of the queue to the number of spans of the average size seen
+// since the last resize that fit in the memory budget. The budget itself is enforced by the queue,
+// the capacity only keeps the number of spans from being the limit.
+func (sp *spanProcessor) resizeQueue() {
+	spans := atomic.SwapInt64(&sp.enqueuedSpans, 0)
+	bytes := atomic.SwapInt64(&sp.enqueuedBytes, 0)
+	if spans == 0 || bytes == 0 {
+		return
+	}
+	capacity := int(sp.queueMaxBytes / (bytes / spans))
+	if capacity < 1 {
+		capacity = 1
+	}
+	current := sp.queue.Capacity()
+	// ignore changes under 20% so that the queue is not swapped for small variations of span sizes
+	if diff := capacity - current; diff*5 < current && -diff*5 < current {
+		return
+	}
+	if sp.queue.Resize(capacity) {
+		sp.logger.Info("Resized the span queue", zap.Int("old-capacity", current), zap.Int("new-capacity", capacity))
+		sp.metrics.QueueCapacity.Update(int64(capacity))
+	}
+}
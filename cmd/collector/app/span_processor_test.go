@@ -21,21 +21,29 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	metricsTest "github.com/uber/jaeger-lib/metrics/testutils"
+	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/thrift"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 
 	zipkinSanitizer "github.com/uber/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/queue"
 	"github.com/uber/jaeger/pkg/testutils"
+	"github.com/uber/jaeger/storage/spanstore"
 	"github.com/uber/jaeger/thrift-gen/jaeger"
 	zc "github.com/uber/jaeger/thrift-gen/zipkincore"
 )
@@ -298,3 +306,333 @@ func TestSpanProcessorBusy(t *testing.T) {
 	assert.Error(t, err, "expcting busy error")
 	assert.Nil(t, res)
 }
+
+// flakyWriter fails the given number of writes, or all of them when failures is negative
+type flakyWriter struct {
+	sync.Mutex
+	failures int
+	spans    []*model.Span
+}
+
+func (w *flakyWriter) WriteSpan(span *model.Span) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.failures != 0 {
+		if w.failures > 0 {
+			w.failures--
+		}
+		return errors.New("storage is down")
+	}
+	w.spans = append(w.spans, span)
+	return nil
+}
+
+func (w *flakyWriter) setFailures(failures int) {
+	w.Lock()
+	defer w.Unlock()
+	w.failures = failures
+}
+
+func (w *flakyWriter) written() int {
+	w.Lock()
+	defer w.Unlock()
+	return len(w.spans)
+}
+
+func waitForCounter(t *testing.T, mf *metrics.LocalFactory, name string, expected int64) {
+	for i := 0; i < 1000; i++ {
+		if counters, _ := mf.Snapshot(); counters[name] == expected {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	counters, _ := mf.Snapshot()
+	t.Fatalf("expected counter %s to be %d, got %d", name, expected, counters[name])
+}
+
+func withSpillQueue(t *testing.T, f func(dir string, spillQueue *queue.DiskQueue)) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	spillQueue, err := queue.NewDiskQueue(dir, 0)
+	require.NoError(t, err)
+	f(dir, spillQueue)
+}
+
+func processSpan(t *testing.T, p SpanProcessor, serviceName string) {
+	res, err := p.ProcessSpans([]*model.Span{{
+		TraceID: model.TraceID{Low: 1},
+		SpanID:  model.SpanID(1),
+		Process: model.NewProcess(serviceName, nil),
+	}}, JaegerFormatType)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, res)
+}
+
+func TestSpanProcessorRetries(t *testing.T) {
+	mf := metrics.NewLocalFactory(0)
+	w := &flakyWriter{failures: 2}
+	p := NewSpanProcessor(w,
+		Options.HostMetrics(mf),
+		Options.MaxRetries(3),
+		Options.RetryBackoff(time.Millisecond),
+	).(*spanProcessor)
+	defer p.Stop()
+
+	processSpan(t, p, "x")
+	waitForCounter(t, mf, "spans.retried", 2)
+	assert.Equal(t, 1, w.written())
+	counters, _ := mf.Snapshot()
+	assert.EqualValues(t, 0, counters["spans.failed"])
+}
+
+func TestSpanProcessorRetriesExhausted(t *testing.T) {
+	mf := metrics.NewLocalFactory(0)
+	p := NewSpanProcessor(&flakyWriter{failures: -1},
+		Options.HostMetrics(mf),
+		Options.MaxRetries(2),
+		Options.RetryBackoff(time.Millisecond),
+	).(*spanProcessor)
+	defer p.Stop()
+
+	processSpan(t, p, "x")
+	waitForCounter(t, mf, "spans.failed", 1)
+	counters, _ := mf.Snapshot()
+	assert.EqualValues(t, 2, counters["spans.retried"])
+}
+
+func TestSpanProcessorRetriesFailedWritersOnly(t *testing.T) {
+	mf := metrics.NewLocalFactory(0)
+	healthy, flaky := &flakyWriter{}, &flakyWriter{failures: 2}
+	p := NewSpanProcessor(spanstore.NewMultiplexWriter(healthy, flaky),
+		Options.HostMetrics(mf),
+		Options.MaxRetries(3),
+		Options.RetryBackoff(time.Millisecond),
+	).(*spanProcessor)
+	defer p.Stop()
+
+	processSpan(t, p, "x")
+	waitForCounter(t, mf, "spans.retried", 2)
+	for i := 0; i < 1000 && flaky.written() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, healthy.written(), "the span is not written again to the writer that succeeded")
+	assert.Equal(t, 1, flaky.written())
+}
+
+func TestSpanProcessorNextBackoff(t *testing.T) {
+	p := newSpanProcessor(&fakeSpanWriter{}, Options.MaxRetryBackoff(3*time.Second))
+	assert.Equal(t, 2*time.Second, p.nextBackoff(time.Second))
+	assert.Equal(t, 3*time.Second, p.nextBackoff(2*time.Second))
+}
+
+func TestSpanProcessorSpillAndReplay(t *testing.T) {
+	withSpillQueue(t, func(dir string, spillQueue *queue.DiskQueue) {
+		mf := metrics.NewLocalFactory(0)
+		w := &flakyWriter{failures: -1}
+		p := NewSpanProcessor(w,
+			Options.HostMetrics(mf),
+			Options.RetryBackoff(time.Millisecond),
+			Options.MaxRetryBackoff(time.Millisecond),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		defer p.Stop()
+
+		processSpan(t, p, "x")
+		waitForCounter(t, mf, "spans.spilled", 1)
+		assert.Equal(t, 0, w.written())
+
+		w.setFailures(0)
+		waitForCounter(t, mf, "spans.replayed", 1)
+		require.Equal(t, 1, w.written())
+		assert.Equal(t, "x", w.spans[0].Process.ServiceName)
+		assert.Equal(t, 0, spillQueue.Size())
+		_, gauges := mf.Snapshot()
+		assert.EqualValues(t, 0, gauges["spill.length"])
+		assert.EqualValues(t, 0, gauges["spill.bytes"])
+	})
+}
+
+func TestSpanProcessorSpillAndReplayFailedWritersOnly(t *testing.T) {
+	withSpillQueue(t, func(dir string, spillQueue *queue.DiskQueue) {
+		mf := metrics.NewLocalFactory(0)
+		healthy, flaky := &flakyWriter{}, &flakyWriter{failures: -1}
+		p := NewSpanProcessor(spanstore.NewMultiplexWriter(healthy, flaky),
+			Options.HostMetrics(mf),
+			Options.RetryBackoff(time.Millisecond),
+			Options.MaxRetryBackoff(time.Millisecond),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		defer p.Stop()
+
+		processSpan(t, p, "x")
+		waitForCounter(t, mf, "spans.spilled", 1)
+		assert.Equal(t, 1, healthy.written())
+		assert.Equal(t, 0, flaky.written())
+
+		flaky.setFailures(0)
+		waitForCounter(t, mf, "spans.replayed", 1)
+		assert.Equal(t, 1, healthy.written())
+		assert.Equal(t, 1, flaky.written())
+	})
+}
+
+func TestSpanProcessorDiscardsSpilledSpansAfterMaxReplayAttempts(t *testing.T) {
+	withSpillQueue(t, func(dir string, spillQueue *queue.DiskQueue) {
+		mf := metrics.NewLocalFactory(0)
+		rejecting, w := &flakyWriter{failures: -1}, &flakyWriter{}
+		p := NewSpanProcessor(spanstore.NewMultiplexWriter(w, rejecting),
+			Options.HostMetrics(mf),
+			Options.RetryBackoff(time.Millisecond),
+			Options.MaxRetryBackoff(time.Millisecond),
+			Options.MaxReplayAttempts(2),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		defer p.Stop()
+
+		processSpan(t, p, "x")
+		processSpan(t, p, "y")
+		waitForCounter(t, mf, "spans.failed", 2)
+		assert.Equal(t, 0, spillQueue.Size())
+		assert.Equal(t, 2, w.written())
+		counters, _ := mf.Snapshot()
+		assert.EqualValues(t, 0, counters["spans.replayed"])
+	})
+}
+
+func TestSpanProcessorSpillSurvivesRestart(t *testing.T) {
+	withSpillQueue(t, func(dir string, spillQueue *queue.DiskQueue) {
+		mf := metrics.NewLocalFactory(0)
+		p := NewSpanProcessor(&flakyWriter{failures: -1},
+			Options.HostMetrics(mf),
+			Options.RetryBackoff(time.Hour),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		processSpan(t, p, "x")
+		waitForCounter(t, mf, "spans.spilled", 1)
+		_, gauges := mf.Snapshot()
+		assert.EqualValues(t, 1, gauges["spill.length"])
+		assert.EqualValues(t, spillQueue.Bytes(), gauges["spill.bytes"])
+		p.Stop()
+
+		spillQueue, err := queue.NewDiskQueue(dir, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, spillQueue.Size())
+		mf = metrics.NewLocalFactory(0)
+		w := &flakyWriter{}
+		p = NewSpanProcessor(w,
+			Options.HostMetrics(mf),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		defer p.Stop()
+		waitForCounter(t, mf, "spans.replayed", 1)
+		assert.Equal(t, 1, w.written())
+	})
+}
+
+func TestSpanProcessorSpillFull(t *testing.T) {
+	withSpillQueue(t, func(dir string, _ *queue.DiskQueue) {
+		spillQueue, err := queue.NewDiskQueue(dir, 1)
+		require.NoError(t, err)
+		mf := metrics.NewLocalFactory(0)
+		p := NewSpanProcessor(&flakyWriter{failures: -1},
+			Options.HostMetrics(mf),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		defer p.Stop()
+
+		processSpan(t, p, "x")
+		waitForCounter(t, mf, "spill.dropped", 1)
+		assert.Equal(t, 0, spillQueue.Size())
+	})
+}
+
+func TestSpanProcessorDiscardsUndecodableSpilledSpans(t *testing.T) {
+	withSpillQueue(t, func(dir string, spillQueue *queue.DiskQueue) {
+		require.NoError(t, spillQueue.Put([]byte("{")))
+		mf := metrics.NewLocalFactory(0)
+		p := NewSpanProcessor(&flakyWriter{}, Options.HostMetrics(mf), Options.SpillQueue(spillQueue)).(*spanProcessor)
+		defer p.Stop()
+
+		waitForCounter(t, mf, "spans.failed", 1)
+		assert.Equal(t, 0, spillQueue.Size())
+	})
+}
+
+func TestSpanProcessorDiscardsUnreadableSpilledSpans(t *testing.T) {
+	withSpillQueue(t, func(dir string, spillQueue *queue.DiskQueue) {
+		require.NoError(t, spillQueue.Put([]byte("missing")))
+		require.NoError(t, spillQueue.Put([]byte("unreadable")))
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		require.NoError(t, os.Remove(filepath.Join(dir, files[0].Name())))
+		// reading a directory fails every time
+		require.NoError(t, os.Remove(filepath.Join(dir, files[1].Name())))
+		require.NoError(t, os.Mkdir(filepath.Join(dir, files[1].Name()), 0755))
+
+		mf := metrics.NewLocalFactory(0)
+		p := NewSpanProcessor(&flakyWriter{},
+			Options.HostMetrics(mf),
+			Options.RetryBackoff(time.Millisecond),
+			Options.SpillQueue(spillQueue),
+		).(*spanProcessor)
+		defer p.Stop()
+
+		waitForCounter(t, mf, "spans.failed", 2)
+		assert.Equal(t, 0, spillQueue.Size())
+	})
+}
+
+func TestSpanProcessorQueueMaxBytes(t *testing.T) {
+	w := &blockingWriter{}
+	mf := metrics.NewLocalFactory(0)
+	span := &model.Span{Process: model.NewProcess("x", nil)}
+	p := NewSpanProcessor(w,
+		Options.HostMetrics(mf),
+		Options.NumWorkers(1),
+		Options.QueueSize(100),
+		Options.QueueMaxBytes(estimateSpanSize(span)),
+		Options.ReportBusy(true),
+	).(*spanProcessor)
+	defer p.Stop()
+
+	// the queue has room for plenty of spans but memory for just one, so with the processor
+	// blocked on the first span either the second or the third one is rejected
+	w.Lock()
+	defer w.Unlock()
+
+	res, err := p.ProcessSpans([]*model.Span{span, span, span}, JaegerFormatType)
+	assert.Equal(t, tchannel.ErrServerBusy, err)
+	assert.Nil(t, res)
+}
+
+func TestSpanProcessorResizeQueue(t *testing.T) {
+	span := &model.Span{Process: model.NewProcess("x", nil)}
+	spanSize := estimateSpanSize(span)
+	mf := metrics.NewLocalFactory(0)
+	p := NewSpanProcessor(&flakyWriter{},
+		Options.HostMetrics(mf),
+		Options.QueueSize(10),
+		Options.QueueMaxBytes(100*spanSize),
+	).(*spanProcessor)
+	defer p.Stop()
+	_, gauges := mf.Snapshot()
+	assert.EqualValues(t, 10, gauges["queue-capacity"])
+
+	p.resizeQueue()
+	assert.Equal(t, 10, p.queue.Capacity(), "nothing enqueued, the capacity is kept")
+
+	for i := 0; i < 3; i++ {
+		processSpan(t, p, "x")
+	}
+	p.resizeQueue()
+	assert.Equal(t, 100, p.queue.Capacity())
+	_, gauges = mf.Snapshot()
+	assert.EqualValues(t, 100, gauges["queue-capacity"])
+
+	p.queueMaxBytes = 110 * spanSize
+	processSpan(t, p, "x")
+	p.resizeQueue()
+	assert.Equal(t, 100, p.queue.Capacity(), "changes under 20% are ignored")
+}
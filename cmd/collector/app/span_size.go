@@ -0,0 +1,63 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"unsafe"
+
+	"github.com/uber/jaeger/model"
+)
+
+var (
+	spanSize     = int64(unsafe.Sizeof(model.Span{}))
+	keyValueSize = int64(unsafe.Sizeof(model.KeyValue{}))
+	logSize      = int64(unsafe.Sizeof(model.Log{}))
+	spanRefSize  = int64(unsafe.Sizeof(model.SpanRef{}))
+	processSize  = int64(unsafe.Sizeof(model.Process{}))
+	stringSize   = int64(unsafe.Sizeof(""))
+)
+
+// estimateSpanSize returns the approximate number of bytes of memory used by the span, i.e. the size of
+// its structs plus the contents of its strings and slices. It is used to bound the queue by memory
+// rather than by the number of spans, whose sizes vary a lot.
+func estimateSpanSize(span *model.Span) int64 {
+	size := spanSize + int64(len(span.OperationName))
+	size += int64(len(span.References)) * spanRefSize
+	size += estimateKeyValuesSize(span.Tags)
+	for _, log := range span.Logs {
+		size += logSize + estimateKeyValuesSize(log.Fields)
+	}
+	for _, warning := range span.Warnings {
+		size += stringSize + int64(len(warning))
+	}
+	if span.Process != nil {
+		size += processSize + int64(len(span.Process.ServiceName)) + estimateKeyValuesSize(span.Process.Tags)
+	}
+	return size
+}
+
+func estimateKeyValuesSize(keyValues []model.KeyValue) int64 {
+	size := int64(len(keyValues)) * keyValueSize
+	for _, kv := range keyValues {
+		size += int64(len(kv.Key) + len(kv.VStr) + len(kv.VBlob))
+	}
+	return size
+}
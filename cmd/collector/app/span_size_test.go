@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger/model"
+)
+
+func TestEstimateSpanSize(t *testing.T) {
+	span := &model.Span{}
+	empty := estimateSpanSize(span)
+	assert.Equal(t, spanSize, empty)
+
+	span.OperationName = "get"
+	span.References = []model.SpanRef{{}}
+	span.Tags = model.KeyValues{model.String("key", "value"), model.Binary("blob", []byte{1, 2})}
+	span.Logs = []model.Log{{Fields: []model.KeyValue{model.Int64("ok", 1)}}}
+	span.Warnings = []string{"warn"}
+	span.Process = model.NewProcess("service", []model.KeyValue{model.Bool("on", true)})
+	expected := empty +
+		3 + // operation name
+		spanRefSize +
+		2*keyValueSize + 3 + 5 + 4 + 2 + // tags
+		logSize + keyValueSize + 2 + // logs
+		stringSize + 4 + // warnings
+		processSize + 7 + keyValueSize + 2 // process
+	assert.Equal(t, expected, estimateSpanSize(span))
+}
@@ -93,7 +93,7 @@ func handleZipkinThrift(zHandler app.ZipkinSpansHandler, bodyBytes []byte, w htt
 
 	ctx, _ := tchanThrift.NewContext(time.Minute)
 	if _, err = zHandler.SubmitZipkinBatch(ctx, spans); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), app.SubmitErrorStatusCode(err))
 		return
 	}
 }
@@ -37,6 +37,7 @@ import (
 	"github.com/stretchr/testify/require"
 	jaegerClient "github.com/uber/jaeger-client-go"
 	zipkinTransport "github.com/uber/jaeger-client-go/transport/zipkin"
+	"github.com/uber/tchannel-go"
 	tchanThrift "github.com/uber/tchannel-go/thrift"
 
 	"github.com/uber/jaeger/thrift-gen/zipkincore"
@@ -120,6 +121,11 @@ func TestThriftFormat(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, http.StatusInternalServerError, statusCode)
 	assert.EqualValues(t, "Cannot submit Zipkin batch: Bad times ahead\n", resBodyStr)
+
+	handler.zipkinSpansHandler.(*mockZipkinHandler).err = tchannel.ErrServerBusy
+	statusCode, _, err = postBytes(server.URL+`/api/v1/spans`, bodyBytes, createHeader("application/x-thrift"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusTooManyRequests, statusCode)
 }
 
 func TestGzipEncoding(t *testing.T) {
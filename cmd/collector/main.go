@@ -25,7 +25,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"github.com/gorilla/mux"
 	"github.com/uber/jaeger/pkg/recoveryhandler"
@@ -35,15 +38,21 @@ import (
 
 	"github.com/uber/jaeger-lib/metrics/go-kit"
 	"github.com/uber/jaeger-lib/metrics/go-kit/expvar"
+	bc "github.com/uber/jaeger/thrift-gen/baggage"
 	jc "github.com/uber/jaeger/thrift-gen/jaeger"
+	sc "github.com/uber/jaeger/thrift-gen/sampling"
 	zc "github.com/uber/jaeger/thrift-gen/zipkincore"
 
 	basicB "github.com/uber/jaeger/cmd/builder"
 	"github.com/uber/jaeger/cmd/collector/app"
+	"github.com/uber/jaeger/cmd/collector/app/baggage"
 	"github.com/uber/jaeger/cmd/collector/app/builder"
+	"github.com/uber/jaeger/cmd/collector/app/sampling"
 	"github.com/uber/jaeger/cmd/collector/app/zipkin"
 	casFlags "github.com/uber/jaeger/cmd/flags/cassandra"
+	esFlags "github.com/uber/jaeger/cmd/flags/es"
 	infFlags "github.com/uber/jaeger/cmd/flags/influxdb"
+	kafkaFlags "github.com/uber/jaeger/cmd/flags/kafka"
 )
 
 const (
@@ -54,8 +63,14 @@ func main() {
 	casOptions := casFlags.NewOptions()
 	casOptions.Bind(flag.CommandLine, "cassandra")
 
+	esOptions := esFlags.NewOptions()
+	esOptions.Bind(flag.CommandLine, "es")
+
 	influxOptions := infFlags.NewOptions()
 	influxOptions.Bind(flag.CommandLine, "influx")
+
+	kafkaOptions := kafkaFlags.NewOptions()
+	kafkaOptions.Bind(flag.CommandLine, "kafka")
 	flag.Parse()
 
 	logger, _ := zap.NewProduction()
@@ -68,11 +83,25 @@ func main() {
 		basicB.Options.LoggerOption(logger),
 		basicB.Options.MetricsFactoryOption(baseMetrics),
 		basicB.Options.InfluxDBOption(influxOptions.GetPrimary()),
+		basicB.Options.ElasticSearchOption(esOptions.GetPrimary()),
+		basicB.Options.KafkaOption(kafkaOptions.GetPrimary()),
 	)
 	if err != nil {
 		logger.Fatal("Unable to set up builder", zap.Error(err))
 	}
-	zipkinSpansHandler, jaegerBatchesHandler, err := spanBuilder.BuildHandlers()
+	strategyStore, spanProcessorOpts, err := builder.NewStrategyStore(
+		basicB.Options.CassandraOption(casOptions.GetPrimary()),
+		basicB.Options.LoggerOption(logger),
+		basicB.Options.MetricsFactoryOption(baseMetrics),
+	)
+	if err != nil {
+		logger.Fatal("Unable to create the sampling strategy store", zap.Error(err))
+	}
+	restrictionStore, err := builder.NewRestrictionStore(basicB.Options.LoggerOption(logger))
+	if err != nil {
+		logger.Fatal("Unable to create the baggage restriction store", zap.Error(err))
+	}
+	zipkinSpansHandler, jaegerBatchesHandler, spanHandlersCloser, err := spanBuilder.BuildHandlers(spanProcessorOpts...)
 	if err != nil {
 		logger.Fatal("Unable to build span handlers", zap.Error(err))
 	}
@@ -84,6 +113,10 @@ func main() {
 	server := thrift.NewServer(ch)
 	server.Register(jc.NewTChanCollectorServer(jaegerBatchesHandler))
 	server.Register(zc.NewTChanZipkinCollectorServer(zipkinSpansHandler))
+	server.Register(sc.NewTChanSamplingManagerServer(sampling.NewHandler(strategyStore)))
+	if restrictionStore != nil {
+		server.Register(bc.NewTChanBaggageRestrictionManagerServer(baggage.NewHandler(restrictionStore)))
+	}
 
 	portStr := ":" + strconv.Itoa(*builder.CollectorPort)
 	listener, err := net.Listen("tcp", portStr)
@@ -93,15 +126,35 @@ func main() {
 	ch.Serve(listener)
 
 	r := mux.NewRouter()
-	app.NewAPIHandler(jaegerBatchesHandler).RegisterRoutes(r)
+	app.NewAPIHandler(jaegerBatchesHandler, zipkinSpansHandler).RegisterRoutes(r)
 	httpPortStr := ":" + strconv.Itoa(*builder.CollectorHTTPPort)
 	recoveryHandler := recoveryhandler.NewRecoveryHandler(logger, true)
 
 	go startZipkinHTTPAPI(logger, zipkinSpansHandler, recoveryHandler)
 
 	logger.Info("Listening for HTTP traffic", zap.Int("http-port", *builder.CollectorHTTPPort))
-	if err := http.ListenAndServe(httpPortStr, recoveryHandler(r)); err != nil {
-		logger.Fatal("Could not launch service", zap.Error(err))
+	go func() {
+		if err := http.ListenAndServe(httpPortStr, recoveryHandler(r)); err != nil {
+			logger.Fatal("Could not launch service", zap.Error(err))
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	logger.Info("Shutting down, waiting for queued spans to be written")
+	ch.Close()
+	if err := spanHandlersCloser.Close(); err != nil {
+		logger.Error("Failed to close the span writer", zap.Error(err))
+	}
+	if err := strategyStore.Close(); err != nil {
+		logger.Error("Failed to close the sampling strategy store", zap.Error(err))
+	}
+	if restrictionStore != nil {
+		if err := restrictionStore.Close(); err != nil {
+			logger.Error("Failed to close the baggage restriction store", zap.Error(err))
+		}
 	}
 }
 
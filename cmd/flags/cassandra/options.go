@@ -55,6 +55,7 @@ func NewOptions() *Options {
 				Keyspace:           "jaeger_v1_local",
 				ProtoVersion:       4,
 				ConnectionsPerHost: 2,
+				ReadWorkers:        10,
 			},
 			servers: "127.0.0.1",
 		},
@@ -147,4 +148,9 @@ func bind(
 		namespace+".socket-keep-alive",
 		defaults.SocketKeepAlive,
 		"Cassandra's keepalive period to use, enabled if > 0")
+	flags.IntVar(
+		&cfg.ReadWorkers,
+		namespace+".read-workers",
+		defaults.ReadWorkers,
+		"The number of traces loaded concurrently when searching for traces")
 }
@@ -54,6 +54,7 @@ func TestOptionsWithFlags(t *testing.T) {
 		"-cas.port=4242",
 		"-cas.proto-version=3",
 		"-cas.socket-keep-alive=42s",
+		"-cas.read-workers=42",
 		// a couple overrides
 		"-cas.aux.keyspace=jaeger-archive",
 		"-cas.aux.servers=3.3.3.3,4.4.4.4",
@@ -72,4 +73,5 @@ func TestOptionsWithFlags(t *testing.T) {
 	assert.Equal(t, 4242, aux.Port)
 	assert.Equal(t, 3, aux.ProtoVersion)
 	assert.Equal(t, 42*time.Second, aux.SocketKeepAlive)
+	assert.Equal(t, 42, aux.ReadWorkers)
 }
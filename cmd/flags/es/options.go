@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package es
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/uber/jaeger/pkg/es/config"
+)
+
+// Options stores the configuration of the ElasticSearch span storage
+type Options struct {
+	conf    config.Configuration
+	servers string
+}
+
+// NewOptions creates Options for the ElasticSearch span storage
+func NewOptions() *Options {
+	return &Options{}
+}
+
+// Bind defines the flags of the ElasticSearch span storage prefixed with the namespace
+func (opt *Options) Bind(flags *flag.FlagSet, namespace string) {
+	flags.StringVar(
+		&opt.servers,
+		namespace+".server-urls",
+		"http://127.0.0.1:9200",
+		"The comma-separated list of ElasticSearch servers, e.g. 'http://es1:9200,http://es2:9200'")
+	flags.StringVar(
+		&opt.conf.Username,
+		namespace+".username",
+		"",
+		"The username required by ElasticSearch, if any")
+	flags.StringVar(
+		&opt.conf.Password,
+		namespace+".password",
+		"",
+		"The password required by ElasticSearch, if any")
+	flags.BoolVar(
+		&opt.conf.Sniffer,
+		namespace+".sniffer",
+		false,
+		"Discover the nodes of the ElasticSearch cluster from the listed servers")
+	flags.DurationVar(
+		&opt.conf.MaxSpanAge,
+		namespace+".max-span-age",
+		72*time.Hour,
+		"The maximum lookback for spans in ElasticSearch")
+}
+
+// GetPrimary returns the configuration of the ElasticSearch span storage
+func (opt *Options) GetPrimary() *config.Configuration {
+	opt.conf.Servers = strings.Split(opt.servers, ",")
+	return &opt.conf
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package es
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewOptions()
+	opts.Bind(flags, "es")
+	flags.Parse([]string{})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, []string{"http://127.0.0.1:9200"}, primary.Servers)
+	assert.Empty(t, primary.Username)
+	assert.Empty(t, primary.Password)
+	assert.False(t, primary.Sniffer)
+	assert.Equal(t, 72*time.Hour, primary.MaxSpanAge)
+}
+
+func TestOptionsWithFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewOptions()
+	opts.Bind(flags, "es")
+	flags.Parse([]string{
+		"-es.server-urls=http://es1:9200,http://es2:9200",
+		"-es.username=jaeger",
+		"-es.password=secret",
+		"-es.sniffer=true",
+		"-es.max-span-age=24h",
+	})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, []string{"http://es1:9200", "http://es2:9200"}, primary.Servers)
+	assert.Equal(t, "jaeger", primary.Username)
+	assert.Equal(t, "secret", primary.Password)
+	assert.True(t, primary.Sniffer)
+	assert.Equal(t, 24*time.Hour, primary.MaxSpanAge)
+}
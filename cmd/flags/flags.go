@@ -35,6 +35,8 @@ const (
 	MemoryStorageType = "memory"
 	// ESStorageType is the storage type flag denoting an ElasticSearch backing store
 	ESStorageType = "elasticsearch"
+	// KafkaStorageType is the storage type flag denoting spans published to Kafka, only supported by the collector
+	KafkaStorageType = "kafka"
 
 	InfluxDBStorageType = "influxdb"
 )
@@ -56,6 +58,27 @@ type spanStorage struct {
 	Type string
 }
 
+// Types returns the span storage types, the span-storage.type flag accepts a comma-separated list of them
+func (s spanStorage) Types() []string {
+	var types []string
+	for _, storageType := range strings.Split(s.Type, ",") {
+		if storageType = strings.TrimSpace(storageType); storageType != "" {
+			types = append(types, storageType)
+		}
+	}
+	return types
+}
+
+// Includes returns true if storageType is one of the span storage types
+func (s spanStorage) Includes(storageType string) bool {
+	for _, t := range s.Types() {
+		if t == storageType {
+			return true
+		}
+	}
+	return false
+}
+
 type dependencyStorage struct {
 	Type          string
 	DataFrequency time.Duration
@@ -83,7 +106,7 @@ func (co cassandraOptions) ServerList() []string {
 }*/
 
 func init() {
-	flag.StringVar(&SpanStorage.Type, "span-storage.type", CassandraStorageType, fmt.Sprintf("The type of span storage backend to use, options are currently [%v,%v,%v]", CassandraStorageType, MemoryStorageType, InfluxDBStorageType))
+	flag.StringVar(&SpanStorage.Type, "span-storage.type", CassandraStorageType, fmt.Sprintf("The type of span storage backend to use, options are currently [%v,%v,%v,%v], a comma-separated list makes the collector write to and the query service read from several backends", CassandraStorageType, MemoryStorageType, InfluxDBStorageType, KafkaStorageType))
 
 	flag.StringVar(&DependencyStorage.Type, "dependency-storage.type", CassandraStorageType, fmt.Sprintf("The type of dependency storage backend to use, options are currently [%v,%v,%v]", CassandraStorageType, MemoryStorageType, InfluxDBStorageType))
 	flag.DurationVar(&DependencyStorage.DataFrequency, "dependency-storage.data-frequency", time.Hour*24, "Frequency of service dependency calculations")
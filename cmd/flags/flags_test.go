@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanStorageTypes(t *testing.T) {
+	testCases := []struct {
+		storageType string
+		expected    []string
+	}{
+		{storageType: "", expected: nil},
+		{storageType: "cassandra", expected: []string{"cassandra"}},
+		{storageType: "cassandra,elasticsearch", expected: []string{"cassandra", "elasticsearch"}},
+		{storageType: " cassandra , elasticsearch,", expected: []string{"cassandra", "elasticsearch"}},
+	}
+	for _, testCase := range testCases {
+		storage := spanStorage{Type: testCase.storageType}
+		assert.Equal(t, testCase.expected, storage.Types(), testCase.storageType)
+	}
+}
+
+func TestSpanStorageIncludes(t *testing.T) {
+	storage := spanStorage{Type: "cassandra,elasticsearch"}
+	assert.True(t, storage.Includes(CassandraStorageType))
+	assert.True(t, storage.Includes(ESStorageType))
+	assert.False(t, storage.Includes(MemoryStorageType))
+}
@@ -21,6 +21,10 @@ func (opt *Options) Bind(flags *flag.FlagSet, namespace string) {
 	flags.StringVar(&opt.conf.Server, fmt.Sprintf("%s.host", namespace), "http://localhost:8086", "InfluxDB instance hostname")
 	flags.StringVar(&opt.conf.Database, fmt.Sprintf("%s.database", namespace), "jaeger", "InfluxDB database to use for storage")
 	flags.StringVar(&opt.conf.ConnectionType, fmt.Sprintf("%s.connection-type", namespace), "http", "Protocol to use for communication with InfluxDB. Valid options: [http, udp]")
+	flags.StringVar(&opt.conf.RetentionPolicy, fmt.Sprintf("%s.retention-policy", namespace), "jaeger", "Default retention policy to create with the database, leave empty to use the existing database default")
+	flags.DurationVar(&opt.conf.Retention, fmt.Sprintf("%s.retention", namespace), 0, "How long the retention policy keeps spans, 0 keeps them forever")
+	flags.DurationVar(&opt.conf.ShardDuration, fmt.Sprintf("%s.shard-duration", namespace), 0, "Time range covered by each shard group of the retention policy, 0 uses the InfluxDB default")
+	flags.IntVar(&opt.conf.Replication, fmt.Sprintf("%s.replication", namespace), 1, "Number of copies of each point kept by the retention policy")
 }
 
 func (opt *Options) GetPrimary() *config.Configuration {
@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxdb
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewOptions()
+	opts.Bind(flags, "influx")
+	flags.Parse([]string{})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, "jaeger", primary.Database)
+	assert.Equal(t, "jaeger", primary.RetentionPolicy)
+	assert.Equal(t, time.Duration(0), primary.Retention)
+	assert.Equal(t, 1, primary.Replication)
+}
+
+func TestOptionsWithFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewOptions()
+	opts.Bind(flags, "influx")
+	flags.Parse([]string{
+		"-influx.host=http://influx:8086",
+		"-influx.database=traces",
+		"-influx.retention-policy=two_days",
+		"-influx.retention=48h",
+		"-influx.shard-duration=2h",
+		"-influx.replication=3",
+	})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, "http://influx:8086", primary.Server)
+	assert.Equal(t, "traces", primary.Database)
+	assert.Equal(t, "two_days", primary.RetentionPolicy)
+	assert.Equal(t, 48*time.Hour, primary.Retention)
+	assert.Equal(t, 2*time.Hour, primary.ShardDuration)
+	assert.Equal(t, 3, primary.Replication)
+}
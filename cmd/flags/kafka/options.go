@@ -0,0 +1,107 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/uber/jaeger/pkg/kafka/config"
+)
+
+const defaultEncoding = "json"
+
+// Options stores the configuration of the Kafka span writer
+type Options struct {
+	conf    config.Configuration
+	brokers string
+}
+
+// NewOptions creates Options for the Kafka span writer
+func NewOptions() *Options {
+	return &Options{}
+}
+
+// Bind defines the flags of the Kafka span writer prefixed with the namespace
+func (opt *Options) Bind(flags *flag.FlagSet, namespace string) {
+	flags.StringVar(
+		&opt.brokers,
+		namespace+".brokers",
+		"127.0.0.1:9092",
+		"The comma-separated list of Kafka brokers, e.g. 'kafka1:9092,kafka2:9092'")
+	flags.StringVar(
+		&opt.conf.Topic,
+		namespace+".topic",
+		"jaeger-spans",
+		"The Kafka topic the spans are published to")
+	flags.StringVar(
+		&opt.conf.Encoding,
+		namespace+".encoding",
+		defaultEncoding,
+		"The encoding of the spans published to Kafka, json or thrift")
+}
+
+// GetPrimary returns the configuration of the Kafka span writer
+func (opt *Options) GetPrimary() *config.Configuration {
+	opt.conf.Brokers = strings.Split(opt.brokers, ",")
+	return &opt.conf
+}
+
+// ConsumerOptions stores the configuration of the Kafka span consumer
+type ConsumerOptions struct {
+	conf    config.ConsumerConfiguration
+	brokers string
+}
+
+// NewConsumerOptions creates ConsumerOptions for the Kafka span consumer
+func NewConsumerOptions() *ConsumerOptions {
+	return &ConsumerOptions{}
+}
+
+// Bind defines the flags of the Kafka span consumer prefixed with the namespace
+func (opt *ConsumerOptions) Bind(flags *flag.FlagSet, namespace string) {
+	flags.StringVar(
+		&opt.brokers,
+		namespace+".brokers",
+		"127.0.0.1:9092",
+		"The comma-separated list of Kafka brokers, e.g. 'kafka1:9092,kafka2:9092'")
+	flags.StringVar(
+		&opt.conf.Topic,
+		namespace+".topic",
+		"jaeger-spans",
+		"The Kafka topic the spans are consumed from")
+	flags.StringVar(
+		&opt.conf.GroupID,
+		namespace+".group-id",
+		"jaeger-ingester",
+		"The Kafka consumer group, the partitions of the topic are shared by the members of the group")
+	flags.StringVar(
+		&opt.conf.Encoding,
+		namespace+".encoding",
+		defaultEncoding,
+		"The encoding of the spans consumed from Kafka, json or thrift")
+}
+
+// GetPrimary returns the configuration of the Kafka span consumer
+func (opt *ConsumerOptions) GetPrimary() *config.ConsumerConfiguration {
+	opt.conf.Brokers = strings.Split(opt.brokers, ",")
+	return &opt.conf
+}
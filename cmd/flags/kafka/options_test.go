@@ -0,0 +1,87 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kafka
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewOptions()
+	opts.Bind(flags, "kafka")
+	flags.Parse([]string{})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, []string{"127.0.0.1:9092"}, primary.Brokers)
+	assert.Equal(t, "jaeger-spans", primary.Topic)
+	assert.Equal(t, "json", primary.Encoding)
+}
+
+func TestOptionsWithFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewOptions()
+	opts.Bind(flags, "kafka")
+	flags.Parse([]string{
+		"-kafka.brokers=kafka1:9092,kafka2:9092",
+		"-kafka.topic=spans",
+		"-kafka.encoding=thrift",
+	})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, []string{"kafka1:9092", "kafka2:9092"}, primary.Brokers)
+	assert.Equal(t, "spans", primary.Topic)
+	assert.Equal(t, "thrift", primary.Encoding)
+}
+
+func TestConsumerOptions(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewConsumerOptions()
+	opts.Bind(flags, "kafka")
+	flags.Parse([]string{})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, []string{"127.0.0.1:9092"}, primary.Brokers)
+	assert.Equal(t, "jaeger-spans", primary.Topic)
+	assert.Equal(t, "jaeger-ingester", primary.GroupID)
+	assert.Equal(t, "json", primary.Encoding)
+}
+
+func TestConsumerOptionsWithFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	opts := NewConsumerOptions()
+	opts.Bind(flags, "kafka")
+	flags.Parse([]string{
+		"-kafka.brokers=kafka1:9092,kafka2:9092",
+		"-kafka.topic=spans",
+		"-kafka.group-id=ingesters",
+		"-kafka.encoding=thrift",
+	})
+
+	primary := opts.GetPrimary()
+	assert.Equal(t, []string{"kafka1:9092", "kafka2:9092"}, primary.Brokers)
+	assert.Equal(t, "spans", primary.Topic)
+	assert.Equal(t, "ingesters", primary.GroupID)
+	assert.Equal(t, "thrift", primary.Encoding)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"flag"
+
+	"github.com/uber/jaeger/pkg/memory/config"
+)
+
+// Options stores the configuration of the in-memory span store
+type Options struct {
+	conf config.Configuration
+}
+
+// NewOptions creates Options for the in-memory span store
+func NewOptions() *Options {
+	return &Options{}
+}
+
+// Bind defines the flags of the in-memory span store prefixed with the namespace
+func (opt *Options) Bind(flags *flag.FlagSet, namespace string) {
+	flags.IntVar(
+		&opt.conf.MaxTraces,
+		namespace+".max-traces",
+		0,
+		"The maximum number of traces kept in memory, 0 for no limit. The least recently written traces are evicted first")
+	flags.IntVar(
+		&opt.conf.MaxSpans,
+		namespace+".max-spans",
+		0,
+		"The maximum number of spans kept in memory, 0 for no limit. Whole traces are evicted, least recently written first")
+}
+
+// GetPrimary returns the configuration of the in-memory span store
+func (opt *Options) GetPrimary() *config.Configuration {
+	return &opt.conf
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bsm/sarama-cluster"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	collectorApp "github.com/uber/jaeger/cmd/collector/app"
+	"github.com/uber/jaeger/pkg/kafka"
+	kafkaSpanstore "github.com/uber/jaeger/plugin/storage/kafka/spanstore"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+var errMissingProcess = errors.New("span has no process")
+
+// Consumer reads spans from the partitions claimed by this member of the Kafka consumer group and writes them
+// to storage. The offset of a message is only marked for commit once the message and all the messages received
+// before it on its partition have been written, so every span is written at least once.
+type Consumer struct {
+	consumer  kafka.Consumer
+	processor *spanProcessor
+	metrics   *collectorApp.SpanProcessorMetrics
+	logger    *zap.Logger
+	options   Options
+
+	// workers limits the number of messages processed concurrently across all partitions
+	workers chan struct{}
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+// NewConsumer creates a Consumer that decodes the consumed messages with the unmarshaller and writes the spans
+// with the span writer. The metrics are the same span processor metrics the collector reports.
+func NewConsumer(
+	consumer kafka.Consumer,
+	unmarshaller kafkaSpanstore.Unmarshaller,
+	spanWriter spanstore.Writer,
+	options Options,
+	logger *zap.Logger,
+	metricsFactory metrics.Factory,
+) *Consumer {
+	hostname, _ := os.Hostname()
+	spanMetrics := collectorApp.NewSpanProcessorMetrics(
+		metricsFactory,
+		metricsFactory.Namespace(hostname, nil),
+		[]string{KafkaFormatType})
+	return &Consumer{
+		consumer: consumer,
+		processor: &spanProcessor{
+			unmarshaller:    unmarshaller,
+			spanWriter:      spanWriter,
+			metrics:         spanMetrics,
+			logger:          logger,
+			maxRetries:      options.MaxRetries,
+			maxRetryBackoff: options.MaxRetryBackoff,
+		},
+		metrics: spanMetrics,
+		logger:  logger,
+		options: options,
+		workers: make(chan struct{}, options.Parallelism),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start starts consuming the partitions claimed by this member of the consumer group.
+func (c *Consumer) Start() {
+	c.done.Add(1)
+	go c.claimPartitions()
+}
+
+// Close stops consuming, waits for the messages being processed, marks their offsets
+// and leaves the consumer group, which commits the marked offsets.
+func (c *Consumer) Close() error {
+	close(c.stop)
+	c.done.Wait()
+	return c.consumer.Close()
+}
+
+func (c *Consumer) claimPartitions() {
+	defer c.done.Done()
+	for {
+		select {
+		case pc, ok := <-c.consumer.Partitions():
+			if !ok {
+				return
+			}
+			c.done.Add(1)
+			go c.consumePartition(pc)
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *Consumer) consumePartition(pc cluster.PartitionConsumer) {
+	defer c.done.Done()
+	logger := c.logger.With(zap.String("topic", pc.Topic()), zap.Int32("partition", pc.Partition()))
+	logger.Info("Started consuming partition")
+
+	tracker := newOffsetTracker()
+	var inFlight sync.WaitGroup
+	defer func() {
+		inFlight.Wait()
+		c.markOffset(pc, tracker)
+		logger.Info("Stopped consuming partition")
+	}()
+
+	ticker := time.NewTicker(c.options.CommitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				// the partition was released to another member of the consumer group
+				return
+			}
+			received := time.Now()
+			select {
+			case c.workers <- struct{}{}:
+			case <-c.stop:
+				return
+			}
+			c.metrics.InQueueLatency.Record(time.Since(received))
+			tracker.add(msg.Offset)
+			inFlight.Add(1)
+			go func() {
+				defer func() {
+					<-c.workers
+					inFlight.Done()
+				}()
+				if c.processor.process(msg, c.stop) {
+					tracker.markDone(msg.Offset)
+				}
+			}()
+		case <-ticker.C:
+			c.markOffset(pc, tracker)
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *Consumer) markOffset(pc cluster.PartitionConsumer, tracker *offsetTracker) {
+	if offset, ok := tracker.committable(); ok {
+		c.consumer.MarkPartitionOffset(pc.Topic(), pc.Partition(), offset, "")
+	}
+}
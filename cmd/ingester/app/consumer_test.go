@@ -0,0 +1,271 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/bsm/sarama-cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	kafkaSpanstore "github.com/uber/jaeger/plugin/storage/kafka/spanstore"
+)
+
+type fakeConsumer struct {
+	partitions chan cluster.PartitionConsumer
+
+	sync.Mutex
+	marked map[int32]int64
+	closed bool
+}
+
+func newFakeConsumer() *fakeConsumer {
+	return &fakeConsumer{
+		partitions: make(chan cluster.PartitionConsumer, 10),
+		marked:     make(map[int32]int64),
+	}
+}
+
+func (c *fakeConsumer) Partitions() <-chan cluster.PartitionConsumer {
+	return c.partitions
+}
+
+func (c *fakeConsumer) MarkPartitionOffset(topic string, partition int32, offset int64, metadata string) {
+	c.Lock()
+	defer c.Unlock()
+	c.marked[partition] = offset
+}
+
+func (c *fakeConsumer) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeConsumer) markedOffset(partition int32) (int64, bool) {
+	c.Lock()
+	defer c.Unlock()
+	offset, ok := c.marked[partition]
+	return offset, ok
+}
+
+type fakePartitionConsumer struct {
+	partition int32
+	messages  chan *sarama.ConsumerMessage
+}
+
+func newFakePartitionConsumer(partition int32) *fakePartitionConsumer {
+	return &fakePartitionConsumer{partition: partition, messages: make(chan *sarama.ConsumerMessage, 100)}
+}
+
+func (pc *fakePartitionConsumer) AsyncClose()                              {}
+func (pc *fakePartitionConsumer) Close() error                             { return nil }
+func (pc *fakePartitionConsumer) Messages() <-chan *sarama.ConsumerMessage { return pc.messages }
+func (pc *fakePartitionConsumer) Errors() <-chan *sarama.ConsumerError     { return nil }
+func (pc *fakePartitionConsumer) HighWaterMarkOffset() int64               { return 0 }
+func (pc *fakePartitionConsumer) Topic() string                            { return "jaeger-spans" }
+func (pc *fakePartitionConsumer) Partition() int32                         { return pc.partition }
+
+func (pc *fakePartitionConsumer) send(t *testing.T, offset int64, span *model.Span) {
+	payload, err := kafkaSpanstore.NewMarshaller(kafkaSpanstore.EncodingJSON)
+	require.NoError(t, err)
+	value, err := payload.Marshal(span)
+	require.NoError(t, err)
+	pc.messages <- &sarama.ConsumerMessage{Topic: pc.Topic(), Partition: pc.partition, Offset: offset, Value: value}
+}
+
+// fakeWriter fails the first failures writes of every span
+type fakeWriter struct {
+	sync.Mutex
+	failures int
+	attempts map[model.SpanID]int
+	spans    []*model.Span
+}
+
+func newFakeWriter(failures int) *fakeWriter {
+	return &fakeWriter{failures: failures, attempts: make(map[model.SpanID]int)}
+}
+
+func (w *fakeWriter) WriteSpan(span *model.Span) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.attempts[span.SpanID]++; w.attempts[span.SpanID] <= w.failures {
+		return errors.New("storage is down")
+	}
+	w.spans = append(w.spans, span)
+	return nil
+}
+
+func (w *fakeWriter) written() int {
+	w.Lock()
+	defer w.Unlock()
+	return len(w.spans)
+}
+
+func makeSpan(spanID uint64) *model.Span {
+	return &model.Span{
+		TraceID:       model.TraceID{Low: 1},
+		SpanID:        model.SpanID(spanID),
+		OperationName: "op",
+		StartTime:     time.Unix(1485467191, 0).UTC(),
+		Process:       model.NewProcess("svc", nil),
+	}
+}
+
+func withConsumer(t *testing.T, writer *fakeWriter, f func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory)) {
+	withMaxRetries(t, 1000, writer, f)
+}
+
+func withMaxRetries(t *testing.T, maxRetries int, writer *fakeWriter, f func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory)) {
+	kafkaConsumer := newFakeConsumer()
+	unmarshaller, err := kafkaSpanstore.NewUnmarshaller(kafkaSpanstore.EncodingJSON)
+	require.NoError(t, err)
+	metricsFactory := metrics.NewLocalFactory(0)
+	consumer := NewConsumer(kafkaConsumer, unmarshaller, writer, Options{
+		Parallelism:     4,
+		CommitInterval:  time.Millisecond,
+		MaxRetries:      maxRetries,
+		MaxRetryBackoff: time.Millisecond,
+	}, zap.NewNop(), metricsFactory)
+	consumer.Start()
+	f(consumer, kafkaConsumer, metricsFactory)
+}
+
+func waitForOffset(t *testing.T, consumer *fakeConsumer, partition int32, expected int64) {
+	for i := 0; i < 1000; i++ {
+		if offset, ok := consumer.markedOffset(partition); ok && offset == expected {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	offset, _ := consumer.markedOffset(partition)
+	t.Fatalf("expected offset %d to be marked for partition %d, got %d", expected, partition, offset)
+}
+
+func TestConsumerWritesSpans(t *testing.T) {
+	writer := newFakeWriter(2)
+	withConsumer(t, writer, func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory) {
+		pc0, pc1 := newFakePartitionConsumer(0), newFakePartitionConsumer(1)
+		kafkaConsumer.partitions <- pc0
+		kafkaConsumer.partitions <- pc1
+		for i := int64(0); i < 10; i++ {
+			pc0.send(t, i, makeSpan(uint64(i+1)))
+			pc1.send(t, 100+i, makeSpan(uint64(i+101)))
+		}
+		waitForOffset(t, kafkaConsumer, 0, 9)
+		waitForOffset(t, kafkaConsumer, 1, 109)
+		assert.Equal(t, 20, writer.written())
+
+		require.NoError(t, consumer.Close())
+		assert.True(t, kafkaConsumer.closed)
+
+		counters, _ := metricsFactory.Snapshot()
+		assert.EqualValues(t, 20, counters["kafka.spans.recd"])
+		assert.EqualValues(t, 20, counters["kafka.spans.by-svc.svc"])
+		assert.EqualValues(t, 20, counters["spans.saved-by-svc.svc"])
+	})
+}
+
+func TestConsumerSkipsUndecodableMessages(t *testing.T) {
+	writer := newFakeWriter(0)
+	withConsumer(t, writer, func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory) {
+		pc := newFakePartitionConsumer(0)
+		kafkaConsumer.partitions <- pc
+		pc.messages <- &sarama.ConsumerMessage{Topic: pc.Topic(), Offset: 0, Value: []byte("{")}
+		pc.messages <- &sarama.ConsumerMessage{Topic: pc.Topic(), Offset: 1, Value: []byte(`{"traceID": "1", "spanID": "2"}`)}
+		pc.send(t, 2, makeSpan(1))
+		waitForOffset(t, kafkaConsumer, 0, 2)
+		require.NoError(t, consumer.Close())
+
+		assert.Equal(t, 1, writer.written())
+		counters, _ := metricsFactory.Snapshot()
+		assert.EqualValues(t, 3, counters["kafka.spans.recd"])
+		assert.EqualValues(t, 2, counters["kafka.spans.rejected"])
+	})
+}
+
+func TestConsumerDoesNotMarkUnwrittenSpans(t *testing.T) {
+	writer := newFakeWriter(1 << 30)
+	withConsumer(t, writer, func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory) {
+		pc := newFakePartitionConsumer(0)
+		kafkaConsumer.partitions <- pc
+		pc.send(t, 0, makeSpan(1))
+		for i := 0; i < 1000; i++ {
+			writer.Lock()
+			attempts := writer.attempts[model.SpanID(1)]
+			writer.Unlock()
+			if attempts > 2 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		require.NoError(t, consumer.Close())
+
+		_, ok := kafkaConsumer.markedOffset(0)
+		assert.False(t, ok, "the offset of a span that was never written must not be committed")
+		assert.Equal(t, 0, writer.written())
+	})
+}
+
+func TestConsumerSkipsSpansAfterMaxRetries(t *testing.T) {
+	writer := newFakeWriter(3)
+	withMaxRetries(t, 2, writer, func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory) {
+		pc := newFakePartitionConsumer(0)
+		kafkaConsumer.partitions <- pc
+		pc.send(t, 0, makeSpan(1))
+		pc.send(t, 1, makeSpan(2))
+		waitForOffset(t, kafkaConsumer, 0, 1)
+		require.NoError(t, consumer.Close())
+
+		writer.Lock()
+		assert.Equal(t, 3, writer.attempts[model.SpanID(1)])
+		writer.Unlock()
+		assert.Equal(t, 0, writer.written())
+		hostname, _ := os.Hostname()
+		counters, _ := metricsFactory.Snapshot()
+		assert.EqualValues(t, 2, counters[hostname+".spans.failed"])
+	})
+}
+
+func TestConsumerReleasedPartition(t *testing.T) {
+	writer := newFakeWriter(0)
+	withConsumer(t, writer, func(consumer *Consumer, kafkaConsumer *fakeConsumer, metricsFactory *metrics.LocalFactory) {
+		pc := newFakePartitionConsumer(3)
+		kafkaConsumer.partitions <- pc
+		pc.send(t, 7, makeSpan(1))
+		close(pc.messages)
+		waitForOffset(t, kafkaConsumer, 3, 7)
+
+		close(kafkaConsumer.partitions)
+		require.NoError(t, consumer.Close())
+		assert.Equal(t, 1, writer.written())
+	})
+}
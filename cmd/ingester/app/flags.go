@@ -0,0 +1,54 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"flag"
+	"fmt"
+)
+
+var defaults = DefaultOptions()
+
+var (
+	// Parallelism is the maximum number of messages written to storage concurrently
+	Parallelism = flag.Int("ingester.parallelism", defaults.Parallelism, "The maximum number of messages written to span storage concurrently, at least 1")
+	// CommitInterval denotes how often the offsets of the processed messages are committed to Kafka
+	CommitInterval = flag.Duration("ingester.commit-interval", defaults.CommitInterval, "How often the offsets of the processed messages are committed to Kafka")
+	// MaxRetries is the number of times a failed write is retried before the span is discarded
+	MaxRetries = flag.Int("ingester.max-retries", defaults.MaxRetries, "The number of times a span that failed to be written to span storage is retried before it is discarded")
+	// MaxRetryBackoff is the upper bound of the delay between retries of a failed write
+	MaxRetryBackoff = flag.Duration("ingester.max-retry-backoff", defaults.MaxRetryBackoff, "The maximum delay between retries of a span that failed to be written to span storage")
+	// HTTPPort is the port the ingester exposes its expvar metrics on
+	HTTPPort = flag.Int("ingester.http-port", 14270, "The http port for the ingester metrics endpoint, 0 disables it")
+)
+
+// OptionsFromFlags returns the ingester options set by the command line flags, or an error if they are invalid
+func OptionsFromFlags() (Options, error) {
+	if *Parallelism < 1 {
+		return Options{}, fmt.Errorf("ingester.parallelism must be at least 1, got %d", *Parallelism)
+	}
+	return Options{
+		Parallelism:     *Parallelism,
+		CommitInterval:  *CommitInterval,
+		MaxRetries:      *MaxRetries,
+		MaxRetryBackoff: *MaxRetryBackoff,
+	}, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"sync"
+)
+
+// offsetTracker tracks the messages of a partition that are being processed. Messages may finish
+// out of order, so only the offset up to which every received message is processed is safe to commit.
+type offsetTracker struct {
+	sync.Mutex
+	// offsets holds the offsets of the received messages that are not yet committable, in the order they were received
+	offsets []int64
+	done    map[int64]struct{}
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{done: make(map[int64]struct{})}
+}
+
+// add records a received message. Messages of a partition must be added in the order of their offsets.
+func (t *offsetTracker) add(offset int64) {
+	t.Lock()
+	t.offsets = append(t.offsets, offset)
+	t.Unlock()
+}
+
+// markDone records that the message at the offset has been processed.
+func (t *offsetTracker) markDone(offset int64) {
+	t.Lock()
+	t.done[offset] = struct{}{}
+	t.Unlock()
+}
+
+// committable returns the offset of the last message that has been processed along with all the messages
+// received before it. It returns false if no more messages have become committable since the previous call.
+func (t *offsetTracker) committable() (int64, bool) {
+	t.Lock()
+	defer t.Unlock()
+	var offset int64
+	i := 0
+	for ; i < len(t.offsets); i++ {
+		if _, ok := t.done[t.offsets[i]]; !ok {
+			break
+		}
+		offset = t.offsets[i]
+		delete(t.done, offset)
+	}
+	t.offsets = t.offsets[i:]
+	return offset, i > 0
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	_, ok := tracker.committable()
+	assert.False(t, ok)
+
+	for _, offset := range []int64{3, 4, 7, 8} {
+		tracker.add(offset)
+	}
+	tracker.markDone(4)
+	_, ok = tracker.committable()
+	assert.False(t, ok, "offset 3 is still being processed")
+
+	tracker.markDone(3)
+	tracker.markDone(8)
+	offset, ok := tracker.committable()
+	assert.True(t, ok)
+	assert.EqualValues(t, 4, offset)
+
+	_, ok = tracker.committable()
+	assert.False(t, ok)
+
+	tracker.markDone(7)
+	offset, ok = tracker.committable()
+	assert.True(t, ok)
+	assert.EqualValues(t, 8, offset)
+	assert.Empty(t, tracker.offsets)
+	assert.Empty(t, tracker.done)
+}
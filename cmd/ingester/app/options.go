@@ -0,0 +1,62 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import "time"
+
+const (
+	// KafkaFormatType is the span format type reported in the metrics of the spans consumed from Kafka
+	KafkaFormatType = "kafka"
+
+	// DefaultParallelism is the default maximum number of messages written to storage concurrently
+	DefaultParallelism = 1000
+	// DefaultCommitInterval is how often the offsets of processed messages are marked by default
+	DefaultCommitInterval = time.Second
+	// DefaultMaxRetries is the default number of times a failed write is retried before the span is discarded
+	DefaultMaxRetries = 10
+	// DefaultMaxRetryBackoff is the default upper bound of the delay between retries of a failed write
+	DefaultMaxRetryBackoff = 30 * time.Second
+
+	initialRetryBackoff = 100 * time.Millisecond
+)
+
+// Options holds the configuration of the ingester
+type Options struct {
+	// Parallelism is the maximum number of messages written to storage concurrently across all partitions
+	Parallelism int
+	// CommitInterval is how often the offsets of the processed messages are marked for commit
+	CommitInterval time.Duration
+	// MaxRetries is the number of times a failed write is retried before the span is discarded, so that
+	// a span the storage keeps rejecting does not hold a worker and the commits of its partition
+	MaxRetries int
+	// MaxRetryBackoff is the upper bound of the exponential backoff between retries of a failed write
+	MaxRetryBackoff time.Duration
+}
+
+// DefaultOptions returns the default ingester options
+func DefaultOptions() Options {
+	return Options{
+		Parallelism:     DefaultParallelism,
+		CommitInterval:  DefaultCommitInterval,
+		MaxRetries:      DefaultMaxRetries,
+		MaxRetryBackoff: DefaultMaxRetryBackoff,
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.uber.org/zap"
+
+	collectorApp "github.com/uber/jaeger/cmd/collector/app"
+	kafkaSpanstore "github.com/uber/jaeger/plugin/storage/kafka/spanstore"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+// spanProcessor decodes the span of a Kafka message and writes it to storage
+type spanProcessor struct {
+	unmarshaller    kafkaSpanstore.Unmarshaller
+	spanWriter      spanstore.Writer
+	metrics         *collectorApp.SpanProcessorMetrics
+	logger          *zap.Logger
+	maxRetries      int
+	maxRetryBackoff time.Duration
+}
+
+// process writes the span of the message to storage. Failed writes are retried with an exponential backoff
+// up to maxRetries times, after which the span is counted as failed and skipped, so it only returns false
+// if it gave up on the span because stop was closed. Messages that cannot be decoded are counted as
+// rejected and skipped.
+func (p *spanProcessor) process(msg *sarama.ConsumerMessage, stop <-chan struct{}) bool {
+	counts := p.metrics.GetCountsForFormat(KafkaFormatType)
+	counts.Received.Inc(1)
+	span, err := p.unmarshaller.Unmarshal(msg.Value)
+	if err == nil && span.Process == nil {
+		err = errMissingProcess
+	}
+	if err != nil {
+		counts.Rejected.Inc(1)
+		p.logger.Error("Failed to decode span, skipping message",
+			zap.String("topic", msg.Topic),
+			zap.Int32("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Error(err))
+		return true
+	}
+	counts.ReceivedBySvc.ReportServiceNameForSpan(span)
+
+	backoff := p.capBackoff(initialRetryBackoff)
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		err := p.spanWriter.WriteSpan(span)
+		p.metrics.SaveLatency.Record(time.Since(start))
+		if err == nil {
+			p.metrics.SavedBySvc.ReportServiceNameForSpan(span)
+			return true
+		}
+		if retry >= p.maxRetries {
+			p.metrics.SpansFailed.Inc(1)
+			p.logger.Error("Failed to save span, skipping message",
+				zap.String("trace_id", span.TraceID.String()),
+				zap.String("span_id", span.SpanID.String()),
+				zap.Int64("offset", msg.Offset),
+				zap.Error(err))
+			return true
+		}
+		p.logger.Error("Failed to save span, retrying",
+			zap.String("trace_id", span.TraceID.String()),
+			zap.String("span_id", span.SpanID.String()),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-stop:
+			return false
+		}
+		backoff = p.capBackoff(2 * backoff)
+	}
+}
+
+func (p *spanProcessor) capBackoff(backoff time.Duration) time.Duration {
+	if backoff > p.maxRetryBackoff {
+		return p.maxRetryBackoff
+	}
+	return backoff
+}
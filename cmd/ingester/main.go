@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	_ "expvar" // registers the /debug/vars handler
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger-lib/metrics/go-kit"
+	jexpvar "github.com/uber/jaeger-lib/metrics/go-kit/expvar"
+
+	basicB "github.com/uber/jaeger/cmd/builder"
+	"github.com/uber/jaeger/cmd/collector/app/builder"
+	"github.com/uber/jaeger/cmd/flags"
+	casFlags "github.com/uber/jaeger/cmd/flags/cassandra"
+	esFlags "github.com/uber/jaeger/cmd/flags/es"
+	infFlags "github.com/uber/jaeger/cmd/flags/influxdb"
+	kafkaFlags "github.com/uber/jaeger/cmd/flags/kafka"
+	"github.com/uber/jaeger/cmd/ingester/app"
+	kafkaSpanstore "github.com/uber/jaeger/plugin/storage/kafka/spanstore"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+const (
+	serviceName = "jaeger-ingester"
+)
+
+func main() {
+	casOptions := casFlags.NewOptions()
+	casOptions.Bind(flag.CommandLine, "cassandra")
+
+	esOptions := esFlags.NewOptions()
+	esOptions.Bind(flag.CommandLine, "es")
+
+	influxOptions := infFlags.NewOptions()
+	influxOptions.Bind(flag.CommandLine, "influx")
+
+	kafkaOptions := kafkaFlags.NewConsumerOptions()
+	kafkaOptions.Bind(flag.CommandLine, "kafka")
+	flag.Parse()
+
+	logger, _ := zap.NewProduction()
+	baseMetrics := xkit.Wrap(serviceName, jexpvar.NewFactory(10))
+
+	if flags.SpanStorage.Includes(flags.KafkaStorageType) {
+		logger.Fatal("The ingester cannot write spans back to Kafka, choose another span storage type")
+	}
+	options, err := app.OptionsFromFlags()
+	if err != nil {
+		logger.Fatal("Invalid ingester options", zap.Error(err))
+	}
+	spanBuilder, err := builder.NewSpanHandlerBuilder(
+		basicB.Options.CassandraOption(casOptions.GetPrimary()),
+		basicB.Options.LoggerOption(logger),
+		basicB.Options.MetricsFactoryOption(baseMetrics),
+		basicB.Options.InfluxDBOption(influxOptions.GetPrimary()),
+		basicB.Options.ElasticSearchOption(esOptions.GetPrimary()),
+	)
+	if err != nil {
+		logger.Fatal("Unable to set up builder", zap.Error(err))
+	}
+	spanWriter, err := spanBuilder.BuildSpanWriter()
+	if err != nil {
+		logger.Fatal("Unable to build span writer", zap.Error(err))
+	}
+
+	kafkaConfig := kafkaOptions.GetPrimary()
+	unmarshaller, err := kafkaSpanstore.NewUnmarshaller(kafkaConfig.Encoding)
+	if err != nil {
+		logger.Fatal("Unable to create span unmarshaller", zap.Error(err))
+	}
+	kafkaConsumer, err := kafkaConfig.NewConsumer()
+	if err != nil {
+		logger.Fatal("Unable to connect to Kafka", zap.Error(err))
+	}
+	consumer := app.NewConsumer(kafkaConsumer, unmarshaller, spanWriter, options, logger, baseMetrics)
+	consumer.Start()
+	logger.Info("Consuming spans from Kafka",
+		zap.Strings("brokers", kafkaConfig.Brokers),
+		zap.String("topic", kafkaConfig.Topic),
+		zap.String("group-id", kafkaConfig.GroupID))
+
+	go startMetricsHTTPAPI(logger)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	logger.Info("Shutting down, waiting for in-flight spans to be written")
+	if err := consumer.Close(); err != nil {
+		logger.Error("Failed to close the Kafka consumer", zap.Error(err))
+	}
+	if err := spanstore.CloseWriter(spanWriter); err != nil {
+		logger.Error("Failed to close the span writer", zap.Error(err))
+	}
+}
+
+func startMetricsHTTPAPI(logger *zap.Logger) {
+	if *app.HTTPPort != 0 {
+		httpPortStr := ":" + strconv.Itoa(*app.HTTPPort)
+		logger.Info("Listening for HTTP traffic", zap.Int("http-port", *app.HTTPPort))
+
+		if err := http.ListenAndServe(httpPortStr, nil); err != nil {
+			logger.Fatal("Could not launch service", zap.Error(err))
+		}
+	}
+}
@@ -29,4 +29,6 @@ var (
 	QueryPrefix = flag.String("query.prefix", "api", "The prefix for the url of the query service")
 	// QueryStaticAssets is the path for the static assets for the UI (https://github.com/uber/jaeger-ui)
 	QueryStaticAssets = flag.String("query.static-files", "jaeger-ui-build/build/", "The path for the static assets for the UI")
+	// QueryTraceFetchWorkers is the number of traces loaded concurrently when searching by trace IDs
+	QueryTraceFetchWorkers = flag.Int("query.trace-fetch-workers", 10, "The number of traces loaded concurrently when searching by trace IDs")
 )
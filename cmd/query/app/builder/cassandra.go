@@ -72,7 +72,12 @@ func (c *cassandraBuilder) NewSpanReader() (spanstore.Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	return cSpanStore.NewSpanReader(session, c.metricsFactory, c.logger), nil
+	return cSpanStore.NewSpanReader(
+		session,
+		c.metricsFactory,
+		c.logger,
+		cSpanStore.ReaderOptions.TraceReadWorkers(c.configuration.ReadWorkers),
+	), nil
 }
 
 func (c *cassandraBuilder) NewDependencyReader() (dependencystore.Reader, error) {
@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package builder
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger/storage/dependencystore"
+	"github.com/uber/jaeger/storage/spanstore"
+	storageMetrics "github.com/uber/jaeger/storage/spanstore/metrics"
+)
+
+type federatedStorageBuilder struct {
+	logger         *zap.Logger
+	metricsFactory metrics.Factory
+	storageTypes   []string
+	builders       []StorageBuilder
+}
+
+func newFederatedStorageBuilder(
+	storageTypes []string,
+	builders []StorageBuilder,
+	logger *zap.Logger,
+	metricsFactory metrics.Factory,
+) *federatedStorageBuilder {
+	return &federatedStorageBuilder{
+		logger:         logger,
+		metricsFactory: metricsFactory,
+		storageTypes:   storageTypes,
+		builders:       builders,
+	}
+}
+
+// NewSpanReader builds the span readers of all the backends, in the order they are listed, and reports the
+// latency and failures of each of them under the span-storage metrics tagged with the backend type
+func (f *federatedStorageBuilder) NewSpanReader() (spanstore.Reader, error) {
+	spanReaders := make([]spanstore.Reader, 0, len(f.builders))
+	for i, storageType := range f.storageTypes {
+		spanReader, err := f.builders[i].NewSpanReader()
+		if err != nil {
+			return nil, err
+		}
+		backendMetrics := f.metricsFactory.Namespace("span-storage", map[string]string{"backend": storageType})
+		spanReaders = append(spanReaders, storageMetrics.NewReadMetricsDecorator(spanReader, backendMetrics))
+	}
+	return spanstore.NewFederatedReader(f.logger, spanReaders...), nil
+}
+
+// NewDependencyReader returns the dependency reader of the first span storage type, dependencies are
+// not merged across backends
+func (f *federatedStorageBuilder) NewDependencyReader() (dependencystore.Reader, error) {
+	return f.builders[0].NewDependencyReader()
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package builder
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger-lib/metrics"
+	basicB "github.com/uber/jaeger/cmd/builder"
+	"github.com/uber/jaeger/cmd/flags"
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/cassandra/mocks"
+	"github.com/uber/jaeger/storage/spanstore"
+	"github.com/uber/jaeger/storage/spanstore/memory"
+)
+
+func TestNewFederatedStorageBuilder(t *testing.T) {
+	originalArgs, originalStorageType := os.Args, flags.SpanStorage.Type
+	defer func() {
+		os.Args, flags.SpanStorage.Type = originalArgs, originalStorageType
+	}()
+	os.Args = []string{"test", "--span-storage.type=memory,cassandra"}
+	sBuilder, err := NewStorageBuilder(basicB.Options.MemoryStoreOption(memory.NewStore()))
+	assert.EqualError(t, err, "Cassandra not configured")
+	assert.Nil(t, sBuilder)
+
+	os.Args = []string{"test", "--span-storage.type=memory,memory"}
+	sBuilder, err = NewStorageBuilder(basicB.Options.MemoryStoreOption(memory.NewStore()))
+	assert.EqualError(t, err, "Span storage type memory is listed more than once")
+	assert.Nil(t, sBuilder)
+}
+
+func TestFederatedStorageBuilderReaders(t *testing.T) {
+	oldStore, newStore := memory.NewStore(), memory.NewStore()
+	require.NoError(t, oldStore.WriteSpan(&model.Span{
+		TraceID: model.TraceID{Low: 1},
+		SpanID:  model.SpanID(1),
+		Process: model.NewProcess("old-service", nil),
+	}))
+	require.NoError(t, newStore.WriteSpan(&model.Span{
+		TraceID: model.TraceID{Low: 2},
+		SpanID:  model.SpanID(1),
+		Process: model.NewProcess("new-service", nil),
+	}))
+	metricsFactory := metrics.NewLocalFactory(0)
+	fBuilder := newFederatedStorageBuilder(
+		[]string{"memory", "memory"},
+		[]StorageBuilder{newMemoryStoreBuilder(oldStore), newMemoryStoreBuilder(newStore)},
+		zap.NewNop(),
+		metricsFactory,
+	)
+
+	spanReader, err := fBuilder.NewSpanReader()
+	require.NoError(t, err)
+	assert.IsType(t, &spanstore.FederatedReader{}, spanReader)
+	services, err := spanReader.GetServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new-service", "old-service"}, services)
+	counters, _ := metricsFactory.Snapshot()
+	assert.EqualValues(t, 2, counters["span-storage.GetServices.successes|backend=memory"])
+
+	depReader, err := fBuilder.NewDependencyReader()
+	require.NoError(t, err)
+	assert.Equal(t, oldStore, depReader)
+}
+
+func TestFederatedStorageBuilderReaderFailure(t *testing.T) {
+	withBuilder(func(cBuilder *cassandraBuilder) {
+		cBuilder.configuration.Servers = []string{"invalidhostname"}
+		fBuilder := newFederatedStorageBuilder(
+			[]string{"memory", "cassandra"},
+			[]StorageBuilder{newMemoryStoreBuilder(memory.NewStore()), cBuilder},
+			zap.NewNop(),
+			metrics.NullFactory,
+		)
+		spanReader, err := fBuilder.NewSpanReader()
+		assert.Error(t, err)
+		assert.Nil(t, spanReader)
+	})
+}
+
+func TestFederatedStorageBuilderCassandra(t *testing.T) {
+	withBuilder(func(cBuilder *cassandraBuilder) {
+		cBuilder.session = &mocks.Session{}
+		fBuilder := newFederatedStorageBuilder(
+			[]string{"cassandra", "memory"},
+			[]StorageBuilder{cBuilder, newMemoryStoreBuilder(memory.NewStore())},
+			zap.NewNop(),
+			metrics.NullFactory,
+		)
+		spanReader, err := fBuilder.NewSpanReader()
+		assert.NoError(t, err)
+		assert.NotNil(t, spanReader)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger/pkg/influxdb"
 	"github.com/uber/jaeger/pkg/influxdb/config"
+	influxDependencyStore "github.com/uber/jaeger/plugin/storage/influxdb/dependencystore"
 	influxstore "github.com/uber/jaeger/plugin/storage/influxdb/spanstore"
 	"github.com/uber/jaeger/storage/dependencystore"
 	"github.com/uber/jaeger/storage/spanstore"
@@ -32,7 +33,7 @@ func (b *influxDBStoreBuilder) NewSpanReader() (spanstore.Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	return influxstore.NewSpanReader(client, b.configuration), nil
+	return influxstore.NewSpanReader(client, b.configuration, b.logger, b.metricsFactory), nil
 
 }
 
@@ -41,7 +42,7 @@ func (b *influxDBStoreBuilder) NewDependencyReader() (dependencystore.Reader, er
 	if err != nil {
 		return nil, err
 	}
-	return influxstore.NewSpanReader(client, b.configuration), nil
+	return influxDependencyStore.NewDependencyStore(client, b.configuration, b.logger), nil
 }
 
 func (s *influxDBStoreBuilder) getClient() (influxdb.Client, error) {
@@ -21,11 +21,22 @@
 package builder
 
 import (
+	"flag"
+	"time"
+
 	"github.com/uber/jaeger/storage/dependencystore"
 	"github.com/uber/jaeger/storage/spanstore"
 	"github.com/uber/jaeger/storage/spanstore/memory"
 )
 
+var (
+	// MemorySnapshotFile is the file the in-memory store is restored from on startup. Executables that write
+	// to the store, like the standalone one, also save it there periodically and on shutdown.
+	MemorySnapshotFile = flag.String("memory.snapshot-file", "", "The file the in-memory store is restored from on startup and saved to periodically and on shutdown, snapshots are disabled if empty")
+	// MemorySnapshotInterval is how often the in-memory store is saved to the snapshot file
+	MemorySnapshotInterval = flag.Duration("memory.snapshot-interval", time.Minute, "How often the in-memory store is saved to the snapshot file, 0 saves it only on shutdown")
+)
+
 type memoryStoreBuilder struct {
 	memStore *memory.Store
 }
@@ -36,6 +47,16 @@ func newMemoryStoreBuilder(memStore *memory.Store) *memoryStoreBuilder {
 	}
 }
 
+// newMemoryStoreFromSnapshot creates a store with the spans of the snapshot file, for query
+// services that do not share the store of a collector
+func newMemoryStoreFromSnapshot(path string) (*memory.Store, error) {
+	memStore := memory.NewStore()
+	if err := memStore.LoadSnapshot(path); err != nil {
+		return nil, err
+	}
+	return memStore, nil
+}
+
 func (c *memoryStoreBuilder) NewSpanReader() (spanstore.Reader, error) {
 	return c.memStore, nil
 }
@@ -23,6 +23,7 @@ package builder
 import (
 	"errors"
 	"flag"
+	"fmt"
 
 	basicB "github.com/uber/jaeger/cmd/builder"
 	"github.com/uber/jaeger/cmd/flags"
@@ -43,28 +44,60 @@ var (
 	errMissingInfluxConfig        = errors.New("InfluxDB not configured")
 )
 
-// NewStorageBuilder creates a StorageBuilder based off the flags that have been set
+// NewStorageBuilder creates a StorageBuilder based off the flags that have been set. When several span
+// storage types are configured the span reader queries all of them.
 func NewStorageBuilder(opts ...basicB.Option) (StorageBuilder, error) {
 	flag.Parse()
 	options := basicB.ApplyOptions(opts...)
+	storageTypes := flags.SpanStorage.Types()
+	if len(storageTypes) == 1 {
+		return newStorageBuilder(storageTypes[0], options)
+	}
+	if len(storageTypes) == 0 {
+		return nil, flags.ErrUnsupportedStorageType
+	}
+	seen := make(map[string]bool, len(storageTypes))
+	builders := make([]StorageBuilder, 0, len(storageTypes))
+	for _, storageType := range storageTypes {
+		if seen[storageType] {
+			return nil, fmt.Errorf("Span storage type %s is listed more than once", storageType)
+		}
+		seen[storageType] = true
+		builder, err := newStorageBuilder(storageType, options)
+		if err != nil {
+			return nil, err
+		}
+		builders = append(builders, builder)
+	}
+	return newFederatedStorageBuilder(storageTypes, builders, options.Logger, options.MetricsFactory), nil
+}
+
+func newStorageBuilder(storageType string, options basicB.BasicOptions) (StorageBuilder, error) {
 	// TODO lots of repeated code + if logic, clean up below
-	if flags.SpanStorage.Type == flags.CassandraStorageType {
+	if storageType == flags.CassandraStorageType {
 		if options.Cassandra == nil {
 			return nil, errMissingCassandraConfig
 		}
 		// TODO technically span and dependency storage might be separate
 		return newCassandraBuilder(options.Cassandra, options.Logger, options.MetricsFactory), nil
-	} else if flags.SpanStorage.Type == flags.MemoryStorageType {
-		if options.MemoryStore == nil {
-			return nil, errMissingMemoryStore
+	} else if storageType == flags.MemoryStorageType {
+		memStore := options.MemoryStore
+		if memStore == nil {
+			if *MemorySnapshotFile == "" {
+				return nil, errMissingMemoryStore
+			}
+			var err error
+			if memStore, err = newMemoryStoreFromSnapshot(*MemorySnapshotFile); err != nil {
+				return nil, err
+			}
 		}
-		return newMemoryStoreBuilder(options.MemoryStore), nil
-	} else if flags.SpanStorage.Type == flags.ESStorageType {
+		return newMemoryStoreBuilder(memStore), nil
+	} else if storageType == flags.ESStorageType {
 		if options.ElasticSearch == nil {
 			return nil, errMissingElasticSearchConfig
 		}
 		return newESBuilder(options.ElasticSearch, options.Logger, options.MetricsFactory), nil
-	} else if flags.SpanStorage.Type == flags.InfluxDBStorageType {
+	} else if storageType == flags.InfluxDBStorageType {
 		if options.InfluxDB == nil {
 			return nil, errMissingInfluxConfig
 		}
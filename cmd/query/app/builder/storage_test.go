@@ -21,14 +21,18 @@
 package builder
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"github.com/uber/jaeger-lib/metrics"
 	basicB "github.com/uber/jaeger/cmd/builder"
+	"github.com/uber/jaeger/model"
 	cascfg "github.com/uber/jaeger/pkg/cassandra/config"
 	escfg "github.com/uber/jaeger/pkg/es/config"
 	"github.com/uber/jaeger/storage/spanstore/memory"
@@ -84,6 +88,35 @@ func TestNewMemoryFailure(t *testing.T) {
 	assert.Nil(t, sBuilder)
 }
 
+func TestNewMemoryFromSnapshot(t *testing.T) {
+	originalArgs := os.Args
+	defer func() {
+		os.Args = originalArgs
+		*MemorySnapshotFile = ""
+	}()
+	dir, err := ioutil.TempDir("", "memory-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot")
+	memStore := memory.NewStore()
+	require.NoError(t, memStore.WriteSpan(&model.Span{Process: model.NewProcess("frontend", nil)}))
+	require.NoError(t, memStore.SaveSnapshot(path))
+
+	os.Args = []string{"test", "--span-storage.type=memory", "--memory.snapshot-file=" + path}
+	sBuilder, err := NewStorageBuilder()
+	require.NoError(t, err)
+	spanReader, err := sBuilder.NewSpanReader()
+	require.NoError(t, err)
+	services, err := spanReader.GetServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend"}, services)
+
+	os.Args = []string{"test", "--span-storage.type=memory", "--memory.snapshot-file=" + dir}
+	sBuilder, err = NewStorageBuilder()
+	assert.Error(t, err)
+	assert.Nil(t, sBuilder)
+}
+
 func TestNewElasticSuccess(t *testing.T) {
 	originalArgs := os.Args
 	defer func() {
@@ -21,10 +21,12 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -43,13 +45,15 @@ import (
 )
 
 const (
-	traceIDParam  = "traceID"
-	endTsParam    = "endTs"
-	lookbackParam = "lookback"
+	traceIDParam      = "traceID"
+	otherTraceIDParam = "otherTraceID"
+	endTsParam        = "endTs"
+	lookbackParam     = "lookback"
 
 	defaultDependencyLookbackDuration = time.Hour * 24
 	defaultTraceQueryLookbackDuration = time.Hour * 24 * 2
 	defaultHTTPPrefix                 = "api"
+	defaultTraceFetchWorkers          = 10
 )
 
 var (
@@ -77,22 +81,23 @@ type structuredError struct {
 
 // APIHandler implements the query service public API by registering routes at httpPrefix
 type APIHandler struct {
-	spanReader        spanstore.Reader
-	archiveSpanReader spanstore.Reader
-	archiveSpanWriter spanstore.Writer
-	dependencyReader  dependencystore.Reader
+	spanReader        spanstore.ContextReader
+	archiveSpanReader spanstore.ContextReader
+	archiveSpanWriter spanstore.ContextWriter
+	dependencyReader  dependencystore.ContextReader
 	adjuster          adjuster.Adjuster
 	logger            *zap.Logger
 	queryParser       queryParser
 	httpPrefix        string
 	tracer            opentracing.Tracer
+	traceFetchWorkers int
 }
 
 // NewAPIHandler returns an APIHandler
 func NewAPIHandler(spanReader spanstore.Reader, dependencyReader dependencystore.Reader, options ...HandlerOption) *APIHandler {
 	aH := &APIHandler{
-		spanReader:       spanReader,
-		dependencyReader: dependencyReader,
+		spanReader:       spanstore.NewContextReader(spanReader),
+		dependencyReader: dependencystore.NewContextReader(dependencyReader),
 		queryParser: queryParser{
 			traceQueryLookbackDuration: defaultTraceQueryLookbackDuration,
 			timeNow:                    time.Now,
@@ -114,12 +119,24 @@ func NewAPIHandler(spanReader spanstore.Reader, dependencyReader dependencystore
 	if aH.tracer == nil {
 		aH.tracer = opentracing.NoopTracer{}
 	}
+	if aH.traceFetchWorkers <= 0 {
+		aH.traceFetchWorkers = defaultTraceFetchWorkers
+	}
+	aH.spanReader = newTracedSpanReader(aH.spanReader, aH.tracer, "spanstore")
+	aH.dependencyReader = newTracedDependencyReader(aH.dependencyReader, aH.tracer)
+	if aH.archiveSpanReader != nil {
+		aH.archiveSpanReader = newTracedSpanReader(aH.archiveSpanReader, aH.tracer, "archive")
+	}
+	if aH.archiveSpanWriter != nil {
+		aH.archiveSpanWriter = newTracedSpanWriter(aH.archiveSpanWriter, aH.tracer, "archive")
+	}
 	return aH
 }
 
 // RegisterRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
 	aH.handleFunc(router, aH.getTrace, "/traces/{%s}", traceIDParam).Methods(http.MethodGet)
+	aH.handleFunc(router, aH.getTraceDiff, "/traces/{%s}/diff/{%s}", traceIDParam, otherTraceIDParam).Methods(http.MethodGet)
 	aH.handleFunc(router, aH.archiveTrace, "/archive/{%s}", traceIDParam).Methods(http.MethodPost)
 	aH.handleFunc(router, aH.search, "/traces").Methods(http.MethodGet)
 	aH.handleFunc(router, aH.getServices, "/services").Methods(http.MethodGet)
@@ -152,7 +169,7 @@ func (aH *APIHandler) route(route string, args ...interface{}) string {
 }
 
 func (aH *APIHandler) getServices(w http.ResponseWriter, r *http.Request) {
-	services, err := aH.spanReader.GetServices()
+	services, err := aH.spanReader.GetServicesContext(r.Context())
 	if aH.handleError(w, err, http.StatusInternalServerError) {
 		return
 	}
@@ -166,7 +183,7 @@ func (aH *APIHandler) getServices(w http.ResponseWriter, r *http.Request) {
 func (aH *APIHandler) getOperationsLegacy(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	service := vars[serviceParam] //given how getOperationsLegacy is used, service will always be a non-empty string
-	operations, err := aH.spanReader.GetOperations(service)
+	operations, err := aH.spanReader.GetOperationsContext(r.Context(), service)
 	if aH.handleError(w, err, http.StatusInternalServerError) {
 		return
 	}
@@ -184,7 +201,7 @@ func (aH *APIHandler) getOperations(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	operations, err := aH.spanReader.GetOperations(service)
+	operations, err := aH.spanReader.GetOperationsContext(r.Context(), service)
 	if aH.handleError(w, err, http.StatusInternalServerError) {
 		return
 	}
@@ -200,10 +217,16 @@ func (aH *APIHandler) search(w http.ResponseWriter, r *http.Request) {
 	if aH.handleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	if acceptsNDJSON(r) {
+		aH.streamSearch(w, r, tQuery)
+		return
+	}
 
 	var tracesFromStorage []*model.Trace
+	var limit, offset int
+	var uiErrors []structuredError
 	if len(tQuery.traceIDs) > 0 {
-		tracesFromStorage, err = aH.tracesByIDs(tQuery.traceIDs)
+		tracesFromStorage, uiErrors, err = aH.tracesByIDs(r.Context(), tQuery.traceIDs)
 		if err == spanstore.ErrTraceNotFound {
 			aH.handleError(w, err, http.StatusNotFound)
 			return
@@ -212,14 +235,14 @@ func (aH *APIHandler) search(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
-		tracesFromStorage, err = aH.spanReader.FindTraces(&tQuery.TraceQueryParameters)
+		tracesFromStorage, err = aH.spanReader.FindTracesContext(r.Context(), &tQuery.TraceQueryParameters)
 		if aH.handleError(w, err, http.StatusInternalServerError) {
 			return
 		}
+		limit, offset = tQuery.NumTraces, tQuery.Offset
 	}
 
 	uiTraces := make([]*ui.Trace, len(tracesFromStorage))
-	var uiErrors []structuredError
 	for i, v := range tracesFromStorage {
 		uiTrace, uiErr := aH.convertModelToUI(v)
 		if uiErr != nil {
@@ -228,23 +251,107 @@ func (aH *APIHandler) search(w http.ResponseWriter, r *http.Request) {
 		uiTraces[i] = uiTrace
 	}
 
+	// Total is left unset because storage does not report how many traces match the query
 	structuredRes := structuredResponse{
 		Data:   uiTraces,
+		Limit:  limit,
+		Offset: offset,
 		Errors: uiErrors,
 	}
 	aH.writeJSON(w, &structuredRes)
 }
 
-func (aH *APIHandler) tracesByIDs(traceIDs []model.TraceID) ([]*model.Trace, error) {
-	retMe := make([]*model.Trace, 0, len(traceIDs))
-	for _, traceID := range traceIDs {
-		trace, err := aH.spanReader.GetTrace(traceID)
-		if err != nil {
-			return nil, err
+// tracesByIDs loads the traces in the order of traceIDs. The traces that cannot be loaded are reported
+// as errors alongside the others, unless none of them can be loaded, in which case the error of the
+// lookups is returned, preferring storage failures over traces not being found.
+func (aH *APIHandler) tracesByIDs(ctx context.Context, traceIDs []model.TraceID) ([]*model.Trace, []structuredError, error) {
+	results := make([]traceResult, len(traceIDs))
+	err := aH.fetchTraces(ctx, traceIDs, func(result traceResult) {
+		results[result.index] = result
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var retMe []*model.Trace
+	var traceErrors []structuredError
+	for _, result := range results {
+		if result.err != nil {
+			if err == nil || err == spanstore.ErrTraceNotFound {
+				err = result.err
+			}
+			traceErrors = append(traceErrors, traceErrorFromStorage(result.traceID, result.err))
+			continue
 		}
-		retMe = append(retMe, trace)
+		retMe = append(retMe, result.trace)
+	}
+	if len(retMe) == 0 {
+		return nil, nil, err
+	}
+	return retMe, traceErrors, nil
+}
+
+// traceResult is the outcome of loading the trace at index in the looked up trace IDs
+type traceResult struct {
+	index   int
+	traceID model.TraceID
+	trace   *model.Trace
+	err     error
+}
+
+// fetchTraces loads the traces with up to traceFetchWorkers concurrent GetTrace calls and passes
+// every result to process, in the calling goroutine, as soon as it is loaded. No more traces are
+// loaded once ctx is done, in which case its error is returned.
+func (aH *APIHandler) fetchTraces(ctx context.Context, traceIDs []model.TraceID, process func(result traceResult)) error {
+	workers := aH.traceFetchWorkers
+	if workers > len(traceIDs) {
+		workers = len(traceIDs)
+	}
+	indices := make(chan int)
+	results := make(chan traceResult)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				trace, err := aH.spanReader.GetTraceContext(ctx, traceIDs[i])
+				results <- traceResult{index: i, traceID: traceIDs[i], trace: trace, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(indices)
+		for i := range traceIDs {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for result := range results {
+		process(result)
+	}
+	return ctx.Err()
+}
+
+func traceErrorFromStorage(traceID model.TraceID, err error) structuredError {
+	statusCode := http.StatusInternalServerError
+	if err == spanstore.ErrTraceNotFound {
+		statusCode = http.StatusNotFound
+	}
+	return structuredError{
+		Code:    statusCode,
+		Msg:     err.Error(),
+		TraceID: ui.TraceID(traceID.String()),
 	}
-	return retMe, nil
 }
 
 func (aH *APIHandler) dependencies(w http.ResponseWriter, r *http.Request) {
@@ -266,7 +373,7 @@ func (aH *APIHandler) dependencies(w http.ResponseWriter, r *http.Request) {
 	}
 	endTs := time.Unix(0, 0).Add(time.Duration(endTsMillis) * time.Millisecond)
 
-	dependencies, err := aH.dependencyReader.GetDependencies(endTs, lookback)
+	dependencies, err := aH.dependencyReader.GetDependenciesContext(r.Context(), endTs, lookback)
 	if aH.handleError(w, err, http.StatusInternalServerError) {
 		return
 	}
@@ -333,8 +440,13 @@ func (aH *APIHandler) filterDependenciesByService(
 
 // Parses trace ID from URL like /traces/{trace-id}
 func (aH *APIHandler) parseTraceID(w http.ResponseWriter, r *http.Request) (model.TraceID, bool) {
+	return aH.parseTraceIDParam(w, r, traceIDParam)
+}
+
+// Parses trace ID from the given path parameter of the URL
+func (aH *APIHandler) parseTraceIDParam(w http.ResponseWriter, r *http.Request, param string) (model.TraceID, bool) {
 	vars := mux.Vars(r)
-	traceIDVar := vars[traceIDParam]
+	traceIDVar := vars[param]
 	traceID, err := model.TraceIDFromString(traceIDVar)
 	if aH.handleError(w, err, http.StatusBadRequest) {
 		return traceID, false
@@ -344,6 +456,10 @@ func (aH *APIHandler) parseTraceID(w http.ResponseWriter, r *http.Request) (mode
 
 // getTrace implements the REST API /traces/{trace-id}
 func (aH *APIHandler) getTrace(w http.ResponseWriter, r *http.Request) {
+	if format := r.FormValue(formatParam); format != "" {
+		aH.exportTrace(w, r, format)
+		return
+	}
 	aH.getTraceFromReaders(w, r, aH.spanReader, aH.archiveSpanReader)
 }
 
@@ -352,8 +468,8 @@ func (aH *APIHandler) getTrace(w http.ResponseWriter, r *http.Request) {
 func (aH *APIHandler) getTraceFromReaders(
 	w http.ResponseWriter,
 	r *http.Request,
-	reader spanstore.Reader,
-	backupReader spanstore.Reader,
+	reader spanstore.ContextReader,
+	backupReader spanstore.ContextReader,
 ) {
 	aH.withTraceFromReader(w, r, reader, backupReader, func(trace *model.Trace) {
 		var uiErrors []structuredError
@@ -377,30 +493,80 @@ func (aH *APIHandler) getTraceFromReaders(
 func (aH *APIHandler) withTraceFromReader(
 	w http.ResponseWriter,
 	r *http.Request,
-	reader spanstore.Reader,
-	backupReader spanstore.Reader,
+	reader spanstore.ContextReader,
+	backupReader spanstore.ContextReader,
 	process func(trace *model.Trace),
 ) {
 	traceID, ok := aH.parseTraceID(w, r)
 	if !ok {
 		return
 	}
-	trace, err := reader.GetTrace(traceID)
+	trace, ok := aH.readTrace(w, r, traceID, reader, backupReader)
+	if !ok {
+		return
+	}
+	process(trace)
+}
+
+// readTrace loads a trace from Reader, or from backupReader if it is not found, and responds
+// to the client with an error if it cannot be loaded.
+func (aH *APIHandler) readTrace(
+	w http.ResponseWriter,
+	r *http.Request,
+	traceID model.TraceID,
+	reader spanstore.ContextReader,
+	backupReader spanstore.ContextReader,
+) (*model.Trace, bool) {
+	trace, err := reader.GetTraceContext(r.Context(), traceID)
 	if err == spanstore.ErrTraceNotFound {
 		if backupReader == nil {
 			aH.handleError(w, err, http.StatusNotFound)
-			return
+			return nil, false
 		}
-		trace, err = backupReader.GetTrace(traceID)
+		trace, err = backupReader.GetTraceContext(r.Context(), traceID)
 		if err == spanstore.ErrTraceNotFound {
 			aH.handleError(w, err, http.StatusNotFound)
-			return
+			return nil, false
 		}
 	}
 	if aH.handleError(w, err, http.StatusInternalServerError) {
-		return
+		return nil, false
 	}
-	process(trace)
+	return trace, true
+}
+
+// getTraceDiff implements the REST API /traces/{trace-id}/diff/{other-trace-id}.
+// It applies the adjusters to both traces and compares them span by span.
+func (aH *APIHandler) getTraceDiff(w http.ResponseWriter, r *http.Request) {
+	traceIDs := make([]model.TraceID, 2)
+	for i, param := range []string{traceIDParam, otherTraceIDParam} {
+		traceID, ok := aH.parseTraceIDParam(w, r, param)
+		if !ok {
+			return
+		}
+		traceIDs[i] = traceID
+	}
+	var uiErrors []structuredError
+	traces := make([]*model.Trace, 2)
+	for i, traceID := range traceIDs {
+		trace, ok := aH.readTrace(w, r, traceID, aH.spanReader, aH.archiveSpanReader)
+		if !ok {
+			return
+		}
+		trace, err := aH.adjuster.Adjust(trace)
+		if err != nil {
+			uiErrors = append(uiErrors, structuredError{
+				Msg:     err.Error(),
+				TraceID: ui.TraceID(traceID.String()),
+			})
+		}
+		traces[i] = trace
+	}
+	structuredRes := structuredResponse{
+		Data:   diffTraces(traceIDs[0], traces[0], traceIDs[1], traces[1]),
+		Errors: uiErrors,
+	}
+	aH.writeJSON(w, &structuredRes)
 }
 
 // archiveTrace implements the REST API POST:/archive/{trace-id}.
@@ -413,7 +579,7 @@ func (aH *APIHandler) archiveTrace(w http.ResponseWriter, r *http.Request) {
 	aH.withTraceFromReader(w, r, aH.spanReader, nil, func(trace *model.Trace) {
 		var writeErrors []error
 		for _, span := range trace.Spans {
-			err := aH.archiveSpanWriter.WriteSpan(span)
+			err := aH.archiveSpanWriter.WriteSpanContext(r.Context(), span)
 			if err != nil {
 				writeErrors = append(writeErrors, err)
 			}
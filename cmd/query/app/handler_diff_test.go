@@ -0,0 +1,125 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/model/adjuster"
+	"github.com/uber/jaeger/storage/spanstore"
+	spanstoremocks "github.com/uber/jaeger/storage/spanstore/mocks"
+)
+
+// structuredTraceDiffResponse is similar to structuredResponse but defines `data` explicitly as traceDiff
+type structuredTraceDiffResponse struct {
+	Diff   *traceDiff        `json:"data"`
+	Errors []structuredError `json:"errors"`
+}
+
+var (
+	otherMockTraceID = model.TraceID{Low: 654321}
+	otherMockTrace   = &model.Trace{
+		Spans: []*model.Span{
+			{
+				TraceID: otherMockTraceID,
+				SpanID:  model.SpanID(1),
+				Process: &model.Process{},
+			},
+		},
+	}
+)
+
+func TestGetTraceDiffSuccess(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+		ts.spanReader.On("GetTrace", otherMockTraceID).Return(otherMockTrace, nil).Once()
+		var response structuredTraceDiffResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"/diff/"+otherMockTraceID.String(), &response)
+		require.NoError(t, err)
+		assert.Len(t, response.Errors, 0)
+		require.NotNil(t, response.Diff)
+		assert.EqualValues(t, mockTraceID.String(), response.Diff.TraceIDA)
+		assert.EqualValues(t, otherMockTraceID.String(), response.Diff.TraceIDB)
+		require.Len(t, response.Diff.Nodes, 1)
+		assert.Equal(t, 2, response.Diff.Nodes[0].CountA)
+		assert.Equal(t, 1, response.Diff.Nodes[0].CountB)
+		assert.Empty(t, response.Diff.OnlyInA)
+		assert.Empty(t, response.Diff.OnlyInB)
+	})
+}
+
+func TestGetTraceDiffFromArchive(t *testing.T) {
+	archiveReader := &spanstoremocks.Reader{}
+	archiveReader.On("GetTrace", otherMockTraceID).Return(otherMockTrace, nil).Once()
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+		ts.spanReader.On("GetTrace", otherMockTraceID).Return(nil, spanstore.ErrTraceNotFound).Once()
+		var response structuredTraceDiffResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"/diff/"+otherMockTraceID.String(), &response)
+		require.NoError(t, err)
+		assert.Len(t, response.Diff.Nodes, 1)
+	}, HandlerOptions.ArchiveSpanReader(archiveReader))
+}
+
+func TestGetTraceDiffAdjustmentFailure(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mock.AnythingOfType("model.TraceID")).Return(mockTrace, nil).Twice()
+		var response structuredTraceDiffResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"/diff/"+otherMockTraceID.String(), &response)
+		require.NoError(t, err)
+		require.Len(t, response.Errors, 2)
+		assert.EqualValues(t, mockTraceID.String(), response.Errors[0].TraceID)
+		assert.EqualValues(t, otherMockTraceID.String(), response.Errors[1].TraceID)
+		assert.Equal(t, errAdjustment.Error(), response.Errors[0].Msg)
+	}, HandlerOptions.Adjusters(
+		adjuster.Func(func(trace *model.Trace) (*model.Trace, error) {
+			return trace, errAdjustment
+		}),
+	))
+}
+
+func TestGetTraceDiffErrors(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		var response structuredTraceDiffResponse
+		err := getJSON(ts.server.URL+"/api/traces/123456/diff/xyz", &response)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "400 error from server")
+	})
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+		ts.spanReader.On("GetTrace", otherMockTraceID).Return(nil, spanstore.ErrTraceNotFound).Once()
+		var response structuredTraceDiffResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"/diff/"+otherMockTraceID.String(), &response)
+		assert.EqualError(t, err, parsedError(404, "trace not found"))
+	})
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(nil, errors.New("cannot read")).Once()
+		var response structuredTraceDiffResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"/diff/"+otherMockTraceID.String(), &response)
+		assert.EqualError(t, err, parsedError(500, "cannot read"))
+	})
+}
@@ -70,14 +70,22 @@ func (handlerOptions) QueryLookbackDuration(queryLookbackDuration time.Duration)
 // ArchiveSpanReader creates a HandlerOption that initializes lookback duration
 func (handlerOptions) ArchiveSpanReader(reader spanstore.Reader) HandlerOption {
 	return func(apiHandler *APIHandler) {
-		apiHandler.archiveSpanReader = reader
+		apiHandler.archiveSpanReader = spanstore.NewContextReader(reader)
 	}
 }
 
 // ArchiveSpanWriter creates a HandlerOption that initializes lookback duration
 func (handlerOptions) ArchiveSpanWriter(writer spanstore.Writer) HandlerOption {
 	return func(apiHandler *APIHandler) {
-		apiHandler.archiveSpanWriter = writer
+		apiHandler.archiveSpanWriter = spanstore.NewContextWriter(writer)
+	}
+}
+
+// TraceFetchWorkers creates a HandlerOption that sets how many traces are loaded concurrently
+// when searching by trace IDs
+func (handlerOptions) TraceFetchWorkers(workers int) HandlerOption {
+	return func(apiHandler *APIHandler) {
+		apiHandler.traceFetchWorkers = workers
 	}
 }
 
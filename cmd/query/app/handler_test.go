@@ -22,6 +22,7 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,12 +30,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	jaeger "github.com/uber/jaeger-client-go"
@@ -158,8 +161,11 @@ func TestTracing(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, response.Errors, 0)
 
-	assert.Len(t, reporter.GetSpans(), 1)
-	assert.Equal(t, "/api/traces/{traceID}", reporter.GetSpans()[0].(*jaeger.Span).OperationName())
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 2)
+	// the storage span finishes, and is reported, before the span of the request
+	assert.Equal(t, "spanstore.GetTrace", spans[0].(*jaeger.Span).OperationName())
+	assert.Equal(t, "/api/traces/{traceID}", spans[1].(*jaeger.Span).OperationName())
 }
 
 func TestGetTraceDBFailure(t *testing.T) {
@@ -218,10 +224,30 @@ func TestSearchSuccess(t *testing.T) {
 	readMock.On("FindTraces", mock.AnythingOfType("*spanstore.TraceQueryParameters")).
 		Return([]*model.Trace{mockTrace}, nil).Once()
 
-	var response structuredResponse
+	var response structuredTraceResponse
 	err := getJSON(server.URL+`/api/traces?service=service&start=0&end=0&operation=operation&limit=200&minDuration=20ms`, &response)
 	assert.NoError(t, err)
 	assert.Len(t, response.Errors, 0)
+	assert.Len(t, response.Traces, 1)
+	assert.Equal(t, 0, response.Total)
+	assert.Equal(t, 200, response.Limit)
+	assert.Equal(t, 0, response.Offset)
+}
+
+func TestSearchWithOffset(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	readMock.On("FindTraces", mock.MatchedBy(func(query *spanstore.TraceQueryParameters) bool {
+		return query.NumTraces == 20 && query.Offset == 40
+	})).Return([]*model.Trace{mockTrace}, nil).Once()
+
+	var response structuredTraceResponse
+	err := getJSON(server.URL+`/api/traces?service=service&limit=20&offset=40`, &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Traces, 1)
+	assert.Equal(t, 0, response.Total)
+	assert.Equal(t, 20, response.Limit)
+	assert.Equal(t, 40, response.Offset)
 }
 
 func TestSearchByTraceIDSuccess(t *testing.T) {
@@ -260,6 +286,73 @@ func TestSearchByTraceIDFailure(t *testing.T) {
 	assert.EqualError(t, err, parsedError(500, whatsamattayou))
 }
 
+func TestSearchByTraceIDPartialFailure(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	readMock.On("GetTrace", model.TraceID{Low: 1}).Return(mockTrace, nil).Once()
+	readMock.On("GetTrace", model.TraceID{Low: 2}).Return(nil, spanstore.ErrTraceNotFound).Once()
+	readMock.On("GetTrace", model.TraceID{Low: 3}).Return(nil, errStorage).Once()
+
+	var response structuredTraceResponse
+	err := getJSON(server.URL+`/api/traces?traceID=1&traceID=2&traceID=3`, &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Traces, 1)
+	assert.Equal(t, []structuredError{
+		{Code: http.StatusNotFound, Msg: spanstore.ErrTraceNotFound.Error(), TraceID: "2"},
+		{Code: http.StatusInternalServerError, Msg: errStorageMsg, TraceID: "3"},
+	}, response.Errors)
+}
+
+func TestSearchByTraceIDAllFailed(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	readMock.On("GetTrace", model.TraceID{Low: 1}).Return(nil, spanstore.ErrTraceNotFound).Once()
+	readMock.On("GetTrace", model.TraceID{Low: 2}).Return(nil, errStorage).Once()
+
+	var response structuredResponse
+	err := getJSON(server.URL+`/api/traces?traceID=1&traceID=2`, &response)
+	assert.EqualError(t, err, parsedError(500, errStorageMsg))
+}
+
+func TestFetchTracesBoundedConcurrency(t *testing.T) {
+	_, readMock, _, handler := initializeTestServerWithOptions(HandlerOptions.TraceFetchWorkers(2))
+	var inFlight, maxInFlight int32
+	readMock.On("GetTrace", mock.AnythingOfType("model.TraceID")).Return(mockTrace, nil).Run(func(mock.Arguments) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	})
+	traceIDs := make([]model.TraceID, 6)
+	for i := range traceIDs {
+		traceIDs[i] = model.TraceID{Low: uint64(i + 1)}
+	}
+
+	traces, traceErrors, err := handler.tracesByIDs(context.Background(), traceIDs)
+	assert.NoError(t, err)
+	assert.Empty(t, traceErrors)
+	assert.Len(t, traces, 6)
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 2, "at most 2 traces are loaded at a time")
+	readMock.AssertNumberOfCalls(t, "GetTrace", 6)
+}
+
+func TestFetchTracesCanceled(t *testing.T) {
+	_, readMock, _, handler := initializeTestServerWithOptions()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	traces, traceErrors, err := handler.tracesByIDs(ctx, []model.TraceID{{Low: 1}, {Low: 2}})
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, traces)
+	assert.Nil(t, traceErrors)
+	readMock.AssertNotCalled(t, "GetTrace", mock.Anything)
+}
+
 func TestSearchModelConversionFailure(t *testing.T) {
 	server, readMock, _, _ := initializeTestServerWithOptions(
 		HandlerOptions.Adjusters(
@@ -40,6 +40,7 @@ const (
 	tagParam         = "tag"
 	startTimeParam   = "start"
 	limitParam       = "limit"
+	offsetParam      = "offset"
 	minDurationParam = "minDuration"
 	maxDurationParam = "maxDuration"
 	serviceParam     = "service"
@@ -49,6 +50,7 @@ const (
 var (
 	errCannotQueryTagAndDuration = fmt.Errorf("Cannot query for tags when '%s' is specified", minDurationParam)
 	errMaxDurationGreaterThanMin = fmt.Errorf("'%s' should be greater than '%s'", maxDurationParam, minDurationParam)
+	errNegativeOffset            = fmt.Errorf("'%s' should not be negative", offsetParam)
 
 	// ErrServiceParameterRequired occurs when no service name is defined
 	ErrServiceParameterRequired = fmt.Errorf("Parameter '%s' is required", serviceParam)
@@ -68,10 +70,11 @@ type traceQueryParameters struct {
 // parse takes a request and constructs a model of parameters
 // Trace query syntax:
 //     query ::= param | param '&' query
-//     param ::= service | operation | limit | start | end | minDuration | maxDuration | tag
+//     param ::= service | operation | limit | offset | start | end | minDuration | maxDuration | tag
 //     service ::= 'service=' strValue
 //     operation ::= 'operation=' strValue
 //     limit ::= 'limit=' intValue
+//     offset ::= 'offset=' intValue
 //     start ::= 'start=' intValue in unix microseconds
 //     end ::= 'end=' intValue in unix microseconds
 //     minDuration ::= 'minDuration=' strValue (units are "ns", "us" (or "µs"), "ms", "s", "m", "h")
@@ -107,6 +110,15 @@ func (p *queryParser) parse(r *http.Request) (*traceQueryParameters, error) {
 		limit = int(limitParsed)
 	}
 
+	offset := 0
+	if offsetParam := r.FormValue(offsetParam); offsetParam != "" {
+		offsetParsed, err := strconv.ParseInt(offsetParam, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		offset = int(offsetParsed)
+	}
+
 	minDuration, err := p.parseDuration(minDurationParam, r)
 	if err != nil {
 		return nil, err
@@ -139,6 +151,7 @@ func (p *queryParser) parse(r *http.Request) (*traceQueryParameters, error) {
 			StartTimeMax:  endTime,
 			Tags:          tags,
 			NumTraces:     limit,
+			Offset:        offset,
 			DurationMin:   minDuration,
 			DurationMax:   maxDuration,
 		},
@@ -182,6 +195,9 @@ func (p *queryParser) validateQuery(traceQuery *traceQueryParameters) error {
 	if len(traceQuery.traceIDs) == 0 && traceQuery.ServiceName == "" {
 		return ErrServiceParameterRequired
 	}
+	if traceQuery.Offset < 0 {
+		return errNegativeOffset
+	}
 	if traceQuery.DurationMin != 0 && traceQuery.DurationMax != 0 {
 		if traceQuery.DurationMax < traceQuery.DurationMin {
 			return errMaxDurationGreaterThanMin
@@ -45,6 +45,8 @@ func TestParseTraceQuery(t *testing.T) {
 		{"x?service=service&start=string", errParseInt, nil},
 		{"x?service=service&end=string", errParseInt, nil},
 		{"x?service=service&limit=string", errParseInt, nil},
+		{"x?service=service&offset=string", errParseInt, nil},
+		{"x?service=service&offset=-1", `'offset' should not be negative`, nil},
 		{"x?service=service&start=0&end=0&operation=operation&limit=200&minDuration=20", "Could not parse minDuration: time: missing unit in duration 20", nil},
 		{"x?service=service&start=0&end=0&operation=operation&limit=200&minDuration=20s&maxDuration=30", "Could not parse maxDuration: time: missing unit in duration 30", nil},
 		{"x?service=service&start=0&end=0&operation=operation&limit=200&tag=k:v&minDuration=1s", `Cannot query for tags when 'minDuration' is specified`, nil},
@@ -76,6 +78,18 @@ func TestParseTraceQuery(t *testing.T) {
 				},
 			},
 		},
+		{"x?service=service&start=0&end=0&limit=20&offset=40", ``,
+			&traceQueryParameters{
+				TraceQueryParameters: spanstore.TraceQueryParameters{
+					ServiceName:  "service",
+					StartTimeMin: time.Unix(0, 0),
+					StartTimeMax: time.Unix(0, 0),
+					NumTraces:    20,
+					Offset:       40,
+					Tags:         make(map[string]string),
+				},
+			},
+		},
 		{"x?service=service&start=0&end=0&operation=operation&limit=200&minDuration=10s", ``,
 			&traceQueryParameters{
 				TraceQueryParameters: spanstore.TraceQueryParameters{
@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/uber/jaeger/model"
+	ui "github.com/uber/jaeger/model/json"
+)
+
+// ndjsonContentType is requested in the Accept header to stream search results as newline delimited JSON
+const ndjsonContentType = "application/x-ndjson"
+
+// streamedTrace is a single line of a streamed search response. It holds either a trace or the errors
+// that occurred while loading or adjusting it.
+type streamedTrace struct {
+	Data   *ui.Trace         `json:"data,omitempty"`
+	Errors []structuredError `json:"errors,omitempty"`
+}
+
+func acceptsNDJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+// traceStreamer writes traces to the response one line at a time, flushing after each of them
+// so that clients can render traces before the whole search completes.
+type traceStreamer struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newTraceStreamer(w http.ResponseWriter) *traceStreamer {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	flusher, _ := w.(http.Flusher)
+	return &traceStreamer{w: w, flusher: flusher}
+}
+
+func (s *traceStreamer) write(line *streamedTrace) {
+	resp, _ := json.Marshal(line)
+	s.w.Write(append(resp, '\n'))
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// streamSearch implements the REST API /traces when the client accepts application/x-ndjson.
+// The traces, looked up by ID or found by the query, are written in the order they are read, and
+// a trace that cannot be read results in a line with its error instead of failing the whole response.
+func (aH *APIHandler) streamSearch(w http.ResponseWriter, r *http.Request, tQuery *traceQueryParameters) {
+	traceIDs := tQuery.traceIDs
+	if len(traceIDs) == 0 {
+		var err error
+		traceIDs, err = aH.spanReader.FindTraceIDs(r.Context(), &tQuery.TraceQueryParameters)
+		if aH.handleError(w, err, http.StatusInternalServerError) {
+			return
+		}
+	}
+	streamer := newTraceStreamer(w)
+	// the client is gone if the request is canceled, so there is no one to report it to
+	aH.fetchTraces(r.Context(), traceIDs, func(result traceResult) {
+		if result.err != nil {
+			streamer.write(&streamedTrace{
+				Errors: []structuredError{traceErrorFromStorage(result.traceID, result.err)},
+			})
+			return
+		}
+		aH.streamTrace(streamer, result.trace)
+	})
+}
+
+func (aH *APIHandler) streamTrace(streamer *traceStreamer, trace *model.Trace) {
+	uiTrace, uiErr := aH.convertModelToUI(trace)
+	line := &streamedTrace{Data: uiTrace}
+	if uiErr != nil {
+		line.Errors = []structuredError{*uiErr}
+	}
+	streamer.write(line)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/model/adjuster"
+	ui "github.com/uber/jaeger/model/json"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+// getNDJSON fetches a streamed search response and parses every line of it
+func getNDJSON(t *testing.T, url string) []streamedTrace {
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	req.Header.Add("Accept", ndjsonContentType)
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		require.FailNow(t, fmt.Sprintf("%d error from server: %s", resp.StatusCode, body))
+	}
+	assert.Equal(t, ndjsonContentType, resp.Header.Get("Content-Type"))
+
+	var lines []streamedTrace
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line streamedTrace
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestStreamSearch(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	otherTrace := &model.Trace{Spans: []*model.Span{{TraceID: model.TraceID{Low: 2}}}}
+	readMock.On("FindTraces", mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+		Return([]*model.Trace{mockTrace, otherTrace}, nil).Once()
+	// the traces found by the query are loaded and streamed one by one
+	readMock.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+	readMock.On("GetTrace", model.TraceID{Low: 2}).Return(nil, errStorage).Once()
+
+	lines := getNDJSON(t, server.URL+`/api/traces?service=service`)
+	require.Len(t, lines, 2)
+	var found []streamedTrace
+	for _, line := range lines {
+		if line.Data != nil {
+			found = append(found, line)
+		} else {
+			assert.Equal(t, []structuredError{
+				{Code: http.StatusInternalServerError, Msg: errStorageMsg, TraceID: "2"},
+			}, line.Errors)
+		}
+	}
+	require.Len(t, found, 1)
+	assert.Equal(t, ui.TraceID(mockTraceID.String()), found[0].Data.TraceID)
+	assert.Len(t, found[0].Data.Spans, 2)
+	assert.Empty(t, found[0].Errors)
+	readMock.AssertExpectations(t)
+}
+
+func TestStreamSearchByTraceID(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	readMock.On("GetTrace", model.TraceID{Low: 1}).Return(mockTrace, nil).Once()
+	readMock.On("GetTrace", model.TraceID{Low: 2}).Return(nil, spanstore.ErrTraceNotFound).Once()
+	readMock.On("GetTrace", model.TraceID{Low: 3}).Return(nil, errStorage).Once()
+
+	lines := getNDJSON(t, server.URL+`/api/traces?traceID=1&traceID=2&traceID=3`)
+	require.Len(t, lines, 3)
+	// the traces are written in the order they are loaded
+	linesByTraceID := make(map[ui.TraceID]streamedTrace)
+	for _, line := range lines {
+		if line.Data != nil {
+			linesByTraceID[line.Data.TraceID] = line
+		} else {
+			require.Len(t, line.Errors, 1)
+			linesByTraceID[line.Errors[0].TraceID] = line
+		}
+	}
+	found := linesByTraceID[ui.TraceID(mockTraceID.String())]
+	require.NotNil(t, found.Data)
+	assert.Empty(t, found.Errors)
+	assert.Equal(t, []structuredError{
+		{Code: http.StatusNotFound, Msg: spanstore.ErrTraceNotFound.Error(), TraceID: "2"},
+	}, linesByTraceID["2"].Errors)
+	assert.Equal(t, []structuredError{
+		{Code: http.StatusInternalServerError, Msg: errStorageMsg, TraceID: "3"},
+	}, linesByTraceID["3"].Errors)
+}
+
+func TestStreamSearchAdjustmentFailure(t *testing.T) {
+	server, readMock, _, _ := initializeTestServerWithOptions(
+		HandlerOptions.Adjusters(
+			adjuster.Func(func(trace *model.Trace) (*model.Trace, error) {
+				return trace, errAdjustment
+			}),
+		),
+	)
+	defer server.Close()
+	readMock.On("FindTraces", mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+		Return([]*model.Trace{mockTrace}, nil).Once()
+	readMock.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+
+	lines := getNDJSON(t, server.URL+`/api/traces?service=service`)
+	require.Len(t, lines, 1)
+	assert.NotNil(t, lines[0].Data)
+	require.Len(t, lines[0].Errors, 1)
+	assert.Equal(t, errAdjustment.Error(), lines[0].Errors[0].Msg)
+}
+
+func TestStreamSearchDBFailure(t *testing.T) {
+	server, readMock, _ := initializeTestServer()
+	defer server.Close()
+	readMock.On("FindTraces", mock.AnythingOfType("*spanstore.TraceQueryParameters")).
+		Return(nil, errStorage).Once()
+
+	req, err := http.NewRequest("GET", server.URL+`/api/traces?service=service`, nil)
+	require.NoError(t, err)
+	req.Header.Add("Accept", ndjsonContentType)
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
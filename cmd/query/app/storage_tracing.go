@@ -0,0 +1,192 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/storage/dependencystore"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+// startStorageSpan starts a child span of the span in ctx, if there is one, for a call to storage
+func startStorageSpan(ctx context.Context, tracer opentracing.Tracer, operationName string) (opentracing.Span, context.Context) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return nil, ctx
+	}
+	span := tracer.StartSpan(operationName, opentracing.ChildOf(parent.Context()))
+	ext.SpanKindRPCClient.Set(span)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
+
+// finishStorageSpan finishes the span, marking it as failed unless the call succeeded
+// or the trace was merely not found
+func finishStorageSpan(span opentracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil && err != spanstore.ErrTraceNotFound {
+		ext.Error.Set(span, true)
+		span.LogKV("event", "error", "message", err.Error())
+	}
+	span.Finish()
+}
+
+// tracedSpanReader traces the calls to a span reader made within a traced request
+type tracedSpanReader struct {
+	reader spanstore.ContextReader
+	tracer opentracing.Tracer
+	prefix string
+}
+
+func newTracedSpanReader(reader spanstore.ContextReader, tracer opentracing.Tracer, prefix string) spanstore.ContextReader {
+	return &tracedSpanReader{reader: reader, tracer: tracer, prefix: prefix}
+}
+
+func (r *tracedSpanReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
+	return r.GetTraceContext(context.Background(), traceID)
+}
+
+func (r *tracedSpanReader) GetServices() ([]string, error) {
+	return r.GetServicesContext(context.Background())
+}
+
+func (r *tracedSpanReader) GetOperations(service string) ([]string, error) {
+	return r.GetOperationsContext(context.Background(), service)
+}
+
+func (r *tracedSpanReader) FindTraces(query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	return r.FindTracesContext(context.Background(), query)
+}
+
+func (r *tracedSpanReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	span, ctx := startStorageSpan(ctx, r.tracer, r.prefix+".GetTrace")
+	if span != nil {
+		span.SetTag("trace_id", traceID.String())
+	}
+	trace, err := r.reader.GetTraceContext(ctx, traceID)
+	finishStorageSpan(span, err)
+	return trace, err
+}
+
+func (r *tracedSpanReader) GetServicesContext(ctx context.Context) ([]string, error) {
+	span, ctx := startStorageSpan(ctx, r.tracer, r.prefix+".GetServices")
+	services, err := r.reader.GetServicesContext(ctx)
+	finishStorageSpan(span, err)
+	return services, err
+}
+
+func (r *tracedSpanReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	span, ctx := startStorageSpan(ctx, r.tracer, r.prefix+".GetOperations")
+	if span != nil {
+		span.SetTag("service", service)
+	}
+	operations, err := r.reader.GetOperationsContext(ctx, service)
+	finishStorageSpan(span, err)
+	return operations, err
+}
+
+func (r *tracedSpanReader) FindTracesContext(
+	ctx context.Context,
+	query *spanstore.TraceQueryParameters,
+) ([]*model.Trace, error) {
+	span, ctx := startStorageSpan(ctx, r.tracer, r.prefix+".FindTraces")
+	if span != nil {
+		span.SetTag("service", query.ServiceName)
+		span.SetTag("num_traces", query.NumTraces)
+	}
+	traces, err := r.reader.FindTracesContext(ctx, query)
+	if span != nil {
+		span.SetTag("traces", len(traces))
+	}
+	finishStorageSpan(span, err)
+	return traces, err
+}
+
+func (r *tracedSpanReader) FindTraceIDs(
+	ctx context.Context,
+	query *spanstore.TraceQueryParameters,
+) ([]model.TraceID, error) {
+	span, ctx := startStorageSpan(ctx, r.tracer, r.prefix+".FindTraceIDs")
+	if span != nil {
+		span.SetTag("service", query.ServiceName)
+		span.SetTag("num_traces", query.NumTraces)
+	}
+	traceIDs, err := r.reader.FindTraceIDs(ctx, query)
+	if span != nil {
+		span.SetTag("traces", len(traceIDs))
+	}
+	finishStorageSpan(span, err)
+	return traceIDs, err
+}
+
+// tracedSpanWriter traces the calls to a span writer made within a traced request
+type tracedSpanWriter struct {
+	writer spanstore.ContextWriter
+	tracer opentracing.Tracer
+	prefix string
+}
+
+func newTracedSpanWriter(writer spanstore.ContextWriter, tracer opentracing.Tracer, prefix string) spanstore.ContextWriter {
+	return &tracedSpanWriter{writer: writer, tracer: tracer, prefix: prefix}
+}
+
+func (w *tracedSpanWriter) WriteSpan(span *model.Span) error {
+	return w.WriteSpanContext(context.Background(), span)
+}
+
+func (w *tracedSpanWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	storageSpan, ctx := startStorageSpan(ctx, w.tracer, w.prefix+".WriteSpan")
+	err := w.writer.WriteSpanContext(ctx, span)
+	finishStorageSpan(storageSpan, err)
+	return err
+}
+
+// tracedDependencyReader traces the calls to a dependency reader made within a traced request
+type tracedDependencyReader struct {
+	reader dependencystore.ContextReader
+	tracer opentracing.Tracer
+}
+
+func newTracedDependencyReader(reader dependencystore.ContextReader, tracer opentracing.Tracer) dependencystore.ContextReader {
+	return &tracedDependencyReader{reader: reader, tracer: tracer}
+}
+
+func (r *tracedDependencyReader) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	return r.GetDependenciesContext(context.Background(), endTs, lookback)
+}
+
+func (r *tracedDependencyReader) GetDependenciesContext(
+	ctx context.Context,
+	endTs time.Time,
+	lookback time.Duration,
+) ([]model.DependencyLink, error) {
+	span, ctx := startStorageSpan(ctx, r.tracer, "dependencystore.GetDependencies")
+	dependencies, err := r.reader.GetDependenciesContext(ctx, endTs, lookback)
+	finishStorageSpan(span, err)
+	return dependencies, err
+}
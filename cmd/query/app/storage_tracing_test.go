@@ -0,0 +1,168 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+	depsmocks "github.com/uber/jaeger/storage/dependencystore/mocks"
+	"github.com/uber/jaeger/storage/spanstore"
+	spanstoremocks "github.com/uber/jaeger/storage/spanstore/mocks"
+)
+
+// withStorageSpan matches the contexts carrying the span of a storage call
+func withStorageSpan() interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return opentracing.SpanFromContext(ctx) != nil
+	})
+}
+
+func TestStorageCallsAreTraced(t *testing.T) {
+	tracer := mocktracer.New()
+	spanReader := &spanstoremocks.ContextReader{}
+	dependencyReader := &depsmocks.ContextReader{}
+	router := mux.NewRouter()
+	NewAPIHandler(spanReader, dependencyReader, HandlerOptions.Tracer(tracer)).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	spanReader.On("GetServicesContext", withStorageSpan()).Return([]string{"svc"}, nil).Once()
+	var response structuredResponse
+	require.NoError(t, getJSON(server.URL+"/api/services", &response))
+
+	dependencyReader.On("GetDependenciesContext", withStorageSpan(), mock.Anything, mock.Anything).
+		Return(nil, errStorage).Once()
+	assert.Error(t, getJSON(server.URL+"/api/dependencies?endTs=0", &response))
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 4)
+	for i, expected := range []struct {
+		operationName string
+		parent        int
+		error         bool
+	}{
+		{operationName: "spanstore.GetServices", parent: 1},
+		{operationName: "/api/services", parent: -1},
+		{operationName: "dependencystore.GetDependencies", parent: 3, error: true},
+		{operationName: "/api/dependencies", parent: -1},
+	} {
+		assert.Equal(t, expected.operationName, spans[i].OperationName)
+		if expected.parent >= 0 {
+			assert.Equal(t, spans[expected.parent].SpanContext.SpanID, spans[i].ParentID, expected.operationName)
+		}
+		assert.Equal(t, expected.error, spans[i].Tag("error") == true, expected.operationName)
+	}
+	spanReader.AssertExpectations(t)
+	dependencyReader.AssertExpectations(t)
+}
+
+func TestTracedSpanReader(t *testing.T) {
+	tracer := mocktracer.New()
+	reader := &spanstoremocks.ContextReader{}
+	traced := newTracedSpanReader(reader, tracer, "archive")
+	query := &spanstore.TraceQueryParameters{ServiceName: "svc", NumTraces: 10}
+
+	reader.On("GetTraceContext", mock.Anything, mockTraceID).Return(nil, spanstore.ErrTraceNotFound).Twice()
+	reader.On("GetServicesContext", mock.Anything).Return(nil, nil).Once()
+	reader.On("GetOperationsContext", mock.Anything, "svc").Return(nil, errStorage).Once()
+	reader.On("FindTracesContext", mock.Anything, query).Return([]*model.Trace{mockTrace}, nil).Once()
+	reader.On("FindTraceIDs", mock.Anything, query).Return([]model.TraceID{mockTraceID}, nil).Once()
+
+	// without a span in the context, there is no parent for the storage spans
+	_, err := traced.GetTrace(mockTraceID)
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+	assert.Empty(t, tracer.FinishedSpans())
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	_, err = traced.GetTraceContext(ctx, mockTraceID)
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+	_, err = traced.GetServicesContext(ctx)
+	assert.NoError(t, err)
+	_, err = traced.GetOperationsContext(ctx, "svc")
+	assert.Equal(t, errStorage, err)
+	traces, err := traced.FindTracesContext(ctx, query)
+	assert.NoError(t, err)
+	assert.Len(t, traces, 1)
+	traceIDs, err := traced.FindTraceIDs(ctx, query)
+	assert.NoError(t, err)
+	assert.Len(t, traceIDs, 1)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 5)
+	assert.Equal(t, "archive.GetTrace", spans[0].OperationName)
+	assert.Equal(t, mockTraceID.String(), spans[0].Tag("trace_id"))
+	assert.Nil(t, spans[0].Tag("error"), "a trace not being found is not an error")
+	assert.Equal(t, "archive.GetServices", spans[1].OperationName)
+	assert.Equal(t, "archive.GetOperations", spans[2].OperationName)
+	assert.Equal(t, true, spans[2].Tag("error"))
+	assert.Equal(t, "archive.FindTraces", spans[3].OperationName)
+	assert.Equal(t, 1, spans[3].Tag("traces"))
+	assert.Equal(t, "archive.FindTraceIDs", spans[4].OperationName)
+	assert.Equal(t, 1, spans[4].Tag("traces"))
+	for _, span := range spans {
+		assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, span.ParentID)
+	}
+	reader.AssertExpectations(t)
+}
+
+func TestTracedSpanWriter(t *testing.T) {
+	tracer := mocktracer.New()
+	writer := &spanstoremocks.ContextWriter{}
+	traced := newTracedSpanWriter(writer, tracer, "archive")
+	writer.On("WriteSpanContext", mock.Anything, mockTrace.Spans[0]).Return(errors.New("write error")).Twice()
+
+	assert.EqualError(t, traced.WriteSpan(mockTrace.Spans[0]), "write error")
+	assert.Empty(t, tracer.FinishedSpans())
+
+	ctx := opentracing.ContextWithSpan(context.Background(), tracer.StartSpan("parent"))
+	assert.EqualError(t, traced.WriteSpanContext(ctx, mockTrace.Spans[0]), "write error")
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "archive.WriteSpan", spans[0].OperationName)
+	assert.Equal(t, true, spans[0].Tag("error"))
+	writer.AssertExpectations(t)
+}
+
+func TestTracedDependencyReader(t *testing.T) {
+	tracer := mocktracer.New()
+	reader := &depsmocks.ContextReader{}
+	traced := newTracedDependencyReader(reader, tracer)
+	endTs := time.Unix(0, 0)
+	reader.On("GetDependenciesContext", mock.Anything, endTs, time.Hour).Return([]model.DependencyLink{}, nil).Once()
+
+	_, err := traced.GetDependencies(endTs, time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, tracer.FinishedSpans())
+	reader.AssertExpectations(t)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/uber/jaeger/model"
+	ui "github.com/uber/jaeger/model/json"
+)
+
+// diffOperation is one step of the path from the root of a trace to a span
+type diffOperation struct {
+	Service   string `json:"serviceName"`
+	Operation string `json:"operationName"`
+}
+
+// traceDiffNode compares the spans found at the same path of services and operations in two traces.
+// Repeated calls at the same path, e.g. in a loop, are aggregated. Durations are in microseconds.
+type traceDiffNode struct {
+	Path          []diffOperation `json:"path"`
+	CountA        int             `json:"countA"`
+	CountB        int             `json:"countB"`
+	DurationA     uint64          `json:"durationA"`
+	DurationB     uint64          `json:"durationB"`
+	DurationDelta int64           `json:"durationDelta"`
+	TagDiffs      []tagDiff       `json:"tagDiffs,omitempty"`
+}
+
+// tagDiff lists the distinct values of a tag in each trace when they differ
+type tagDiff struct {
+	Key     string   `json:"key"`
+	ValuesA []string `json:"valuesA"`
+	ValuesB []string `json:"valuesB"`
+}
+
+// traceDiff is the comparison of trace A with trace B, deltas are B minus A
+type traceDiff struct {
+	TraceIDA ui.TraceID       `json:"traceIDA"`
+	TraceIDB ui.TraceID       `json:"traceIDB"`
+	Nodes    []*traceDiffNode `json:"nodes"`
+	OnlyInA  []*traceDiffNode `json:"onlyInA"`
+	OnlyInB  []*traceDiffNode `json:"onlyInB"`
+}
+
+type pathSpans struct {
+	path  []diffOperation
+	spans []*model.Span
+}
+
+type spansByStartTime []*model.Span
+
+func (s spansByStartTime) Len() int           { return len(s) }
+func (s spansByStartTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s spansByStartTime) Less(i, j int) bool { return s[i].StartTime.Before(s[j].StartTime) }
+
+// diffTraces lines up the spans of the two traces by their path of services and operations
+// from the root, and compares the durations and tags of the spans at each path.
+func diffTraces(traceIDA model.TraceID, traceA *model.Trace, traceIDB model.TraceID, traceB *model.Trace) *traceDiff {
+	keysA, pathsA := groupSpansByPath(traceA)
+	keysB, pathsB := groupSpansByPath(traceB)
+	diff := &traceDiff{
+		TraceIDA: ui.TraceID(traceIDA.String()),
+		TraceIDB: ui.TraceID(traceIDB.String()),
+		Nodes:    []*traceDiffNode{},
+		OnlyInA:  []*traceDiffNode{},
+		OnlyInB:  []*traceDiffNode{},
+	}
+	for _, key := range keysA {
+		a := pathsA[key]
+		if b, ok := pathsB[key]; ok {
+			node := newTraceDiffNode(a.path, a.spans, b.spans)
+			node.TagDiffs = diffTags(a.spans, b.spans)
+			diff.Nodes = append(diff.Nodes, node)
+		} else {
+			diff.OnlyInA = append(diff.OnlyInA, newTraceDiffNode(a.path, a.spans, nil))
+		}
+	}
+	for _, key := range keysB {
+		if _, ok := pathsA[key]; !ok {
+			b := pathsB[key]
+			diff.OnlyInB = append(diff.OnlyInB, newTraceDiffNode(b.path, nil, b.spans))
+		}
+	}
+	return diff
+}
+
+func newTraceDiffNode(path []diffOperation, spansA, spansB []*model.Span) *traceDiffNode {
+	durationA := totalDuration(spansA)
+	durationB := totalDuration(spansB)
+	return &traceDiffNode{
+		Path:          path,
+		CountA:        len(spansA),
+		CountB:        len(spansB),
+		DurationA:     durationA,
+		DurationB:     durationB,
+		DurationDelta: int64(durationB) - int64(durationA),
+	}
+}
+
+func totalDuration(spans []*model.Span) uint64 {
+	var duration uint64
+	for _, span := range spans {
+		duration += model.DurationAsMicroseconds(span.Duration)
+	}
+	return duration
+}
+
+// groupSpansByPath walks the trace depth first, children in the order they started, and returns
+// the keys of the paths in the order they were first seen along with the spans at each path. Spans
+// that are not reached from a root, because their parents form a cycle, are walked as extra roots.
+func groupSpansByPath(trace *model.Trace) ([]string, map[string]*pathSpans) {
+	spanIDs := make(map[model.SpanID]struct{}, len(trace.Spans))
+	for _, span := range trace.Spans {
+		spanIDs[span.SpanID] = struct{}{}
+	}
+	var roots []*model.Span
+	children := make(map[model.SpanID][]*model.Span)
+	for _, span := range trace.Spans {
+		if _, ok := spanIDs[span.ParentSpanID]; ok && span.ParentSpanID != span.SpanID {
+			children[span.ParentSpanID] = append(children[span.ParentSpanID], span)
+		} else {
+			roots = append(roots, span)
+		}
+	}
+
+	var keys []string
+	paths := make(map[string]*pathSpans)
+	visited := make(map[model.SpanID]struct{}, len(trace.Spans))
+	var walk func(spans []*model.Span, parentPath []diffOperation)
+	walk = func(spans []*model.Span, parentPath []diffOperation) {
+		sort.Stable(spansByStartTime(spans))
+		for _, span := range spans {
+			if _, ok := visited[span.SpanID]; ok {
+				continue
+			}
+			visited[span.SpanID] = struct{}{}
+			path := make([]diffOperation, len(parentPath), len(parentPath)+1)
+			copy(path, parentPath)
+			path = append(path, diffOperation{Service: serviceName(span), Operation: span.OperationName})
+			key := pathKey(path)
+			group, ok := paths[key]
+			if !ok {
+				group = &pathSpans{path: path}
+				paths[key] = group
+				keys = append(keys, key)
+			}
+			group.spans = append(group.spans, span)
+			walk(children[span.SpanID], path)
+		}
+	}
+	walk(roots, nil)
+	var unvisited []*model.Span
+	for _, span := range trace.Spans {
+		if _, ok := visited[span.SpanID]; !ok {
+			unvisited = append(unvisited, span)
+		}
+	}
+	walk(unvisited, nil)
+	return keys, paths
+}
+
+func serviceName(span *model.Span) string {
+	if span.Process == nil {
+		return ""
+	}
+	return span.Process.ServiceName
+}
+
+func pathKey(path []diffOperation) string {
+	parts := make([]string, len(path))
+	for i, op := range path {
+		parts[i] = op.Service + "\x00" + op.Operation
+	}
+	return strings.Join(parts, "\x01")
+}
+
+// diffTags returns the tags whose distinct values differ between the two groups of spans
+func diffTags(spansA, spansB []*model.Span) []tagDiff {
+	valuesA := tagValues(spansA)
+	valuesB := tagValues(spansB)
+	keys := make(map[string]struct{}, len(valuesA)+len(valuesB))
+	for key := range valuesA {
+		keys[key] = struct{}{}
+	}
+	for key := range valuesB {
+		keys[key] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var diffs []tagDiff
+	for _, key := range sortedKeys {
+		a, b := sortedValues(valuesA[key]), sortedValues(valuesB[key])
+		if !equalStrings(a, b) {
+			diffs = append(diffs, tagDiff{Key: key, ValuesA: a, ValuesB: b})
+		}
+	}
+	return diffs
+}
+
+func tagValues(spans []*model.Span) map[string]map[string]struct{} {
+	values := make(map[string]map[string]struct{})
+	for _, span := range spans {
+		for i := range span.Tags {
+			tag := &span.Tags[i]
+			if values[tag.Key] == nil {
+				values[tag.Key] = make(map[string]struct{})
+			}
+			values[tag.Key][tag.AsString()] = struct{}{}
+		}
+	}
+	return values
+}
+
+func sortedValues(values map[string]struct{}) []string {
+	sorted := make([]string, 0, len(values))
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+)
+
+func diffSpan(spanID, parentID model.SpanID, service, operation string, duration time.Duration, tags ...model.KeyValue) *model.Span {
+	return &model.Span{
+		SpanID:        spanID,
+		ParentSpanID:  parentID,
+		OperationName: operation,
+		StartTime:     time.Unix(0, int64(spanID)),
+		Duration:      duration,
+		Tags:          tags,
+		Process:       model.NewProcess(service, nil),
+	}
+}
+
+func TestDiffTraces(t *testing.T) {
+	traceA := &model.Trace{Spans: []*model.Span{
+		diffSpan(1, 0, "frontend", "GET /dispatch", 100*time.Millisecond, model.String("http.status_code", "200")),
+		diffSpan(2, 1, "driver", "FindNearest", 40*time.Millisecond),
+		diffSpan(3, 1, "redis", "GetDriver", 10*time.Millisecond),
+		diffSpan(4, 1, "redis", "GetDriver", 10*time.Millisecond),
+		diffSpan(5, 1, "mysql", "SQL SELECT", 20*time.Millisecond),
+	}}
+	traceB := &model.Trace{Spans: []*model.Span{
+		diffSpan(15, 0, "frontend", "GET /dispatch", 150*time.Millisecond, model.String("http.status_code", "500")),
+		diffSpan(11, 15, "redis", "GetDriver", 30*time.Millisecond),
+		diffSpan(12, 15, "driver", "FindNearest", 35*time.Millisecond, model.Bool("error", true)),
+		diffSpan(13, 12, "route", "FindRoute", 5*time.Millisecond),
+	}}
+	traceIDA := model.TraceID{Low: 1}
+	traceIDB := model.TraceID{Low: 2}
+
+	diff := diffTraces(traceIDA, traceA, traceIDB, traceB)
+	assert.EqualValues(t, "1", diff.TraceIDA)
+	assert.EqualValues(t, "2", diff.TraceIDB)
+
+	root := []diffOperation{{Service: "frontend", Operation: "GET /dispatch"}}
+	assert.Equal(t, []*traceDiffNode{
+		{
+			Path:          root,
+			CountA:        1,
+			CountB:        1,
+			DurationA:     100000,
+			DurationB:     150000,
+			DurationDelta: 50000,
+			TagDiffs: []tagDiff{
+				{Key: "http.status_code", ValuesA: []string{"200"}, ValuesB: []string{"500"}},
+			},
+		},
+		{
+			Path:          append(root, diffOperation{Service: "driver", Operation: "FindNearest"}),
+			CountA:        1,
+			CountB:        1,
+			DurationA:     40000,
+			DurationB:     35000,
+			DurationDelta: -5000,
+			TagDiffs: []tagDiff{
+				{Key: "error", ValuesA: []string{}, ValuesB: []string{"true"}},
+			},
+		},
+		{
+			Path:          append(root, diffOperation{Service: "redis", Operation: "GetDriver"}),
+			CountA:        2,
+			CountB:        1,
+			DurationA:     20000,
+			DurationB:     30000,
+			DurationDelta: 10000,
+		},
+	}, diff.Nodes)
+	assert.Equal(t, []*traceDiffNode{
+		{
+			Path:          append(root, diffOperation{Service: "mysql", Operation: "SQL SELECT"}),
+			CountA:        1,
+			DurationA:     20000,
+			DurationDelta: -20000,
+		},
+	}, diff.OnlyInA)
+	assert.Equal(t, []*traceDiffNode{
+		{
+			Path: append(root,
+				diffOperation{Service: "driver", Operation: "FindNearest"},
+				diffOperation{Service: "route", Operation: "FindRoute"},
+			),
+			CountB:        1,
+			DurationB:     5000,
+			DurationDelta: 5000,
+		},
+	}, diff.OnlyInB)
+}
+
+func TestDiffTracesIdentical(t *testing.T) {
+	trace := &model.Trace{Spans: []*model.Span{
+		diffSpan(1, 0, "frontend", "GET /", time.Millisecond, model.Int64("count", 1)),
+		diffSpan(2, 1, "backend", "query", time.Millisecond),
+	}}
+	diff := diffTraces(model.TraceID{Low: 1}, trace, model.TraceID{Low: 1}, trace)
+	require.Len(t, diff.Nodes, 2)
+	for _, node := range diff.Nodes {
+		assert.EqualValues(t, 0, node.DurationDelta)
+		assert.Empty(t, node.TagDiffs)
+	}
+	assert.Empty(t, diff.OnlyInA)
+	assert.Empty(t, diff.OnlyInB)
+}
+
+func TestDiffTracesOrphansAndCycles(t *testing.T) {
+	trace := &model.Trace{Spans: []*model.Span{
+		// parent is missing from the trace, the span is treated as a root
+		diffSpan(1, 42, "frontend", "GET /", time.Millisecond),
+		// the spans are parents of each other, the one that started first is treated as a root
+		diffSpan(2, 3, "a", "x", time.Millisecond),
+		diffSpan(3, 2, "b", "y", time.Millisecond),
+		// span is its own parent
+		diffSpan(4, 4, "c", "z", time.Millisecond),
+	}}
+	diff := diffTraces(model.TraceID{Low: 1}, trace, model.TraceID{Low: 2}, &model.Trace{})
+	require.Len(t, diff.OnlyInA, 4)
+	assert.Equal(t, []diffOperation{{Service: "frontend", Operation: "GET /"}}, diff.OnlyInA[0].Path)
+	assert.Equal(t, []diffOperation{{Service: "c", Operation: "z"}}, diff.OnlyInA[1].Path)
+	assert.Equal(t, []diffOperation{{Service: "a", Operation: "x"}}, diff.OnlyInA[2].Path)
+	assert.Equal(t, []diffOperation{{Service: "a", Operation: "x"}, {Service: "b", Operation: "y"}}, diff.OnlyInA[3].Path)
+	assert.Empty(t, diff.Nodes)
+	assert.Empty(t, diff.OnlyInB)
+}
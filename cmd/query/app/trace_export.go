@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/uber/jaeger/model"
+	jConverter "github.com/uber/jaeger/model/converter/thrift/jaeger"
+	zipkinConverter "github.com/uber/jaeger/model/converter/thrift/zipkin"
+	"github.com/uber/jaeger/thrift-gen/jaeger"
+)
+
+const (
+	formatParam = "format"
+
+	// zipkinJSONFormat exports a trace as a list of spans in Zipkin v1 JSON
+	zipkinJSONFormat = "zipkin-json"
+	// jaegerThriftFormat exports a trace as a list of jaeger.thrift batches, one per process,
+	// serialized with the binary protocol
+	jaegerThriftFormat = "jaeger-thrift"
+)
+
+// exportTrace implements the REST API /traces/{trace-id}?format={format}. The trace is exported
+// as stored, without adjustments, so that importing it into a collector does not alter it.
+func (aH *APIHandler) exportTrace(w http.ResponseWriter, r *http.Request, format string) {
+	var contentType string
+	var serialize func(trace *model.Trace) ([]byte, error)
+	switch format {
+	case zipkinJSONFormat:
+		contentType = "application/json"
+		serialize = func(trace *model.Trace) ([]byte, error) {
+			return zipkinConverter.SerializeJSON(zipkinConverter.FromDomain(trace))
+		}
+	case jaegerThriftFormat:
+		contentType = "application/x-thrift"
+		serialize = func(trace *model.Trace) ([]byte, error) {
+			return serializeJaegerBatches(batchesFromDomain(trace))
+		}
+	default:
+		aH.handleError(w, fmt.Errorf("Unsupported format type: %v", format), http.StatusBadRequest)
+		return
+	}
+	aH.withTraceFromReader(w, r, aH.spanReader, aH.archiveSpanReader, func(trace *model.Trace) {
+		body, err := serialize(trace)
+		if aH.handleError(w, err, http.StatusInternalServerError) {
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	})
+}
+
+// batchesFromDomain groups the spans of the trace by process into jaeger.thrift batches
+func batchesFromDomain(trace *model.Trace) []*jaeger.Batch {
+	var processes []*model.Process
+	var batches []*jaeger.Batch
+	for _, span := range trace.Spans {
+		i := 0
+		for i < len(processes) && !processes[i].Equal(span.Process) {
+			i++
+		}
+		if i == len(processes) {
+			processes = append(processes, span.Process)
+			batches = append(batches, &jaeger.Batch{Process: jConverter.FromDomainProcess(span.Process)})
+		}
+		batches[i].Spans = append(batches[i].Spans, jConverter.FromDomainSpan(span))
+	}
+	return batches
+}
+
+func serializeJaegerBatches(batches []*jaeger.Batch) ([]byte, error) {
+	buffer := thrift.NewTMemoryBuffer()
+	protocol := thrift.NewTBinaryProtocolTransport(buffer)
+	if err := protocol.WriteListBegin(thrift.STRUCT, len(batches)); err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		if err := batch.Write(protocol); err != nil {
+			return nil, err
+		}
+	}
+	if err := protocol.WriteListEnd(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
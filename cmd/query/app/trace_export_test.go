@@ -0,0 +1,124 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+	zipkinConverter "github.com/uber/jaeger/model/converter/thrift/zipkin"
+	"github.com/uber/jaeger/storage/spanstore"
+	spanstoremocks "github.com/uber/jaeger/storage/spanstore/mocks"
+	"github.com/uber/jaeger/thrift-gen/jaeger"
+)
+
+func getBytes(t *testing.T, url string) (*http.Response, []byte) {
+	res, err := httpClient.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, body
+}
+
+func deserializeJaegerBatches(t *testing.T, b []byte) []*jaeger.Batch {
+	buffer := thrift.NewTMemoryBuffer()
+	buffer.Write(b)
+	protocol := thrift.NewTBinaryProtocolTransport(buffer)
+	_, size, err := protocol.ReadListBegin()
+	require.NoError(t, err)
+	batches := make([]*jaeger.Batch, size)
+	for i := range batches {
+		batches[i] = &jaeger.Batch{}
+		require.NoError(t, batches[i].Read(protocol))
+	}
+	return batches
+}
+
+func TestExportTraceZipkinJSON(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+		res, body := getBytes(t, ts.server.URL+"/api/traces/"+mockTraceID.String()+"?format=zipkin-json")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		zSpans, err := zipkinConverter.DeserializeJSON(body)
+		require.NoError(t, err)
+		require.Len(t, zSpans, 2)
+		assert.EqualValues(t, mockTraceID.Low, zSpans[0].TraceID)
+		assert.EqualValues(t, 1, zSpans[0].ID)
+		assert.EqualValues(t, 2, zSpans[1].ID)
+	})
+}
+
+func TestExportTraceJaegerThrift(t *testing.T) {
+	trace := &model.Trace{
+		Spans: []*model.Span{
+			{TraceID: mockTraceID, SpanID: model.SpanID(1), Process: model.NewProcess("a", nil)},
+			{TraceID: mockTraceID, SpanID: model.SpanID(2), Process: model.NewProcess("b", nil)},
+			{TraceID: mockTraceID, SpanID: model.SpanID(3), Process: model.NewProcess("a", nil)},
+		},
+	}
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(trace, nil).Once()
+		res, body := getBytes(t, ts.server.URL+"/api/traces/"+mockTraceID.String()+"?format=jaeger-thrift")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/x-thrift", res.Header.Get("Content-Type"))
+		batches := deserializeJaegerBatches(t, body)
+		require.Len(t, batches, 2)
+		assert.Equal(t, "a", batches[0].Process.ServiceName)
+		require.Len(t, batches[0].Spans, 2)
+		assert.EqualValues(t, 1, batches[0].Spans[0].SpanId)
+		assert.EqualValues(t, 3, batches[0].Spans[1].SpanId)
+		assert.Equal(t, "b", batches[1].Process.ServiceName)
+		require.Len(t, batches[1].Spans, 1)
+		assert.EqualValues(t, 2, batches[1].Spans[0].SpanId)
+	})
+}
+
+func TestExportTraceFromArchive(t *testing.T) {
+	archiveReader := &spanstoremocks.Reader{}
+	archiveReader.On("GetTrace", mockTraceID).Return(mockTrace, nil).Once()
+	withTestServer(t, func(ts *testServer) {
+		ts.spanReader.On("GetTrace", mockTraceID).Return(nil, spanstore.ErrTraceNotFound).Once()
+		res, _ := getBytes(t, ts.server.URL+"/api/traces/"+mockTraceID.String()+"?format=jaeger-thrift")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}, HandlerOptions.ArchiveSpanReader(archiveReader))
+}
+
+func TestExportTraceErrors(t *testing.T) {
+	withTestServer(t, func(ts *testServer) {
+		var response structuredResponse
+		err := getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"?format=zipkin-thrift", &response)
+		assert.EqualError(t, err, parsedError(400, "Unsupported format type: zipkin-thrift"))
+		ts.spanReader.AssertNotCalled(t, "GetTrace", mock.Anything)
+
+		ts.spanReader.On("GetTrace", mockTraceID).Return(nil, spanstore.ErrTraceNotFound).Once()
+		err = getJSON(ts.server.URL+"/api/traces/"+mockTraceID.String()+"?format=zipkin-json", &response)
+		assert.EqualError(t, err, parsedError(404, "trace not found"))
+	})
+}
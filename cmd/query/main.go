@@ -34,6 +34,7 @@ import (
 
 	basicB "github.com/uber/jaeger/cmd/builder"
 	casFlags "github.com/uber/jaeger/cmd/flags/cassandra"
+	esFlags "github.com/uber/jaeger/cmd/flags/es"
 	infFlags "github.com/uber/jaeger/cmd/flags/influxdb"
 	"github.com/uber/jaeger/cmd/query/app/builder"
 	"github.com/uber/jaeger/pkg/recoveryhandler"
@@ -43,6 +44,9 @@ func main() {
 	casOptions := casFlags.NewOptions()
 	casOptions.Bind(flag.CommandLine, "cassandra", "cassandra.archive")
 
+	esOptions := esFlags.NewOptions()
+	esOptions.Bind(flag.CommandLine, "es")
+
 	influxOptions := infFlags.NewOptions()
 	influxOptions.Bind(flag.CommandLine, "influx")
 
@@ -56,6 +60,7 @@ func main() {
 		basicB.Options.MetricsFactoryOption(metricsFactory),
 		basicB.Options.CassandraOption(casOptions.GetPrimary()),
 		basicB.Options.InfluxDBOption(influxOptions.GetPrimary()),
+		basicB.Options.ElasticSearchOption(esOptions.GetPrimary()),
 	)
 	if err != nil {
 		logger.Fatal("Failed to init storage builder", zap.Error(err))
@@ -72,7 +77,8 @@ func main() {
 		spanReader,
 		dependencyReader,
 		app.HandlerOptions.Prefix(*builder.QueryPrefix),
-		app.HandlerOptions.Logger(logger))
+		app.HandlerOptions.Logger(logger),
+		app.HandlerOptions.TraceFetchWorkers(*builder.QueryTraceFetchWorkers))
 	sHandler := app.NewStaticAssetsHandler(*builder.QueryStaticAssets)
 	r := mux.NewRouter()
 	rHandler.RegisterRoutes(r)
@@ -23,12 +23,15 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
@@ -44,16 +47,21 @@ import (
 	agentApp "github.com/uber/jaeger/cmd/agent/app"
 	basic "github.com/uber/jaeger/cmd/builder"
 	"github.com/uber/jaeger/cmd/collector/app"
+	"github.com/uber/jaeger/cmd/collector/app/baggage"
 	collector "github.com/uber/jaeger/cmd/collector/app/builder"
+	"github.com/uber/jaeger/cmd/collector/app/sampling"
 	collectorZipkin "github.com/uber/jaeger/cmd/collector/app/zipkin"
 	infFlags "github.com/uber/jaeger/cmd/flags/influxdb"
+	memFlags "github.com/uber/jaeger/cmd/flags/memory"
 	queryApp "github.com/uber/jaeger/cmd/query/app"
 	query "github.com/uber/jaeger/cmd/query/app/builder"
 	influx "github.com/uber/jaeger/pkg/influxdb/config"
 	pMetrics "github.com/uber/jaeger/pkg/metrics"
 	"github.com/uber/jaeger/pkg/recoveryhandler"
 	"github.com/uber/jaeger/storage/spanstore/memory"
+	bc "github.com/uber/jaeger/thrift-gen/baggage"
 	jc "github.com/uber/jaeger/thrift-gen/jaeger"
+	sc "github.com/uber/jaeger/thrift-gen/sampling"
 	zc "github.com/uber/jaeger/thrift-gen/zipkincore"
 )
 
@@ -61,8 +69,9 @@ import (
 func main() {
 	logger, _ := zap.NewProduction()
 	metricsFactory := xkit.Wrap("jaeger-standalone", expvar.NewFactory(10))
-	memStore := memory.NewStore()
 
+	memoryOptions := memFlags.NewOptions()
+	memoryOptions.Bind(flag.CommandLine, "memory")
 	influxOptions := infFlags.NewOptions()
 	influxOptions.Bind(flag.CommandLine, "influx")
 
@@ -70,12 +79,31 @@ func main() {
 
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	memStore := memory.NewBoundedStore(*memoryOptions.GetPrimary(), metricsFactory)
+	var snapshotter *memory.Snapshotter
+	if *query.MemorySnapshotFile != "" {
+		if err := memStore.LoadSnapshot(*query.MemorySnapshotFile); err != nil {
+			logger.Fatal("Failed to restore the memory store", zap.String("path", *query.MemorySnapshotFile), zap.Error(err))
+		}
+		snapshotter = memory.NewSnapshotter(memStore, *query.MemorySnapshotFile, *query.MemorySnapshotInterval, logger)
+	}
+
 	influxConf := influxOptions.GetPrimary()
 	startAgent(logger, metricsFactory)
-	startCollector(logger, metricsFactory, memStore, influxConf)
-	startQuery(logger, metricsFactory, memStore, influxConf)
+	spanHandlersCloser := startCollector(logger, metricsFactory, memStore, influxConf)
+	go startQuery(logger, metricsFactory, memStore, influxConf)
 
-	select {}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	if err := spanHandlersCloser.Close(); err != nil {
+		logger.Error("Failed to close the span writer", zap.Error(err))
+	}
+	if snapshotter != nil {
+		if err := snapshotter.Close(); err != nil {
+			logger.Error("Failed to save the memory store", zap.String("path", *query.MemorySnapshotFile), zap.Error(err))
+		}
+	}
 }
 
 func startAgent(logger *zap.Logger, baseFactory metrics.Factory) {
@@ -107,7 +135,12 @@ func startAgent(logger *zap.Logger, baseFactory metrics.Factory) {
 	}
 }
 
-func startCollector(logger *zap.Logger, baseFactory metrics.Factory, memoryStore *memory.Store, c *influx.Configuration) {
+func startCollector(
+	logger *zap.Logger,
+	baseFactory metrics.Factory,
+	memoryStore *memory.Store,
+	c *influx.Configuration,
+) io.Closer {
 	metricsFactory := baseFactory.Namespace("jaeger-collector", nil)
 
 	spanBuilder, err := collector.NewSpanHandlerBuilder(
@@ -119,7 +152,18 @@ func startCollector(logger *zap.Logger, baseFactory metrics.Factory, memoryStore
 	if err != nil {
 		logger.Fatal("Unable to set up builder", zap.Error(err))
 	}
-	zipkinSpansHandler, jaegerBatchesHandler, err := spanBuilder.BuildHandlers()
+	strategyStore, spanProcessorOpts, err := collector.NewStrategyStore(
+		basic.Options.LoggerOption(logger),
+		basic.Options.MetricsFactoryOption(metricsFactory),
+	)
+	if err != nil {
+		logger.Fatal("Unable to create the sampling strategy store", zap.Error(err))
+	}
+	restrictionStore, err := collector.NewRestrictionStore(basic.Options.LoggerOption(logger))
+	if err != nil {
+		logger.Fatal("Unable to create the baggage restriction store", zap.Error(err))
+	}
+	zipkinSpansHandler, jaegerBatchesHandler, spanHandlersCloser, err := spanBuilder.BuildHandlers(spanProcessorOpts...)
 	if err != nil {
 		logger.Fatal("Unable to build span handlers", zap.Error(err))
 	}
@@ -131,6 +175,10 @@ func startCollector(logger *zap.Logger, baseFactory metrics.Factory, memoryStore
 	server := thrift.NewServer(ch)
 	server.Register(jc.NewTChanCollectorServer(jaegerBatchesHandler))
 	server.Register(zc.NewTChanZipkinCollectorServer(zipkinSpansHandler))
+	server.Register(sc.NewTChanSamplingManagerServer(sampling.NewHandler(strategyStore)))
+	if restrictionStore != nil {
+		server.Register(bc.NewTChanBaggageRestrictionManagerServer(baggage.NewHandler(restrictionStore)))
+	}
 	portStr := ":" + strconv.Itoa(*collector.CollectorPort)
 	listener, err := net.Listen("tcp", portStr)
 	if err != nil {
@@ -140,6 +188,7 @@ func startCollector(logger *zap.Logger, baseFactory metrics.Factory, memoryStore
 	logger.Info("Starting jaeger-collector TChannel server", zap.Int("port", *collector.CollectorPort))
 
 	r := mux.NewRouter()
+	app.NewAPIHandler(jaegerBatchesHandler, zipkinSpansHandler).RegisterRoutes(r)
 	httpPortStr := ":" + strconv.Itoa(*collector.CollectorHTTPPort)
 	recoveryHandler := recoveryhandler.NewRecoveryHandler(logger, true)
 
@@ -151,6 +200,7 @@ func startCollector(logger *zap.Logger, baseFactory metrics.Factory, memoryStore
 			logger.Fatal("Could not launch jaeger-collector HTTP server", zap.Error(err))
 		}
 	}()
+	return spanHandlersCloser
 }
 
 func startZipkinHTTPAPI(logger *zap.Logger, zipkinSpansHandler app.ZipkinSpansHandler, recoveryHandler func(http.Handler) http.Handler) {
@@ -202,6 +252,7 @@ func startQuery(logger *zap.Logger, baseFactory metrics.Factory, memoryStore *me
 		dependencyReader,
 		queryApp.HandlerOptions.Prefix(*query.QueryPrefix),
 		queryApp.HandlerOptions.Logger(logger),
+		queryApp.HandlerOptions.TraceFetchWorkers(*query.QueryTraceFetchWorkers),
 		queryApp.HandlerOptions.Tracer(tracer))
 	sHandler := queryApp.NewStaticAssetsHandler(*query.QueryStaticAssets)
 	r := mux.NewRouter()
@@ -47,6 +47,12 @@ func FromDomainSpan(span *model.Span) *jaeger.Span {
 	return dToJ.transformSpan(span)
 }
 
+// FromDomainProcess takes a model.Process and converts it into a jaeger.Process.
+func FromDomainProcess(process *model.Process) *jaeger.Process {
+	dToJ := &domainToJaegerTransformer{}
+	return dToJ.transformProcess(process)
+}
+
 type domainToJaegerTransformer struct{}
 
 func (d domainToJaegerTransformer) keyValueToTag(kv *model.KeyValue) *jaeger.Tag {
@@ -160,3 +166,13 @@ func (d domainToJaegerTransformer) transformSpan(span *model.Span) *jaeger.Span
 	}
 	return jaegerSpan
 }
+
+func (d domainToJaegerTransformer) transformProcess(process *model.Process) *jaeger.Process {
+	if process == nil {
+		return nil
+	}
+	return &jaeger.Process{
+		ServiceName: process.ServiceName,
+		Tags:        d.convertKeyValuesToTags(process.Tags),
+	}
+}
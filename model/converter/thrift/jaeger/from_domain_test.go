@@ -80,6 +80,15 @@ func TestFromDomain(t *testing.T) {
 	assert.Equal(t, modelSpans, newModelSpans)
 }
 
+func TestFromDomainProcess(t *testing.T) {
+	batchFile := "fixtures/thrift_batch_01.json"
+	jaegerBatch := loadBatch(t, batchFile)
+
+	modelSpan := ToDomainSpan(jaegerBatch.Spans[0], jaegerBatch.Process)
+	assert.Equal(t, jaegerBatch.Process, FromDomainProcess(modelSpan.Process))
+	assert.Nil(t, FromDomainProcess(nil))
+}
+
 func TestKeyValueToTag(t *testing.T) {
 	dToJ := domainToJaegerTransformer{}
 	jaegerTag := dToJ.keyValueToTag(&model.KeyValue{
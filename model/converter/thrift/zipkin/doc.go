@@ -18,5 +18,6 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
 
-// Package zipkin allows converting model.Trace to/from zipkin.thrift model.
+// Package zipkin allows converting model.Trace to/from zipkin.thrift model,
+// and encoding zipkin.thrift spans to/from Zipkin v1 JSON.
 package zipkin
@@ -0,0 +1,262 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zipkin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+
+	"github.com/opentracing/opentracing-go/ext"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/thrift-gen/zipkincore"
+)
+
+var (
+	falseByteSlice = []byte{0}
+
+	// spanKindAnnotations are the core annotations recorded at the start and at the end of a span of the given kind
+	spanKindAnnotations = map[string][2]string{
+		string(ext.SpanKindRPCClientEnum): {zipkincore.CLIENT_SEND, zipkincore.CLIENT_RECV},
+		string(ext.SpanKindRPCServerEnum): {zipkincore.SERVER_RECV, zipkincore.SERVER_SEND},
+	}
+
+	// spanKindPeerAddresses are the binary annotations describing the remote peer of a span of the given kind
+	spanKindPeerAddresses = map[string]string{
+		string(ext.SpanKindRPCClientEnum): zipkincore.SERVER_ADDR,
+		string(ext.SpanKindRPCServerEnum): zipkincore.CLIENT_ADDR,
+	}
+
+	// fromProcessTagAnnotations is the reverse of processTagAnnotations
+	fromProcessTagAnnotations = map[string]string{
+		"jaeger.version": "jaeger.version",
+		"hostname":       "jaeger.hostname",
+	}
+)
+
+// FromDomain transforms a model.Trace into a trace in zipkin.thrift format.
+// Zipkin has no notion of a process, so only the service name, the IP address, the hostname
+// and the client version of the process are kept, and the high 64 bits of trace IDs are lost.
+func FromDomain(trace *model.Trace) []*zipkincore.Span {
+	zSpans := make([]*zipkincore.Span, len(trace.Spans))
+	for i, span := range trace.Spans {
+		zSpans[i] = FromDomainSpan(span)
+	}
+	return zSpans
+}
+
+// FromDomainSpan transforms a model.Span into a span in zipkin.thrift format.
+func FromDomainSpan(span *model.Span) *zipkincore.Span {
+	return fromDomain{}.transformSpan(span)
+}
+
+type fromDomain struct{}
+
+func (fd fromDomain) transformSpan(span *model.Span) *zipkincore.Span {
+	endpoint := fd.getEndpoint(span.Process)
+	timestamp := int64(model.TimeAsEpochMicroseconds(span.StartTime))
+	duration := int64(model.DurationAsMicroseconds(span.Duration))
+	zSpan := &zipkincore.Span{
+		TraceID:   int64(span.TraceID.Low),
+		ID:        int64(span.SpanID),
+		Name:      span.OperationName,
+		Debug:     span.Flags.IsDebug(),
+		Timestamp: &timestamp,
+		Duration:  &duration,
+	}
+	if span.ParentSpanID != 0 {
+		parentID := int64(span.ParentSpanID)
+		zSpan.ParentID = &parentID
+	}
+
+	var spanKind string
+	tags := make([]model.KeyValue, 0, len(span.Tags))
+	for _, tag := range span.Tags {
+		if tag.Key == string(ext.SpanKind) {
+			if _, ok := spanKindAnnotations[tag.VStr]; ok {
+				spanKind = tag.VStr
+				continue
+			}
+		}
+		tags = append(tags, tag)
+	}
+	if annotations, ok := spanKindAnnotations[spanKind]; ok {
+		zSpan.Annotations = append(zSpan.Annotations,
+			&zipkincore.Annotation{Timestamp: timestamp, Value: annotations[0], Host: endpoint},
+			&zipkincore.Annotation{Timestamp: timestamp + duration, Value: annotations[1], Host: endpoint},
+		)
+		tags = fd.addPeerAddress(zSpan, spanKindPeerAddresses[spanKind], tags)
+	}
+	zSpan.Annotations = append(zSpan.Annotations, fd.getAnnotations(span.Logs, endpoint)...)
+
+	hasLocalComponent := false
+	for i := range tags {
+		if tags[i].Key == string(ext.Component) {
+			hasLocalComponent = true
+			zSpan.BinaryAnnotations = append(zSpan.BinaryAnnotations, &zipkincore.BinaryAnnotation{
+				Key:            zipkincore.LOCAL_COMPONENT,
+				Value:          []byte(tags[i].AsString()),
+				AnnotationType: zipkincore.AnnotationType_STRING,
+				Host:           endpoint,
+			})
+			continue
+		}
+		zSpan.BinaryAnnotations = append(zSpan.BinaryAnnotations, fd.getBinaryAnnotation(&tags[i], endpoint))
+	}
+	if spanKind == "" && !hasLocalComponent {
+		// the service name of a local span is read from the host of the local component annotation
+		zSpan.BinaryAnnotations = append(zSpan.BinaryAnnotations, &zipkincore.BinaryAnnotation{
+			Key:            zipkincore.LOCAL_COMPONENT,
+			Value:          []byte{},
+			AnnotationType: zipkincore.AnnotationType_STRING,
+			Host:           endpoint,
+		})
+	}
+	if span.Process != nil {
+		for i := range span.Process.Tags {
+			tag := span.Process.Tags[i]
+			if key, ok := fromProcessTagAnnotations[tag.Key]; ok {
+				tag.Key = key
+				zSpan.BinaryAnnotations = append(zSpan.BinaryAnnotations, fd.getBinaryAnnotation(&tag, endpoint))
+			}
+		}
+	}
+	return zSpan
+}
+
+func (fd fromDomain) getEndpoint(process *model.Process) *zipkincore.Endpoint {
+	if process == nil {
+		return &zipkincore.Endpoint{ServiceName: UnknownServiceName}
+	}
+	endpoint := &zipkincore.Endpoint{ServiceName: process.ServiceName}
+	if ip, ok := process.Tags.FindByKey(IPTagName); ok {
+		endpoint.Ipv4 = fd.getIPv4(&ip)
+	}
+	return endpoint
+}
+
+func (fd fromDomain) getIPv4(ip *model.KeyValue) int32 {
+	switch ip.VType {
+	case model.Int64Type:
+		return int32(ip.Int64())
+	case model.StringType:
+		if ipv4 := net.ParseIP(ip.VStr).To4(); ipv4 != nil {
+			return int32(binary.BigEndian.Uint32(ipv4))
+		}
+	}
+	return 0
+}
+
+// addPeerAddress replaces the peer tags with a binary annotation of the address of the remote peer
+func (fd fromDomain) addPeerAddress(zSpan *zipkincore.Span, key string, tags []model.KeyValue) []model.KeyValue {
+	var peer *zipkincore.Endpoint
+	remaining := tags[:0]
+	for _, tag := range tags {
+		switch tag.Key {
+		case string(ext.PeerService), string(ext.PeerHostIPv4), string(ext.PeerPort):
+			if peer == nil {
+				peer = &zipkincore.Endpoint{}
+			}
+		default:
+			remaining = append(remaining, tag)
+			continue
+		}
+		switch tag.Key {
+		case string(ext.PeerService):
+			peer.ServiceName = tag.AsString()
+		case string(ext.PeerHostIPv4):
+			peer.Ipv4 = fd.getIPv4(&tag)
+		case string(ext.PeerPort):
+			peer.Port = int16(tag.Int64())
+		}
+	}
+	if peer != nil {
+		zSpan.BinaryAnnotations = append(zSpan.BinaryAnnotations, &zipkincore.BinaryAnnotation{
+			Key:            key,
+			Value:          trueByteSlice,
+			AnnotationType: zipkincore.AnnotationType_BOOL,
+			Host:           peer,
+		})
+	}
+	return remaining
+}
+
+func (fd fromDomain) getAnnotations(logs []model.Log, endpoint *zipkincore.Endpoint) []*zipkincore.Annotation {
+	var annotations []*zipkincore.Annotation
+	for _, log := range logs {
+		annotations = append(annotations, &zipkincore.Annotation{
+			Timestamp: int64(model.TimeAsEpochMicroseconds(log.Timestamp)),
+			Value:     fd.getAnnotationValue(log.Fields),
+			Host:      endpoint,
+		})
+	}
+	return annotations
+}
+
+// getAnnotationValue encodes log fields into Annotation.Value the way getLogFields decodes them
+func (fd fromDomain) getAnnotationValue(fields []model.KeyValue) string {
+	if len(fields) == 1 && fields[0].Key == DefaultLogFieldKey {
+		return fields[0].AsString()
+	}
+	values := make(map[string]string, len(fields))
+	for i := range fields {
+		values[fields[i].Key] = fields[i].AsString()
+	}
+	value, _ := json.Marshal(values)
+	return string(value)
+}
+
+func (fd fromDomain) getBinaryAnnotation(tag *model.KeyValue, endpoint *zipkincore.Endpoint) *zipkincore.BinaryAnnotation {
+	binaryAnnotation := &zipkincore.BinaryAnnotation{
+		Key:  tag.Key,
+		Host: endpoint,
+	}
+	switch tag.VType {
+	case model.BoolType:
+		binaryAnnotation.AnnotationType = zipkincore.AnnotationType_BOOL
+		if tag.Bool() {
+			binaryAnnotation.Value = trueByteSlice
+		} else {
+			binaryAnnotation.Value = falseByteSlice
+		}
+	case model.Int64Type:
+		binaryAnnotation.AnnotationType = zipkincore.AnnotationType_I64
+		binaryAnnotation.Value = numberToBytes(tag.Int64())
+	case model.Float64Type:
+		binaryAnnotation.AnnotationType = zipkincore.AnnotationType_DOUBLE
+		binaryAnnotation.Value = numberToBytes(tag.Float64())
+	case model.BinaryType:
+		binaryAnnotation.AnnotationType = zipkincore.AnnotationType_BYTES
+		binaryAnnotation.Value = tag.Binary()
+	default:
+		binaryAnnotation.AnnotationType = zipkincore.AnnotationType_STRING
+		binaryAnnotation.Value = []byte(tag.AsString())
+	}
+	return binaryAnnotation
+}
+
+func numberToBytes(number interface{}) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, number)
+	return buf.Bytes()
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zipkin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+	z "github.com/uber/jaeger/thrift-gen/zipkincore"
+)
+
+func testTrace() *model.Trace {
+	startTime := time.Unix(1485467191, 639875000)
+	clientProcess := model.NewProcess("service-x", []model.KeyValue{
+		model.String("hostname", "host-x"),
+		model.Int64("ip", 0x7f000001),
+		model.String("jaeger.version", "Go-2.9.0"),
+	})
+	serverProcess := model.NewProcess("service-y", nil)
+	return &model.Trace{Spans: []*model.Span{
+		{
+			TraceID:       model.TraceID{Low: 1},
+			SpanID:        model.SpanID(2),
+			OperationName: "call-y",
+			Flags:         model.Flags(2),
+			StartTime:     startTime,
+			Duration:      100 * time.Millisecond,
+			Tags: []model.KeyValue{
+				model.String(string(ext.SpanKind), string(ext.SpanKindRPCClientEnum)),
+				model.String(string(ext.Component), "http"),
+				model.String(string(ext.PeerService), "service-y"),
+				model.Int64(string(ext.PeerHostIPv4), 0x0a000001),
+				model.Int64(string(ext.PeerPort), 8080),
+				model.Bool("error", true),
+				model.Bool("retry", false),
+				model.Int64("http.status_code", 500),
+				model.Float64("load", 0.5),
+				model.Binary("payload", []byte{1, 2, 3}),
+			},
+			Logs: []model.Log{
+				{Timestamp: startTime.Add(time.Millisecond), Fields: []model.KeyValue{model.String("event", "retrying")}},
+				{Timestamp: startTime.Add(2 * time.Millisecond), Fields: []model.KeyValue{
+					model.String("event", "error"),
+					model.String("message", "timeout"),
+				}},
+			},
+			Process: clientProcess,
+		},
+		{
+			TraceID:       model.TraceID{Low: 1},
+			SpanID:        model.SpanID(3),
+			ParentSpanID:  model.SpanID(2),
+			OperationName: "serve",
+			StartTime:     startTime.Add(10 * time.Millisecond),
+			Duration:      50 * time.Millisecond,
+			Tags: []model.KeyValue{
+				model.String(string(ext.SpanKind), string(ext.SpanKindRPCServerEnum)),
+			},
+			Process: serverProcess,
+		},
+		{
+			TraceID:       model.TraceID{Low: 1},
+			SpanID:        model.SpanID(4),
+			ParentSpanID:  model.SpanID(3),
+			OperationName: "compute",
+			StartTime:     startTime.Add(20 * time.Millisecond),
+			Duration:      10 * time.Millisecond,
+			Tags: []model.KeyValue{
+				model.String(string(ext.Component), "worker"),
+				model.String(string(ext.SpanKind), "producer"),
+			},
+			Process: serverProcess,
+		},
+	}}
+}
+
+func TestFromDomainRoundTrip(t *testing.T) {
+	expected := testTrace()
+	trace, err := ToDomain(FromDomain(expected))
+	require.NoError(t, err)
+	model.SortTrace(expected)
+	model.SortTrace(trace)
+	assert.Equal(t, expected, trace)
+}
+
+func TestFromDomainSpan(t *testing.T) {
+	span := testTrace().Spans[0]
+	zSpan := FromDomainSpan(span)
+	assert.EqualValues(t, 1, zSpan.TraceID)
+	assert.EqualValues(t, 2, zSpan.ID)
+	assert.Nil(t, zSpan.ParentID)
+	assert.True(t, zSpan.Debug)
+	assert.EqualValues(t, 1485467191639875, *zSpan.Timestamp)
+	assert.EqualValues(t, 100000, *zSpan.Duration)
+
+	host := &z.Endpoint{ServiceName: "service-x", Ipv4: 0x7f000001}
+	require.Len(t, zSpan.Annotations, 4)
+	assert.Equal(t, &z.Annotation{Timestamp: 1485467191639875, Value: z.CLIENT_SEND, Host: host}, zSpan.Annotations[0])
+	assert.Equal(t, &z.Annotation{Timestamp: 1485467191739875, Value: z.CLIENT_RECV, Host: host}, zSpan.Annotations[1])
+	assert.Equal(t, "retrying", zSpan.Annotations[2].Value)
+	assert.Equal(t, `{"event":"error","message":"timeout"}`, zSpan.Annotations[3].Value)
+
+	assert.Equal(t, &z.BinaryAnnotation{
+		Key:            z.SERVER_ADDR,
+		Value:          trueByteSlice,
+		AnnotationType: z.AnnotationType_BOOL,
+		Host:           &z.Endpoint{ServiceName: "service-y", Ipv4: 0x0a000001, Port: 8080},
+	}, zSpan.BinaryAnnotations[0])
+	assert.Equal(t, &z.BinaryAnnotation{
+		Key:            z.LOCAL_COMPONENT,
+		Value:          []byte("http"),
+		AnnotationType: z.AnnotationType_STRING,
+		Host:           host,
+	}, zSpan.BinaryAnnotations[1])
+}
+
+func TestFromDomainLocalSpan(t *testing.T) {
+	span := &model.Span{
+		TraceID:       model.TraceID{Low: 1},
+		SpanID:        model.SpanID(2),
+		OperationName: "local",
+		Process:       model.NewProcess("service-x", []model.KeyValue{model.String("ip", "10.0.0.1")}),
+	}
+	zSpan := FromDomainSpan(span)
+	assert.Empty(t, zSpan.Annotations)
+	require.Len(t, zSpan.BinaryAnnotations, 1)
+	assert.Equal(t, z.LOCAL_COMPONENT, zSpan.BinaryAnnotations[0].Key)
+	assert.Equal(t, &z.Endpoint{ServiceName: "service-x", Ipv4: 0x0a000001}, zSpan.BinaryAnnotations[0].Host)
+
+	jSpan, err := ToDomainSpan(zSpan)
+	require.NoError(t, err)
+	assert.Equal(t, "service-x", jSpan.Process.ServiceName)
+}
+
+func TestFromDomainNoProcess(t *testing.T) {
+	zSpan := FromDomainSpan(&model.Span{})
+	require.Len(t, zSpan.BinaryAnnotations, 1)
+	assert.Equal(t, UnknownServiceName, zSpan.BinaryAnnotations[0].Host.ServiceName)
+}
@@ -0,0 +1,317 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zipkin
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/uber/jaeger/thrift-gen/zipkincore"
+)
+
+// jsonSpan is a span in Zipkin v1 JSON format, as accepted by POST /api/v1/spans of Zipkin
+type jsonSpan struct {
+	TraceID           string                 `json:"traceId"`
+	Name              string                 `json:"name"`
+	ID                string                 `json:"id"`
+	ParentID          string                 `json:"parentId,omitempty"`
+	Timestamp         *int64                 `json:"timestamp,omitempty"`
+	Duration          *int64                 `json:"duration,omitempty"`
+	Debug             bool                   `json:"debug,omitempty"`
+	Annotations       []jsonAnnotation       `json:"annotations"`
+	BinaryAnnotations []jsonBinaryAnnotation `json:"binaryAnnotations"`
+}
+
+type jsonEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4,omitempty"`
+	Port        uint16 `json:"port,omitempty"`
+}
+
+type jsonAnnotation struct {
+	Timestamp int64         `json:"timestamp"`
+	Value     string        `json:"value"`
+	Endpoint  *jsonEndpoint `json:"endpoint,omitempty"`
+}
+
+type jsonBinaryAnnotation struct {
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Type     string          `json:"type,omitempty"`
+	Endpoint *jsonEndpoint   `json:"endpoint,omitempty"`
+}
+
+// SerializeJSON encodes spans in zipkin.thrift format as Zipkin v1 JSON
+func SerializeJSON(zSpans []*zipkincore.Span) ([]byte, error) {
+	jSpans := make([]jsonSpan, len(zSpans))
+	for i, zSpan := range zSpans {
+		jSpan, err := spanToJSON(zSpan)
+		if err != nil {
+			return nil, err
+		}
+		jSpans[i] = jSpan
+	}
+	return json.Marshal(jSpans)
+}
+
+// DeserializeJSON decodes spans in Zipkin v1 JSON format into zipkin.thrift format
+func DeserializeJSON(b []byte) ([]*zipkincore.Span, error) {
+	var jSpans []jsonSpan
+	if err := json.Unmarshal(b, &jSpans); err != nil {
+		return nil, err
+	}
+	zSpans := make([]*zipkincore.Span, len(jSpans))
+	for i := range jSpans {
+		zSpan, err := spanFromJSON(&jSpans[i])
+		if err != nil {
+			return nil, err
+		}
+		zSpans[i] = zSpan
+	}
+	return zSpans, nil
+}
+
+func spanToJSON(zSpan *zipkincore.Span) (jsonSpan, error) {
+	jSpan := jsonSpan{
+		TraceID:           idToHex(zSpan.TraceID),
+		Name:              zSpan.Name,
+		ID:                idToHex(zSpan.ID),
+		Timestamp:         zSpan.Timestamp,
+		Duration:          zSpan.Duration,
+		Debug:             zSpan.Debug,
+		Annotations:       make([]jsonAnnotation, len(zSpan.Annotations)),
+		BinaryAnnotations: make([]jsonBinaryAnnotation, len(zSpan.BinaryAnnotations)),
+	}
+	if zSpan.ParentID != nil {
+		jSpan.ParentID = idToHex(*zSpan.ParentID)
+	}
+	for i, a := range zSpan.Annotations {
+		jSpan.Annotations[i] = jsonAnnotation{
+			Timestamp: a.Timestamp,
+			Value:     a.Value,
+			Endpoint:  endpointToJSON(a.Host),
+		}
+	}
+	for i, a := range zSpan.BinaryAnnotations {
+		value, err := binaryAnnotationValueToJSON(a)
+		if err != nil {
+			return jSpan, err
+		}
+		jSpan.BinaryAnnotations[i] = jsonBinaryAnnotation{
+			Key:      a.Key,
+			Value:    value,
+			Endpoint: endpointToJSON(a.Host),
+		}
+		if a.AnnotationType != zipkincore.AnnotationType_STRING {
+			jSpan.BinaryAnnotations[i].Type = a.AnnotationType.String()
+		}
+	}
+	return jSpan, nil
+}
+
+func spanFromJSON(jSpan *jsonSpan) (*zipkincore.Span, error) {
+	traceID, err := idFromHex(jSpan.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := idFromHex(jSpan.ID)
+	if err != nil {
+		return nil, err
+	}
+	zSpan := &zipkincore.Span{
+		TraceID:   traceID,
+		ID:        id,
+		Name:      jSpan.Name,
+		Timestamp: jSpan.Timestamp,
+		Duration:  jSpan.Duration,
+		Debug:     jSpan.Debug,
+	}
+	if jSpan.ParentID != "" {
+		parentID, err := idFromHex(jSpan.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		zSpan.ParentID = &parentID
+	}
+	for _, a := range jSpan.Annotations {
+		host, err := endpointFromJSON(a.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		zSpan.Annotations = append(zSpan.Annotations, &zipkincore.Annotation{
+			Timestamp: a.Timestamp,
+			Value:     a.Value,
+			Host:      host,
+		})
+	}
+	for i := range jSpan.BinaryAnnotations {
+		binaryAnnotation, err := binaryAnnotationFromJSON(&jSpan.BinaryAnnotations[i])
+		if err != nil {
+			return nil, err
+		}
+		zSpan.BinaryAnnotations = append(zSpan.BinaryAnnotations, binaryAnnotation)
+	}
+	return zSpan, nil
+}
+
+// idToHex encodes IDs as 16 lower-hex characters as Zipkin does
+func idToHex(id int64) string {
+	return fmt.Sprintf("%016x", uint64(id))
+}
+
+// idFromHex decodes IDs of up to 32 lower-hex characters, only the low 64 bits of 128 bit trace IDs are kept
+func idFromHex(id string) (int64, error) {
+	if len(id) > 32 {
+		return 0, fmt.Errorf("Zipkin ID %q is longer than 32 characters", id)
+	}
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	value, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot parse Zipkin ID %q: %v", id, err)
+	}
+	return int64(value), nil
+}
+
+func endpointToJSON(endpoint *zipkincore.Endpoint) *jsonEndpoint {
+	if endpoint == nil {
+		return nil
+	}
+	jEndpoint := &jsonEndpoint{
+		ServiceName: endpoint.ServiceName,
+		Port:        uint16(endpoint.Port),
+	}
+	if endpoint.Ipv4 != 0 {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, uint32(endpoint.Ipv4))
+		jEndpoint.IPv4 = ip.String()
+	}
+	return jEndpoint
+}
+
+func endpointFromJSON(jEndpoint *jsonEndpoint) (*zipkincore.Endpoint, error) {
+	if jEndpoint == nil {
+		return nil, nil
+	}
+	endpoint := &zipkincore.Endpoint{
+		ServiceName: jEndpoint.ServiceName,
+		Port:        int16(jEndpoint.Port),
+	}
+	if jEndpoint.IPv4 != "" {
+		ip := net.ParseIP(jEndpoint.IPv4).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("Cannot parse Zipkin IPv4 address %q", jEndpoint.IPv4)
+		}
+		endpoint.Ipv4 = int32(binary.BigEndian.Uint32(ip))
+	}
+	return endpoint, nil
+}
+
+func binaryAnnotationValueToJSON(a *zipkincore.BinaryAnnotation) (json.RawMessage, error) {
+	switch a.AnnotationType {
+	case zipkincore.AnnotationType_STRING:
+		return json.Marshal(string(a.Value))
+	case zipkincore.AnnotationType_BYTES:
+		return json.Marshal(base64.StdEncoding.EncodeToString(a.Value))
+	}
+	tag, err := toDomain{}.transformBinaryAnnotation(a)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tag.Value())
+}
+
+func binaryAnnotationFromJSON(jAnnotation *jsonBinaryAnnotation) (*zipkincore.BinaryAnnotation, error) {
+	host, err := endpointFromJSON(jAnnotation.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	binaryAnnotation := &zipkincore.BinaryAnnotation{
+		Key:  jAnnotation.Key,
+		Host: host,
+	}
+	annotationType := jAnnotation.Type
+	if annotationType == "" {
+		// without a type the value is either a string or a boolean
+		var b bool
+		if json.Unmarshal(jAnnotation.Value, &b) == nil {
+			annotationType = zipkincore.AnnotationType_BOOL.String()
+		} else {
+			annotationType = zipkincore.AnnotationType_STRING.String()
+		}
+	}
+	if binaryAnnotation.AnnotationType, err = zipkincore.AnnotationTypeFromString(annotationType); err != nil {
+		return nil, fmt.Errorf("Unknown Zipkin annotation type %q of %s", annotationType, jAnnotation.Key)
+	}
+
+	switch binaryAnnotation.AnnotationType {
+	case zipkincore.AnnotationType_BOOL:
+		var b bool
+		err = json.Unmarshal(jAnnotation.Value, &b)
+		binaryAnnotation.Value = falseByteSlice
+		if b {
+			binaryAnnotation.Value = trueByteSlice
+		}
+	case zipkincore.AnnotationType_STRING:
+		var s string
+		err = json.Unmarshal(jAnnotation.Value, &s)
+		binaryAnnotation.Value = []byte(s)
+	case zipkincore.AnnotationType_BYTES:
+		var s string
+		if err = json.Unmarshal(jAnnotation.Value, &s); err == nil {
+			binaryAnnotation.Value, err = base64.StdEncoding.DecodeString(s)
+		}
+	case zipkincore.AnnotationType_DOUBLE:
+		var f float64
+		err = unmarshalNumber(jAnnotation.Value, &f)
+		binaryAnnotation.Value = numberToBytes(f)
+	case zipkincore.AnnotationType_I16:
+		var i int16
+		err = unmarshalNumber(jAnnotation.Value, &i)
+		binaryAnnotation.Value = numberToBytes(i)
+	case zipkincore.AnnotationType_I32:
+		var i int32
+		err = unmarshalNumber(jAnnotation.Value, &i)
+		binaryAnnotation.Value = numberToBytes(i)
+	case zipkincore.AnnotationType_I64:
+		var i int64
+		err = unmarshalNumber(jAnnotation.Value, &i)
+		binaryAnnotation.Value = numberToBytes(i)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse value of Zipkin annotation %s: %v", jAnnotation.Key, err)
+	}
+	return binaryAnnotation, nil
+}
+
+// unmarshalNumber decodes a JSON number, which Zipkin also accepts quoted to preserve 64 bit integers
+func unmarshalNumber(value json.RawMessage, number interface{}) error {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		value = json.RawMessage(s)
+	}
+	return json.Unmarshal(value, number)
+}
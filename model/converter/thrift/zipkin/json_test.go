@@ -0,0 +1,143 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zipkin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	z "github.com/uber/jaeger/thrift-gen/zipkincore"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	zSpans := FromDomain(testTrace())
+	b, err := SerializeJSON(zSpans)
+	require.NoError(t, err)
+	decoded, err := DeserializeJSON(b)
+	require.NoError(t, err)
+	assert.Equal(t, zSpans, decoded)
+}
+
+func TestSerializeJSON(t *testing.T) {
+	parentID := int64(-1)
+	timestamp := int64(1485467191639875)
+	b, err := SerializeJSON([]*z.Span{{
+		TraceID:   1,
+		ID:        2,
+		ParentID:  &parentID,
+		Name:      "x",
+		Timestamp: &timestamp,
+		Annotations: []*z.Annotation{
+			{Timestamp: timestamp, Value: z.CLIENT_SEND, Host: &z.Endpoint{ServiceName: "a", Ipv4: 0x7f000001, Port: -1}},
+		},
+		BinaryAnnotations: []*z.BinaryAnnotation{
+			{Key: "s", Value: []byte("v"), AnnotationType: z.AnnotationType_STRING},
+			{Key: "i", Value: []byte{0, 0, 0, 42}, AnnotationType: z.AnnotationType_I32},
+			{Key: "b", Value: []byte{1}, AnnotationType: z.AnnotationType_BYTES},
+		},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"traceId":"0000000000000001","name":"x","id":"0000000000000002","parentId":"ffffffffffffffff",`+
+		`"timestamp":1485467191639875,"annotations":[{"timestamp":1485467191639875,"value":"cs",`+
+		`"endpoint":{"serviceName":"a","ipv4":"127.0.0.1","port":65535}}],"binaryAnnotations":[`+
+		`{"key":"s","value":"v"},{"key":"i","value":42,"type":"I32"},{"key":"b","value":"AQ==","type":"BYTES"}]}]`,
+		string(b))
+}
+
+func TestSerializeJSONError(t *testing.T) {
+	_, err := SerializeJSON([]*z.Span{{
+		BinaryAnnotations: []*z.BinaryAnnotation{{Key: "i", Value: []byte{1}, AnnotationType: z.AnnotationType_I64}},
+	}})
+	assert.EqualError(t, err, "unexpected EOF")
+}
+
+func TestDeserializeJSON(t *testing.T) {
+	zSpans, err := DeserializeJSON([]byte(`[{
+		"traceId": "463ac35c9f6413ad48485a3953bb6124",
+		"id": "48485a3953bb6124",
+		"name": "get",
+		"binaryAnnotations": [
+			{"key": "sa", "value": true, "endpoint": {"serviceName": "db", "ipv4": "10.0.0.1", "port": 5432}},
+			{"key": "http.path", "value": "/api"},
+			{"key": "count", "value": "9007199254740993", "type": "I64"},
+			{"key": "ratio", "value": 0.25, "type": "DOUBLE"},
+			{"key": "small", "value": 7, "type": "I16"}
+		]
+	}]`))
+	require.NoError(t, err)
+	require.Len(t, zSpans, 1)
+	zSpan := zSpans[0]
+	assert.EqualValues(t, 0x48485a3953bb6124, zSpan.TraceID)
+	assert.EqualValues(t, 0x48485a3953bb6124, zSpan.ID)
+	assert.Nil(t, zSpan.ParentID)
+
+	tags := toDomain{}.getTags(zSpan.BinaryAnnotations, toDomain{}.isSpanTag)
+	require.Len(t, tags, 7)
+	assert.Equal(t, "db", tags[0].AsString())
+	assert.EqualValues(t, 0x0a000001, tags[1].Int64())
+	assert.EqualValues(t, 5432, tags[2].Int64())
+	assert.Equal(t, "/api", tags[3].AsString())
+	assert.EqualValues(t, 9007199254740993, tags[4].Int64())
+	assert.EqualValues(t, 0.25, tags[5].Float64())
+	assert.EqualValues(t, 7, tags[6].Int64())
+}
+
+func TestDeserializeJSONErrors(t *testing.T) {
+	testCases := []struct {
+		json string
+		err  string
+	}{
+		{json: `{`, err: "unexpected end of JSON input"},
+		{json: `[{"traceId": "x", "id": "1"}]`, err: `Cannot parse Zipkin ID "x": strconv.ParseUint: parsing "x": invalid syntax`},
+		{json: `[{"traceId": "1", "id": "x"}]`, err: `Cannot parse Zipkin ID "x": strconv.ParseUint: parsing "x": invalid syntax`},
+		{json: `[{"traceId": "1", "id": "1", "parentId": "x"}]`, err: `Cannot parse Zipkin ID "x": strconv.ParseUint: parsing "x": invalid syntax`},
+		{
+			json: `[{"traceId": "463ac35c9f6413ad48485a3953bb61240", "id": "1"}]`,
+			err:  `Zipkin ID "463ac35c9f6413ad48485a3953bb61240" is longer than 32 characters`,
+		},
+		{
+			json: `[{"traceId": "1", "id": "1", "annotations": [{"value": "cs", "endpoint": {"ipv4": "::1"}}]}]`,
+			err:  `Cannot parse Zipkin IPv4 address "::1"`,
+		},
+		{
+			json: `[{"traceId": "1", "id": "1", "binaryAnnotations": [{"key": "sa", "endpoint": {"ipv4": "x"}}]}]`,
+			err:  `Cannot parse Zipkin IPv4 address "x"`,
+		},
+		{
+			json: `[{"traceId": "1", "id": "1", "binaryAnnotations": [{"key": "k", "value": 1, "type": "LONG"}]}]`,
+			err:  `Unknown Zipkin annotation type "LONG" of k`,
+		},
+		{
+			json: `[{"traceId": "1", "id": "1", "binaryAnnotations": [{"key": "k", "value": "x", "type": "I64"}]}]`,
+			err:  `Cannot parse value of Zipkin annotation k: invalid character 'x' looking for beginning of value`,
+		},
+		{
+			json: `[{"traceId": "1", "id": "1", "binaryAnnotations": [{"key": "k", "value": "!", "type": "BYTES"}]}]`,
+			err:  `Cannot parse value of Zipkin annotation k: illegal base64 data at input byte 0`,
+		},
+	}
+	for _, testCase := range testCases {
+		_, err := DeserializeJSON([]byte(testCase.json))
+		assert.EqualError(t, err, testCase.err, testCase.json)
+	}
+}
@@ -41,6 +41,7 @@ type Configuration struct {
 	ProtoVersion       int           `yaml:"proto_version"`
 	Consistency        string        `yaml:"consistency"`
 	Port               int           `yaml:"port"`
+	ReadWorkers        int           `yaml:"read_workers"`
 }
 
 // ApplyDefaults copies settings from source unless its own value is non-zero.
@@ -66,6 +67,9 @@ func (c *Configuration) ApplyDefaults(source *Configuration) {
 	if c.SocketKeepAlive == 0 {
 		c.SocketKeepAlive = source.SocketKeepAlive
 	}
+	if c.ReadWorkers == 0 {
+		c.ReadWorkers = source.ReadWorkers
+	}
 }
 
 // NewSession creates a new Cassandra session
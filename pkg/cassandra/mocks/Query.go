@@ -21,6 +21,7 @@
 package mocks
 
 import cassandra "github.com/uber/jaeger/pkg/cassandra"
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 
 // Query is an autogenerated mock type for the Query type
@@ -76,6 +77,22 @@ func (_m *Query) PageSize(n int) cassandra.Query {
 	return r0
 }
 
+// WithContext provides a mock function with given fields: ctx
+func (_m *Query) WithContext(ctx context.Context) cassandra.Query {
+	ret := _m.Called(ctx)
+
+	var r0 cassandra.Query
+	if rf, ok := ret.Get(0).(func(context.Context) cassandra.Query); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cassandra.Query)
+		}
+	}
+
+	return r0
+}
+
 // Exec provides a mock function with given fields:
 func (_m *Query) Exec() error {
 	ret := _m.Called()
@@ -20,6 +20,8 @@
 
 package cassandra
 
+import "context"
+
 // Consistency is Cassandra's consistency level for queries.
 type Consistency uint16
 
@@ -69,6 +71,16 @@ type Query interface {
 	Bind(v ...interface{}) Query
 	Consistency(level Consistency) Query
 	PageSize(int) Query
+	WithContext(ctx context.Context) Query
+}
+
+// WithContext binds the query to ctx, so that it is canceled when ctx is done or past its deadline.
+// Contexts that can never be canceled, like context.Background(), are not bound.
+func WithContext(ctx context.Context, query Query) Query {
+	if ctx.Done() == nil {
+		return query
+	}
+	return query.WithContext(ctx)
 }
 
 // Iterator is an abstraction of gocql.Iter
@@ -61,5 +61,6 @@ type SearchService interface {
 	Aggregation(name string, aggregation elastic.Aggregation) SearchService
 	IgnoreUnavailable(ignoreUnavailable bool) SearchService
 	Query(query elastic.Query) SearchService
+	Sort(field string, ascending bool) SearchService
 	Do(ctx context.Context) (*elastic.SearchResult, error)
 }
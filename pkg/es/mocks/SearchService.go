@@ -118,6 +118,22 @@ func (_m *SearchService) Size(size int) es.SearchService {
 	return r0
 }
 
+// Sort provides a mock function with given fields: field, ascending
+func (_m *SearchService) Sort(field string, ascending bool) es.SearchService {
+	ret := _m.Called(field, ascending)
+
+	var r0 es.SearchService
+	if rf, ok := ret.Get(0).(func(string, bool) es.SearchService); ok {
+		r0 = rf(field, ascending)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(es.SearchService)
+		}
+	}
+
+	return r0
+}
+
 // Type provides a mock function with given fields: typ
 func (_m *SearchService) Type(typ string) es.SearchService {
 	ret := _m.Called(typ)
@@ -171,6 +171,11 @@ func (s ESSearchService) Query(query elastic.Query) SearchService {
 	return WrapESSearchService(s.searchService.Query(query))
 }
 
+// Sort calls this function to internal service.
+func (s ESSearchService) Sort(field string, ascending bool) SearchService {
+	return WrapESSearchService(s.searchService.Sort(field, ascending))
+}
+
 // Do calls this function to internal service.
 func (s ESSearchService) Do(ctx context.Context) (*elastic.SearchResult, error) {
 	return s.searchService.Do(ctx)
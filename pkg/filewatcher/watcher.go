@@ -0,0 +1,115 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package filewatcher
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Watcher loads a file and, if a reload interval is configured, periodically re-reads it and
+// loads it again when its content changes. A failed reload is logged and the previously loaded
+// content is kept.
+type Watcher struct {
+	path        string
+	description string
+	load        func(content []byte) error
+	logger      *zap.Logger
+
+	// content is the last successfully loaded file content, only accessed by the reload loop
+	content []byte
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+// New reads the file at path and passes its content to load, returning the error of either.
+// The description names the file content in errors and logs, e.g. "sampling strategies".
+func New(
+	path string,
+	description string,
+	reloadInterval time.Duration,
+	load func(content []byte) error,
+	logger *zap.Logger,
+) (*Watcher, error) {
+	w := &Watcher{
+		path:        path,
+		description: description,
+		load:        load,
+		logger:      logger,
+		stop:        make(chan struct{}),
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read the %s file", description)
+	}
+	if err := load(content); err != nil {
+		return nil, err
+	}
+	w.content = content
+	if reloadInterval > 0 {
+		w.done.Add(1)
+		go w.reloadLoop(reloadInterval)
+	}
+	return w, nil
+}
+
+// Close stops reloading the file.
+func (w *Watcher) Close() error {
+	close(w.stop)
+	w.done.Wait()
+	return nil
+}
+
+func (w *Watcher) reloadLoop(reloadInterval time.Duration) {
+	defer w.done.Done()
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.reload()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	content, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		w.logger.Error("Failed to read the "+w.description+" file", zap.String("path", w.path), zap.Error(err))
+		return
+	}
+	if bytes.Equal(content, w.content) {
+		return
+	}
+	if err := w.load(content); err != nil {
+		w.logger.Error("Failed to reload the "+w.description+", keeping the previous ones", zap.String("path", w.path), zap.Error(err))
+		return
+	}
+	w.content = content
+	w.logger.Info("Reloaded the "+w.description, zap.String("path", w.path))
+}
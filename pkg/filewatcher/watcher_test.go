@@ -0,0 +1,127 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package filewatcher
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/pkg/testutils"
+)
+
+// loads records the contents passed to the load function of a watcher
+type loads struct {
+	sync.Mutex
+	contents []string
+}
+
+func (l *loads) load(content []byte) error {
+	if string(content) == "invalid" {
+		return errors.New("invalid content")
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.contents = append(l.contents, string(content))
+	return nil
+}
+
+func (l *loads) loaded() []string {
+	l.Lock()
+	defer l.Unlock()
+	return append([]string(nil), l.contents...)
+}
+
+func withFile(t *testing.T, content string, f func(path string)) {
+	dir, err := ioutil.TempDir("", "filewatcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "file.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	f(path)
+}
+
+func TestNew(t *testing.T) {
+	withFile(t, "first", func(path string) {
+		l := &loads{}
+		w, err := New(path, "test settings", 0, l.load, zap.NewNop())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first"}, l.loaded())
+		assert.NoError(t, w.Close())
+	})
+}
+
+func TestNewErrors(t *testing.T) {
+	l := &loads{}
+	_, err := New("fixtures/missing.json", "test settings", 0, l.load, zap.NewNop())
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "Failed to read the test settings file: "), err.Error())
+
+	withFile(t, "invalid", func(path string) {
+		_, err := New(path, "test settings", time.Millisecond, l.load, zap.NewNop())
+		assert.EqualError(t, err, "invalid content")
+	})
+	assert.Empty(t, l.loaded())
+}
+
+func waitForLogs(logBuffer *testutils.Buffer, message string) bool {
+	for i := 0; i < 100; i++ {
+		if strings.Contains(logBuffer.Stripped(), message) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestReload(t *testing.T) {
+	withFile(t, "first", func(path string) {
+		l := &loads{}
+		logger, logBuffer := testutils.NewLogger()
+		w, err := New(path, "test settings", time.Millisecond, l.load, logger)
+		require.NoError(t, err)
+		defer w.Close()
+
+		// an unchanged file is not loaded again
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, []string{"first"}, l.loaded())
+
+		require.NoError(t, ioutil.WriteFile(path, []byte("invalid"), 0644))
+		assert.True(t, waitForLogs(logBuffer, `"msg":"Failed to reload the test settings, keeping the previous ones"`))
+
+		require.NoError(t, ioutil.WriteFile(path, []byte("second"), 0644))
+		assert.True(t, waitForLogs(logBuffer, `"msg":"Reloaded the test settings"`))
+		assert.Equal(t, []string{"first", "second"}, l.loaded())
+
+		require.NoError(t, os.Remove(path))
+		assert.True(t, waitForLogs(logBuffer, `"msg":"Failed to read the test settings file"`))
+		assert.Equal(t, []string{"first", "second"}, l.loaded())
+	})
+}
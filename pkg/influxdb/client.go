@@ -5,7 +5,9 @@ import (
 	"github.com/uber/jaeger/model"
 )
 
+// Client is an abstraction over the InfluxDB client used by the span storage
 type Client interface {
 	WriteSpans([]*model.Span) error
+	WritePoints([]*influxclient.Point) error
 	QuerySpans(string, string) (*influxclient.Response, error)
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"time"
 
 	influxclient "github.com/influxdata/influxdb/client/v2"
 	"github.com/uber/jaeger/pkg/influxdb"
@@ -14,14 +15,26 @@ const (
 	UDP
 )*/
 
+// Configuration describes the configuration properties needed to connect to an InfluxDB instance
 type Configuration struct {
 	Server         string
 	Username       string
 	Password       string
 	Database       string
 	ConnectionType string
+
+	// RetentionPolicy is the name of the default retention policy created with the database.
+	// If empty, no retention policy is created and the database default is used.
+	RetentionPolicy string
+	// Retention is how long the retention policy keeps data, zero keeps it forever.
+	Retention time.Duration
+	// ShardDuration is the time range covered by a shard group, zero leaves it to InfluxDB.
+	ShardDuration time.Duration
+	// Replication is the number of copies of each point kept in a cluster.
+	Replication int
 }
 
+// NewClient creates a new InfluxDB client based on the connection type
 func (c *Configuration) NewClient() (influxdb.Client, error) {
 
 	// If for some reason this isn't set, use http (it should be set to http default anyway through flags)
@@ -42,7 +55,8 @@ func (c *Configuration) NewClient() (influxdb.Client, error) {
 		}
 
 		return &influxdb.InternalClient{
-			Client: client,
+			Client:   client,
+			Database: c.Database,
 		}, nil
 	case "udp":
 		client, err := influxclient.NewUDPClient(influxclient.UDPConfig{
@@ -53,7 +67,8 @@ func (c *Configuration) NewClient() (influxdb.Client, error) {
 		}
 
 		return &influxdb.InternalClient{
-			Client: client,
+			Client:   client,
+			Database: c.Database,
 		}, nil
 	default:
 		return nil, errors.New("Missing choice of client protocol")
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+
+	"github.com/uber/jaeger/pkg/influxdb"
+	"github.com/uber/jaeger/pkg/influxdb/influxql"
+)
+
+// CreateSchema creates the database and the retention policy when they are missing. Existing ones are
+// left as they are. The retention policy is made the default of the database so that spans are written
+// to it without naming it. The UDP protocol does not support queries, so the schema is not created for
+// UDP connections.
+func (c *Configuration) CreateSchema(client influxdb.Client) error {
+	if c.ConnectionType == "udp" {
+		return nil
+	}
+	exists, err := c.databaseExists(client)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := exec(client, "CREATE DATABASE "+influxql.QuoteIdent(c.Database), ""); err != nil {
+			return err
+		}
+	}
+	if c.RetentionPolicy != "" {
+		if err := c.createRetentionPolicy(client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Configuration) databaseExists(client influxdb.Client) (bool, error) {
+	res, err := query(client, "SHOW DATABASES", "")
+	if err != nil {
+		return false, err
+	}
+	return hasValue(res, c.Database), nil
+}
+
+func (c *Configuration) createRetentionPolicy(client influxdb.Client) error {
+	res, err := query(client, "SHOW RETENTION POLICIES ON "+influxql.QuoteIdent(c.Database), "")
+	if err != nil {
+		return err
+	}
+	if hasValue(res, c.RetentionPolicy) {
+		return nil
+	}
+	statement := fmt.Sprintf(
+		"CREATE RETENTION POLICY %s ON %s DURATION %s REPLICATION %d",
+		influxql.QuoteIdent(c.RetentionPolicy),
+		influxql.QuoteIdent(c.Database),
+		formatDuration(c.Retention),
+		c.replication(),
+	)
+	if c.ShardDuration != 0 {
+		statement += " SHARD DURATION " + formatDuration(c.ShardDuration)
+	}
+	return exec(client, statement+" DEFAULT", "")
+}
+
+func (c *Configuration) replication() int {
+	if c.Replication <= 0 {
+		return 1
+	}
+	return c.Replication
+}
+
+// formatDuration renders a duration literal in the largest unit that represents it exactly.
+// A zero duration means an infinite retention.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "INF"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	case d%time.Millisecond == 0:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10) + "ms"
+	case d%time.Microsecond == 0:
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + "u"
+	default:
+		return strconv.FormatInt(int64(d), 10) + "ns"
+	}
+}
+
+func exec(client influxdb.Client, statement, database string) error {
+	_, err := query(client, statement, database)
+	return err
+}
+
+func query(client influxdb.Client, statement, database string) (*influxclient.Response, error) {
+	res, err := client.QuerySpans(statement, database)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Error(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// hasValue checks whether the first column of any row is the value.
+func hasValue(res *influxclient.Response, value string) bool {
+	for _, result := range res.Results {
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				if len(v) > 0 && v[0] == value {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
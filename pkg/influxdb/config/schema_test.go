@@ -0,0 +1,154 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/pkg/influxdb/mocks"
+)
+
+func firstColumn(name string, values ...string) *client.Response {
+	row := models.Row{Name: name, Columns: []string{"name"}}
+	for _, v := range values {
+		row.Values = append(row.Values, []interface{}{v})
+	}
+	return &client.Response{Results: []client.Result{{Series: []models.Row{row}}}}
+}
+
+func TestCreateSchema(t *testing.T) {
+	testCases := []struct {
+		caption       string
+		config        Configuration
+		responses     map[string]*client.Response
+		expectedError string
+	}{
+		{
+			caption: "everything exists",
+			config:  Configuration{Database: "jaeger", RetentionPolicy: "jaeger"},
+			responses: map[string]*client.Response{
+				"SHOW DATABASES":                      firstColumn("databases", "_internal", "jaeger"),
+				`SHOW RETENTION POLICIES ON "jaeger"`: firstColumn("", "autogen", "jaeger"),
+			},
+		},
+		{
+			caption: "nothing exists",
+			config: Configuration{
+				Database:        "jaeger",
+				RetentionPolicy: "jaeger",
+				Retention:       7 * 24 * time.Hour,
+				ShardDuration:   90 * time.Minute,
+				Replication:     2,
+			},
+			responses: map[string]*client.Response{
+				"SHOW DATABASES":                      firstColumn("databases", "_internal"),
+				`CREATE DATABASE "jaeger"`:            {},
+				`SHOW RETENTION POLICIES ON "jaeger"`: firstColumn(""),
+				`CREATE RETENTION POLICY "jaeger" ON "jaeger" DURATION 168h REPLICATION 2 SHARD DURATION 90m DEFAULT`: {},
+			},
+		},
+		{
+			caption: "infinite retention",
+			config:  Configuration{Database: "jaeger", RetentionPolicy: "forever"},
+			responses: map[string]*client.Response{
+				"SHOW DATABASES":                      firstColumn("databases", "jaeger"),
+				`SHOW RETENTION POLICIES ON "jaeger"`: firstColumn("", "autogen"),
+				`CREATE RETENTION POLICY "forever" ON "jaeger" DURATION INF REPLICATION 1 DEFAULT`: {},
+			},
+		},
+		{
+			caption: "database default retention policy",
+			config:  Configuration{Database: "jaeger"},
+			responses: map[string]*client.Response{
+				"SHOW DATABASES": firstColumn("databases", "jaeger"),
+			},
+		},
+		{
+			caption: "database name is quoted",
+			config:  Configuration{Database: `x"; DROP DATABASE "y`},
+			responses: map[string]*client.Response{
+				"SHOW DATABASES": firstColumn("databases"),
+				`CREATE DATABASE "x\"; DROP DATABASE \"y"`: {},
+			},
+		},
+		{
+			caption: "create error",
+			config:  Configuration{Database: "jaeger"},
+			responses: map[string]*client.Response{
+				"SHOW DATABASES":           firstColumn("databases"),
+				`CREATE DATABASE "jaeger"`: {Err: "forbidden"},
+			},
+			expectedError: "forbidden",
+		},
+		{
+			caption: "udp is not supported",
+			config:  Configuration{Database: "jaeger", ConnectionType: "udp"},
+		},
+	}
+	for _, tc := range testCases {
+		testCase := tc
+		t.Run(testCase.caption, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			for query, response := range testCase.responses {
+				mockClient.On("QuerySpans", query, "").Return(response, nil).Once()
+			}
+			err := testCase.config.CreateSchema(mockClient)
+			if testCase.expectedError == "" {
+				require.NoError(t, err)
+				mockClient.AssertExpectations(t)
+			} else {
+				assert.EqualError(t, err, testCase.expectedError)
+			}
+		})
+	}
+}
+
+func TestCreateSchemaQueryError(t *testing.T) {
+	mockClient := &mocks.Client{}
+	mockClient.On("QuerySpans", "SHOW DATABASES", "").Return(nil, errors.New("connection refused"))
+	c := &Configuration{Database: "jaeger"}
+	assert.EqualError(t, c.CreateSchema(mockClient), "connection refused")
+}
+
+func TestFormatDuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "INF"},
+		{72 * time.Hour, "72h"},
+		{90 * time.Minute, "90m"},
+		{90 * time.Second, "90s"},
+		{1500 * time.Millisecond, "1500ms"},
+		{1500 * time.Microsecond, "1500u"},
+		{1500, "1500ns"},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, formatDuration(testCase.duration))
+	}
+}
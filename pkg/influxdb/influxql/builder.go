@@ -0,0 +1,196 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxql
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	identReplacer  = strings.NewReplacer("\n", `\n`, `\`, `\\`, `"`, `\"`)
+	stringReplacer = strings.NewReplacer("\n", `\n`, `\`, `\\`, `'`, `\'`)
+)
+
+// QuoteIdent returns a double-quoted InfluxQL identifier, such as a measurement, tag or field name.
+func QuoteIdent(ident string) string {
+	return `"` + identReplacer.Replace(ident) + `"`
+}
+
+// QuoteString returns a single-quoted InfluxQL string literal.
+func QuoteString(s string) string {
+	return `'` + stringReplacer.Replace(s) + `'`
+}
+
+// Expr is a rendered InfluxQL boolean expression. Values of Expr are only created
+// by the functions of this package, so all identifiers and literals within are quoted.
+type Expr string
+
+// Eq returns an expression that matches when the tag or field equals the string value.
+func Eq(key, value string) Expr {
+	return Expr(QuoteIdent(key) + " = " + QuoteString(value))
+}
+
+// Gte returns an expression that matches when the field is greater than or equal to the value.
+func Gte(key string, value int64) Expr {
+	return Expr(QuoteIdent(key) + " >= " + strconv.FormatInt(value, 10))
+}
+
+// Lte returns an expression that matches when the field is less than or equal to the value.
+func Lte(key string, value int64) Expr {
+	return Expr(QuoteIdent(key) + " <= " + strconv.FormatInt(value, 10))
+}
+
+// TimeRange returns an expression that matches points within [start, end].
+// A zero start or end leaves that side of the range open. The bounds are not
+// parenthesized, which is safe because AND binds tighter than OR.
+func TimeRange(start, end time.Time) Expr {
+	var bounds []string
+	if !start.IsZero() {
+		bounds = append(bounds, "time >= "+strconv.FormatInt(start.UnixNano(), 10))
+	}
+	if !end.IsZero() {
+		bounds = append(bounds, "time <= "+strconv.FormatInt(end.UnixNano(), 10))
+	}
+	return Expr(strings.Join(bounds, " AND "))
+}
+
+// And returns an expression that matches when all the non-empty expressions match.
+func And(exprs ...Expr) Expr {
+	return join(" AND ", exprs)
+}
+
+// Or returns an expression that matches when any of the non-empty expressions match.
+func Or(exprs ...Expr) Expr {
+	return join(" OR ", exprs)
+}
+
+func join(op string, exprs []Expr) Expr {
+	parts := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		if e != "" {
+			parts = append(parts, string(e))
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		return Expr(parts[0])
+	default:
+		return Expr("(" + strings.Join(parts, op) + ")")
+	}
+}
+
+// Query builds SELECT and SHOW TAG VALUES statements.
+type Query struct {
+	statement string
+	where     []Expr
+	groupBy   []string
+	limit     int
+	slimit    int
+}
+
+// Select starts a SELECT statement for the given fields from the measurement.
+// The wildcard field "*" is not quoted.
+func Select(measurement string, fields ...string) *Query {
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		if f == "*" {
+			quoted[i] = f
+		} else {
+			quoted[i] = QuoteIdent(f)
+		}
+	}
+	return &Query{
+		statement: "SELECT " + strings.Join(quoted, ", ") + " FROM " + QuoteIdent(measurement),
+	}
+}
+
+// SelectCall starts a SELECT statement for an aggregate or selector function of a field,
+// e.g. COUNT("duration_ns"). The function name must not come from user input.
+func SelectCall(measurement, function, field string) *Query {
+	return &Query{
+		statement: "SELECT " + function + "(" + QuoteIdent(field) + ") FROM " + QuoteIdent(measurement),
+	}
+}
+
+// ShowTagValues starts a SHOW TAG VALUES statement for the tag key of the measurement.
+func ShowTagValues(measurement, key string) *Query {
+	return &Query{
+		statement: "SHOW TAG VALUES FROM " + QuoteIdent(measurement) + " WITH KEY = " + QuoteIdent(key),
+	}
+}
+
+// Where adds the non-empty expressions, combined with AND, to the WHERE clause.
+func (q *Query) Where(exprs ...Expr) *Query {
+	for _, e := range exprs {
+		if e != "" {
+			q.where = append(q.where, e)
+		}
+	}
+	return q
+}
+
+// GroupBy groups the results by the given tags.
+func (q *Query) GroupBy(tags ...string) *Query {
+	q.groupBy = append(q.groupBy, tags...)
+	return q
+}
+
+// Limit limits the number of points returned per series.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// SLimit limits the number of series returned.
+func (q *Query) SLimit(n int) *Query {
+	q.slimit = n
+	return q
+}
+
+// String renders the statement.
+func (q *Query) String() string {
+	s := q.statement
+	if len(q.where) > 0 {
+		where := make([]string, len(q.where))
+		for i, e := range q.where {
+			where[i] = string(e)
+		}
+		s += " WHERE " + strings.Join(where, " AND ")
+	}
+	if len(q.groupBy) > 0 {
+		tags := make([]string, len(q.groupBy))
+		for i, t := range q.groupBy {
+			tags[i] = QuoteIdent(t)
+		}
+		s += " GROUP BY " + strings.Join(tags, ", ")
+	}
+	if q.limit > 0 {
+		s += " LIMIT " + strconv.Itoa(q.limit)
+	}
+	if q.slimit > 0 {
+		s += " SLIMIT " + strconv.Itoa(q.slimit)
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	testCases := []struct {
+		ident    string
+		expected string
+	}{
+		{ident: "service_name", expected: `"service_name"`},
+		{ident: "", expected: `""`},
+		{ident: `na"me`, expected: `"na\"me"`},
+		{ident: `name\`, expected: `"name\\"`},
+		{ident: `x" = 'a' OR "y`, expected: `"x\" = 'a' OR \"y"`},
+		{ident: "line\nbreak", expected: `"line\nbreak"`},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, QuoteIdent(testCase.ident), testCase.ident)
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{value: "frontend", expected: `'frontend'`},
+		{value: "", expected: `''`},
+		{value: "it's", expected: `'it\'s'`},
+		{value: `' OR 1=1 --`, expected: `'\' OR 1=1 --'`},
+		{value: `\' OR "name" =~ /.*/`, expected: `'\\\' OR "name" =~ /.*/'`},
+		{value: `value\`, expected: `'value\\'`},
+		{value: "a'; DROP DATABASE \"jaeger\"", expected: `'a\'; DROP DATABASE "jaeger"'`},
+		{value: "line\nbreak", expected: `'line\nbreak'`},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, QuoteString(testCase.value), testCase.value)
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	start := time.Unix(0, 100)
+	end := time.Unix(0, 200)
+	testCases := []struct {
+		caption  string
+		expr     Expr
+		expected Expr
+	}{
+		{caption: "eq", expr: Eq("name", "get'"), expected: `"name" = 'get\''`},
+		{caption: "gte", expr: Gte("duration_ns", 5), expected: `"duration_ns" >= 5`},
+		{caption: "lte", expr: Lte("duration_ns", -1), expected: `"duration_ns" <= -1`},
+		{caption: "time range", expr: TimeRange(start, end), expected: `time >= 100 AND time <= 200`},
+		{caption: "open end", expr: TimeRange(start, time.Time{}), expected: `time >= 100`},
+		{caption: "open range", expr: TimeRange(time.Time{}, time.Time{}), expected: ``},
+		{caption: "empty and", expr: And(), expected: ``},
+		{caption: "single and", expr: And("", Eq("a", "b")), expected: `"a" = 'b'`},
+		{
+			caption:  "and",
+			expr:     And(Eq("a", "b"), Eq("c", "d")),
+			expected: `("a" = 'b' AND "c" = 'd')`,
+		},
+		{
+			caption:  "or of ands",
+			expr:     Or(And(Eq("k", "1"), Eq("v", "x' OR 'y")), And(Eq("k", "2"), Eq("v", "z"))),
+			expected: `(("k" = '1' AND "v" = 'x\' OR \'y') OR ("k" = '2' AND "v" = 'z'))`,
+		},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.expr, testCase.caption)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	testCases := []struct {
+		caption  string
+		query    *Query
+		expected string
+	}{
+		{
+			caption:  "select all",
+			query:    Select("zipkin", "*").Where(Eq("trace_id", "abc")),
+			expected: `SELECT * FROM "zipkin" WHERE "trace_id" = 'abc'`,
+		},
+		{
+			caption: "select fields",
+			query: Select("zipkin", "time", "name", `evil"field`).
+				Where(TimeRange(time.Unix(0, 1), time.Unix(0, 2)), Eq("service_name", `svc' OR "1"='1`), "").
+				GroupBy("trace_id").
+				Limit(10).
+				SLimit(20),
+			expected: `SELECT "time", "name", "evil\"field" FROM "zipkin" WHERE time >= 1 AND time <= 2 AND "service_name" = 'svc\' OR "1"=\'1' GROUP BY "trace_id" LIMIT 10 SLIMIT 20`,
+		},
+		{
+			caption:  "select call",
+			query:    SelectCall("dependencies", "SUM", "call_count").GroupBy("parent", "child"),
+			expected: `SELECT SUM("call_count") FROM "dependencies" GROUP BY "parent", "child"`,
+		},
+		{
+			caption:  "show tag values",
+			query:    ShowTagValues("zipkin", "name").Where(Eq("service_name", `x' WITH KEY = "id`)),
+			expected: `SHOW TAG VALUES FROM "zipkin" WITH KEY = "name" WHERE "service_name" = 'x\' WITH KEY = "id'`,
+		},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.query.String(), testCase.caption)
+	}
+}
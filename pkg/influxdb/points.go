@@ -0,0 +1,174 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxdb
+
+import (
+	"encoding/json"
+	"strconv"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+
+	"github.com/uber/jaeger/model"
+)
+
+// Measurement is the InfluxDB measurement that holds all span points.
+// The layout is compatible with the one produced by Telegraf's zipkin input.
+const Measurement = "zipkin"
+
+// OperationNamesMeasurement indexes the known services and operations, tagged with ServiceNameTag
+// and OperationTag. It has one series per operation, so listing them does not scan the span series.
+const OperationNamesMeasurement = "operation_names"
+
+// SeenField is the only field of the points in OperationNamesMeasurement.
+const SeenField = "seen"
+
+// Tag keys of the span measurement.
+const (
+	TraceIDTag       = "trace_id"
+	SpanIDTag        = "id"
+	ParentSpanIDTag  = "parent_id"
+	ServiceNameTag   = "service_name"
+	OperationTag     = "name"
+	AnnotationKeyTag = "annotation_key"
+	AnnotationTag    = "annotation"
+)
+
+// Field keys of the span measurement.
+const (
+	DurationField    = "duration_ns"
+	FlagsField       = "flags"
+	TagsField        = "tags"
+	LogsField        = "logs"
+	ProcessTagsField = "process_tags"
+	ReferencesField  = "references"
+	WarningsField    = "warnings"
+)
+
+// maxAnnotationSize is the longest tag key or value that will be indexed as an annotation point.
+const maxAnnotationSize = 256
+
+// SpanPoints converts a span into InfluxDB points. The first point carries the complete span,
+// with tags, logs, process tags and references JSON-encoded into fields. It is followed by one
+// annotation point per unique span tag, process tag and log field, which exist only so that
+// spans can be searched by tag.
+func SpanPoints(span *model.Span) ([]*influxclient.Point, error) {
+	tags := spanTags(span)
+	fields := map[string]interface{}{
+		DurationField: span.Duration.Nanoseconds(),
+		FlagsField:    int64(span.Flags),
+	}
+	var processTags model.KeyValues
+	if span.Process != nil {
+		processTags = span.Process.Tags
+	}
+	encoded := map[string]interface{}{
+		TagsField:        span.Tags,
+		LogsField:        span.Logs,
+		ProcessTagsField: processTags,
+		ReferencesField:  span.References,
+		WarningsField:    span.Warnings,
+	}
+	for key, value := range encoded {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = string(b)
+	}
+	spanPoint, err := influxclient.NewPoint(Measurement, tags, fields, span.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	points := []*influxclient.Point{spanPoint}
+	for _, kv := range annotations(span) {
+		annotationTags := spanTags(span)
+		annotationTags[AnnotationKeyTag] = kv.Key
+		annotationTags[AnnotationTag] = kv.AsString()
+		point, err := influxclient.NewPoint(
+			Measurement,
+			annotationTags,
+			map[string]interface{}{DurationField: span.Duration.Nanoseconds()},
+			span.StartTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// OperationNamePoint converts a span into the point that indexes its service and operation,
+// or returns nil if the span has no service.
+func OperationNamePoint(span *model.Span) (*influxclient.Point, error) {
+	if span.Process == nil || span.Process.ServiceName == "" {
+		return nil, nil
+	}
+	tags := map[string]string{ServiceNameTag: span.Process.ServiceName}
+	if span.OperationName != "" {
+		tags[OperationTag] = span.OperationName
+	}
+	return influxclient.NewPoint(OperationNamesMeasurement, tags, map[string]interface{}{SeenField: true}, span.StartTime)
+}
+
+func spanTags(span *model.Span) map[string]string {
+	tags := map[string]string{
+		TraceIDTag:      span.TraceID.String(),
+		SpanIDTag:       strconv.FormatUint(uint64(span.SpanID), 10),
+		ParentSpanIDTag: strconv.FormatUint(uint64(span.ParentSpanID), 10),
+	}
+	// InfluxDB does not store empty tag values
+	if span.OperationName != "" {
+		tags[OperationTag] = span.OperationName
+	}
+	if span.Process != nil && span.Process.ServiceName != "" {
+		tags[ServiceNameTag] = span.Process.ServiceName
+	}
+	return tags
+}
+
+// annotations returns the unique, indexable key-values from span tags, process tags and log fields.
+func annotations(span *model.Span) model.KeyValues {
+	all := make(model.KeyValues, 0, len(span.Tags))
+	all = append(all, span.Tags...)
+	if span.Process != nil {
+		all = append(all, span.Process.Tags...)
+	}
+	for _, log := range span.Logs {
+		all = append(all, log.Fields...)
+	}
+	all.Sort()
+	unique := make(model.KeyValues, 0, len(all))
+	for i := range all {
+		if all[i].VType == model.BinaryType {
+			continue // do not index binary tags
+		}
+		if i > 0 && all[i-1].Equal(&all[i]) {
+			continue // skip identical tags
+		}
+		value := all[i].AsString()
+		if all[i].Key == "" || value == "" || len(all[i].Key) > maxAnnotationSize || len(value) > maxAnnotationSize {
+			continue
+		}
+		unique = append(unique, all[i])
+	}
+	return unique
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+)
+
+func testSpan() *model.Span {
+	return &model.Span{
+		TraceID:       model.TraceID{Low: 0xab},
+		SpanID:        model.SpanID(3),
+		ParentSpanID:  model.SpanID(2),
+		OperationName: "get",
+		References: []model.SpanRef{
+			{RefType: model.ChildOf, TraceID: model.TraceID{Low: 0xab}, SpanID: model.SpanID(2)},
+		},
+		Flags:     model.Flags(1),
+		StartTime: time.Unix(0, 1000).UTC(),
+		Duration:  5 * time.Millisecond,
+		Tags: model.KeyValues{
+			model.String("http.method", "GET"),
+			model.Bool("error", true),
+			model.Binary("blob", []byte("x")),
+		},
+		Logs: []model.Log{
+			{
+				Timestamp: time.Unix(0, 2000).UTC(),
+				Fields:    []model.KeyValue{model.String("event", "retry"), model.String("http.method", "GET")},
+			},
+		},
+		Process: model.NewProcess("frontend", []model.KeyValue{model.String("hostname", "host1")}),
+	}
+}
+
+func TestSpanPoints(t *testing.T) {
+	span := testSpan()
+	points, err := SpanPoints(span)
+	require.NoError(t, err)
+	// one span point plus error, event, hostname and http.method annotations
+	require.Len(t, points, 5)
+
+	spanPoint := points[0]
+	assert.Equal(t, Measurement, spanPoint.Name())
+	assert.Equal(t, span.StartTime, spanPoint.Time())
+	assert.Equal(t, map[string]string{
+		TraceIDTag:      "ab",
+		SpanIDTag:       "3",
+		ParentSpanIDTag: "2",
+		ServiceNameTag:  "frontend",
+		OperationTag:    "get",
+	}, spanPoint.Tags())
+
+	fields, err := spanPoint.Fields()
+	require.NoError(t, err)
+	assert.EqualValues(t, 5*time.Millisecond, fields[DurationField])
+	assert.EqualValues(t, 1, fields[FlagsField])
+
+	var tags model.KeyValues
+	require.NoError(t, json.Unmarshal([]byte(fields[TagsField].(string)), &tags))
+	assert.Equal(t, span.Tags, tags)
+	var logs []model.Log
+	require.NoError(t, json.Unmarshal([]byte(fields[LogsField].(string)), &logs))
+	assert.Equal(t, span.Logs, logs)
+	var processTags model.KeyValues
+	require.NoError(t, json.Unmarshal([]byte(fields[ProcessTagsField].(string)), &processTags))
+	assert.Equal(t, span.Process.Tags, processTags)
+	var refs []model.SpanRef
+	require.NoError(t, json.Unmarshal([]byte(fields[ReferencesField].(string)), &refs))
+	assert.Equal(t, span.References, refs)
+
+	annotations := map[string]string{}
+	for _, p := range points[1:] {
+		assert.Equal(t, span.StartTime, p.Time())
+		assert.Equal(t, "3", p.Tags()[SpanIDTag])
+		annotations[p.Tags()[AnnotationKeyTag]] = p.Tags()[AnnotationTag]
+	}
+	assert.Equal(t, map[string]string{
+		"error":       "true",
+		"event":       "retry",
+		"hostname":    "host1",
+		"http.method": "GET",
+	}, annotations)
+}
+
+func TestSpanPointsWithoutProcess(t *testing.T) {
+	span := &model.Span{
+		TraceID:   model.TraceID{High: 1, Low: 2},
+		SpanID:    model.SpanID(1),
+		StartTime: time.Unix(10, 0),
+		Tags:      model.KeyValues{model.String("empty", "")},
+	}
+	points, err := SpanPoints(span)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, map[string]string{
+		TraceIDTag:      "10000000000000002",
+		SpanIDTag:       "1",
+		ParentSpanIDTag: "0",
+	}, points[0].Tags())
+}
+
+func TestOperationNamePoint(t *testing.T) {
+	point, err := OperationNamePoint(testSpan())
+	require.NoError(t, err)
+	assert.Equal(t, OperationNamesMeasurement, point.Name())
+	assert.Equal(t, map[string]string{ServiceNameTag: "frontend", OperationTag: "get"}, point.Tags())
+	fields, err := point.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{SeenField: true}, fields)
+	assert.Equal(t, time.Unix(0, 1000).UTC(), point.Time().UTC())
+
+	point, err = OperationNamePoint(&model.Span{Process: model.NewProcess("frontend", nil)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{ServiceNameTag: "frontend"}, point.Tags())
+
+	point, err = OperationNamePoint(&model.Span{OperationName: "get"})
+	require.NoError(t, err)
+	assert.Nil(t, point)
+}
@@ -5,35 +5,41 @@ import (
 	"github.com/uber/jaeger/model"
 )
 
+// InternalClient implements Client on top of an HTTP or UDP InfluxDB client
 type InternalClient struct {
-	Client influxclient.Client
+	Client   influxclient.Client
+	Database string
 }
 
+// WriteSpans converts the spans into points and writes them to the database as a single batch
 func (c *InternalClient) WriteSpans(spans []*model.Span) error {
-	return nil
-}
-
-func (c *InternalClient) QuerySpans(query string, database string) (*influxclient.Response, error) {
-	return c.Client.Query(influxclient.NewQuery(query, database, "ns"))
-	/*
-		res, err := c.Client.Query(influxclient.NewQuery(query, database, "ns"))
+	var points []*influxclient.Point
+	for _, span := range spans {
+		spanPoints, err := SpanPoints(span)
 		if err != nil {
-			return nil, err
-		}
-
-		if err = res.Error(); err != nil {
-			return nil, err
+			return err
 		}
+		points = append(points, spanPoints...)
+	}
+	return c.WritePoints(points)
+}
 
-		var ret [][]*models.Row
-
-		for _, r := range res.Results {
-			models := []*models.Row{}
-			for _, series := range r.Series {
-				models = append(models, &series)
-			}
-			ret = append(ret, models)
-		}
+// WritePoints writes the points to the database as a single batch
+func (c *InternalClient) WritePoints(points []*influxclient.Point) error {
+	bp, err := influxclient.NewBatchPoints(influxclient.BatchPointsConfig{
+		Database:  c.Database,
+		Precision: "ns",
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range points {
+		bp.AddPoint(p)
+	}
+	return c.Client.Write(bp)
+}
 
-		return ret, nil*/
+// QuerySpans runs the InfluxQL query against the database
+func (c *InternalClient) QuerySpans(query string, database string) (*influxclient.Response, error) {
+	return c.Client.Query(influxclient.NewQuery(query, database, "ns"))
 }
@@ -0,0 +1,93 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxdb
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+)
+
+func TestWriteSpansHTTP(t *testing.T) {
+	var body string
+	var db string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(b)
+		db = r.URL.Query().Get("db")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	httpClient, err := influxclient.NewHTTPClient(influxclient.HTTPConfig{Addr: server.URL})
+	require.NoError(t, err)
+	client := &InternalClient{Client: httpClient, Database: "jaeger"}
+
+	require.NoError(t, client.WriteSpans([]*model.Span{testSpan(), testSpan()}))
+	assert.Equal(t, "jaeger", db)
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	assert.Len(t, lines, 10)
+	for _, line := range lines {
+		assert.True(t, strings.HasPrefix(line, Measurement+","), line)
+	}
+}
+
+func TestWriteSpansHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"database not found: \"jaeger\""}`))
+	}))
+	defer server.Close()
+
+	httpClient, err := influxclient.NewHTTPClient(influxclient.HTTPConfig{Addr: server.URL})
+	require.NoError(t, err)
+	client := &InternalClient{Client: httpClient, Database: "jaeger"}
+
+	err = client.WriteSpans([]*model.Span{testSpan()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database not found")
+}
+
+func TestWriteSpansUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	udpClient, err := influxclient.NewUDPClient(influxclient.UDPConfig{Addr: conn.LocalAddr().String()})
+	require.NoError(t, err)
+	client := &InternalClient{Client: udpClient, Database: "jaeger"}
+	require.NoError(t, client.WriteSpans([]*model.Span{testSpan()}))
+
+	buf := make([]byte, 65536)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf[:n]), Measurement+","))
+}
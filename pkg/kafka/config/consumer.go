@@ -0,0 +1,53 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/bsm/sarama-cluster"
+
+	"github.com/uber/jaeger/pkg/kafka"
+)
+
+// ConsumerConfiguration describes the configuration properties needed to consume spans from Kafka
+type ConsumerConfiguration struct {
+	// Brokers is the list of Kafka brokers used to bootstrap the consumer
+	Brokers []string
+	// Topic is the Kafka topic the spans are consumed from
+	Topic string
+	// GroupID is the consumer group the consumer joins, its members share the partitions of the topic
+	GroupID string
+	// Encoding is the encoding of the consumed spans, json or thrift
+	Encoding string
+}
+
+// NewConsumer joins the consumer group. A group without committed offsets starts from the
+// oldest messages, so that no spans published before the first ingester started are lost.
+func (c *ConsumerConfiguration) NewConsumer() (kafka.Consumer, error) {
+	saramaConfig := cluster.NewConfig()
+	saramaConfig.Group.Mode = cluster.ConsumerModePartitions
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	consumer, err := cluster.NewConsumer(c.Brokers, c.GroupID, []string{c.Topic}, saramaConfig)
+	if err != nil {
+		return nil, err
+	}
+	return consumer, nil
+}
@@ -32,12 +32,22 @@ import (
 // where the queue is bounded and if it fills up due to slow consumers, the new items written by
 // the producer force the earliest items to be dropped. The implementation is actually based on
 // channels, with a special Reaper goroutine that wakes up when the queue is full and consumers
-// the items from the top of the queue until its size drops back to maxSize
+// the items from the top of the queue until its size drops back to maxSize.
+//
+// Besides the number of items, the queue can be bounded by the total size of the items, as
+// measured by a sizer function, and its capacity can be changed at runtime with Resize.
 type BoundedQueue struct {
+	// lock guards the items channel, which is swapped by Resize and closed by Stop
+	lock          sync.RWMutex
 	capacity      int
 	size          int32
+	bytes         int64
+	maxBytes      int64
+	sizer         func(item interface{}) int64
 	onDroppedItem func(item interface{})
 	items         chan interface{}
+	consumer      func(item interface{})
+	numConsumers  int
 	stopCh        chan struct{}
 	stopWG        sync.WaitGroup
 	stopped       int32
@@ -54,11 +64,34 @@ func NewBoundedQueue(capacity int, onDroppedItem func(item interface{})) *Bounde
 	}
 }
 
+// NewByteBoundedQueue constructs a queue of specified capacity that also rejects new items once
+// the total size of the queued items, as returned by sizer, would exceed maxBytes.
+func NewByteBoundedQueue(
+	capacity int,
+	maxBytes int64,
+	sizer func(item interface{}) int64,
+	onDroppedItem func(item interface{}),
+) *BoundedQueue {
+	q := NewBoundedQueue(capacity, onDroppedItem)
+	q.maxBytes = maxBytes
+	q.sizer = sizer
+	return q
+}
+
 // StartConsumers starts a given number of goroutines consuming items from the queue
 // and passing them into the consumer callback.
 func (q *BoundedQueue) StartConsumers(num int, consumer func(item interface{})) {
+	q.lock.Lock()
+	q.numConsumers = num
+	q.consumer = consumer
+	items := q.items
+	q.lock.Unlock()
+	q.startConsumers(items)
+}
+
+func (q *BoundedQueue) startConsumers(items chan interface{}) {
 	var startWG sync.WaitGroup
-	for i := 0; i < num; i++ {
+	for i := 0; i < q.numConsumers; i++ {
 		q.stopWG.Add(1)
 		startWG.Add(1)
 		go func() {
@@ -66,9 +99,16 @@ func (q *BoundedQueue) StartConsumers(num int, consumer func(item interface{}))
 			defer q.stopWG.Done()
 			for {
 				select {
-				case item := <-q.items:
+				case item, ok := <-items:
+					if !ok {
+						// the queue was resized, the consumers of the new items channel take over
+						return
+					}
 					atomic.AddInt32(&q.size, -1)
-					consumer(item)
+					if q.sizer != nil {
+						atomic.AddInt64(&q.bytes, -q.sizer(item))
+					}
+					q.consumer(item)
 				case <-q.stopCh:
 					return
 				}
@@ -80,15 +120,30 @@ func (q *BoundedQueue) StartConsumers(num int, consumer func(item interface{}))
 
 // Produce is used by the producer to submit new item to the queue. Returns false in case of queue overflow.
 func (q *BoundedQueue) Produce(item interface{}) bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 	if atomic.LoadInt32(&q.stopped) != 0 {
 		q.onDroppedItem(item)
 		return false
 	}
+	var size int64
+	if q.sizer != nil {
+		size = q.sizer(item)
+		maxBytes := atomic.LoadInt64(&q.maxBytes)
+		if bytes := atomic.AddInt64(&q.bytes, size); maxBytes > 0 && bytes > maxBytes {
+			atomic.AddInt64(&q.bytes, -size)
+			if q.onDroppedItem != nil {
+				q.onDroppedItem(item)
+			}
+			return false
+		}
+	}
 	select {
 	case q.items <- item:
 		atomic.AddInt32(&q.size, 1)
 		return true
 	default:
+		atomic.AddInt64(&q.bytes, -size)
 		if q.onDroppedItem != nil {
 			q.onDroppedItem(item)
 		}
@@ -96,10 +151,37 @@ func (q *BoundedQueue) Produce(item interface{}) bool {
 	}
 }
 
+// Resize changes the capacity of the queue. The queued items are kept, which is why the new capacity
+// cannot be smaller than the current size of the queue. Returns false if the queue was not resized.
+func (q *BoundedQueue) Resize(capacity int) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if capacity == q.capacity || capacity < q.Size() || atomic.LoadInt32(&q.stopped) != 0 {
+		return false
+	}
+	previous := q.items
+	q.items = make(chan interface{}, capacity)
+	q.capacity = capacity
+	if q.consumer != nil {
+		q.startConsumers(q.items)
+	}
+	// the consumers of the previous channel drain it and exit once it is closed
+	close(previous)
+	return true
+}
+
+// SetMaxBytes changes the limit of the total size of the queued items, 0 removes the limit.
+// It has no effect on queues constructed without a sizer.
+func (q *BoundedQueue) SetMaxBytes(maxBytes int64) {
+	atomic.StoreInt64(&q.maxBytes, maxBytes)
+}
+
 // Stop stops all consumers, as well as the length reporter if started,
 // and releases the items channel. It blocks until all consumers have stopped.
 func (q *BoundedQueue) Stop() {
+	q.lock.Lock()
 	atomic.StoreInt32(&q.stopped, 1) // disable producer
+	q.lock.Unlock()
 	close(q.stopCh)
 	q.stopWG.Wait()
 	close(q.items)
@@ -110,8 +192,15 @@ func (q *BoundedQueue) Size() int {
 	return int(atomic.LoadInt32(&q.size))
 }
 
+// Bytes returns the total size of the queued items, or 0 if the queue has no sizer
+func (q *BoundedQueue) Bytes() int64 {
+	return atomic.LoadInt64(&q.bytes)
+}
+
 // Capacity returns capacity of the queue
 func (q *BoundedQueue) Capacity() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 	return q.capacity
 }
 
@@ -160,3 +160,71 @@ func (s *consumerState) assertConsumed(expected map[string]bool) {
 	}
 	assert.Equal(s.t, expected, s.snapshot())
 }
+
+func TestByteBoundedQueue(t *testing.T) {
+	var dropped []string
+	q := NewByteBoundedQueue(10, 5, func(item interface{}) int64 {
+		return int64(len(item.(string)))
+	}, func(item interface{}) {
+		dropped = append(dropped, item.(string))
+	})
+
+	assert.True(t, q.Produce("abc"))
+	assert.False(t, q.Produce("def"), "does not fit in the byte budget")
+	assert.True(t, q.Produce("gh"))
+	assert.EqualValues(t, 5, q.Bytes())
+	assert.Equal(t, []string{"def"}, dropped)
+
+	q.SetMaxBytes(8)
+	assert.True(t, q.Produce("ijk"))
+	assert.EqualValues(t, 8, q.Bytes())
+
+	consumerState := newConsumerState(t)
+	q.StartConsumers(1, func(item interface{}) {
+		consumerState.record(item.(string))
+	})
+	consumerState.assertConsumed(map[string]bool{
+		"abc": true,
+		"gh":  true,
+		"ijk": true,
+	})
+	assert.EqualValues(t, 0, q.Bytes())
+	q.Stop()
+}
+
+func TestResizeBoundedQueue(t *testing.T) {
+	q := NewBoundedQueue(2, func(item interface{}) {})
+	var startLock sync.Mutex
+	startLock.Lock() // block consumers
+	consumerState := newConsumerState(t)
+	q.StartConsumers(1, func(item interface{}) {
+		consumerState.record(item.(string))
+		startLock.Lock()
+		startLock.Unlock()
+	})
+
+	assert.True(t, q.Produce("a"))
+	consumerState.waitToConsumeOnce()
+	assert.True(t, q.Produce("b"))
+	assert.True(t, q.Produce("c"))
+	assert.False(t, q.Produce("d"), "the queue is full")
+
+	assert.False(t, q.Resize(1), "cannot shrink below the number of queued items")
+	assert.False(t, q.Resize(2), "same capacity")
+	assert.True(t, q.Resize(4))
+	assert.Equal(t, 4, q.Capacity())
+	assert.True(t, q.Produce("d"))
+	assert.Equal(t, 3, q.Size())
+
+	startLock.Unlock() // unblock consumers
+	consumerState.assertConsumed(map[string]bool{
+		"a": true,
+		"b": true,
+		"c": true,
+		"d": true,
+	})
+	assert.Equal(t, 0, q.Size())
+
+	q.Stop()
+	assert.False(t, q.Resize(8), "cannot resize a stopped queue")
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	diskItemSuffix = ".item"
+	diskTempSuffix = ".tmp"
+)
+
+var (
+	// ErrDiskQueueFull is returned by DiskQueue's Put when the item does not fit within the size limit
+	ErrDiskQueueFull = errors.New("disk queue is full")
+	// ErrDiskQueueEmpty is returned by DiskQueue's Front when the queue has no items
+	ErrDiskQueueEmpty = errors.New("disk queue is empty")
+)
+
+// DiskQueue is a FIFO queue that keeps every item in its own file in a directory, so that the items
+// survive restarts of the process. Items are written to a temporary file first and renamed once
+// complete, so a crash never leaves a partially written item in the queue. The total size of the
+// items can be bounded, in which case new items are rejected until older ones are removed.
+type DiskQueue struct {
+	sync.Mutex
+	dir      string
+	maxBytes int64
+	bytes    int64
+	items    []diskItem
+	nextSeq  uint64
+}
+
+type diskItem struct {
+	seq  uint64
+	size int64
+}
+
+// NewDiskQueue opens the queue stored in dir, creating the directory if needed. The items found in
+// the directory are kept in the order they were added. maxBytes limits the total size of the items,
+// 0 means no limit.
+func NewDiskQueue(dir string, maxBytes int64) (*DiskQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	q := &DiskQueue{dir: dir, maxBytes: maxBytes}
+	// ReadDir sorts the files by name, and the zero-padded sequence numbers sort in the order they were added
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasSuffix(name, diskTempSuffix) {
+			// left over by a crash in the middle of a Put
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if file.IsDir() || !strings.HasSuffix(name, diskItemSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, diskItemSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.items = append(q.items, diskItem{seq: seq, size: file.Size()})
+		q.bytes += file.Size()
+		q.nextSeq = seq + 1
+	}
+	return q, nil
+}
+
+func (q *DiskQueue) path(seq uint64, suffix string) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, suffix))
+}
+
+// Put appends an item to the end of the queue
+func (q *DiskQueue) Put(data []byte) error {
+	q.Lock()
+	defer q.Unlock()
+	size := int64(len(data))
+	if q.maxBytes > 0 && q.bytes+size > q.maxBytes {
+		return ErrDiskQueueFull
+	}
+	seq := q.nextSeq
+	tempPath := q.path(seq, diskTempSuffix)
+	if err := ioutil.WriteFile(tempPath, data, 0644); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, q.path(seq, diskItemSuffix)); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	q.nextSeq++
+	q.items = append(q.items, diskItem{seq: seq, size: size})
+	q.bytes += size
+	return nil
+}
+
+// Front returns the item at the front of the queue without removing it, or ErrDiskQueueEmpty
+func (q *DiskQueue) Front() ([]byte, error) {
+	q.Lock()
+	defer q.Unlock()
+	if len(q.items) == 0 {
+		return nil, ErrDiskQueueEmpty
+	}
+	return ioutil.ReadFile(q.path(q.items[0].seq, diskItemSuffix))
+}
+
+// Remove deletes the item at the front of the queue
+func (q *DiskQueue) Remove() error {
+	q.Lock()
+	defer q.Unlock()
+	if len(q.items) == 0 {
+		return ErrDiskQueueEmpty
+	}
+	item := q.items[0]
+	if err := os.Remove(q.path(item.seq, diskItemSuffix)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	q.items = q.items[1:]
+	q.bytes -= item.size
+	return nil
+}
+
+// Size returns the number of items in the queue
+func (q *DiskQueue) Size() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.items)
+}
+
+// Bytes returns the total size of the items in the queue
+func (q *DiskQueue) Bytes() int64 {
+	q.Lock()
+	defer q.Unlock()
+	return q.bytes
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package queue
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withDiskQueueDir(t *testing.T, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "disk-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	f(dir)
+}
+
+func TestDiskQueue(t *testing.T) {
+	withDiskQueueDir(t, func(dir string) {
+		q, err := NewDiskQueue(filepath.Join(dir, "spill"), 0)
+		require.NoError(t, err)
+		_, err = q.Front()
+		assert.Equal(t, ErrDiskQueueEmpty, err)
+		assert.Equal(t, ErrDiskQueueEmpty, q.Remove())
+
+		require.NoError(t, q.Put([]byte("first")))
+		require.NoError(t, q.Put([]byte("second")))
+		assert.Equal(t, 2, q.Size())
+		assert.EqualValues(t, 11, q.Bytes())
+
+		data, err := q.Front()
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(data))
+		require.NoError(t, q.Remove())
+
+		data, err = q.Front()
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(data))
+		require.NoError(t, q.Remove())
+		assert.Equal(t, 0, q.Size())
+		assert.EqualValues(t, 0, q.Bytes())
+	})
+}
+
+func TestDiskQueueReopen(t *testing.T) {
+	withDiskQueueDir(t, func(dir string) {
+		q, err := NewDiskQueue(dir, 0)
+		require.NoError(t, err)
+		for _, item := range []string{"a", "b", "c"} {
+			require.NoError(t, q.Put([]byte(item)))
+		}
+		require.NoError(t, q.Remove())
+		// a crash in the middle of a Put and files that do not belong to the queue
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "00000000000000000003.tmp"), []byte("d"), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("e"), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "backup.item"), []byte("f"), 0644))
+
+		q, err = NewDiskQueue(dir, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, q.Size())
+		assert.EqualValues(t, 2, q.Bytes())
+		_, err = os.Stat(filepath.Join(dir, "00000000000000000003.tmp"))
+		assert.True(t, os.IsNotExist(err))
+
+		require.NoError(t, q.Put([]byte("d")))
+		var items []string
+		for q.Size() > 0 {
+			data, err := q.Front()
+			require.NoError(t, err)
+			items = append(items, string(data))
+			require.NoError(t, q.Remove())
+		}
+		assert.Equal(t, []string{"b", "c", "d"}, items)
+	})
+}
+
+func TestDiskQueueFull(t *testing.T) {
+	withDiskQueueDir(t, func(dir string) {
+		q, err := NewDiskQueue(dir, 10)
+		require.NoError(t, err)
+		require.NoError(t, q.Put([]byte("12345678")))
+		assert.Equal(t, ErrDiskQueueFull, q.Put([]byte("123")))
+		require.NoError(t, q.Put([]byte("12")))
+		require.NoError(t, q.Remove())
+		require.NoError(t, q.Put([]byte("123")))
+		assert.EqualValues(t, 5, q.Bytes())
+	})
+}
+
+func TestDiskQueueErrors(t *testing.T) {
+	withDiskQueueDir(t, func(dir string) {
+		file := filepath.Join(dir, "file")
+		require.NoError(t, ioutil.WriteFile(file, nil, 0644))
+		_, err := NewDiskQueue(file, 0)
+		assert.Error(t, err)
+
+		q, err := NewDiskQueue(filepath.Join(dir, "spill"), 0)
+		require.NoError(t, err)
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "spill")))
+		assert.Error(t, q.Put([]byte("a")))
+	})
+}
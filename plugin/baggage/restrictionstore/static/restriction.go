@@ -0,0 +1,39 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package static
+
+// restriction defines the maximum length of the value of a baggage key that clients are allowed to set.
+type restriction struct {
+	BaggageKey     string `json:"baggage_key"`
+	MaxValueLength int32  `json:"max_value_length"`
+}
+
+// serviceRestrictions defines the baggage restrictions of a specific service.
+type serviceRestrictions struct {
+	Service      string         `json:"service"`
+	Restrictions []*restriction `json:"restrictions"`
+}
+
+// restrictions holds the default baggage restrictions and service specific baggage restrictions.
+type restrictions struct {
+	DefaultRestrictions []*restriction         `json:"default_restrictions"`
+	ServiceRestrictions []*serviceRestrictions `json:"service_restrictions"`
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package static
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/pkg/filewatcher"
+	"github.com/uber/jaeger/thrift-gen/baggage"
+)
+
+// Store is a baggage restriction store backed by a JSON file. If a reload interval is
+// configured, the file is periodically re-read and the restrictions are replaced when it changes.
+type Store struct {
+	logger  *zap.Logger
+	options Options
+
+	sync.RWMutex
+	defaultRestrictions []*baggage.BaggageRestriction
+	serviceRestrictions map[string][]*baggage.BaggageRestriction
+
+	watcher *filewatcher.Watcher
+}
+
+// NewRestrictionStore creates a restriction store that holds the static baggage restrictions of the file.
+// Services without their own restrictions get the default restrictions; service specific
+// restrictions replace the default ones rather than extending them.
+func NewRestrictionStore(options Options, logger *zap.Logger) (*Store, error) {
+	s := &Store{
+		logger:              logger,
+		options:             options,
+		defaultRestrictions: []*baggage.BaggageRestriction{},
+		serviceRestrictions: make(map[string][]*baggage.BaggageRestriction),
+	}
+	watcher, err := filewatcher.New(options.RestrictionsFile, "baggage restrictions", options.ReloadInterval, s.load, logger)
+	if err != nil {
+		return nil, err
+	}
+	s.watcher = watcher
+	return s, nil
+}
+
+// GetBaggageRestrictions implements RestrictionStore#GetBaggageRestrictions.
+func (s *Store) GetBaggageRestrictions(serviceName string) ([]*baggage.BaggageRestriction, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if restrictions, ok := s.serviceRestrictions[serviceName]; ok {
+		return restrictions, nil
+	}
+	return s.defaultRestrictions, nil
+}
+
+// Close stops reloading the restrictions file.
+func (s *Store) Close() error {
+	return s.watcher.Close()
+}
+
+func (s *Store) load(content []byte) error {
+	var restrictions restrictions
+	if err := json.Unmarshal(content, &restrictions); err != nil {
+		return errors.Wrap(err, "Failed to parse the baggage restrictions")
+	}
+	defaultRestrictions, err := parseRestrictions(restrictions.DefaultRestrictions)
+	if err != nil {
+		return errors.Wrap(err, "Invalid default baggage restrictions")
+	}
+	serviceRestrictions := make(map[string][]*baggage.BaggageRestriction, len(restrictions.ServiceRestrictions))
+	for _, sr := range restrictions.ServiceRestrictions {
+		if _, ok := serviceRestrictions[sr.Service]; ok {
+			return fmt.Errorf("duplicate baggage restrictions for service %s", sr.Service)
+		}
+		parsed, err := parseRestrictions(sr.Restrictions)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid baggage restrictions for service %s", sr.Service)
+		}
+		serviceRestrictions[sr.Service] = parsed
+	}
+	s.Lock()
+	s.defaultRestrictions = defaultRestrictions
+	s.serviceRestrictions = serviceRestrictions
+	s.Unlock()
+	return nil
+}
+
+func parseRestrictions(restrictions []*restriction) ([]*baggage.BaggageRestriction, error) {
+	keys := make(map[string]struct{}, len(restrictions))
+	parsed := make([]*baggage.BaggageRestriction, 0, len(restrictions))
+	for _, r := range restrictions {
+		if r.BaggageKey == "" {
+			return nil, errors.New("baggage key must not be empty")
+		}
+		if _, ok := keys[r.BaggageKey]; ok {
+			return nil, fmt.Errorf("duplicate baggage key %q", r.BaggageKey)
+		}
+		if r.MaxValueLength < 0 {
+			return nil, fmt.Errorf("max value length %d of baggage key %q is negative", r.MaxValueLength, r.BaggageKey)
+		}
+		keys[r.BaggageKey] = struct{}{}
+		parsed = append(parsed, &baggage.BaggageRestriction{
+			BaggageKey:     r.BaggageKey,
+			MaxValueLength: r.MaxValueLength,
+		})
+	}
+	return parsed, nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package static
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/thrift-gen/baggage"
+)
+
+func TestRestrictionStore(t *testing.T) {
+	store, err := NewRestrictionStore(Options{RestrictionsFile: "fixtures/restrictions.json"}, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	r, err := store.GetBaggageRestrictions("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage.BaggageRestriction{
+		{BaggageKey: "request-id", MaxValueLength: 36},
+		{BaggageKey: "user-id", MaxValueLength: 64},
+	}, r)
+
+	r, err = store.GetBaggageRestrictions("bar")
+	require.NoError(t, err)
+	assert.Empty(t, r)
+
+	r, err = store.GetBaggageRestrictions("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage.BaggageRestriction{{BaggageKey: "request-id", MaxValueLength: 36}}, r)
+}
+
+func TestRestrictionStoreNoFile(t *testing.T) {
+	// without a file there are no restrictions to serve, rather than no baggage keys being allowed
+	store, err := NewRestrictionStore(Options{}, zap.NewNop())
+	assert.Error(t, err)
+	assert.Nil(t, store)
+}
+
+func TestRestrictionStoreErrors(t *testing.T) {
+	testCases := []struct {
+		content     string
+		expectedErr string
+	}{
+		{
+			content:     "{",
+			expectedErr: "Failed to parse the baggage restrictions: unexpected end of JSON input",
+		},
+		{
+			content:     `{"default_restrictions": [{"max_value_length": 1}]}`,
+			expectedErr: "Invalid default baggage restrictions: baggage key must not be empty",
+		},
+		{
+			content:     `{"default_restrictions": [{"baggage_key": "key", "max_value_length": -1}]}`,
+			expectedErr: `Invalid default baggage restrictions: max value length -1 of baggage key "key" is negative`,
+		},
+		{
+			content:     `{"service_restrictions": [{"service": "foo", "restrictions": [{"baggage_key": "key"}, {"baggage_key": "key"}]}]}`,
+			expectedErr: `Invalid baggage restrictions for service foo: duplicate baggage key "key"`,
+		},
+		{
+			content:     `{"service_restrictions": [{"service": "foo"}, {"service": "foo"}]}`,
+			expectedErr: "duplicate baggage restrictions for service foo",
+		},
+	}
+	for _, testCase := range testCases {
+		withRestrictionsFile(t, testCase.content, func(path string) {
+			_, err := NewRestrictionStore(Options{RestrictionsFile: path}, zap.NewNop())
+			assert.EqualError(t, err, testCase.expectedErr)
+		})
+	}
+
+	_, err := NewRestrictionStore(Options{RestrictionsFile: "fixtures/missing.json"}, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestRestrictionStoreReload(t *testing.T) {
+	withRestrictionsFile(t, `{"default_restrictions": [{"baggage_key": "key", "max_value_length": 1}]}`, func(path string) {
+		store, err := NewRestrictionStore(Options{RestrictionsFile: path, ReloadInterval: time.Millisecond}, zap.NewNop())
+		require.NoError(t, err)
+		defer store.Close()
+
+		expected := []*baggage.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 1}}
+		r, err := store.GetBaggageRestrictions("foo")
+		require.NoError(t, err)
+		assert.Equal(t, expected, r)
+
+		// an invalid file keeps the previously loaded restrictions
+		require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+		time.Sleep(10 * time.Millisecond)
+		r, err = store.GetBaggageRestrictions("foo")
+		require.NoError(t, err)
+		assert.Equal(t, expected, r)
+
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"default_restrictions": [{"baggage_key": "key", "max_value_length": 2}]}`), 0644))
+		for i := 0; i < 100; i++ {
+			if r, _ = store.GetBaggageRestrictions("foo"); r[0].MaxValueLength == 2 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, []*baggage.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 2}}, r)
+	})
+}
+
+func withRestrictionsFile(t *testing.T, content string, f func(path string)) {
+	dir, err := ioutil.TempDir("", "restrictions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "restrictions.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	f(path)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptive
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	jModel "github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/storage/samplingstore"
+)
+
+const (
+	samplerTypeKey           = "sampler.type"
+	samplerParamKey          = "sampler.param"
+	samplerTypeProbabilistic = "probabilistic"
+)
+
+// Aggregator counts the root spans sampled by probabilistic samplers per service and operation,
+// and flushes the counts to the sampling store at every multiple of the interval, so that all the
+// collectors flush at about the same time.
+type Aggregator struct {
+	sync.Mutex
+
+	storage    samplingstore.Store
+	logger     *zap.Logger
+	interval   time.Duration
+	throughput map[string]map[string]*model.Throughput
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewAggregator creates an Aggregator that flushes throughput to storage every interval.
+func NewAggregator(storage samplingstore.Store, interval time.Duration, logger *zap.Logger) *Aggregator {
+	a := &Aggregator{
+		storage:    storage,
+		logger:     logger,
+		interval:   interval,
+		throughput: make(map[string]map[string]*model.Throughput),
+		stop:       make(chan struct{}),
+	}
+	a.done.Add(1)
+	go a.flushLoop()
+	return a
+}
+
+// HandleSpans records the throughput of the root spans among the given spans.
+// It can be used as the span processor's PreProcessSpans hook.
+func (a *Aggregator) HandleSpans(spans []*jModel.Span) {
+	for _, span := range spans {
+		if span.ParentSpanID != 0 || span.Process == nil {
+			continue
+		}
+		probability, ok := samplingProbability(span)
+		if !ok {
+			continue
+		}
+		a.RecordThroughput(span.Process.ServiceName, span.OperationName, probability)
+	}
+}
+
+// RecordThroughput counts one trace of the given operation sampled with the given probability.
+func (a *Aggregator) RecordThroughput(service, operation string, probability float64) {
+	a.Lock()
+	defer a.Unlock()
+	operations, ok := a.throughput[service]
+	if !ok {
+		operations = make(map[string]*model.Throughput)
+		a.throughput[service] = operations
+	}
+	throughput, ok := operations[operation]
+	if !ok {
+		throughput = &model.Throughput{
+			Service:       service,
+			Operation:     operation,
+			Probabilities: make(map[string]struct{}),
+		}
+		operations[operation] = throughput
+	}
+	throughput.Count++
+	throughput.Probabilities[strconv.FormatFloat(probability, 'g', -1, 64)] = struct{}{}
+}
+
+// Close stops the aggregator after flushing the pending throughput.
+func (a *Aggregator) Close() error {
+	close(a.stop)
+	a.done.Wait()
+	return nil
+}
+
+func (a *Aggregator) flushLoop() {
+	defer a.done.Done()
+	for {
+		timer := time.NewTimer(untilNextFlush(time.Now(), a.interval))
+		select {
+		case <-timer.C:
+			a.flush()
+		case <-a.stop:
+			timer.Stop()
+			a.flush()
+			return
+		}
+	}
+}
+
+// untilNextFlush returns the time until the next multiple of the interval.
+func untilNextFlush(now time.Time, interval time.Duration) time.Duration {
+	return now.Truncate(interval).Add(interval).Sub(now)
+}
+
+func (a *Aggregator) flush() {
+	a.Lock()
+	current := a.throughput
+	a.throughput = make(map[string]map[string]*model.Throughput)
+	a.Unlock()
+
+	var throughput []*model.Throughput
+	for _, operations := range current {
+		for _, t := range operations {
+			throughput = append(throughput, t)
+		}
+	}
+	if len(throughput) == 0 {
+		return
+	}
+	if err := a.storage.InsertThroughput(throughput); err != nil {
+		a.logger.Error("Failed to save throughput", zap.Error(err))
+	}
+}
+
+// samplingProbability returns the probability the span's trace was sampled with,
+// if it was sampled by a probabilistic sampler.
+func samplingProbability(span *jModel.Span) (float64, bool) {
+	samplerType, ok := span.Tags.FindByKey(samplerTypeKey)
+	if !ok || samplerType.AsString() != samplerTypeProbabilistic {
+		return 0, false
+	}
+	samplerParam, ok := span.Tags.FindByKey(samplerParamKey)
+	if !ok {
+		return 0, false
+	}
+	if samplerParam.VType == jModel.Float64Type {
+		return samplerParam.Float64(), true
+	}
+	probability, err := strconv.ParseFloat(samplerParam.AsString(), 64)
+	return probability, err == nil
+}
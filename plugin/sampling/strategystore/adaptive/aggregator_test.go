@@ -0,0 +1,107 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptive
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	jModel "github.com/uber/jaeger/model"
+	smocks "github.com/uber/jaeger/storage/samplingstore/mocks"
+)
+
+func makeSpan(service, operation string, parentID jModel.SpanID, tags ...jModel.KeyValue) *jModel.Span {
+	return &jModel.Span{
+		ParentSpanID:  parentID,
+		OperationName: operation,
+		Tags:          tags,
+		Process:       jModel.NewProcess(service, nil),
+	}
+}
+
+func TestAggregatorHandleSpans(t *testing.T) {
+	store := &smocks.Store{}
+	var stored []*model.Throughput
+	store.On("InsertThroughput", mock.AnythingOfType("[]*model.Throughput")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(0).([]*model.Throughput)
+		}).
+		Return(nil)
+
+	a := NewAggregator(store, time.Hour, zap.NewNop())
+	probabilistic := jModel.String(samplerTypeKey, samplerTypeProbabilistic)
+	a.HandleSpans([]*jModel.Span{
+		makeSpan("svc", "GET", 0, probabilistic, jModel.Float64(samplerParamKey, 0.001)),
+		makeSpan("svc", "GET", 0, probabilistic, jModel.String(samplerParamKey, "0.01")),
+		// child spans, spans sampled by other samplers and malformed spans are ignored
+		makeSpan("svc", "GET", 1, probabilistic, jModel.Float64(samplerParamKey, 0.001)),
+		makeSpan("svc", "GET", 0, jModel.String(samplerTypeKey, "ratelimiting"), jModel.Float64(samplerParamKey, 2)),
+		makeSpan("svc", "GET", 0, probabilistic),
+		makeSpan("svc", "GET", 0, probabilistic, jModel.String(samplerParamKey, "x")),
+		makeSpan("svc", "GET", 0),
+		{OperationName: "GET"},
+	})
+	require.NoError(t, a.Close())
+
+	assert.Equal(t, []*model.Throughput{
+		{
+			Service:       "svc",
+			Operation:     "GET",
+			Count:         2,
+			Probabilities: map[string]struct{}{"0.001": {}, "0.01": {}},
+		},
+	}, stored)
+	store.AssertNumberOfCalls(t, "InsertThroughput", 1)
+}
+
+func TestAggregatorFlush(t *testing.T) {
+	store := &smocks.Store{}
+	flushed := make(chan struct{}, 10)
+	store.On("InsertThroughput", mock.AnythingOfType("[]*model.Throughput")).
+		Run(func(args mock.Arguments) {
+			flushed <- struct{}{}
+		}).
+		Return(errors.New("storage error"))
+
+	a := NewAggregator(store, time.Millisecond, zap.NewNop())
+	a.RecordThroughput("svc", "GET", 0.1)
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("throughput was not flushed")
+	}
+	// nothing is left to flush on close
+	require.NoError(t, a.Close())
+	store.AssertNumberOfCalls(t, "InsertThroughput", 1)
+}
+
+func TestUntilNextFlush(t *testing.T) {
+	assert.Equal(t, 7*time.Second, untilNextFlush(time.Unix(1003, 0), 10*time.Second))
+	assert.Equal(t, 10*time.Second, untilNextFlush(time.Unix(1000, 0), 10*time.Second))
+	assert.Equal(t, time.Millisecond, untilNextFlush(time.Unix(1009, int64(999*time.Millisecond)), 10*time.Second))
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptive
+
+import "time"
+
+const (
+	defaultTargetTracesPerSecond      = 1.0
+	defaultCalculationInterval        = time.Minute
+	defaultAggregationBuckets         = 3
+	defaultSamplingProbability        = 0.001
+	defaultMinSamplingProbability     = 0.00001
+	defaultLowerBoundTracesPerSecond  = 1.0 / float64(time.Minute/time.Second)
+	defaultMaxSamplingProbabilityGain = 2.0
+)
+
+// Options holds configuration for the adaptive sampling strategy store.
+type Options struct {
+	// TargetTracesPerSecond is the number of traces per second each operation should be sampled at
+	TargetTracesPerSecond float64
+	// CalculationInterval is how often throughput is flushed and probabilities are recalculated
+	CalculationInterval time.Duration
+	// AggregationBuckets is the number of calculation intervals the throughput is averaged over
+	AggregationBuckets int
+	// DefaultSamplingProbability is the probability used for operations without enough data
+	DefaultSamplingProbability float64
+	// MinSamplingProbability is the lowest probability an operation can be sampled at
+	MinSamplingProbability float64
+	// LowerBoundTracesPerSecond guarantees a minimum rate of sampled traces for rare operations
+	LowerBoundTracesPerSecond float64
+}
+
+// DefaultOptions returns the default adaptive sampling options.
+func DefaultOptions() Options {
+	return Options{
+		TargetTracesPerSecond:      defaultTargetTracesPerSecond,
+		CalculationInterval:        defaultCalculationInterval,
+		AggregationBuckets:         defaultAggregationBuckets,
+		DefaultSamplingProbability: defaultSamplingProbability,
+		MinSamplingProbability:     defaultMinSamplingProbability,
+		LowerBoundTracesPerSecond:  defaultLowerBoundTracesPerSecond,
+	}
+}
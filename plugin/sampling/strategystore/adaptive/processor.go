@@ -0,0 +1,318 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptive
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	"github.com/uber/jaeger/pkg/distributedlock"
+	"github.com/uber/jaeger/storage/samplingstore"
+	"github.com/uber/jaeger/thrift-gen/sampling"
+)
+
+const (
+	// samplingLock is the resource that collectors compete for to become the leader
+	// that calculates the sampling probabilities.
+	samplingLock = "sampling_lock"
+)
+
+// Processor calculates per-operation sampling probabilities that aim to sample every operation
+// at TargetTracesPerSecond. Only the collector holding the sampling lock calculates the probabilities
+// from the throughput aggregated by all collectors, the others load the results from storage.
+type Processor struct {
+	sync.RWMutex
+	Options
+
+	storage  samplingstore.Store
+	lock     distributedlock.Lock
+	hostname string
+	logger   *zap.Logger
+
+	probabilities     model.ServiceOperationProbabilities
+	strategyResponses map[string]*sampling.SamplingStrategyResponse
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewProcessor creates a new sampling processor that generates sampling strategies.
+func NewProcessor(
+	options Options,
+	hostname string,
+	storage samplingstore.Store,
+	lock distributedlock.Lock,
+	logger *zap.Logger,
+) *Processor {
+	return &Processor{
+		Options:           options,
+		storage:           storage,
+		lock:              lock,
+		hostname:          hostname,
+		logger:            logger,
+		probabilities:     make(model.ServiceOperationProbabilities),
+		strategyResponses: make(map[string]*sampling.SamplingStrategyResponse),
+		stop:              make(chan struct{}),
+	}
+}
+
+// Start loads the latest probabilities from storage and starts recalculating them every CalculationInterval.
+func (p *Processor) Start() error {
+	if err := p.loadProbabilities(); err != nil {
+		return err
+	}
+	p.done.Add(1)
+	go p.runCalculationLoop()
+	return nil
+}
+
+// Close stops the processor.
+func (p *Processor) Close() error {
+	close(p.stop)
+	p.done.Wait()
+	return nil
+}
+
+// GetSamplingStrategy implements StrategyStore#GetSamplingStrategy.
+func (p *Processor) GetSamplingStrategy(serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if strategy, ok := p.strategyResponses[serviceName]; ok {
+		return strategy, nil
+	}
+	return p.generateDefaultStrategyResponse(), nil
+}
+
+func (p *Processor) runCalculationLoop() {
+	defer p.done.Done()
+	ticker := time.NewTicker(p.CalculationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.runCalculation(time.Now())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// runCalculation recalculates the probabilities if this host is the leader, otherwise
+// it loads the probabilities calculated by the leader.
+func (p *Processor) runCalculation(now time.Time) {
+	// The lease outlives one interval so that the leader keeps it across consecutive calculations
+	isLeader, err := p.lock.Acquire(samplingLock, 2*p.CalculationInterval)
+	if err != nil {
+		p.logger.Error("Failed to acquire the sampling lock", zap.Error(err))
+		return
+	}
+	if !isLeader {
+		if err := p.loadProbabilities(); err != nil {
+			p.logger.Error("Failed to load the sampling probabilities", zap.Error(err))
+		}
+		return
+	}
+	start, end := p.throughputWindow(now)
+	throughput, err := p.storage.GetThroughput(start, end)
+	if err != nil {
+		p.logger.Error("Failed to get the throughput", zap.Error(err))
+		return
+	}
+	qps := calculateQPS(throughput, end.Sub(start))
+
+	p.RLock()
+	probabilities := p.calculateProbabilities(qps, recordedProbabilities(throughput))
+	p.RUnlock()
+
+	p.setProbabilities(probabilities)
+	if err := p.storage.InsertProbabilitiesAndQPS(p.hostname, probabilities, qps); err != nil {
+		p.logger.Error("Failed to save the sampling probabilities", zap.Error(err))
+	}
+}
+
+// throughputWindow returns the range of the throughput the probabilities are calculated from. The
+// aggregators flush at the multiples of the interval, so the range starts and ends half an interval
+// away from them: it holds exactly AggregationBuckets flushes of every collector, even if the flushes
+// are late or the clocks of the collectors differ by less than half an interval. The range ends before
+// the latest flush, which may not have happened yet on every collector.
+func (p *Processor) throughputWindow(now time.Time) (time.Time, time.Time) {
+	buckets := p.AggregationBuckets
+	if buckets < 1 {
+		buckets = 1
+	}
+	end := now.Truncate(p.CalculationInterval).Add(-p.CalculationInterval / 2)
+	return end.Add(-time.Duration(buckets) * p.CalculationInterval), end
+}
+
+func (p *Processor) loadProbabilities() error {
+	probabilities, err := p.storage.GetLatestProbabilities()
+	if err != nil {
+		return err
+	}
+	p.setProbabilities(probabilities)
+	return nil
+}
+
+func (p *Processor) setProbabilities(probabilities model.ServiceOperationProbabilities) {
+	strategyResponses := make(map[string]*sampling.SamplingStrategyResponse, len(probabilities))
+	for service, operations := range probabilities {
+		strategyResponses[service] = p.generateStrategyResponse(operations)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.probabilities = probabilities
+	p.strategyResponses = strategyResponses
+}
+
+// calculateQPS returns the sampled traces per second of every operation.
+func calculateQPS(throughput []*model.Throughput, interval time.Duration) model.ServiceOperationQPS {
+	counts := make(map[string]map[string]int64)
+	for _, t := range throughput {
+		if _, ok := counts[t.Service]; !ok {
+			counts[t.Service] = make(map[string]int64)
+		}
+		counts[t.Service][t.Operation] += t.Count
+	}
+	qps := make(model.ServiceOperationQPS, len(counts))
+	for service, operations := range counts {
+		qps[service] = make(map[string]float64, len(operations))
+		for operation, count := range operations {
+			qps[service][operation] = float64(count) / interval.Seconds()
+		}
+	}
+	return qps
+}
+
+// recordedProbabilities returns the mean of the probabilities every operation was sampled with,
+// as recorded by the clients in the throughput.
+func recordedProbabilities(throughput []*model.Throughput) model.ServiceOperationProbabilities {
+	values := make(map[string]map[string]map[string]struct{})
+	for _, t := range throughput {
+		if _, ok := values[t.Service]; !ok {
+			values[t.Service] = make(map[string]map[string]struct{})
+		}
+		if _, ok := values[t.Service][t.Operation]; !ok {
+			values[t.Service][t.Operation] = make(map[string]struct{})
+		}
+		for value := range t.Probabilities {
+			values[t.Service][t.Operation][value] = struct{}{}
+		}
+	}
+	probabilities := make(model.ServiceOperationProbabilities, len(values))
+	for service, operations := range values {
+		for operation, recorded := range operations {
+			sum, count := 0.0, 0
+			for value := range recorded {
+				probability, err := strconv.ParseFloat(value, 64)
+				if err != nil || probability <= 0 || probability > 1 {
+					continue
+				}
+				sum += probability
+				count++
+			}
+			if count == 0 {
+				continue
+			}
+			if _, ok := probabilities[service]; !ok {
+				probabilities[service] = make(map[string]float64)
+			}
+			probabilities[service][operation] = sum / float64(count)
+		}
+	}
+	return probabilities
+}
+
+// calculateProbabilities scales the current probability of every operation by the ratio between the
+// target and the measured qps. Operations without throughput keep their current probability. New
+// operations start from the probability recorded in their throughput, or the default probability.
+func (p *Processor) calculateProbabilities(
+	qps model.ServiceOperationQPS,
+	recorded model.ServiceOperationProbabilities,
+) model.ServiceOperationProbabilities {
+	probabilities := make(model.ServiceOperationProbabilities, len(p.probabilities))
+	for service, operations := range p.probabilities {
+		probabilities[service] = make(map[string]float64, len(operations))
+		for operation, probability := range operations {
+			probabilities[service][operation] = probability
+		}
+	}
+	for service, operations := range qps {
+		if _, ok := probabilities[service]; !ok {
+			probabilities[service] = make(map[string]float64, len(operations))
+		}
+		for operation, operationQPS := range operations {
+			current, ok := probabilities[service][operation]
+			if !ok {
+				current, ok = recorded[service][operation]
+			}
+			if !ok {
+				current = p.DefaultSamplingProbability
+			}
+			probabilities[service][operation] = p.calculateProbability(current, operationQPS)
+		}
+	}
+	return probabilities
+}
+
+func (p *Processor) calculateProbability(current, qps float64) float64 {
+	if qps == 0 {
+		return current
+	}
+	probability := current * p.TargetTracesPerSecond / qps
+	// Limit how fast the probability grows so that a quiet interval does not cause a burst of traces
+	probability = math.Min(probability, current*defaultMaxSamplingProbabilityGain)
+	return math.Min(1, math.Max(p.MinSamplingProbability, probability))
+}
+
+func (p *Processor) generateStrategyResponse(operations map[string]float64) *sampling.SamplingStrategyResponse {
+	response := p.generateDefaultStrategyResponse()
+	names := make([]string, 0, len(operations))
+	for operation := range operations {
+		names = append(names, operation)
+	}
+	sort.Strings(names)
+	for _, operation := range names {
+		response.OperationSampling.PerOperationStrategies = append(response.OperationSampling.PerOperationStrategies,
+			&sampling.OperationSamplingStrategy{
+				Operation:             operation,
+				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: operations[operation]},
+			})
+	}
+	return response
+}
+
+func (p *Processor) generateDefaultStrategyResponse() *sampling.SamplingStrategyResponse {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: p.DefaultSamplingProbability},
+		OperationSampling: &sampling.PerOperationSamplingStrategies{
+			DefaultSamplingProbability:       p.DefaultSamplingProbability,
+			DefaultLowerBoundTracesPerSecond: p.LowerBoundTracesPerSecond,
+			PerOperationStrategies:           []*sampling.OperationSamplingStrategy{},
+		},
+	}
+}
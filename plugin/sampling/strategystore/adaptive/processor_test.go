@@ -0,0 +1,253 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package adaptive
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	lmocks "github.com/uber/jaeger/pkg/distributedlock/mocks"
+	smocks "github.com/uber/jaeger/storage/samplingstore/mocks"
+	"github.com/uber/jaeger/thrift-gen/sampling"
+)
+
+func testOptions() Options {
+	options := DefaultOptions()
+	options.CalculationInterval = 10 * time.Second
+	return options
+}
+
+func TestProcessorLeader(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := &smocks.Store{}
+	lock := &lmocks.Lock{}
+	lock.On("Acquire", samplingLock, 20*time.Second).Return(true, nil)
+	// three intervals of throughput ending half an interval before the latest flush
+	store.On("GetThroughput", now.Add(-35*time.Second), now.Add(-5*time.Second)).Return([]*model.Throughput{
+		{Service: "svc", Operation: "GET", Count: 30},
+		{Service: "svc", Operation: "GET", Count: 30},
+		{Service: "svc", Operation: "PUT", Count: 3},
+		{Service: "svc", Operation: "rare", Count: 3},
+	}, nil)
+	expectedQPS := model.ServiceOperationQPS{"svc": {"GET": 2, "PUT": 0.1, "rare": 0.1}}
+	expectedProbabilities := model.ServiceOperationProbabilities{
+		"svc": {
+			// 0.2 * 1 / 2
+			"GET": 0.1,
+			// growth is limited to twice the current probability
+			"PUT": 0.2,
+			// capped at 1
+			"rare": 1,
+			// operations without throughput keep their probability
+			"idle": 0.3,
+		},
+	}
+	store.On("InsertProbabilitiesAndQPS", "host", expectedProbabilities, expectedQPS).Return(nil)
+
+	p := NewProcessor(testOptions(), "host", store, lock, zap.NewNop())
+	p.setProbabilities(model.ServiceOperationProbabilities{"svc": {"GET": 0.2, "PUT": 0.1, "rare": 0.9, "idle": 0.3}})
+	p.runCalculation(now)
+	store.AssertExpectations(t)
+
+	s, err := p.GetSamplingStrategy("svc")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_PROBABILISTIC, s.StrategyType)
+	assert.Equal(t, defaultSamplingProbability, s.ProbabilisticSampling.SamplingRate)
+	assert.Equal(t, defaultSamplingProbability, s.OperationSampling.DefaultSamplingProbability)
+	assert.Equal(t, defaultLowerBoundTracesPerSecond, s.OperationSampling.DefaultLowerBoundTracesPerSecond)
+	assert.Equal(t, []*sampling.OperationSamplingStrategy{
+		makeOperationStrategy("GET", 0.1),
+		makeOperationStrategy("PUT", 0.2),
+		makeOperationStrategy("idle", 0.3),
+		makeOperationStrategy("rare", 1),
+	}, s.OperationSampling.PerOperationStrategies)
+}
+
+// flushingStore returns the throughput flushed by the collectors within the requested range
+type flushingStore struct {
+	smocks.Store
+	flushes map[time.Time]*model.Throughput
+}
+
+func (s *flushingStore) GetThroughput(start, end time.Time) ([]*model.Throughput, error) {
+	var throughput []*model.Throughput
+	for ts, t := range s.flushes {
+		if ts.After(start) && !ts.After(end) {
+			throughput = append(throughput, t)
+		}
+	}
+	return throughput, nil
+}
+
+func TestProcessorLeaderWithUnalignedFlushes(t *testing.T) {
+	now := time.Unix(1000, int64(50*time.Millisecond))
+	store := &flushingStore{flushes: make(map[time.Time]*model.Throughput)}
+	// two collectors flush 10 spans every 10 seconds: the first one is sometimes late and has not flushed
+	// at 1000s yet, the second one flushes 4.9 seconds after the multiples of the interval. A window of
+	// one interval ending now would only see a single flush of the second collector.
+	for _, ts := range []float64{990, 980.1, 970, 960.1, 994.9, 984.9, 974.9, 964.9} {
+		store.flushes[time.Unix(0, int64(ts*float64(time.Second)))] = &model.Throughput{Service: "svc", Operation: "GET", Count: 10}
+	}
+	store.On("InsertProbabilitiesAndQPS", "host", mock.Anything, model.ServiceOperationQPS{"svc": {"GET": 2}}).Return(nil)
+	lock := &lmocks.Lock{}
+	lock.On("Acquire", samplingLock, 20*time.Second).Return(true, nil)
+
+	p := NewProcessor(testOptions(), "host", store, lock, zap.NewNop())
+	p.runCalculation(now)
+	store.AssertExpectations(t)
+}
+
+func TestProcessorNewOperation(t *testing.T) {
+	p := NewProcessor(testOptions(), "host", nil, nil, zap.NewNop())
+	probabilities := p.calculateProbabilities(model.ServiceOperationQPS{"svc": {"GET": 0.0001}}, nil)
+	assert.Equal(t, model.ServiceOperationProbabilities{"svc": {"GET": 2 * defaultSamplingProbability}}, probabilities)
+
+	p.MinSamplingProbability = 0.01
+	probabilities = p.calculateProbabilities(model.ServiceOperationQPS{"svc": {"GET": 1000}}, nil)
+	assert.Equal(t, model.ServiceOperationProbabilities{"svc": {"GET": 0.01}}, probabilities)
+}
+
+func TestProcessorNewOperationRecordedProbability(t *testing.T) {
+	p := NewProcessor(testOptions(), "host", nil, nil, zap.NewNop())
+	throughput := []*model.Throughput{
+		{Service: "svc", Operation: "GET", Count: 10, Probabilities: map[string]struct{}{"0.5": {}}},
+		{Service: "svc", Operation: "GET", Count: 10, Probabilities: map[string]struct{}{"0.5": {}, "0.3": {}}},
+		{Service: "svc", Operation: "PUT", Count: 10, Probabilities: map[string]struct{}{"invalid": {}, "0": {}}},
+		{Service: "svc", Operation: "DELETE", Count: 10},
+	}
+	recorded := recordedProbabilities(throughput)
+	assert.Equal(t, model.ServiceOperationProbabilities{"svc": {"GET": 0.4}}, recorded)
+
+	// the clients sampled GET with 0.4 on average, which yields 40 traces per second
+	probabilities := p.calculateProbabilities(model.ServiceOperationQPS{"svc": {"GET": 40, "PUT": 40}}, recorded)
+	assert.InDelta(t, 0.01, probabilities["svc"]["GET"], 1e-9)
+	// without a valid recorded probability, the default one is scaled
+	assert.InDelta(t, defaultSamplingProbability/40, probabilities["svc"]["PUT"], 1e-12)
+}
+
+func TestProcessorFollower(t *testing.T) {
+	store := &smocks.Store{}
+	lock := &lmocks.Lock{}
+	lock.On("Acquire", samplingLock, 20*time.Second).Return(false, nil)
+	store.On("GetLatestProbabilities").Return(model.ServiceOperationProbabilities{"svc": {"GET": 0.5}}, nil)
+
+	p := NewProcessor(testOptions(), "host", store, lock, zap.NewNop())
+	p.runCalculation(time.Now())
+	store.AssertNotCalled(t, "GetThroughput", mock.Anything, mock.Anything)
+
+	s, err := p.GetSamplingStrategy("svc")
+	require.NoError(t, err)
+	assert.Equal(t, []*sampling.OperationSamplingStrategy{makeOperationStrategy("GET", 0.5)}, s.OperationSampling.PerOperationStrategies)
+
+	s, err = p.GetSamplingStrategy("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, p.generateDefaultStrategyResponse(), s)
+}
+
+func TestProcessorErrors(t *testing.T) {
+	testErr := errors.New("error")
+	testCases := []struct {
+		caption string
+		setup   func(store *smocks.Store, lock *lmocks.Lock)
+	}{
+		{
+			caption: "lock error",
+			setup: func(store *smocks.Store, lock *lmocks.Lock) {
+				lock.On("Acquire", samplingLock, mock.Anything).Return(false, testErr)
+			},
+		},
+		{
+			caption: "load error",
+			setup: func(store *smocks.Store, lock *lmocks.Lock) {
+				lock.On("Acquire", samplingLock, mock.Anything).Return(false, nil)
+				store.On("GetLatestProbabilities").Return(model.ServiceOperationProbabilities(nil), testErr)
+			},
+		},
+		{
+			caption: "throughput error",
+			setup: func(store *smocks.Store, lock *lmocks.Lock) {
+				lock.On("Acquire", samplingLock, mock.Anything).Return(true, nil)
+				store.On("GetThroughput", mock.Anything, mock.Anything).Return(nil, testErr)
+			},
+		},
+		{
+			caption: "insert error",
+			setup: func(store *smocks.Store, lock *lmocks.Lock) {
+				lock.On("Acquire", samplingLock, mock.Anything).Return(true, nil)
+				store.On("GetThroughput", mock.Anything, mock.Anything).Return([]*model.Throughput{}, nil)
+				store.On("InsertProbabilitiesAndQPS", "host", mock.Anything, mock.Anything).Return(testErr)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		store := &smocks.Store{}
+		lock := &lmocks.Lock{}
+		testCase.setup(store, lock)
+		p := NewProcessor(testOptions(), "host", store, lock, zap.NewNop())
+		p.setProbabilities(model.ServiceOperationProbabilities{"svc": {"GET": 0.5}})
+		p.runCalculation(time.Now())
+
+		s, err := p.GetSamplingStrategy("svc")
+		require.NoError(t, err, testCase.caption)
+		assert.Equal(t, []*sampling.OperationSamplingStrategy{makeOperationStrategy("GET", 0.5)}, s.OperationSampling.PerOperationStrategies, testCase.caption)
+	}
+}
+
+func TestProcessorStartAndClose(t *testing.T) {
+	store := &smocks.Store{}
+	lock := &lmocks.Lock{}
+	store.On("GetLatestProbabilities").Return(model.ServiceOperationProbabilities(nil), errors.New("error")).Once()
+	p := NewProcessor(testOptions(), "host", store, lock, zap.NewNop())
+	assert.EqualError(t, p.Start(), "error")
+
+	calculated := make(chan struct{}, 10)
+	lock.On("Acquire", samplingLock, 2*time.Millisecond).Return(false, nil)
+	store.On("GetLatestProbabilities").
+		Run(func(args mock.Arguments) {
+			calculated <- struct{}{}
+		}).
+		Return(model.ServiceOperationProbabilities{}, nil)
+	options := testOptions()
+	options.CalculationInterval = time.Millisecond
+	p = NewProcessor(options, "host", store, lock, zap.NewNop())
+	require.NoError(t, p.Start())
+	<-calculated
+	select {
+	case <-calculated:
+	case <-time.After(time.Second):
+		t.Fatal("probabilities were not recalculated")
+	}
+	require.NoError(t, p.Close())
+}
+
+func makeOperationStrategy(operation string, probability float64) *sampling.OperationSamplingStrategy {
+	return &sampling.OperationSamplingStrategy{
+		Operation:             operation,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: probability},
+	}
+}
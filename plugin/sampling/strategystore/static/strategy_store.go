@@ -0,0 +1,187 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package static
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/pkg/filewatcher"
+	"github.com/uber/jaeger/thrift-gen/sampling"
+)
+
+// Store is a sampling strategy store backed by a JSON file. If a reload interval is
+// configured, the file is periodically re-read and the strategies are replaced when it changes.
+type Store struct {
+	logger  *zap.Logger
+	options Options
+
+	sync.RWMutex
+	defaultStrategy   *sampling.SamplingStrategyResponse
+	serviceStrategies map[string]*sampling.SamplingStrategyResponse
+
+	watcher *filewatcher.Watcher
+}
+
+// NewStrategyStore creates a strategy store that holds static sampling strategies.
+func NewStrategyStore(options Options, logger *zap.Logger) (*Store, error) {
+	s := &Store{
+		logger:            logger,
+		options:           options,
+		defaultStrategy:   defaultStrategyResponse(),
+		serviceStrategies: make(map[string]*sampling.SamplingStrategyResponse),
+	}
+	if options.StrategiesFile == "" {
+		logger.Info("No sampling strategies file provided, using the default sampling strategy")
+		return s, nil
+	}
+	watcher, err := filewatcher.New(options.StrategiesFile, "sampling strategies", options.ReloadInterval, s.load, logger)
+	if err != nil {
+		return nil, err
+	}
+	s.watcher = watcher
+	return s, nil
+}
+
+// GetSamplingStrategy implements StrategyStore#GetSamplingStrategy.
+func (s *Store) GetSamplingStrategy(serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if strategy, ok := s.serviceStrategies[serviceName]; ok {
+		return strategy, nil
+	}
+	return s.defaultStrategy, nil
+}
+
+// Close stops reloading the strategies file.
+func (s *Store) Close() error {
+	if s.watcher == nil {
+		return nil
+	}
+	return s.watcher.Close()
+}
+
+func (s *Store) load(content []byte) error {
+	var strategies strategies
+	if err := json.Unmarshal(content, &strategies); err != nil {
+		return errors.Wrap(err, "Failed to parse the sampling strategies")
+	}
+	defaultStrategy, serviceStrategies, err := s.parseStrategies(&strategies)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	s.defaultStrategy = defaultStrategy
+	s.serviceStrategies = serviceStrategies
+	s.Unlock()
+	return nil
+}
+
+func (s *Store) parseStrategies(strategies *strategies) (*sampling.SamplingStrategyResponse, map[string]*sampling.SamplingStrategyResponse, error) {
+	defaultStrategy := defaultStrategyResponse()
+	if strategies.DefaultStrategy != nil {
+		var err error
+		if defaultStrategy, err = parseStrategy(strategies.DefaultStrategy); err != nil {
+			return nil, nil, errors.Wrap(err, "Invalid default sampling strategy")
+		}
+	}
+	serviceStrategies := make(map[string]*sampling.SamplingStrategyResponse, len(strategies.ServiceStrategies))
+	for _, st := range strategies.ServiceStrategies {
+		response, err := parseStrategy(&st.strategy)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Invalid sampling strategy for service %s", st.Service)
+		}
+		if len(st.OperationStrategies) > 0 {
+			response.OperationSampling = s.parseOperationStrategies(st, response, defaultStrategy)
+		}
+		serviceStrategies[st.Service] = response
+	}
+	return defaultStrategy, serviceStrategies, nil
+}
+
+func (s *Store) parseOperationStrategies(
+	st *serviceStrategy,
+	serviceResponse *sampling.SamplingStrategyResponse,
+	defaultStrategy *sampling.SamplingStrategyResponse,
+) *sampling.PerOperationSamplingStrategies {
+	defaultProbability := defaultSamplingProbability
+	if serviceResponse.ProbabilisticSampling != nil {
+		defaultProbability = serviceResponse.ProbabilisticSampling.SamplingRate
+	} else if defaultStrategy.ProbabilisticSampling != nil {
+		defaultProbability = defaultStrategy.ProbabilisticSampling.SamplingRate
+	}
+	operationStrategies := make([]*sampling.OperationSamplingStrategy, 0, len(st.OperationStrategies))
+	for _, op := range st.OperationStrategies {
+		response, err := parseStrategy(&op.strategy)
+		if err != nil || response.ProbabilisticSampling == nil {
+			// Only probabilistic strategies can be expressed per operation
+			s.logger.Warn("Ignoring operation sampling strategy",
+				zap.String("service", st.Service),
+				zap.String("operation", op.Operation),
+				zap.String("type", op.Type),
+				zap.Error(err))
+			continue
+		}
+		operationStrategies = append(operationStrategies, &sampling.OperationSamplingStrategy{
+			Operation:             op.Operation,
+			ProbabilisticSampling: response.ProbabilisticSampling,
+		})
+	}
+	return &sampling.PerOperationSamplingStrategies{
+		DefaultSamplingProbability: defaultProbability,
+		PerOperationStrategies:     operationStrategies,
+	}
+}
+
+func parseStrategy(strategy *strategy) (*sampling.SamplingStrategyResponse, error) {
+	switch strategy.Type {
+	case samplerTypeProbabilistic:
+		if strategy.Param < 0 || strategy.Param > 1 {
+			return nil, fmt.Errorf("sampling probability %v is not within [0, 1]", strategy.Param)
+		}
+		return &sampling.SamplingStrategyResponse{
+			StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+			ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: strategy.Param},
+		}, nil
+	case samplerTypeRateLimiting:
+		if strategy.Param < 0 || strategy.Param > math.MaxInt16 {
+			return nil, fmt.Errorf("max traces per second %v is not within [0, %d]", strategy.Param, math.MaxInt16)
+		}
+		return &sampling.SamplingStrategyResponse{
+			StrategyType:         sampling.SamplingStrategyType_RATE_LIMITING,
+			RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: int16(strategy.Param)},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sampling strategy type %q", strategy.Type)
+	}
+}
+
+func defaultStrategyResponse() *sampling.SamplingStrategyResponse {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: defaultSamplingProbability},
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package static
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/thrift-gen/sampling"
+)
+
+func TestStrategyStore(t *testing.T) {
+	store, err := NewStrategyStore(Options{StrategiesFile: "fixtures/strategies.json"}, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	s, err := store.GetSamplingStrategy("foo")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_PROBABILISTIC, s.StrategyType)
+	assert.Equal(t, 0.8, s.ProbabilisticSampling.SamplingRate)
+	assert.Equal(t, &sampling.PerOperationSamplingStrategies{
+		DefaultSamplingProbability: 0.8,
+		PerOperationStrategies: []*sampling.OperationSamplingStrategy{
+			{
+				Operation:             "op1",
+				ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: 0.2},
+			},
+		},
+	}, s.OperationSampling)
+
+	s, err = store.GetSamplingStrategy("bar")
+	require.NoError(t, err)
+	assert.Equal(t, &sampling.SamplingStrategyResponse{
+		StrategyType:         sampling.SamplingStrategyType_RATE_LIMITING,
+		RateLimitingSampling: &sampling.RateLimitingSamplingStrategy{MaxTracesPerSecond: 5},
+	}, s)
+
+	s, err = store.GetSamplingStrategy("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, makeProbabilisticResponse(0.5), s)
+}
+
+func TestStrategyStoreNoFile(t *testing.T) {
+	store, err := NewStrategyStore(Options{}, zap.NewNop())
+	require.NoError(t, err)
+	defer store.Close()
+
+	s, err := store.GetSamplingStrategy("foo")
+	require.NoError(t, err)
+	assert.Equal(t, makeProbabilisticResponse(defaultSamplingProbability), s)
+}
+
+func TestStrategyStoreErrors(t *testing.T) {
+	testCases := []struct {
+		content     string
+		expectedErr string
+	}{
+		{
+			content:     "{",
+			expectedErr: "Failed to parse the sampling strategies: unexpected end of JSON input",
+		},
+		{
+			content:     `{"default_strategy": {"type": "constant", "param": 1}}`,
+			expectedErr: `Invalid default sampling strategy: unknown sampling strategy type "constant"`,
+		},
+		{
+			content:     `{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 2}]}`,
+			expectedErr: "Invalid sampling strategy for service foo: sampling probability 2 is not within [0, 1]",
+		},
+		{
+			content:     `{"service_strategies": [{"service": "foo", "type": "ratelimiting", "param": -1}]}`,
+			expectedErr: "Invalid sampling strategy for service foo: max traces per second -1 is not within [0, 32767]",
+		},
+	}
+	for _, testCase := range testCases {
+		withStrategiesFile(t, testCase.content, func(path string) {
+			_, err := NewStrategyStore(Options{StrategiesFile: path}, zap.NewNop())
+			assert.EqualError(t, err, testCase.expectedErr)
+		})
+	}
+
+	_, err := NewStrategyStore(Options{StrategiesFile: "fixtures/missing.json"}, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestStrategyStoreReload(t *testing.T) {
+	withStrategiesFile(t, `{"default_strategy": {"type": "probabilistic", "param": 0.1}}`, func(path string) {
+		store, err := NewStrategyStore(Options{StrategiesFile: path, ReloadInterval: time.Millisecond}, zap.NewNop())
+		require.NoError(t, err)
+		defer store.Close()
+
+		s, err := store.GetSamplingStrategy("foo")
+		require.NoError(t, err)
+		assert.Equal(t, makeProbabilisticResponse(0.1), s)
+
+		// an invalid file keeps the previously loaded strategies
+		require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+		time.Sleep(10 * time.Millisecond)
+		s, err = store.GetSamplingStrategy("foo")
+		require.NoError(t, err)
+		assert.Equal(t, makeProbabilisticResponse(0.1), s)
+
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"default_strategy": {"type": "probabilistic", "param": 0.2}}`), 0644))
+		for i := 0; i < 100; i++ {
+			if s, _ = store.GetSamplingStrategy("foo"); s.ProbabilisticSampling.SamplingRate == 0.2 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, makeProbabilisticResponse(0.2), s)
+	})
+}
+
+func withStrategiesFile(t *testing.T, content string, f func(path string)) {
+	dir, err := ioutil.TempDir("", "strategies")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "strategies.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	f(path)
+}
+
+func makeProbabilisticResponse(rate float64) *sampling.SamplingStrategyResponse {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: rate},
+	}
+}
@@ -21,6 +21,7 @@
 package dependencystore
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -76,7 +77,16 @@ func (s *DependencyStore) WriteDependencies(ts time.Time, dependencies []model.D
 
 // GetDependencies returns all interservice dependencies
 func (s *DependencyStore) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
-	query := s.session.Query(depsSelectStmt, endTs.Add(-1*lookback), endTs)
+	return s.GetDependenciesContext(context.Background(), endTs, lookback)
+}
+
+// GetDependenciesContext is GetDependencies bound to ctx
+func (s *DependencyStore) GetDependenciesContext(
+	ctx context.Context,
+	endTs time.Time,
+	lookback time.Duration,
+) ([]model.DependencyLink, error) {
+	query := cassandra.WithContext(ctx, s.session.Query(depsSelectStmt, endTs.Add(-1*lookback), endTs))
 	iter := query.Consistency(cassandra.One).Iter()
 
 	var mDependency []model.DependencyLink
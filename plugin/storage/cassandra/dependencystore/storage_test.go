@@ -21,6 +21,7 @@
 package dependencystore
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
@@ -59,8 +61,8 @@ func withDepStore(fn func(s *depStorageTest)) {
 	fn(s)
 }
 
-var _ dependencystore.Reader = &DependencyStore{} // check API conformance
-var _ dependencystore.Writer = &DependencyStore{} // check API conformance
+var _ dependencystore.ContextReader = &DependencyStore{} // check API conformance
+var _ dependencystore.Writer = &DependencyStore{}        // check API conformance
 
 func TestDependencyStoreWrite(t *testing.T) {
 	withDepStore(func(s *depStorageTest) {
@@ -198,6 +200,28 @@ func TestDependencyStoreGetDependencies(t *testing.T) {
 	}
 }
 
+func TestDependencyStoreGetDependenciesContext(t *testing.T) {
+	withDepStore(func(s *depStorageTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		iter := &mocks.Iterator{}
+		iter.On("Scan", matchEverything()).Return(false)
+		iter.On("Close").Return(nil)
+
+		query := &mocks.Query{}
+		query.On("WithContext", ctx).Return(query)
+		query.On("Consistency", cassandra.One).Return(query)
+		query.On("Iter").Return(iter)
+
+		s.session.On("Query", mock.AnythingOfType("string"), matchEverything()).Return(query)
+
+		_, err := s.storage.GetDependenciesContext(ctx, time.Now(), 48*time.Hour)
+		require.NoError(t, err)
+		query.AssertCalled(t, "WithContext", ctx)
+	})
+}
+
 func TestDependencyStoreTimeIntervalToPoints(t *testing.T) {
 	withDepStore(func(s *depStorageTest) {
 		for _, truncate := range []bool{false, true} {
@@ -22,6 +22,7 @@ package samplingstore
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"io"
 	"strconv"
@@ -77,14 +78,28 @@ func New(session cassandra.Session, factory metrics.Factory, logger *zap.Logger)
 
 // InsertThroughput implements samplingstore.Writer#InsertThroughput.
 func (s *SamplingStore) InsertThroughput(throughput []*model.Throughput) error {
+	return s.InsertThroughputContext(context.Background(), throughput)
+}
+
+// InsertThroughputContext implements samplingstore.ContextStore#InsertThroughputContext.
+func (s *SamplingStore) InsertThroughputContext(ctx context.Context, throughput []*model.Throughput) error {
 	throughputStr := throughputToString(throughput)
-	query := s.session.Query(insertThroughput, generateRandomBucket(), gocql.TimeUUID(), throughputStr)
+	query := cassandra.WithContext(
+		ctx,
+		s.session.Query(insertThroughput, generateRandomBucket(), gocql.TimeUUID(), throughputStr),
+	)
 	return s.metrics.operationThroughput.Exec(query, s.logger)
 }
 
 // GetThroughput implements samplingstore.Reader#GetThroughput.
 func (s *SamplingStore) GetThroughput(start, end time.Time) ([]*model.Throughput, error) {
-	iter := s.session.Query(getThroughput, gocql.UUIDFromTime(start), gocql.UUIDFromTime(end)).Iter()
+	return s.GetThroughputContext(context.Background(), start, end)
+}
+
+// GetThroughputContext implements samplingstore.ContextStore#GetThroughputContext.
+func (s *SamplingStore) GetThroughputContext(ctx context.Context, start, end time.Time) ([]*model.Throughput, error) {
+	query := s.session.Query(getThroughput, gocql.UUIDFromTime(start), gocql.UUIDFromTime(end))
+	iter := cassandra.WithContext(ctx, query).Iter()
 	var throughput []*model.Throughput
 	var throughputStr string
 	for iter.Scan(&throughputStr) {
@@ -102,15 +117,33 @@ func (s *SamplingStore) InsertProbabilitiesAndQPS(
 	hostname string,
 	probabilities model.ServiceOperationProbabilities,
 	qps model.ServiceOperationQPS,
+) error {
+	return s.InsertProbabilitiesAndQPSContext(context.Background(), hostname, probabilities, qps)
+}
+
+// InsertProbabilitiesAndQPSContext implements samplingstore.ContextStore#InsertProbabilitiesAndQPSContext.
+func (s *SamplingStore) InsertProbabilitiesAndQPSContext(
+	ctx context.Context,
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
 ) error {
 	probabilitiesAndQPSStr := probabilitiesAndQPSToString(probabilities, qps)
-	query := s.session.Query(insertProbabilities, constBucket, gocql.TimeUUID(), hostname, probabilitiesAndQPSStr)
+	query := cassandra.WithContext(
+		ctx,
+		s.session.Query(insertProbabilities, constBucket, gocql.TimeUUID(), hostname, probabilitiesAndQPSStr),
+	)
 	return s.metrics.probabilities.Exec(query, s.logger)
 }
 
 // GetLatestProbabilities implements samplingstore.Reader#GetLatestProbabilities.
 func (s *SamplingStore) GetLatestProbabilities() (model.ServiceOperationProbabilities, error) {
-	iter := s.session.Query(getLatestProbabilities).Iter()
+	return s.GetLatestProbabilitiesContext(context.Background())
+}
+
+// GetLatestProbabilitiesContext implements samplingstore.ContextStore#GetLatestProbabilitiesContext.
+func (s *SamplingStore) GetLatestProbabilitiesContext(ctx context.Context) (model.ServiceOperationProbabilities, error) {
+	iter := cassandra.WithContext(ctx, s.session.Query(getLatestProbabilities)).Iter()
 	var probabilitiesStr string
 	iter.Scan(&probabilitiesStr)
 	if err := iter.Close(); err != nil {
@@ -122,7 +155,16 @@ func (s *SamplingStore) GetLatestProbabilities() (model.ServiceOperationProbabil
 
 // GetProbabilitiesAndQPS implements samplingstore.Reader#GetProbabilitiesAndQPS.
 func (s *SamplingStore) GetProbabilitiesAndQPS(start, end time.Time) (map[string][]model.ServiceOperationData, error) {
-	iter := s.session.Query(getProbabilities, gocql.UUIDFromTime(start), gocql.UUIDFromTime(end)).Iter()
+	return s.GetProbabilitiesAndQPSContext(context.Background(), start, end)
+}
+
+// GetProbabilitiesAndQPSContext implements samplingstore.ContextStore#GetProbabilitiesAndQPSContext.
+func (s *SamplingStore) GetProbabilitiesAndQPSContext(
+	ctx context.Context,
+	start, end time.Time,
+) (map[string][]model.ServiceOperationData, error) {
+	query := s.session.Query(getProbabilities, gocql.UUIDFromTime(start), gocql.UUIDFromTime(end))
+	iter := cassandra.WithContext(ctx, query).Iter()
 	hostProbabilitiesAndQPS := make(map[string][]model.ServiceOperationData)
 	var probabilitiesAndQPSStr, host string
 	for iter.Scan(&probabilitiesAndQPSStr, &host) {
@@ -21,6 +21,7 @@
 package samplingstore
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
@@ -61,7 +63,7 @@ func withSamplingStore(fn func(r *samplingStoreTest)) {
 	fn(r)
 }
 
-var _ samplingstore.Store = &SamplingStore{} // check API conformance
+var _ samplingstore.ContextStore = &SamplingStore{} // check API conformance
 
 func TestInsertThroughput(t *testing.T) {
 	withSamplingStore(func(s *samplingStoreTest) {
@@ -358,6 +360,34 @@ func TestGetLatestProbabilities(t *testing.T) {
 	}
 }
 
+func TestSamplingStoreContext(t *testing.T) {
+	withSamplingStore(func(s *samplingStoreTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		iter := &mocks.Iterator{}
+		iter.On("Scan", matchEverything()).Return(false)
+		iter.On("Close").Return(nil)
+
+		query := &mocks.Query{}
+		query.On("WithContext", ctx).Return(query)
+		query.On("Exec").Return(nil)
+		query.On("Iter").Return(iter)
+
+		s.session.On("Query", mock.AnythingOfType("string"), matchEverything()).Return(query)
+
+		require.NoError(t, s.store.InsertThroughputContext(ctx, []*model.Throughput{}))
+		require.NoError(t, s.store.InsertProbabilitiesAndQPSContext(ctx, "localhost", nil, nil))
+		_, err := s.store.GetThroughputContext(ctx, testTime, testTime)
+		require.NoError(t, err)
+		_, err = s.store.GetProbabilitiesAndQPSContext(ctx, testTime, testTime)
+		require.NoError(t, err)
+		_, err = s.store.GetLatestProbabilitiesContext(ctx)
+		require.NoError(t, err)
+		query.AssertNumberOfCalls(t, "WithContext", 5)
+	})
+}
+
 func matchEverything() interface{} {
 	return mock.MatchedBy(func(v []interface{}) bool { return true })
 }
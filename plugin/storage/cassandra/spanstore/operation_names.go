@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -88,7 +89,12 @@ func (s *OperationNamesStorage) Write(serviceName string, operationName string)
 
 // GetOperations returns all operations for a specific service traced by Jaeger
 func (s *OperationNamesStorage) GetOperations(service string) ([]string, error) {
-	iter := s.session.Query(s.QueryStmt, service).Iter()
+	return s.GetOperationsContext(context.Background(), service)
+}
+
+// GetOperationsContext is GetOperations bound to ctx
+func (s *OperationNamesStorage) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	iter := cassandra.WithContext(ctx, s.session.Query(s.QueryStmt, service)).Iter()
 
 	var operation string
 	var operations []string
@@ -21,6 +21,10 @@
 package spanstore
 
 import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -41,30 +45,32 @@ const (
 		FROM traces
 		WHERE trace_id = ?`
 	queryByTag = `
-		SELECT trace_id
+		SELECT trace_id, start_time
 		FROM tag_index
 		WHERE service_name = ? AND tag_key = ? AND tag_value = ? and start_time > ? and start_time < ?
 		ORDER BY start_time DESC
 		LIMIT ?`
 	queryByServiceName = `
-		SELECT trace_id
+		SELECT trace_id, start_time
 		FROM service_name_index
 		WHERE bucket IN ` + bucketRange + ` AND service_name = ? AND start_time > ? AND start_time < ?
 		ORDER BY start_time DESC
 		LIMIT ?`
 	queryByServiceAndOperationName = `
-		SELECT trace_id
+		SELECT trace_id, start_time
 		FROM service_operation_index
 		WHERE service_name = ? AND operation_name = ? AND start_time > ? AND start_time < ?
 		ORDER BY start_time DESC
 		LIMIT ?`
 	queryByDuration = `
-		SELECT trace_id
+		SELECT trace_id, start_time
 		FROM duration_index
 		WHERE bucket = ? AND service_name = ? AND operation_name = ? AND duration > ? AND duration < ?
 		LIMIT ?`
 
 	defaultNumTraces = 100
+	// defaultTraceReadWorkers is the number of traces FindTraces loads concurrently unless set by TraceReadWorkers
+	defaultTraceReadWorkers = 10
 	// limitMultiple exists because many spans that are returned from indices can have the same trace, limitMultiple increases
 	// the number of responses from the index, so we can respect the user's limit value they provided.
 	limitMultiple = 3
@@ -90,9 +96,9 @@ var (
 	ErrStartAndEndTimeNotSet = errors.New("Start and End Time must be set")
 )
 
-type serviceNamesReader func() ([]string, error)
+type serviceNamesReader func(ctx context.Context) ([]string, error)
 
-type operationNamesReader func(service string) ([]string, error)
+type operationNamesReader func(ctx context.Context, service string) ([]string, error)
 
 type spanReaderMetrics struct {
 	readTraces                 *casMetrics.Table
@@ -111,6 +117,25 @@ type SpanReader struct {
 	operationNamesReader operationNamesReader
 	metrics              spanReaderMetrics
 	logger               *zap.Logger
+	traceReadWorkers     int
+}
+
+// ReaderOption is a function that sets some option on the SpanReader
+type ReaderOption func(reader *SpanReader)
+
+// ReaderOptions is a factory for all available ReaderOptions
+var ReaderOptions readerOptions
+
+type readerOptions struct{}
+
+// TraceReadWorkers creates a ReaderOption that sets how many traces FindTraces loads concurrently.
+// Values below one keep the default.
+func (readerOptions) TraceReadWorkers(workers int) ReaderOption {
+	return func(reader *SpanReader) {
+		if workers > 0 {
+			reader.traceReadWorkers = workers
+		}
+	}
 }
 
 // NewSpanReader returns a new SpanReader.
@@ -118,15 +143,16 @@ func NewSpanReader(
 	session cassandra.Session,
 	metricsFactory metrics.Factory,
 	logger *zap.Logger,
+	options ...ReaderOption,
 ) *SpanReader {
 	readFactory := metricsFactory.Namespace("Read", nil)
 	serviceNamesStorage := NewServiceNamesStorage(session, 0, metricsFactory, logger)
 	operationNamesStorage := NewOperationNamesStorage(session, 0, metricsFactory, logger)
-	return &SpanReader{
+	reader := &SpanReader{
 		session:              session,
 		consistency:          cassandra.One,
-		serviceNamesReader:   serviceNamesStorage.GetServices,
-		operationNamesReader: operationNamesStorage.GetOperations,
+		serviceNamesReader:   serviceNamesStorage.GetServicesContext,
+		operationNamesReader: operationNamesStorage.GetOperationsContext,
 		metrics: spanReaderMetrics{
 			readTraces:                 casMetrics.NewTable(readFactory, "ReadTraces"),
 			queryTrace:                 casMetrics.NewTable(readFactory, "QueryTraces"),
@@ -135,24 +161,38 @@ func NewSpanReader(
 			queryServiceOperationIndex: casMetrics.NewTable(readFactory, "ServiceOperationIndex"),
 			queryServiceNameIndex:      casMetrics.NewTable(readFactory, "ServiceNameIndex"),
 		},
-		logger: logger,
+		logger:           logger,
+		traceReadWorkers: defaultTraceReadWorkers,
 	}
+	for _, option := range options {
+		option(reader)
+	}
+	return reader
 }
 
 // GetServices returns all services traced by Jaeger
 func (s *SpanReader) GetServices() ([]string, error) {
-	return s.serviceNamesReader()
+	return s.GetServicesContext(context.Background())
+}
 
+// GetServicesContext is GetServices bound to ctx
+func (s *SpanReader) GetServicesContext(ctx context.Context) ([]string, error) {
+	return s.serviceNamesReader(ctx)
 }
 
 // GetOperations returns all operations for a specific service traced by Jaeger
 func (s *SpanReader) GetOperations(service string) ([]string, error) {
-	return s.operationNamesReader(service)
+	return s.GetOperationsContext(context.Background(), service)
 }
 
-func (s *SpanReader) readTrace(traceID dbmodel.TraceID) (*model.Trace, error) {
+// GetOperationsContext is GetOperations bound to ctx
+func (s *SpanReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	return s.operationNamesReader(ctx, service)
+}
+
+func (s *SpanReader) readTrace(ctx context.Context, traceID dbmodel.TraceID) (*model.Trace, error) {
 	start := time.Now()
-	q := s.session.Query(querySpanByTraceID, traceID)
+	q := cassandra.WithContext(ctx, s.session.Query(querySpanByTraceID, traceID))
 	i := q.Consistency(s.consistency).Iter()
 	var traceIDFromSpan dbmodel.TraceID
 	var startTime, spanID, duration, parentID int64
@@ -200,7 +240,12 @@ func (s *SpanReader) readTrace(traceID dbmodel.TraceID) (*model.Trace, error) {
 
 // GetTrace takes a traceID and returns a Trace associated with that traceID
 func (s *SpanReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
-	return s.readTrace(dbmodel.TraceIDFromDomain(traceID))
+	return s.GetTraceContext(context.Background(), traceID)
+}
+
+// GetTraceContext is GetTrace bound to ctx
+func (s *SpanReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	return s.readTrace(ctx, dbmodel.TraceIDFromDomain(traceID))
 }
 
 func validateQuery(p *spanstore.TraceQueryParameters) error {
@@ -225,49 +270,202 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 	return nil
 }
 
-// FindTraces retrieves traces that match the traceQuery
+// FindTraces retrieves traces that match the traceQuery, the ones with the most recent indexed span first.
+// The traces are paginated by the offset of the query in that order.
 func (s *SpanReader) FindTraces(traceQuery *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	return s.FindTracesContext(context.Background(), traceQuery)
+}
+
+// FindTracesContext is FindTraces bound to ctx. No more traces are loaded once ctx is done.
+func (s *SpanReader) FindTracesContext(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]*model.Trace, error) {
+	traceIDs, err := s.findPageTraceIDs(ctx, traceQuery)
+	if err != nil {
+		return nil, err
+	}
+	var retMe []*model.Trace
+	for len(traceIDs) > 0 && len(retMe) < traceQuery.NumTraces {
+		// load only as many traces as are missing to reach the limit, and more only if some of them fail
+		batch := traceIDs
+		if missing := traceQuery.NumTraces - len(retMe); len(batch) > missing {
+			batch = batch[:missing]
+		}
+		traceIDs = traceIDs[len(batch):]
+		for _, jTrace := range s.readTraces(ctx, batch) {
+			if jTrace != nil {
+				retMe = append(retMe, jTrace)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return retMe, nil
+}
+
+// FindTraceIDs returns the IDs of the traces that match the traceQuery, in the order of FindTraces,
+// without loading the traces. Unlike FindTraces, it cannot replace the traces that fail to load.
+func (s *SpanReader) FindTraceIDs(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]model.TraceID, error) {
+	dbTraceIDs, err := s.findPageTraceIDs(ctx, traceQuery)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbTraceIDs) > traceQuery.NumTraces {
+		dbTraceIDs = dbTraceIDs[:traceQuery.NumTraces]
+	}
+	traceIDs := make([]model.TraceID, len(dbTraceIDs))
+	for i, traceID := range dbTraceIDs {
+		traceIDs[i] = traceID.ToDomain()
+	}
+	return traceIDs, nil
+}
+
+// findPageTraceIDs returns the IDs of the traces matching the traceQuery newest first, starting at
+// its offset. The number of traces of the query is set to the default if it is missing.
+func (s *SpanReader) findPageTraceIDs(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]dbmodel.TraceID, error) {
 	if err := validateQuery(traceQuery); err != nil {
 		return nil, err
 	}
 	if traceQuery.NumTraces == 0 {
 		traceQuery.NumTraces = defaultNumTraces
 	}
-	uniqueTraceIDs, err := s.findTraceIDs(traceQuery)
+	// look up enough trace IDs to skip the offset
+	indexQuery := *traceQuery
+	indexQuery.NumTraces += traceQuery.Offset
+	startTimes, err := s.findTraceIDs(ctx, &indexQuery)
 	if err != nil {
 		return nil, err
 	}
-	var retMe []*model.Trace
-	for traceID := range uniqueTraceIDs {
-		if len(retMe) >= traceQuery.NumTraces {
-			break
+	traceIDs := startTimes.newestFirst()
+	if traceQuery.Offset >= len(traceIDs) {
+		return nil, nil
+	}
+	return traceIDs[traceQuery.Offset:], nil
+}
+
+// readTraces loads the traces with up to traceReadWorkers concurrent reads and returns them in the
+// order of traceIDs. Traces that fail to load are logged and left nil, and so are the traces not
+// loaded yet when ctx is done.
+func (s *SpanReader) readTraces(ctx context.Context, traceIDs []dbmodel.TraceID) []*model.Trace {
+	traces := make([]*model.Trace, len(traceIDs))
+	workers := s.traceReadWorkers
+	if workers > len(traceIDs) {
+		workers = len(traceIDs)
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if ctx.Err() != nil {
+					continue
+				}
+				jTrace, err := s.readTrace(ctx, traceIDs[i])
+				if err != nil {
+					s.logger.Error("Failure to read trace", zap.String("trace_id", traceIDs[i].String()), zap.Error(err))
+					continue
+				}
+				traces[i] = jTrace
+			}
+		}()
+	}
+	for i := range traceIDs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return traces
+}
+
+// traceStartTimes maps the trace IDs found in the indices to the start time of their latest indexed span
+type traceStartTimes map[dbmodel.TraceID]int64
+
+func (t traceStartTimes) add(traceID dbmodel.TraceID, startTime int64) {
+	if latest, ok := t[traceID]; !ok || startTime > latest {
+		t[traceID] = startTime
+	}
+}
+
+// intersectTraceStartTimes returns the trace IDs found in all of the given sets
+func intersectTraceStartTimes(sets []traceStartTimes) traceStartTimes {
+	retMe := traceStartTimes{}
+	for traceID, startTime := range sets[0] {
+		found := true
+		for _, other := range sets[1:] {
+			otherStartTime, ok := other[traceID]
+			if !ok {
+				found = false
+				break
+			}
+			if otherStartTime > startTime {
+				startTime = otherStartTime
+			}
 		}
-		jTrace, err := s.readTrace(traceID)
-		if err != nil {
-			s.logger.Error("Failure to read trace", zap.String("trace_id", traceID.String()), zap.Error(err))
-			continue
+		if found {
+			retMe[traceID] = startTime
 		}
-		retMe = append(retMe, jTrace)
 	}
-	return retMe, nil
+	return retMe
+}
+
+// newestFirst returns the trace IDs sorted by descending start time, and by value for the same start time,
+// so that consecutive pages of a query do not overlap
+func (t traceStartTimes) newestFirst() []dbmodel.TraceID {
+	traceIDs := make([]dbmodel.TraceID, 0, len(t))
+	for traceID := range t {
+		traceIDs = append(traceIDs, traceID)
+	}
+	sort.Sort(traceIDsByStartTime{traceIDs: traceIDs, startTimes: t})
+	return traceIDs
+}
+
+type traceIDsByStartTime struct {
+	traceIDs   []dbmodel.TraceID
+	startTimes traceStartTimes
+}
+
+func (t traceIDsByStartTime) Len() int {
+	return len(t.traceIDs)
+}
+
+func (t traceIDsByStartTime) Swap(i, j int) {
+	t.traceIDs[i], t.traceIDs[j] = t.traceIDs[j], t.traceIDs[i]
+}
+
+func (t traceIDsByStartTime) Less(i, j int) bool {
+	startTimeI, startTimeJ := t.startTimes[t.traceIDs[i]], t.startTimes[t.traceIDs[j]]
+	if startTimeI != startTimeJ {
+		return startTimeI > startTimeJ
+	}
+	return bytes.Compare(t.traceIDs[i][:], t.traceIDs[j][:]) < 0
 }
 
-func (s *SpanReader) findTraceIDs(traceQuery *spanstore.TraceQueryParameters) (dbmodel.UniqueTraceIDs, error) {
+func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) (traceStartTimes, error) {
 	if traceQuery.DurationMin != 0 || traceQuery.DurationMax != 0 {
-		return s.queryByDuration(traceQuery)
+		return s.queryByDuration(ctx, traceQuery)
 	}
 
 	if traceQuery.OperationName != "" {
-		traceIds, err := s.queryByServiceNameAndOperation(traceQuery)
+		traceIds, err := s.queryByServiceNameAndOperation(ctx, traceQuery)
 		if err != nil {
 			return nil, err
 		}
 		if len(traceQuery.Tags) > 0 {
-			tagTraceIds, err := s.queryByTagsAndLogs(traceQuery)
+			tagTraceIds, err := s.queryByTagsAndLogs(ctx, traceQuery)
 			if err != nil {
 				return nil, err
 			}
-			return dbmodel.IntersectTraceIDs([]dbmodel.UniqueTraceIDs{
+			return intersectTraceStartTimes([]traceStartTimes{
 				traceIds,
 				tagTraceIds,
 			}), nil
@@ -275,13 +473,13 @@ func (s *SpanReader) findTraceIDs(traceQuery *spanstore.TraceQueryParameters) (d
 		return traceIds, nil
 	}
 	if len(traceQuery.Tags) > 0 {
-		return s.queryByTagsAndLogs(traceQuery)
+		return s.queryByTagsAndLogs(ctx, traceQuery)
 	}
-	return s.queryByService(traceQuery)
+	return s.queryByService(ctx, traceQuery)
 }
 
-func (s *SpanReader) queryByTagsAndLogs(tq *spanstore.TraceQueryParameters) (dbmodel.UniqueTraceIDs, error) {
-	results := make([]dbmodel.UniqueTraceIDs, 0, len(tq.Tags))
+func (s *SpanReader) queryByTagsAndLogs(ctx context.Context, tq *spanstore.TraceQueryParameters) (traceStartTimes, error) {
+	results := make([]traceStartTimes, 0, len(tq.Tags))
 	for k, v := range tq.Tags {
 		query := s.session.Query(
 			queryByTag,
@@ -292,17 +490,17 @@ func (s *SpanReader) queryByTagsAndLogs(tq *spanstore.TraceQueryParameters) (dbm
 			model.TimeAsEpochMicroseconds(tq.StartTimeMax),
 			tq.NumTraces*limitMultiple,
 		).PageSize(0)
-		t, err := s.executeQuery(query, s.metrics.queryTagIndex)
+		t, err := s.executeQuery(ctx, query, s.metrics.queryTagIndex)
 		if err != nil {
 			return nil, err
 		}
 		results = append(results, t)
 	}
-	return dbmodel.IntersectTraceIDs(results), nil
+	return intersectTraceStartTimes(results), nil
 }
 
-func (s *SpanReader) queryByDuration(traceQuery *spanstore.TraceQueryParameters) (dbmodel.UniqueTraceIDs, error) {
-	results := dbmodel.UniqueTraceIDs{}
+func (s *SpanReader) queryByDuration(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) (traceStartTimes, error) {
+	results := traceStartTimes{}
 
 	minDurationMicros := traceQuery.DurationMin.Nanoseconds() / int64(time.Microsecond/time.Nanosecond)
 	maxDurationMicros := (time.Hour * 24).Nanoseconds() / int64(time.Microsecond/time.Nanosecond)
@@ -324,13 +522,13 @@ func (s *SpanReader) queryByDuration(traceQuery *spanstore.TraceQueryParameters)
 			minDurationMicros,
 			maxDurationMicros,
 			traceQuery.NumTraces*limitMultiple)
-		t, err := s.executeQuery(query, s.metrics.queryDurationIndex)
+		t, err := s.executeQuery(ctx, query, s.metrics.queryDurationIndex)
 		if err != nil {
 			return nil, err
 		}
 
-		for traceID := range t {
-			results.Add(traceID)
+		for traceID, startTime := range t {
+			results.add(traceID, startTime)
 			if len(results) == traceQuery.NumTraces {
 				break
 			}
@@ -339,7 +537,10 @@ func (s *SpanReader) queryByDuration(traceQuery *spanstore.TraceQueryParameters)
 	return results, nil
 }
 
-func (s *SpanReader) queryByServiceNameAndOperation(tq *spanstore.TraceQueryParameters) (dbmodel.UniqueTraceIDs, error) {
+func (s *SpanReader) queryByServiceNameAndOperation(
+	ctx context.Context,
+	tq *spanstore.TraceQueryParameters,
+) (traceStartTimes, error) {
 	query := s.session.Query(
 		queryByServiceAndOperationName,
 		tq.ServiceName,
@@ -348,10 +549,10 @@ func (s *SpanReader) queryByServiceNameAndOperation(tq *spanstore.TraceQueryPara
 		model.TimeAsEpochMicroseconds(tq.StartTimeMax),
 		tq.NumTraces*limitMultiple,
 	).PageSize(0)
-	return s.executeQuery(query, s.metrics.queryServiceOperationIndex)
+	return s.executeQuery(ctx, query, s.metrics.queryServiceOperationIndex)
 }
 
-func (s *SpanReader) queryByService(tq *spanstore.TraceQueryParameters) (dbmodel.UniqueTraceIDs, error) {
+func (s *SpanReader) queryByService(ctx context.Context, tq *spanstore.TraceQueryParameters) (traceStartTimes, error) {
 	query := s.session.Query(
 		queryByServiceName,
 		tq.ServiceName,
@@ -359,16 +560,21 @@ func (s *SpanReader) queryByService(tq *spanstore.TraceQueryParameters) (dbmodel
 		model.TimeAsEpochMicroseconds(tq.StartTimeMax),
 		tq.NumTraces*limitMultiple,
 	).PageSize(0)
-	return s.executeQuery(query, s.metrics.queryServiceNameIndex)
+	return s.executeQuery(ctx, query, s.metrics.queryServiceNameIndex)
 }
 
-func (s *SpanReader) executeQuery(query cassandra.Query, tableMetrics *casMetrics.Table) (dbmodel.UniqueTraceIDs, error) {
+func (s *SpanReader) executeQuery(
+	ctx context.Context,
+	query cassandra.Query,
+	tableMetrics *casMetrics.Table,
+) (traceStartTimes, error) {
 	start := time.Now()
-	i := query.Consistency(s.consistency).Iter()
-	retMe := dbmodel.UniqueTraceIDs{}
+	i := cassandra.WithContext(ctx, query).Consistency(s.consistency).Iter()
+	retMe := traceStartTimes{}
 	var traceID dbmodel.TraceID
-	for i.Scan(&traceID) {
-		retMe.Add(traceID)
+	var startTime int64
+	for i.Scan(&traceID, &startTime) {
+		retMe.add(traceID, startTime)
 	}
 	err := i.Close()
 	tableMetrics.Emit(err, time.Since(start))
@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -61,9 +62,20 @@ func withSpanReader(fn func(r *spanReaderTest)) {
 
 var _ spanstore.Reader = &SpanReader{} // check API conformance
 
+func TestSpanReaderTraceReadWorkers(t *testing.T) {
+	session := &mocks.Session{}
+	metricsFactory := metrics.NewLocalFactory(0)
+	reader := NewSpanReader(session, metricsFactory, zap.NewNop())
+	assert.Equal(t, defaultTraceReadWorkers, reader.traceReadWorkers)
+	reader = NewSpanReader(session, metricsFactory, zap.NewNop(), ReaderOptions.TraceReadWorkers(3))
+	assert.Equal(t, 3, reader.traceReadWorkers)
+	reader = NewSpanReader(session, metricsFactory, zap.NewNop(), ReaderOptions.TraceReadWorkers(0))
+	assert.Equal(t, defaultTraceReadWorkers, reader.traceReadWorkers)
+}
+
 func TestSpanReaderGetServices(t *testing.T) {
 	withSpanReader(func(r *spanReaderTest) {
-		r.reader.serviceNamesReader = func() ([]string, error) { return []string{"service-a"}, nil }
+		r.reader.serviceNamesReader = func(context.Context) ([]string, error) { return []string{"service-a"}, nil }
 		s, err := r.reader.GetServices()
 		assert.NoError(t, err)
 		assert.Equal(t, []string{"service-a"}, s)
@@ -72,7 +84,7 @@ func TestSpanReaderGetServices(t *testing.T) {
 
 func TestSpanReaderGetOperations(t *testing.T) {
 	withSpanReader(func(r *spanReaderTest) {
-		r.reader.operationNamesReader = func(string) ([]string, error) { return []string{"operation-a"}, nil }
+		r.reader.operationNamesReader = func(context.Context, string) ([]string, error) { return []string{"operation-a"}, nil }
 		s, err := r.reader.GetOperations("service-x")
 		assert.NoError(t, err)
 		assert.Equal(t, []string{"operation-a"}, s)
@@ -135,6 +147,61 @@ func TestSpanReaderGetTrace(t *testing.T) {
 	}
 }
 
+func TestSpanReaderGetTraceContext(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		iter := &mocks.Iterator{}
+		iter.On("Scan", matchOnce()).Return(true)
+		iter.On("Scan", matchEverything()).Return(false)
+		iter.On("Close").Return(nil)
+
+		query := &mocks.Query{}
+		query.On("WithContext", ctx).Return(query)
+		query.On("Consistency", cassandra.One).Return(query)
+		query.On("Iter").Return(iter)
+
+		r.session.On("Query", mock.AnythingOfType("string"), matchEverything()).Return(query)
+
+		trace, err := r.reader.GetTraceContext(ctx, model.TraceID{})
+		assert.NoError(t, err)
+		assert.NotNil(t, trace)
+		query.AssertCalled(t, "WithContext", ctx)
+	})
+}
+
+func TestSpanReaderFindTracesContextCanceled(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		iter := &mocks.Iterator{}
+		iter.On("Scan", matchOnceWithSideEffect(func(args []interface{}) {
+			*args[0].(*dbmodel.TraceID) = dbmodel.TraceIDFromDomain(model.TraceID{Low: 1})
+		})).Return(true)
+		iter.On("Scan", matchEverything()).Return(false)
+		iter.On("Close").Return(nil)
+
+		query := &mocks.Query{}
+		query.On("PageSize", 0).Return(query)
+		query.On("WithContext", ctx).Return(query)
+		query.On("Consistency", cassandra.One).Return(query)
+		query.On("Iter").Return(iter)
+
+		r.session.On("Query", stringMatcher(queryByServiceName), matchEverything()).Return(query)
+
+		traces, err := r.reader.FindTracesContext(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  "service-a",
+			StartTimeMax: time.Now(),
+			StartTimeMin: time.Now().Add(-1 * time.Minute * 30),
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Nil(t, traces)
+		r.session.AssertNumberOfCalls(t, "Query", 1)
+	})
+}
+
 func TestSpanReaderGetTrace_TraceNotFound(t *testing.T) {
 	withSpanReader(func(r *spanReaderTest) {
 		iter := &mocks.Iterator{}
@@ -164,6 +231,7 @@ func TestSpanReaderFindTraces(t *testing.T) {
 	testCases := []struct {
 		caption                           string
 		numTraces                         int
+		offset                            int
 		queryTags                         bool
 		queryOperation                    bool
 		queryDuration                     bool
@@ -190,6 +258,16 @@ func TestSpanReaderFindTraces(t *testing.T) {
 			numTraces:     1,
 			expectedCount: 1,
 		},
+		{
+			caption:       "with offset",
+			offset:        1,
+			expectedCount: 1,
+		},
+		{
+			caption:       "with offset past the results",
+			offset:        2,
+			expectedCount: 0,
+		},
 		{
 			caption:        "main query error",
 			mainQueryError: errors.New("main query error"),
@@ -270,6 +348,17 @@ func TestSpanReaderFindTraces(t *testing.T) {
 				`"trace_id":"2"`,
 			},
 		},
+		{
+			caption:        "load trace error with limit",
+			numTraces:      1,
+			loadQueryError: errors.New("load query error"),
+			expectedCount:  0,
+			expectedLogs: []string{
+				"Failure to read trace",
+				`"trace_id":"1"`,
+				`"trace_id":"2"`,
+			},
+		},
 	}
 	for _, tc := range testCases {
 		testCase := tc // capture loop var
@@ -345,6 +434,7 @@ func TestSpanReaderFindTraces(t *testing.T) {
 				}
 
 				queryParams.NumTraces = testCase.numTraces
+				queryParams.Offset = testCase.offset
 				if testCase.queryTags {
 					queryParams.Tags = make(map[string]string)
 					queryParams.Tags["x"] = "y"
@@ -376,6 +466,84 @@ func TestSpanReaderFindTraces(t *testing.T) {
 	}
 }
 
+func TestSpanReaderFindTracesPagesDoNotOverlap(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		// the index returns the rows of a trace for every span, not ordered by trace
+		type indexRow struct {
+			traceID   uint64
+			startTime int64
+		}
+		rows := []indexRow{{3, 30}, {1, 50}, {4, 10}, {2, 40}, {4, 60}, {5, 20}}
+		indexQuery := func() *mocks.Query {
+			remaining := rows
+			iter := &mocks.Iterator{}
+			iter.On("Scan", mock.MatchedBy(func(args []interface{}) bool {
+				if len(remaining) == 0 {
+					return false
+				}
+				*args[0].(*dbmodel.TraceID) = dbmodel.TraceIDFromDomain(model.TraceID{Low: remaining[0].traceID})
+				*args[1].(*int64) = remaining[0].startTime
+				remaining = remaining[1:]
+				return true
+			})).Return(true)
+			iter.On("Scan", matchEverything()).Return(false)
+			iter.On("Close").Return(nil)
+			query := &mocks.Query{}
+			query.On("PageSize", 0).Return(query)
+			query.On("Consistency", cassandra.One).Return(query)
+			query.On("Iter").Return(iter)
+			return query
+		}
+		loadQuery := func(traceID dbmodel.TraceID) *mocks.Query {
+			iter := &mocks.Iterator{}
+			iter.On("Scan", matchOnceWithSideEffect(func(args []interface{}) {
+				*args[0].(*dbmodel.TraceID) = traceID
+			})).Return(true)
+			iter.On("Scan", matchEverything()).Return(false)
+			iter.On("Close").Return(nil)
+			query := &mocks.Query{}
+			query.On("Consistency", cassandra.One).Return(query)
+			query.On("Iter").Return(iter)
+			return query
+		}
+		for i := uint64(1); i <= 5; i++ {
+			traceID := dbmodel.TraceIDFromDomain(model.TraceID{Low: i})
+			r.session.On("Query", querySpanByTraceID, []interface{}{traceID}).Return(loadQuery(traceID))
+		}
+
+		findPage := func(offset int) []uint64 {
+			r.session.On("Query", queryByServiceName, matchEverything()).Return(indexQuery()).Once()
+			traces, err := r.reader.FindTraces(&spanstore.TraceQueryParameters{
+				ServiceName:  "service-a",
+				StartTimeMax: time.Now(),
+				StartTimeMin: time.Now().Add(-1 * time.Minute * 30),
+				NumTraces:    2,
+				Offset:       offset,
+			})
+			assert.NoError(t, err)
+			var traceIDs []uint64
+			for _, trace := range traces {
+				traceIDs = append(traceIDs, trace.Spans[0].TraceID.Low)
+			}
+			return traceIDs
+		}
+		assert.Equal(t, []uint64{4, 1}, findPage(0), "newest first, by the latest span of each trace")
+		assert.Equal(t, []uint64{2, 3}, findPage(2))
+		assert.Equal(t, []uint64{5}, findPage(4))
+
+		r.session.On("Query", queryByServiceName, matchEverything()).Return(indexQuery()).Once()
+		traceIDs, err := r.reader.FindTraceIDs(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName:  "service-a",
+			StartTimeMax: time.Now(),
+			StartTimeMin: time.Now().Add(-1 * time.Minute * 30),
+			NumTraces:    2,
+			Offset:       2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.TraceID{{Low: 2}, {Low: 3}}, traceIDs, "the same page as FindTraces")
+	})
+}
+
 func TestTraceQueryParameterValidation(t *testing.T) {
 	tsp := &spanstore.TraceQueryParameters{
 		ServiceName: "",
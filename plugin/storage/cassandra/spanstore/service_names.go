@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -101,7 +102,12 @@ func checkWriteCache(key string, c cache.Cache, writeCacheTTL time.Duration) boo
 
 // GetServices returns all services traced by Jaeger
 func (s *ServiceNamesStorage) GetServices() ([]string, error) {
-	iter := s.session.Query(s.QueryStmt).Iter()
+	return s.GetServicesContext(context.Background())
+}
+
+// GetServicesContext is GetServices bound to ctx
+func (s *ServiceNamesStorage) GetServicesContext(ctx context.Context) ([]string, error) {
+	iter := cassandra.WithContext(ctx, s.session.Query(s.QueryStmt)).Iter()
 
 	var service string
 	var services []string
@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 	"sync/atomic"
@@ -122,8 +123,13 @@ func NewSpanWriter(
 
 // WriteSpan saves the span into Cassandra
 func (s *SpanWriter) WriteSpan(span *model.Span) error {
+	return s.WriteSpanContext(context.Background(), span)
+}
+
+// WriteSpanContext is WriteSpan bound to ctx
+func (s *SpanWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
 	ds := dbmodel.FromDomain(span)
-	mainQuery := s.session.Query(
+	mainQuery := cassandra.WithContext(ctx, s.session.Query(
 		insertSpan,
 		ds.TraceID,
 		ds.SpanID,
@@ -137,7 +143,7 @@ func (s *SpanWriter) WriteSpan(span *model.Span) error {
 		ds.Logs,
 		ds.Refs,
 		ds.Process,
-	)
+	))
 
 	if err := s.writerMetrics.traces.Exec(mainQuery, s.logger); err != nil {
 		return s.logError(ds, err, "Failed to insert span", s.logger)
@@ -147,30 +153,33 @@ func (s *SpanWriter) WriteSpan(span *model.Span) error {
 		return s.logError(ds, err, "Failed to insert service name and operation name", s.logger)
 	}
 
-	if err := s.indexByTags(span, ds); err != nil {
+	if err := s.indexByTags(ctx, span, ds); err != nil {
 		return s.logError(ds, err, "Failed to index tags", s.logger)
 	}
 
-	if err := s.indexBySerice(span.TraceID, ds); err != nil {
+	if err := s.indexBySerice(ctx, span.TraceID, ds); err != nil {
 		return s.logError(ds, err, "Failed to index service name", s.logger)
 	}
 
-	if err := s.indexByOperation(span.TraceID, ds); err != nil {
+	if err := s.indexByOperation(ctx, span.TraceID, ds); err != nil {
 		return s.logError(ds, err, "Failed to index operation name", s.logger)
 	}
 
-	if err := s.indexByDuration(ds, span.StartTime); err != nil {
+	if err := s.indexByDuration(ctx, ds, span.StartTime); err != nil {
 		return s.logError(ds, err, "Failed to index duration", s.logger)
 	}
 	return nil
 }
 
-func (s *SpanWriter) indexByTags(span *model.Span, ds *dbmodel.Span) error {
+func (s *SpanWriter) indexByTags(ctx context.Context, span *model.Span, ds *dbmodel.Span) error {
 	for _, v := range dbmodel.GetAllUniqueTags(span) {
 		// we should introduce retries or just ignore failures imo, retrying each individual tag insertion might be better
 		// we should consider bucketing.
 		if s.shouldIndexTag(v) {
-			insertTagQuery := s.session.Query(insertTag, ds.TraceID, ds.SpanID, v.ServiceName, ds.StartTime, v.TagKey, v.TagValue)
+			insertTagQuery := cassandra.WithContext(
+				ctx,
+				s.session.Query(insertTag, ds.TraceID, ds.SpanID, v.ServiceName, ds.StartTime, v.TagKey, v.TagValue),
+			)
 			if err := s.writerMetrics.tagIndex.Exec(insertTagQuery, s.logger); err != nil {
 				withTagInfo := s.logger.
 					With(zap.String("tag_key", v.TagKey)).
@@ -185,8 +194,8 @@ func (s *SpanWriter) indexByTags(span *model.Span, ds *dbmodel.Span) error {
 	return nil
 }
 
-func (s *SpanWriter) indexByDuration(span *dbmodel.Span, startTime time.Time) error {
-	query := s.session.Query(durationIndex)
+func (s *SpanWriter) indexByDuration(ctx context.Context, span *dbmodel.Span, startTime time.Time) error {
+	query := cassandra.WithContext(ctx, s.session.Query(durationIndex))
 	timeBucket := startTime.Round(durationBucketSize)
 	var err error
 	indexByOperationName := func(operationName string) {
@@ -201,15 +210,15 @@ func (s *SpanWriter) indexByDuration(span *dbmodel.Span, startTime time.Time) er
 	return err
 }
 
-func (s *SpanWriter) indexBySerice(traceID model.TraceID, span *dbmodel.Span) error {
+func (s *SpanWriter) indexBySerice(ctx context.Context, traceID model.TraceID, span *dbmodel.Span) error {
 	bucketNo := atomic.AddUint32(&s.bucketCounter, 1) % defaultNumBuckets
-	query := s.session.Query(serviceNameIndex)
+	query := cassandra.WithContext(ctx, s.session.Query(serviceNameIndex))
 	q := query.Bind(span.Process.ServiceName, bucketNo, span.StartTime, span.TraceID)
 	return s.writerMetrics.serviceNameIndex.Exec(q, s.logger)
 }
 
-func (s *SpanWriter) indexByOperation(traceID model.TraceID, span *dbmodel.Span) error {
-	query := s.session.Query(serviceOperationIndex)
+func (s *SpanWriter) indexByOperation(ctx context.Context, traceID model.TraceID, span *dbmodel.Span) error {
+	query := cassandra.WithContext(ctx, s.session.Query(serviceOperationIndex))
 	q := query.Bind(span.Process.ServiceName, span.OperationName, span.StartTime, span.TraceID)
 	return s.writerMetrics.serviceOperationIndex.Exec(q, s.logger)
 }
@@ -21,12 +21,15 @@
 package spanstore
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
@@ -271,3 +274,28 @@ func TestSpanWriterSkippingTags(t *testing.T) {
 		})
 	}
 }
+
+func TestSpanWriterContext(t *testing.T) {
+	withSpanWriter(0, func(w *spanWriterTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		span := &model.Span{
+			TraceID:       model.TraceID{Low: 1},
+			OperationName: "operation-a",
+			Tags:          model.KeyValues{model.String("x", "y")},
+			Process:       &model.Process{ServiceName: "service-a"},
+		}
+
+		query := &mocks.Query{}
+		query.On("WithContext", ctx).Return(query)
+		query.On("Bind", matchEverything()).Return(query)
+		query.On("Exec").Return(nil)
+		w.session.On("Query", mock.AnythingOfType("string"), matchEverything()).Return(query)
+
+		w.writer.serviceNamesWriter = func(serviceName string) error { return nil }
+		w.writer.operationNamesWriter = func(serviceName, operationName string) error { return nil }
+		require.NoError(t, w.writer.WriteSpanContext(ctx, span))
+		// span, tag, service, operation and duration index queries
+		query.AssertNumberOfCalls(t, "WithContext", 5)
+	})
+}
@@ -95,12 +95,21 @@ func (s *DependencyStore) writeDependencies(indexName string, ts time.Time, depe
 
 // GetDependencies returns all interservice dependencies
 func (s *DependencyStore) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	return s.GetDependenciesContext(s.ctx, endTs, lookback)
+}
+
+// GetDependenciesContext is GetDependencies bound to ctx
+func (s *DependencyStore) GetDependenciesContext(
+	ctx context.Context,
+	endTs time.Time,
+	lookback time.Duration,
+) ([]model.DependencyLink, error) {
 	searchResult, err := s.client.Search(getIndices(endTs, lookback)...).
 		Type(dependencyType).
 		Size(10000). // the default elasticsearch allowed limit
 		Query(buildTSQuery(endTs, lookback)).
 		IgnoreUnavailable(true).
-		Do(s.ctx)
+		Do(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to search for dependencies")
 	}
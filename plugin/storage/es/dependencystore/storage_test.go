@@ -21,6 +21,7 @@
 package dependencystore
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -57,8 +58,8 @@ func withDepStorage(fn func(r *depStorageTest)) {
 	fn(r)
 }
 
-var _ dependencystore.Reader = &DependencyStore{} // check API conformance
-var _ dependencystore.Writer = &DependencyStore{} // check API conformance
+var _ dependencystore.ContextReader = &DependencyStore{} // check API conformance
+var _ dependencystore.Writer = &DependencyStore{}        // check API conformance
 
 func TestWriteDependencies(t *testing.T) {
 	testCases := []struct {
@@ -169,6 +170,27 @@ func TestGetDependencies(t *testing.T) {
 	}
 }
 
+func TestGetDependenciesContext(t *testing.T) {
+	withDepStorage(func(r *depStorageTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fixedTime := time.Date(1995, time.April, 21, 4, 21, 19, 95, time.UTC)
+
+		searchService := &mocks.SearchService{}
+		r.client.On("Search", "jaeger-dependencies-1995-04-21", "jaeger-dependencies-1995-04-20").Return(searchService)
+
+		searchService.On("Type", stringMatcher(dependencyType)).Return(searchService)
+		searchService.On("Size", mock.Anything).Return(searchService)
+		searchService.On("Query", mock.Anything).Return(searchService)
+		searchService.On("IgnoreUnavailable", mock.AnythingOfType("bool")).Return(searchService)
+		searchService.On("Do", ctx).Return(nil, errors.New("search failure"))
+
+		_, err := r.storage.GetDependenciesContext(ctx, fixedTime, 24*time.Hour)
+		assert.EqualError(t, err, "Failed to search for dependencies: search failure")
+		searchService.AssertCalled(t, "Do", ctx)
+	})
+}
+
 func createSearchResult(dependencyLink string) *elastic.SearchResult {
 	dependencyLinkRaw := []byte(dependencyLink)
 	hits := make([]*elastic.SearchHit, 1)
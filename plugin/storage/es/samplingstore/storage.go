@@ -0,0 +1,369 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package samplingstore
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/pkg/errors"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	jModel "github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/es"
+	storageMetrics "github.com/uber/jaeger/storage/spanstore/metrics"
+)
+
+const (
+	throughputType      = "throughput"
+	probabilitiesType   = "probabilities"
+	samplingIndexPrefix = "jaeger-sampling-"
+	timestampField      = "timestamp"
+
+	// defaultSearchSize is the default elasticsearch allowed limit
+	defaultSearchSize = 10000
+)
+
+type throughput struct {
+	Service       string   `json:"service"`
+	Operation     string   `json:"operation"`
+	Count         int64    `json:"count"`
+	Probabilities []string `json:"probabilities"`
+}
+
+type timeThroughput struct {
+	Timestamp  uint64       `json:"timestamp"`
+	Throughput []throughput `json:"throughput"`
+}
+
+type probabilityAndQPS struct {
+	Service     string  `json:"service"`
+	Operation   string  `json:"operation"`
+	Probability float64 `json:"probability"`
+	QPS         float64 `json:"qps"`
+}
+
+type timeProbabilitiesAndQPS struct {
+	Timestamp           uint64              `json:"timestamp"`
+	Hostname            string              `json:"hostname"`
+	ProbabilitiesAndQPS []probabilityAndQPS `json:"probabilitiesAndQPS"`
+}
+
+type samplingStoreMetrics struct {
+	indexCreate   *storageMetrics.WriteMetrics
+	throughput    *storageMetrics.WriteMetrics
+	probabilities *storageMetrics.WriteMetrics
+}
+
+// SamplingStore handles all insertions and queries for sampling data to and from ElasticSearch
+type SamplingStore struct {
+	ctx     context.Context
+	client  es.Client
+	logger  *zap.Logger
+	metrics samplingStoreMetrics
+	now     func() time.Time
+
+	sync.Mutex
+	indices map[string]struct{}
+}
+
+// NewSamplingStore creates a new ElasticSearch sampling store.
+func NewSamplingStore(client es.Client, logger *zap.Logger, metricsFactory metrics.Factory) *SamplingStore {
+	return &SamplingStore{
+		ctx:    context.Background(),
+		client: client,
+		logger: logger,
+		metrics: samplingStoreMetrics{
+			indexCreate:   storageMetrics.NewWriteMetrics(metricsFactory, "IndexCreate"),
+			throughput:    storageMetrics.NewWriteMetrics(metricsFactory, "OperationThroughput"),
+			probabilities: storageMetrics.NewWriteMetrics(metricsFactory, "Probabilities"),
+		},
+		now:     time.Now,
+		indices: make(map[string]struct{}),
+	}
+}
+
+// InsertThroughput implements samplingstore.Writer#InsertThroughput.
+func (s *SamplingStore) InsertThroughput(throughput []*model.Throughput) error {
+	return s.InsertThroughputContext(s.ctx, throughput)
+}
+
+// InsertThroughputContext implements samplingstore.ContextStore#InsertThroughputContext.
+func (s *SamplingStore) InsertThroughputContext(ctx context.Context, throughput []*model.Throughput) error {
+	ts := s.now()
+	doc := &timeThroughput{
+		Timestamp:  jModel.TimeAsEpochMicroseconds(ts),
+		Throughput: fromThroughput(throughput),
+	}
+	return s.writeDocument(ctx, ts, throughputType, doc, s.metrics.throughput, "Failed to write throughput")
+}
+
+// InsertProbabilitiesAndQPS implements samplingstore.Writer#InsertProbabilitiesAndQPS.
+func (s *SamplingStore) InsertProbabilitiesAndQPS(
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
+) error {
+	return s.InsertProbabilitiesAndQPSContext(s.ctx, hostname, probabilities, qps)
+}
+
+// InsertProbabilitiesAndQPSContext implements samplingstore.ContextStore#InsertProbabilitiesAndQPSContext.
+func (s *SamplingStore) InsertProbabilitiesAndQPSContext(
+	ctx context.Context,
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
+) error {
+	ts := s.now()
+	doc := &timeProbabilitiesAndQPS{
+		Timestamp:           jModel.TimeAsEpochMicroseconds(ts),
+		Hostname:            hostname,
+		ProbabilitiesAndQPS: fromProbabilitiesAndQPS(probabilities, qps),
+	}
+	return s.writeDocument(ctx, ts, probabilitiesType, doc, s.metrics.probabilities, "Failed to write probabilities")
+}
+
+// GetThroughput implements samplingstore.Reader#GetThroughput.
+func (s *SamplingStore) GetThroughput(start, end time.Time) ([]*model.Throughput, error) {
+	return s.GetThroughputContext(s.ctx, start, end)
+}
+
+// GetThroughputContext implements samplingstore.ContextStore#GetThroughputContext.
+func (s *SamplingStore) GetThroughputContext(ctx context.Context, start, end time.Time) ([]*model.Throughput, error) {
+	hits, err := s.search(ctx, start, end, throughputType)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to search for throughput")
+	}
+	var ret []*model.Throughput
+	for _, hit := range hits {
+		var doc timeThroughput
+		if err := json.Unmarshal(*hit.Source, &doc); err != nil {
+			return nil, errors.Wrap(err, "Unmarshalling throughput failed")
+		}
+		ret = append(ret, toThroughput(doc.Throughput)...)
+	}
+	return ret, nil
+}
+
+// GetProbabilitiesAndQPS implements samplingstore.Reader#GetProbabilitiesAndQPS.
+func (s *SamplingStore) GetProbabilitiesAndQPS(start, end time.Time) (map[string][]model.ServiceOperationData, error) {
+	return s.GetProbabilitiesAndQPSContext(s.ctx, start, end)
+}
+
+// GetProbabilitiesAndQPSContext implements samplingstore.ContextStore#GetProbabilitiesAndQPSContext.
+func (s *SamplingStore) GetProbabilitiesAndQPSContext(
+	ctx context.Context,
+	start, end time.Time,
+) (map[string][]model.ServiceOperationData, error) {
+	hits, err := s.search(ctx, start, end, probabilitiesType)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to search for probabilities and qps")
+	}
+	hostProbabilitiesAndQPS := make(map[string][]model.ServiceOperationData)
+	for _, hit := range hits {
+		var doc timeProbabilitiesAndQPS
+		if err := json.Unmarshal(*hit.Source, &doc); err != nil {
+			return nil, errors.Wrap(err, "Unmarshalling probabilities and qps failed")
+		}
+		hostProbabilitiesAndQPS[doc.Hostname] = append(hostProbabilitiesAndQPS[doc.Hostname], toServiceOperationData(doc.ProbabilitiesAndQPS))
+	}
+	return hostProbabilitiesAndQPS, nil
+}
+
+// GetLatestProbabilities implements samplingstore.Reader#GetLatestProbabilities.
+func (s *SamplingStore) GetLatestProbabilities() (model.ServiceOperationProbabilities, error) {
+	return s.GetLatestProbabilitiesContext(s.ctx)
+}
+
+// GetLatestProbabilitiesContext implements samplingstore.ContextStore#GetLatestProbabilitiesContext.
+func (s *SamplingStore) GetLatestProbabilitiesContext(ctx context.Context) (model.ServiceOperationProbabilities, error) {
+	searchResult, err := s.client.Search(samplingIndexPrefix+"*").
+		Type(probabilitiesType).
+		Size(1).
+		Sort(timestampField, false).
+		IgnoreUnavailable(true).
+		Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to search for the latest probabilities")
+	}
+	probabilities := make(model.ServiceOperationProbabilities)
+	if searchResult.Hits == nil || len(searchResult.Hits.Hits) == 0 {
+		return probabilities, nil
+	}
+	var doc timeProbabilitiesAndQPS
+	if err := json.Unmarshal(*searchResult.Hits.Hits[0].Source, &doc); err != nil {
+		return nil, errors.Wrap(err, "Unmarshalling probabilities failed")
+	}
+	for _, p := range doc.ProbabilitiesAndQPS {
+		if _, ok := probabilities[p.Service]; !ok {
+			probabilities[p.Service] = make(map[string]float64)
+		}
+		probabilities[p.Service][p.Operation] = p.Probability
+	}
+	return probabilities, nil
+}
+
+func (s *SamplingStore) writeDocument(
+	ctx context.Context,
+	ts time.Time,
+	docType string,
+	doc interface{},
+	writeMetrics *storageMetrics.WriteMetrics,
+	msg string,
+) error {
+	indexName := indexName(ts)
+	if err := s.createIndex(ctx, indexName); err != nil {
+		return err
+	}
+	start := time.Now()
+	_, err := s.client.Index().Index(indexName).Type(docType).BodyJson(doc).Do(ctx)
+	writeMetrics.Emit(err, time.Since(start))
+	if err != nil {
+		s.logger.Error(msg, zap.String("index", indexName), zap.Error(err))
+		return errors.Wrap(err, msg)
+	}
+	return nil
+}
+
+func (s *SamplingStore) createIndex(ctx context.Context, indexName string) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.indices[indexName]; ok {
+		return nil
+	}
+	// don't need to check the error because exists will be false anyway if there is an error
+	exists, _ := s.client.IndexExists(indexName).Do(ctx)
+	if !exists {
+		start := time.Now()
+		_, err := s.client.CreateIndex(indexName).Body(samplingMapping).Do(ctx)
+		s.metrics.indexCreate.Emit(err, time.Since(start))
+		if err != nil {
+			s.logger.Error("Failed to create index", zap.String("index", indexName), zap.Error(err))
+			return errors.Wrap(err, "Failed to create index")
+		}
+	}
+	s.indices[indexName] = struct{}{}
+	return nil
+}
+
+func (s *SamplingStore) search(ctx context.Context, start, end time.Time, docType string) ([]*elastic.SearchHit, error) {
+	// The range excludes start and includes end, like the Cassandra sampling store
+	query := elastic.NewRangeQuery(timestampField).
+		Gt(jModel.TimeAsEpochMicroseconds(start)).
+		Lte(jModel.TimeAsEpochMicroseconds(end))
+	searchResult, err := s.client.Search(getIndices(start, end)...).
+		Type(docType).
+		Size(defaultSearchSize).
+		Query(query).
+		IgnoreUnavailable(true).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if searchResult.Hits == nil {
+		return nil, nil
+	}
+	return searchResult.Hits.Hits, nil
+}
+
+func getIndices(start, end time.Time) []string {
+	var indices []string
+	firstIndex := indexName(start)
+	currentIndex := indexName(end)
+	for currentIndex != firstIndex && end.After(start) {
+		indices = append(indices, currentIndex)
+		end = end.Add(-24 * time.Hour)
+		currentIndex = indexName(end)
+	}
+	return append(indices, firstIndex)
+}
+
+func indexName(date time.Time) string {
+	return samplingIndexPrefix + date.UTC().Format("2006-01-02")
+}
+
+func fromThroughput(throughputs []*model.Throughput) []throughput {
+	ret := make([]throughput, 0, len(throughputs))
+	for _, t := range throughputs {
+		probabilities := make([]string, 0, len(t.Probabilities))
+		for probability := range t.Probabilities {
+			probabilities = append(probabilities, probability)
+		}
+		ret = append(ret, throughput{
+			Service:       t.Service,
+			Operation:     t.Operation,
+			Count:         t.Count,
+			Probabilities: probabilities,
+		})
+	}
+	return ret
+}
+
+func toThroughput(throughputs []throughput) []*model.Throughput {
+	ret := make([]*model.Throughput, 0, len(throughputs))
+	for _, t := range throughputs {
+		probabilities := make(map[string]struct{}, len(t.Probabilities))
+		for _, probability := range t.Probabilities {
+			probabilities[probability] = struct{}{}
+		}
+		ret = append(ret, &model.Throughput{
+			Service:       t.Service,
+			Operation:     t.Operation,
+			Count:         t.Count,
+			Probabilities: probabilities,
+		})
+	}
+	return ret
+}
+
+func fromProbabilitiesAndQPS(probabilities model.ServiceOperationProbabilities, qps model.ServiceOperationQPS) []probabilityAndQPS {
+	var ret []probabilityAndQPS
+	for service, operations := range probabilities {
+		for operation, probability := range operations {
+			ret = append(ret, probabilityAndQPS{
+				Service:     service,
+				Operation:   operation,
+				Probability: probability,
+				QPS:         qps[service][operation],
+			})
+		}
+	}
+	return ret
+}
+
+func toServiceOperationData(probabilitiesAndQPS []probabilityAndQPS) model.ServiceOperationData {
+	data := make(model.ServiceOperationData)
+	for _, p := range probabilitiesAndQPS {
+		if _, ok := data[p.Service]; !ok {
+			data[p.Service] = make(map[string]*model.ProbabilityAndQPS)
+		}
+		data[p.Service][p.Operation] = &model.ProbabilityAndQPS{
+			Probability: p.Probability,
+			QPS:         p.QPS,
+		}
+	}
+	return data
+}
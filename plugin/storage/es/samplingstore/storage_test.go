@@ -0,0 +1,327 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package samplingstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	jModel "github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/es/mocks"
+	"github.com/uber/jaeger/pkg/testutils"
+	"github.com/uber/jaeger/storage/samplingstore"
+)
+
+var _ samplingstore.ContextStore = &SamplingStore{} // check API conformance
+
+var fixedTime = time.Date(1995, time.April, 21, 4, 21, 19, 95000, time.UTC)
+
+type samplingStoreTest struct {
+	client    *mocks.Client
+	logger    *zap.Logger
+	logBuffer *testutils.Buffer
+	storage   *SamplingStore
+}
+
+func withSamplingStore(fn func(s *samplingStoreTest)) {
+	client := &mocks.Client{}
+	logger, logBuffer := testutils.NewLogger()
+	s := &samplingStoreTest{
+		client:    client,
+		logger:    logger,
+		logBuffer: logBuffer,
+		storage:   NewSamplingStore(client, logger, metrics.NullFactory),
+	}
+	s.storage.now = func() time.Time { return fixedTime }
+	fn(s)
+}
+
+func (s *samplingStoreTest) mockWrite(docType string, existsErr error, createErr, writeErr error) *mocks.IndexService {
+	indexName := indexName(fixedTime)
+	existsService := &mocks.IndicesExistsService{}
+	existsService.On("Do", mock.Anything).Return(false, existsErr)
+	s.client.On("IndexExists", indexName).Return(existsService)
+
+	createService := &mocks.IndicesCreateService{}
+	createService.On("Body", samplingMapping).Return(createService)
+	createService.On("Do", mock.Anything).Return(nil, createErr)
+	s.client.On("CreateIndex", indexName).Return(createService)
+
+	writeService := &mocks.IndexService{}
+	writeService.On("Index", indexName).Return(writeService)
+	writeService.On("Type", docType).Return(writeService)
+	writeService.On("BodyJson", mock.Anything).Return(writeService)
+	writeService.On("Do", mock.Anything).Return(nil, writeErr)
+	s.client.On("Index").Return(writeService)
+	return writeService
+}
+
+func TestInsertThroughput(t *testing.T) {
+	testCases := []struct {
+		createIndexError error
+		writeError       error
+		expectedError    string
+	}{
+		{
+			createIndexError: errors.New("index not created"),
+			expectedError:    "Failed to create index: index not created",
+		},
+		{
+			writeError:    errors.New("write failed"),
+			expectedError: "Failed to write throughput: write failed",
+		},
+		{},
+	}
+	for _, testCase := range testCases {
+		withSamplingStore(func(s *samplingStoreTest) {
+			writeService := s.mockWrite(throughputType, nil, testCase.createIndexError, testCase.writeError)
+			err := s.storage.InsertThroughput([]*model.Throughput{
+				{Service: "svc", Operation: "GET", Count: 10, Probabilities: map[string]struct{}{"0.1": {}}},
+			})
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			writeService.AssertCalled(t, "BodyJson", &timeThroughput{
+				Timestamp: jModel.TimeAsEpochMicroseconds(fixedTime),
+				Throughput: []throughput{
+					{Service: "svc", Operation: "GET", Count: 10, Probabilities: []string{"0.1"}},
+				},
+			})
+		})
+	}
+}
+
+func TestInsertProbabilitiesAndQPS(t *testing.T) {
+	withSamplingStore(func(s *samplingStoreTest) {
+		writeService := s.mockWrite(probabilitiesType, errors.New("exists failed"), nil, nil)
+		err := s.storage.InsertProbabilitiesAndQPS("host",
+			model.ServiceOperationProbabilities{"svc": {"GET": 0.1}},
+			model.ServiceOperationQPS{"svc": {"GET": 2}})
+		require.NoError(t, err)
+		writeService.AssertCalled(t, "BodyJson", &timeProbabilitiesAndQPS{
+			Timestamp: jModel.TimeAsEpochMicroseconds(fixedTime),
+			Hostname:  "host",
+			ProbabilitiesAndQPS: []probabilityAndQPS{
+				{Service: "svc", Operation: "GET", Probability: 0.1, QPS: 2},
+			},
+		})
+
+		// the index is only created once
+		require.NoError(t, s.storage.InsertProbabilitiesAndQPS("host", nil, nil))
+		s.client.AssertNumberOfCalls(t, "IndexExists", 1)
+		s.client.AssertNumberOfCalls(t, "CreateIndex", 1)
+	})
+}
+
+func TestInsertContext(t *testing.T) {
+	withSamplingStore(func(s *samplingStoreTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		writeService := s.mockWrite(throughputType, nil, nil, nil)
+		err := s.storage.InsertThroughputContext(ctx, []*model.Throughput{{Service: "svc", Operation: "op"}})
+		require.NoError(t, err)
+		writeService.AssertCalled(t, "Do", ctx)
+	})
+}
+
+func (s *samplingStoreTest) mockSearch(indices []interface{}, docType string, hits []string, searchErr error) {
+	searchService := &mocks.SearchService{}
+	searchService.On("Type", docType).Return(searchService)
+	searchService.On("Size", mock.Anything).Return(searchService)
+	searchService.On("Query", mock.Anything).Return(searchService)
+	searchService.On("Sort", timestampField, false).Return(searchService)
+	searchService.On("IgnoreUnavailable", true).Return(searchService)
+	searchService.On("Do", mock.Anything).Return(createSearchResult(hits), searchErr)
+	s.client.On("Search", indices...).Return(searchService)
+}
+
+func createSearchResult(sources []string) *elastic.SearchResult {
+	hits := make([]*elastic.SearchHit, len(sources))
+	for i, source := range sources {
+		rawSource := json.RawMessage(source)
+		hits[i] = &elastic.SearchHit{Source: &rawSource}
+	}
+	return &elastic.SearchResult{Hits: &elastic.SearchHits{Hits: hits}}
+}
+
+func TestGetThroughput(t *testing.T) {
+	testCases := []struct {
+		hits          []string
+		searchError   error
+		expected      []*model.Throughput
+		expectedError string
+	}{
+		{
+			hits: []string{
+				`{"timestamp": 1, "throughput": [{"service": "svc", "operation": "GET", "count": 10, "probabilities": ["0.1"]}]}`,
+				`{"timestamp": 2, "throughput": [{"service": "svc", "operation": "PUT", "count": 1}]}`,
+			},
+			expected: []*model.Throughput{
+				{Service: "svc", Operation: "GET", Count: 10, Probabilities: map[string]struct{}{"0.1": {}}},
+				{Service: "svc", Operation: "PUT", Count: 1, Probabilities: map[string]struct{}{}},
+			},
+		},
+		{
+			hits:          []string{`badJson{hello}world`},
+			expectedError: "Unmarshalling throughput failed: invalid character 'b' looking for beginning of value",
+		},
+		{
+			searchError:   errors.New("search failure"),
+			expectedError: "Failed to search for throughput: search failure",
+		},
+	}
+	for _, testCase := range testCases {
+		withSamplingStore(func(s *samplingStoreTest) {
+			s.mockSearch([]interface{}{"jaeger-sampling-1995-04-21", "jaeger-sampling-1995-04-20"}, throughputType, testCase.hits, testCase.searchError)
+			actual, err := s.storage.GetThroughput(fixedTime.Add(-24*time.Hour), fixedTime)
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetProbabilitiesAndQPS(t *testing.T) {
+	testCases := []struct {
+		hits          []string
+		searchError   error
+		expected      map[string][]model.ServiceOperationData
+		expectedError string
+	}{
+		{
+			hits: []string{
+				`{"timestamp": 1, "hostname": "host1", "probabilitiesAndQPS": [{"service": "svc", "operation": "GET", "probability": 0.1, "qps": 2}]}`,
+				`{"timestamp": 2, "hostname": "host1", "probabilitiesAndQPS": []}`,
+				`{"timestamp": 2, "hostname": "host2", "probabilitiesAndQPS": [{"service": "svc", "operation": "PUT", "probability": 0.5, "qps": 1}]}`,
+			},
+			expected: map[string][]model.ServiceOperationData{
+				"host1": {
+					{"svc": {"GET": {Probability: 0.1, QPS: 2}}},
+					{},
+				},
+				"host2": {
+					{"svc": {"PUT": {Probability: 0.5, QPS: 1}}},
+				},
+			},
+		},
+		{
+			hits:          []string{`badJson{hello}world`},
+			expectedError: "Unmarshalling probabilities and qps failed: invalid character 'b' looking for beginning of value",
+		},
+		{
+			searchError:   errors.New("search failure"),
+			expectedError: "Failed to search for probabilities and qps: search failure",
+		},
+	}
+	for _, testCase := range testCases {
+		withSamplingStore(func(s *samplingStoreTest) {
+			s.mockSearch([]interface{}{"jaeger-sampling-1995-04-21"}, probabilitiesType, testCase.hits, testCase.searchError)
+			actual, err := s.storage.GetProbabilitiesAndQPS(fixedTime.Add(-time.Hour), fixedTime)
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetLatestProbabilities(t *testing.T) {
+	testCases := []struct {
+		hits          []string
+		searchError   error
+		expected      model.ServiceOperationProbabilities
+		expectedError string
+	}{
+		{
+			hits: []string{
+				`{"timestamp": 1, "hostname": "host", "probabilitiesAndQPS": [{"service": "svc", "operation": "GET", "probability": 0.1, "qps": 2}]}`,
+			},
+			expected: model.ServiceOperationProbabilities{"svc": {"GET": 0.1}},
+		},
+		{
+			expected: model.ServiceOperationProbabilities{},
+		},
+		{
+			hits:          []string{`badJson{hello}world`},
+			expectedError: "Unmarshalling probabilities failed: invalid character 'b' looking for beginning of value",
+		},
+		{
+			searchError:   errors.New("search failure"),
+			expectedError: "Failed to search for the latest probabilities: search failure",
+		},
+	}
+	for _, testCase := range testCases {
+		withSamplingStore(func(s *samplingStoreTest) {
+			s.mockSearch([]interface{}{"jaeger-sampling-*"}, probabilitiesType, testCase.hits, testCase.searchError)
+			actual, err := s.storage.GetLatestProbabilities()
+			if testCase.expectedError != "" {
+				assert.EqualError(t, err, testCase.expectedError)
+				assert.Nil(t, actual)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetIndices(t *testing.T) {
+	testCases := []struct {
+		start    time.Time
+		expected []string
+	}{
+		{
+			start:    fixedTime.Add(-time.Hour),
+			expected: []string{"jaeger-sampling-1995-04-21"},
+		},
+		{
+			start:    fixedTime.Add(-49 * time.Hour),
+			expected: []string{"jaeger-sampling-1995-04-21", "jaeger-sampling-1995-04-20", "jaeger-sampling-1995-04-19"},
+		},
+		{
+			start:    fixedTime.Add(24 * time.Hour),
+			expected: []string{"jaeger-sampling-1995-04-22"},
+		},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, getIndices(testCase.start, fixedTime))
+	}
+}
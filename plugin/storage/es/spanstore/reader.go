@@ -111,8 +111,14 @@ func newSpanReader(client es.Client, logger *zap.Logger, maxLookback time.Durati
 
 // GetTrace takes a traceID and returns a Trace associated with that traceID
 func (s *SpanReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
+	return s.GetTraceContext(s.ctx, traceID)
+}
+
+// GetTraceContext is GetTrace bound to ctx
+func (s *SpanReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
 	currentTime := time.Now()
 	return s.readTrace(
+		ctx,
 		traceID.String(),
 		&spanstore.TraceQueryParameters{
 			StartTimeMax: currentTime,
@@ -121,13 +127,17 @@ func (s *SpanReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
 	)
 }
 
-func (s *SpanReader) readTrace(traceID string, traceQuery *spanstore.TraceQueryParameters) (*model.Trace, error) {
+func (s *SpanReader) readTrace(
+	ctx context.Context,
+	traceID string,
+	traceQuery *spanstore.TraceQueryParameters,
+) (*model.Trace, error) {
 	query := elastic.NewTermQuery(traceIDField, traceID)
 
 	traceQuery.StartTimeMax = traceQuery.StartTimeMax.Add(time.Hour)
 	traceQuery.StartTimeMin = traceQuery.StartTimeMin.Add(-time.Hour)
 	indices := findIndices(traceQuery.StartTimeMin, traceQuery.StartTimeMax)
-	esSpansRaw, err := s.executeQuery(query, indices...)
+	esSpansRaw, err := s.executeQuery(ctx, query, indices...)
 	if err != nil {
 		return nil, errors.Wrap(err, "Query execution failed")
 	}
@@ -159,13 +169,13 @@ func (s *SpanReader) collectSpans(esSpansRaw []*elastic.SearchHit) ([]*model.Spa
 	return spans, nil
 }
 
-func (s *SpanReader) executeQuery(query elastic.Query, indices ...string) ([]*elastic.SearchHit, error) {
+func (s *SpanReader) executeQuery(ctx context.Context, query elastic.Query, indices ...string) ([]*elastic.SearchHit, error) {
 	searchService, err := s.client.Search(indices...).
 		Type(spanType).
 		Query(query).
 		Size(defaultDocCount).
 		IgnoreUnavailable(true).
-		Do(s.ctx)
+		Do(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -202,16 +212,26 @@ func IndexWithDate(date time.Time) string {
 
 // GetServices returns all services traced by Jaeger, ordered by frequency
 func (s *SpanReader) GetServices() ([]string, error) {
+	return s.GetServicesContext(s.ctx)
+}
+
+// GetServicesContext is GetServices bound to ctx
+func (s *SpanReader) GetServicesContext(ctx context.Context) ([]string, error) {
 	currentTime := time.Now()
 	jaegerIndices := findIndices(currentTime.Add(-s.maxLookback), currentTime)
-	return s.serviceOperationStorage.getServices(jaegerIndices)
+	return s.serviceOperationStorage.getServices(ctx, jaegerIndices)
 }
 
 // GetOperations returns all operations for a specific service traced by Jaeger
 func (s *SpanReader) GetOperations(service string) ([]string, error) {
+	return s.GetOperationsContext(s.ctx, service)
+}
+
+// GetOperationsContext is GetOperations bound to ctx
+func (s *SpanReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
 	currentTime := time.Now()
 	jaegerIndices := findIndices(currentTime.Add(-s.maxLookback), currentTime)
-	return s.serviceOperationStorage.getOperations(jaegerIndices, service)
+	return s.serviceOperationStorage.getOperations(ctx, jaegerIndices, service)
 }
 
 func bucketToStringArray(buckets []*elastic.AggregationBucketKeyItem) ([]string, error) {
@@ -226,15 +246,17 @@ func bucketToStringArray(buckets []*elastic.AggregationBucketKeyItem) ([]string,
 	return strings, nil
 }
 
-// FindTraces retrieves traces that match the traceQuery
+// FindTraces retrieves traces that match the traceQuery, newest first and paginated by its offset
 func (s *SpanReader) FindTraces(traceQuery *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
-	if err := validateQuery(traceQuery); err != nil {
-		return nil, err
-	}
-	if traceQuery.NumTraces == 0 {
-		traceQuery.NumTraces = defaultNumTraces
-	}
-	uniqueTraceIDs, err := s.findTraceIDs(traceQuery)
+	return s.FindTracesContext(s.ctx, traceQuery)
+}
+
+// FindTracesContext is FindTraces bound to ctx
+func (s *SpanReader) FindTracesContext(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]*model.Trace, error) {
+	uniqueTraceIDs, err := s.findPageTraceIDs(ctx, traceQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +265,10 @@ func (s *SpanReader) FindTraces(traceQuery *spanstore.TraceQueryParameters) ([]*
 		if len(retMe) >= traceQuery.NumTraces {
 			break
 		}
-		trace, err := s.readTrace(traceID, traceQuery)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		trace, err := s.readTrace(ctx, traceID, traceQuery)
 		if err != nil {
 			s.logger.Error("Failure to read trace", zap.String("trace_id", string(traceID)), zap.Error(err))
 			continue
@@ -253,6 +278,52 @@ func (s *SpanReader) FindTraces(traceQuery *spanstore.TraceQueryParameters) ([]*
 	return retMe, nil
 }
 
+// FindTraceIDs returns the IDs of the traces that match the traceQuery, in the order of FindTraces,
+// without loading the traces. Unlike FindTraces, it cannot replace the traces that fail to load.
+func (s *SpanReader) FindTraceIDs(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]model.TraceID, error) {
+	uniqueTraceIDs, err := s.findPageTraceIDs(ctx, traceQuery)
+	if err != nil {
+		return nil, err
+	}
+	if len(uniqueTraceIDs) > traceQuery.NumTraces {
+		uniqueTraceIDs = uniqueTraceIDs[:traceQuery.NumTraces]
+	}
+	traceIDs := make([]model.TraceID, 0, len(uniqueTraceIDs))
+	for _, uniqueTraceID := range uniqueTraceIDs {
+		traceID, err := model.TraceIDFromString(uniqueTraceID)
+		if err != nil {
+			return nil, errors.Wrap(err, "Malformed trace ID")
+		}
+		traceIDs = append(traceIDs, traceID)
+	}
+	return traceIDs, nil
+}
+
+// findPageTraceIDs returns the IDs of the traces matching the traceQuery, starting at its offset.
+// The number of traces of the query is set to the default if it is missing.
+func (s *SpanReader) findPageTraceIDs(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]string, error) {
+	if err := validateQuery(traceQuery); err != nil {
+		return nil, err
+	}
+	if traceQuery.NumTraces == 0 {
+		traceQuery.NumTraces = defaultNumTraces
+	}
+	uniqueTraceIDs, err := s.findTraceIDs(ctx, traceQuery)
+	if err != nil {
+		return nil, err
+	}
+	if traceQuery.Offset >= len(uniqueTraceIDs) {
+		return nil, nil
+	}
+	return uniqueTraceIDs[traceQuery.Offset:], nil
+}
+
 func validateQuery(p *spanstore.TraceQueryParameters) error {
 	if p == nil {
 		return ErrMalformedRequestObject
@@ -272,7 +343,7 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 	return nil
 }
 
-func (s *SpanReader) findTraceIDs(traceQuery *spanstore.TraceQueryParameters) ([]string, error) {
+func (s *SpanReader) findTraceIDs(ctx context.Context, traceQuery *spanstore.TraceQueryParameters) ([]string, error) {
 	//  Below is the JSON body to our HTTP GET request to ElasticSearch. This function creates this.
 	// {
 	//      "size": 0,
@@ -318,7 +389,7 @@ func (s *SpanReader) findTraceIDs(traceQuery *spanstore.TraceQueryParameters) ([
 	//      },
 	//      "aggs": { "traceIDs" : { "terms" : {"size": 100,"field": "traceID" }}}
 	//  }
-	aggregation := s.buildTraceIDAggregation(traceQuery.NumTraces)
+	aggregation := s.buildTraceIDAggregation(traceQuery.NumTraces + traceQuery.Offset)
 	boolQuery := s.buildFindTraceIDsQuery(traceQuery)
 
 	jaegerIndices := findIndices(traceQuery.StartTimeMin, traceQuery.StartTimeMax)
@@ -330,7 +401,7 @@ func (s *SpanReader) findTraceIDs(traceQuery *spanstore.TraceQueryParameters) ([
 		IgnoreUnavailable(true).
 		Query(boolQuery)
 
-	searchResult, err := searchService.Do(s.ctx)
+	searchResult, err := searchService.Do(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "Search service failed")
 	}
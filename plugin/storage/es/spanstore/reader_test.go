@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -100,7 +101,7 @@ func withSpanReader(fn func(r *spanReaderTest)) {
 	fn(r)
 }
 
-var _ spanstore.Reader = &SpanReader{} // check API conformance
+var _ spanstore.ContextReader = &SpanReader{} // check API conformance
 
 func TestNewSpanReader(t *testing.T) {
 	client := &mocks.Client{}
@@ -140,6 +141,18 @@ func TestSpanReader_GetTraceQueryError(t *testing.T) {
 	})
 }
 
+func TestSpanReader_GetTraceContext(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockSearchServiceWithContext(r, ctx).
+			Return(nil, errors.New("query error occurred"))
+		trace, err := r.reader.GetTraceContext(ctx, model.TraceID{Low: 1})
+		require.EqualError(t, err, "Query execution failed: query error occurred")
+		require.Nil(t, trace)
+	})
+}
+
 func TestSpanReader_GetTraceNoSpansError(t *testing.T) {
 	withSpanReader(func(r *spanReaderTest) {
 		hits := make([]*elastic.SearchHit, 0)
@@ -196,7 +209,7 @@ func TestSpanReader_executeQuery(t *testing.T) {
 		mockSearchService(r).Return(&elastic.SearchResult{Hits: searchHits}, nil)
 
 		query := elastic.NewTermQuery(traceIDField, "helloo")
-		hits, err := r.reader.executeQuery(query, "hello", "world", "index")
+		hits, err := r.reader.executeQuery(r.reader.ctx, query, "hello", "world", "index")
 
 		require.NoError(t, err)
 		assert.Len(t, hits, 7)
@@ -208,7 +221,7 @@ func TestSpanReader_executeQueryError(t *testing.T) {
 		mockSearchService(r).Return(nil, errors.New("query error"))
 
 		query := elastic.NewTermQuery(traceIDField, "helloo")
-		hits, err := r.reader.executeQuery(query, "hello", "world", "index")
+		hits, err := r.reader.executeQuery(r.reader.ctx, query, "hello", "world", "index")
 
 		require.Error(t, err, "query error")
 		assert.Nil(t, hits)
@@ -352,7 +365,7 @@ func returnSearchFunc(typ string, r *spanReaderTest) ([]string, error) {
 	} else if typ == operationsAggregation {
 		return r.reader.GetOperations("someService")
 	} else if typ == traceIDAggregation {
-		return r.reader.findTraceIDs(&spanstore.TraceQueryParameters{})
+		return r.reader.findTraceIDs(r.reader.ctx, &spanstore.TraceQueryParameters{})
 	}
 	return nil, errors.New("Specify services, operations, traceIDs only")
 }
@@ -419,6 +432,63 @@ func TestSpanReader_FindTraces(t *testing.T) {
 	})
 }
 
+func TestSpanReader_FindTracesOffset(t *testing.T) {
+	goodAggregations := make(map[string]*json.RawMessage)
+	rawMessage := []byte(`{"buckets": [{"key": "1","doc_count": 16},{"key": "2","doc_count": 16},{"key": "3","doc_count": 16}]}`)
+	goodAggregations[traceIDAggregation] = (*json.RawMessage)(&rawMessage)
+
+	hits := make([]*elastic.SearchHit, 1)
+	hits[0] = &elastic.SearchHit{
+		Source: (*json.RawMessage)(&exampleESSpan),
+	}
+	searchHits := &elastic.SearchHits{Hits: hits}
+
+	withSpanReader(func(r *spanReaderTest) {
+		mockSearchService(r).
+			Return(&elastic.SearchResult{Aggregations: elastic.Aggregations(goodAggregations), Hits: searchHits}, nil)
+		traceQuery := &spanstore.TraceQueryParameters{
+			ServiceName:  serviceName,
+			StartTimeMin: time.Now().Add(-1 * time.Hour),
+			StartTimeMax: time.Now(),
+			NumTraces:    2,
+			Offset:       2,
+		}
+
+		traces, err := r.reader.FindTraces(traceQuery)
+		require.NoError(t, err)
+		assert.Len(t, traces, 1)
+
+		traceQuery.Offset = 3
+		traces, err = r.reader.FindTraces(traceQuery)
+		require.NoError(t, err)
+		assert.Empty(t, traces)
+	})
+}
+
+func TestSpanReader_FindTraceIDs(t *testing.T) {
+	goodAggregations := make(map[string]*json.RawMessage)
+	rawMessage := []byte(`{"buckets": [{"key": "1","doc_count": 16},{"key": "2","doc_count": 16},{"key": "3","doc_count": 16}]}`)
+	goodAggregations[traceIDAggregation] = (*json.RawMessage)(&rawMessage)
+
+	withSpanReader(func(r *spanReaderTest) {
+		mockSearchService(r).
+			Return(&elastic.SearchResult{Aggregations: elastic.Aggregations(goodAggregations)}, nil)
+		traceQuery := &spanstore.TraceQueryParameters{
+			ServiceName:  serviceName,
+			StartTimeMin: time.Now().Add(-1 * time.Hour),
+			StartTimeMax: time.Now(),
+			NumTraces:    1,
+			Offset:       1,
+		}
+
+		traceIDs, err := r.reader.FindTraceIDs(context.Background(), traceQuery)
+		require.NoError(t, err)
+		assert.Equal(t, []model.TraceID{{Low: 2}}, traceIDs)
+		// the traces are not loaded
+		r.client.AssertNumberOfCalls(t, "Search", 1)
+	})
+}
+
 func TestSpanReader_FindTracesInvalidQuery(t *testing.T) {
 	goodAggregations := make(map[string]*json.RawMessage)
 	rawMessage := []byte(`{"buckets": [{"key": "1","doc_count": 16},{"key": "2","doc_count": 16},{"key": "3","doc_count": 16}]}`)
@@ -510,6 +580,10 @@ func TestFindTraceIDs(t *testing.T) {
 }
 
 func mockSearchService(r *spanReaderTest) *mock.Call {
+	return mockSearchServiceWithContext(r, mock.AnythingOfType("*context.emptyCtx"))
+}
+
+func mockSearchServiceWithContext(r *spanReaderTest, ctx interface{}) *mock.Call {
 	searchService := &mocks.SearchService{}
 	searchService.On("Type", stringMatcher(serviceType)).Return(searchService)
 	searchService.On("Type", stringMatcher(spanType)).Return(searchService)
@@ -522,7 +596,7 @@ func mockSearchService(r *spanReaderTest) *mock.Call {
 	searchService.On("Aggregation", stringMatcher(operationsAggregation), mock.AnythingOfType("*elastic.TermsAggregation")).Return(searchService)
 	searchService.On("Aggregation", stringMatcher(traceIDAggregation), mock.AnythingOfType("*elastic.TermsAggregation")).Return(searchService)
 	r.client.On("Search", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(searchService)
-	return searchService.On("Do", mock.AnythingOfType("*context.emptyCtx"))
+	return searchService.On("Do", ctx)
 }
 
 func TestTraceQueryParameterValidation(t *testing.T) {
@@ -76,6 +76,11 @@ func NewServiceOperationStorage(
 
 // Write saves a service to operation pair.
 func (s *ServiceOperationStorage) Write(indexName string, jsonSpan *jModel.Span) error {
+	return s.WriteContext(s.ctx, indexName, jsonSpan)
+}
+
+// WriteContext is Write bound to ctx.
+func (s *ServiceOperationStorage) WriteContext(ctx context.Context, indexName string, jsonSpan *jModel.Span) error {
 	// Insert serviceName:operationName document
 	service := Service{
 		ServiceName:   jsonSpan.Process.ServiceName,
@@ -85,7 +90,7 @@ func (s *ServiceOperationStorage) Write(indexName string, jsonSpan *jModel.Span)
 	cacheKey := fmt.Sprintf("%s:%s", indexName, serviceID)
 	if !keyInCache(cacheKey, s.serviceCache) {
 		start := time.Now()
-		_, err := s.client.Index().Index(indexName).Type(serviceType).Id(serviceID).BodyJson(service).Do(s.ctx)
+		_, err := s.client.Index().Index(indexName).Type(serviceType).Id(serviceID).BodyJson(service).Do(ctx)
 		s.metrics.Emit(err, time.Since(start))
 		if err != nil {
 			return s.logError(jsonSpan, err, "Failed to insert service:operation", s.logger)
@@ -95,7 +100,7 @@ func (s *ServiceOperationStorage) Write(indexName string, jsonSpan *jModel.Span)
 	return nil
 }
 
-func (s *ServiceOperationStorage) getServices(indices []string) ([]string, error) {
+func (s *ServiceOperationStorage) getServices(ctx context.Context, indices []string) ([]string, error) {
 	serviceAggregation := getServicesAggregation()
 
 	searchService := s.client.Search(indices...).
@@ -104,7 +109,7 @@ func (s *ServiceOperationStorage) getServices(indices []string) ([]string, error
 		IgnoreUnavailable(true).
 		Aggregation(servicesAggregation, serviceAggregation)
 
-	searchResult, err := searchService.Do(s.ctx)
+	searchResult, err := searchService.Do(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "Search service failed")
 	}
@@ -123,7 +128,7 @@ func getServicesAggregation() elastic.Query {
 		Size(defaultDocCount) // Must set to some large number. ES deprecated size omission for aggregating all. https://github.com/elastic/elasticsearch/issues/18838
 }
 
-func (s *ServiceOperationStorage) getOperations(indices []string, service string) ([]string, error) {
+func (s *ServiceOperationStorage) getOperations(ctx context.Context, indices []string, service string) ([]string, error) {
 	serviceQuery := elastic.NewTermQuery(serviceName, service)
 	serviceFilter := getOperationsAggregation()
 
@@ -134,7 +139,7 @@ func (s *ServiceOperationStorage) getOperations(indices []string, service string
 		IgnoreUnavailable(true).
 		Aggregation(operationsAggregation, serviceFilter)
 
-	searchResult, err := searchService.Do(s.ctx)
+	searchResult, err := searchService.Do(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "Search service failed")
 	}
@@ -56,14 +56,14 @@ func TestWriteService(t *testing.T) {
 			},
 		}
 
-		err := w.writer.writeService(indexName, jsonSpan)
+		err := w.writer.writeService(w.writer.ctx, indexName, jsonSpan)
 		require.NoError(t, err)
 
 		indexService.AssertNumberOfCalls(t, "Do", 1)
 		assert.Equal(t, "", w.logBuffer.String())
 
 		// test that cache works, will call the index service only once.
-		err = w.writer.writeService(indexName, jsonSpan)
+		err = w.writer.writeService(w.writer.ctx, indexName, jsonSpan)
 		require.NoError(t, err)
 		indexService.AssertNumberOfCalls(t, "Do", 1)
 	})
@@ -91,7 +91,7 @@ func TestWriteServiceError(t *testing.T) {
 			},
 		}
 
-		err := w.writer.writeService(indexName, jsonSpan)
+		err := w.writer.writeService(w.writer.ctx, indexName, jsonSpan)
 		assert.EqualError(t, err, "Failed to insert service:operation: service insertion error")
 
 		indexService.AssertNumberOfCalls(t, "Do", 1)
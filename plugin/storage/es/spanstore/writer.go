@@ -46,7 +46,7 @@ type spanWriterMetrics struct {
 	spans       *storageMetrics.WriteMetrics
 }
 
-type serviceWriter func(string, *jModel.Span) error
+type serviceWriter func(context.Context, string, *jModel.Span) error
 
 // SpanWriter is a wrapper around elastic.Client
 type SpanWriter struct {
@@ -77,7 +77,7 @@ func NewSpanWriter(client es.Client, logger *zap.Logger, metricsFactory metrics.
 			indexCreate: storageMetrics.NewWriteMetrics(metricsFactory, "IndexCreate"),
 			spans:       storageMetrics.NewWriteMetrics(metricsFactory, "Spans"),
 		},
-		serviceWriter: serviceOperationStorage.Write,
+		serviceWriter: serviceOperationStorage.WriteContext,
 		indexCache: cache.NewLRUWithOptions(
 			5,
 			&cache.Options{
@@ -89,17 +89,22 @@ func NewSpanWriter(client es.Client, logger *zap.Logger, metricsFactory metrics.
 
 // WriteSpan writes a span and its corresponding service:operation in ElasticSearch
 func (s *SpanWriter) WriteSpan(span *model.Span) error {
+	return s.WriteSpanContext(s.ctx, span)
+}
+
+// WriteSpanContext is WriteSpan bound to ctx
+func (s *SpanWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
 	jaegerIndexName := spanIndexName(span)
 	// Convert model.Span into json.Span
 	jsonSpan := json.FromDomainEmbedProcess(span)
 
-	if err := s.createIndex(jaegerIndexName, jsonSpan); err != nil {
+	if err := s.createIndex(ctx, jaegerIndexName, jsonSpan); err != nil {
 		return err
 	}
-	if err := s.writeService(jaegerIndexName, jsonSpan); err != nil {
+	if err := s.writeService(ctx, jaegerIndexName, jsonSpan); err != nil {
 		return err
 	}
-	if err := s.writeSpan(jaegerIndexName, jsonSpan); err != nil {
+	if err := s.writeSpan(ctx, jaegerIndexName, jsonSpan); err != nil {
 		return err
 	}
 	return nil
@@ -110,15 +115,15 @@ func spanIndexName(span *model.Span) string {
 	return indexPrefix + spanDate
 }
 
-func (s *SpanWriter) createIndex(indexName string, jsonSpan *jModel.Span) error {
+func (s *SpanWriter) createIndex(ctx context.Context, indexName string, jsonSpan *jModel.Span) error {
 	if !keyInCache(indexName, s.indexCache) {
 		start := time.Now()
-		exists, _ := s.client.IndexExists(indexName).Do(s.ctx) // don't need to check the error because the exists variable will be false anyway if there is an error
+		exists, _ := s.client.IndexExists(indexName).Do(ctx) // don't need to check the error because the exists variable will be false anyway if there is an error
 		if !exists {
 			// if there are multiple collectors writing to the same elasticsearch host, if the collectors pass
 			// the exists check above and try to create the same index all at once, this might fail and
 			// drop a couple spans (~1 per collector). Creating indices ahead of time alleviates this issue.
-			_, err := s.client.CreateIndex(indexName).Body(spanMapping).Do(s.ctx)
+			_, err := s.client.CreateIndex(indexName).Body(spanMapping).Do(ctx)
 			s.writerMetrics.indexCreate.Emit(err, time.Since(start))
 			if err != nil {
 				return s.logError(jsonSpan, err, "Failed to create index", s.logger)
@@ -137,13 +142,13 @@ func writeCache(key string, c cache.Cache) {
 	c.Put(key, key)
 }
 
-func (s *SpanWriter) writeService(indexName string, jsonSpan *jModel.Span) error {
-	return s.serviceWriter(indexName, jsonSpan)
+func (s *SpanWriter) writeService(ctx context.Context, indexName string, jsonSpan *jModel.Span) error {
+	return s.serviceWriter(ctx, indexName, jsonSpan)
 }
 
-func (s *SpanWriter) writeSpan(indexName string, jsonSpan *jModel.Span) error {
+func (s *SpanWriter) writeSpan(ctx context.Context, indexName string, jsonSpan *jModel.Span) error {
 	start := time.Now()
-	_, err := s.client.Index().Index(indexName).Type(spanType).BodyJson(jsonSpan).Do(s.ctx)
+	_, err := s.client.Index().Index(indexName).Type(spanType).BodyJson(jsonSpan).Do(ctx)
 	s.writerMetrics.spans.Emit(err, time.Since(start))
 	if err != nil {
 		return s.logError(jsonSpan, err, "Failed to insert span", s.logger)
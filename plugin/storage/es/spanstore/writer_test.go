@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -62,7 +63,7 @@ func withSpanWriter(fn func(w *spanWriterTest)) {
 
 func TestNewSpanWriter(t *testing.T) {
 	withSpanWriter(func(w *spanWriterTest) {
-		var writer spanstore.Writer = w.writer
+		var writer spanstore.ContextWriter = w.writer
 		assert.NotNil(t, writer)
 	})
 }
@@ -272,13 +273,13 @@ func TestCheckAndCreateIndex(t *testing.T) {
 				SpanID:  json.SpanID("0"),
 			}
 
-			err := w.writer.createIndex(indexName, jsonSpan)
+			err := w.writer.createIndex(w.writer.ctx, indexName, jsonSpan)
 			createService.AssertNumberOfCalls(t, "Do", 1)
 
 			if testCase.expectedError == "" {
 				assert.NoError(t, err)
 				// makes sure that the cache works
-				_ = w.writer.createIndex(indexName, jsonSpan)
+				_ = w.writer.createIndex(w.writer.ctx, indexName, jsonSpan)
 				createService.AssertNumberOfCalls(t, "Do", 1)
 			} else {
 				assert.EqualError(t, err, testCase.expectedError)
@@ -308,7 +309,7 @@ func TestWriteSpanInternal(t *testing.T) {
 
 		jsonSpan := &json.Span{}
 
-		err := w.writer.writeSpan(indexName, jsonSpan)
+		err := w.writer.writeSpan(w.writer.ctx, indexName, jsonSpan)
 		require.NoError(t, err)
 
 		indexService.AssertNumberOfCalls(t, "Do", 1)
@@ -316,6 +317,27 @@ func TestWriteSpanInternal(t *testing.T) {
 	})
 }
 
+func TestWriteSpanInternalContext(t *testing.T) {
+	withSpanWriter(func(w *spanWriterTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		indexService := &mocks.IndexService{}
+
+		indexName := "jaeger-1995-04-21"
+		indexService.On("Index", stringMatcher(indexName)).Return(indexService)
+		indexService.On("Type", stringMatcher(spanType)).Return(indexService)
+		indexService.On("BodyJson", mock.AnythingOfType("*json.Span")).Return(indexService)
+		indexService.On("Do", ctx).Return(&elastic.IndexResponse{}, nil)
+
+		w.client.On("Index").Return(indexService)
+
+		err := w.writer.writeSpan(ctx, indexName, &json.Span{})
+		require.NoError(t, err)
+
+		indexService.AssertNumberOfCalls(t, "Do", 1)
+	})
+}
+
 func TestWriteSpanInternalError(t *testing.T) {
 	withSpanWriter(func(w *spanWriterTest) {
 		indexService := &mocks.IndexService{}
@@ -333,7 +355,7 @@ func TestWriteSpanInternalError(t *testing.T) {
 			SpanID:  json.SpanID("0"),
 		}
 
-		err := w.writer.writeSpan(indexName, jsonSpan)
+		err := w.writer.writeSpan(w.writer.ctx, indexName, jsonSpan)
 		assert.EqualError(t, err, "Failed to insert span: span insertion error")
 
 		indexService.AssertNumberOfCalls(t, "Do", 1)
@@ -0,0 +1,244 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dependencystore
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/influxdb"
+	"github.com/uber/jaeger/pkg/influxdb/config"
+	"github.com/uber/jaeger/pkg/influxdb/influxql"
+)
+
+const (
+	dependenciesMeasurement = "dependencies"
+	parentTag               = "parent"
+	childTag                = "child"
+	callCountField          = "call_count"
+)
+
+// ErrIncorrectValueFormat occurs when data from InfluxDB was of an unexpected type
+var ErrIncorrectValueFormat = errors.New("Malformed response object")
+
+// DependencyStore handles all queries and insertions to InfluxDB dependencies
+type DependencyStore struct {
+	client influxdb.Client
+	conf   *config.Configuration
+	logger *zap.Logger
+}
+
+// NewDependencyStore returns a DependencyStore
+func NewDependencyStore(client influxdb.Client, conf *config.Configuration, logger *zap.Logger) *DependencyStore {
+	return &DependencyStore{
+		client: client,
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// WriteDependencies implements dependencystore.Writer#WriteDependencies.
+// Each link is stored as a separate point at ts, which is the time bucket the links were aggregated for.
+func (s *DependencyStore) WriteDependencies(ts time.Time, dependencies []model.DependencyLink) error {
+	points := make([]*influxclient.Point, 0, len(dependencies))
+	for _, d := range dependencies {
+		point, err := influxclient.NewPoint(
+			dependenciesMeasurement,
+			map[string]string{parentTag: d.Parent, childTag: d.Child},
+			map[string]interface{}{callCountField: int64(d.CallCount)},
+			ts,
+		)
+		if err != nil {
+			return errors.Wrap(err, "Failed to create dependency point")
+		}
+		points = append(points, point)
+	}
+	if err := s.client.WritePoints(points); err != nil {
+		return errors.Wrap(err, "Failed to write dependencies")
+	}
+	return nil
+}
+
+// GetDependencies implements dependencystore.Reader#GetDependencies. It returns the pre-aggregated
+// links stored within the time range, or computes them from the span measurement if there are none.
+func (s *DependencyStore) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	return s.GetDependenciesContext(context.Background(), endTs, lookback)
+}
+
+// GetDependenciesContext implements dependencystore.ContextReader#GetDependenciesContext.
+func (s *DependencyStore) GetDependenciesContext(
+	ctx context.Context,
+	endTs time.Time,
+	lookback time.Duration,
+) ([]model.DependencyLink, error) {
+	start, end := endTs.Add(-lookback), endTs
+	deps, err := s.aggregatedDependencies(ctx, start, end)
+	if err != nil {
+		s.logger.Error("Failure to read dependencies", zap.Time("endTs", endTs), zap.Duration("lookback", lookback), zap.Error(err))
+		return nil, errors.Wrap(err, "Error reading dependencies from storage")
+	}
+	if len(deps) > 0 {
+		return deps, nil
+	}
+	deps, err = s.spanDependencies(ctx, start, end)
+	if err != nil {
+		s.logger.Error("Failure to compute dependencies from spans", zap.Time("endTs", endTs), zap.Duration("lookback", lookback), zap.Error(err))
+		return nil, errors.Wrap(err, "Error computing dependencies from spans")
+	}
+	return deps, nil
+}
+
+func (s *DependencyStore) aggregatedDependencies(ctx context.Context, start, end time.Time) ([]model.DependencyLink, error) {
+	query := influxql.SelectCall(dependenciesMeasurement, "SUM", callCountField).
+		Where(influxql.TimeRange(start, end)).
+		GroupBy(parentTag, childTag)
+	res, err := s.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	var deps []model.DependencyLink
+	for _, result := range res.Results {
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				count, err := intValue(v)
+				if err != nil {
+					return nil, err
+				}
+				deps = append(deps, model.DependencyLink{
+					Parent:    row.Tags[parentTag],
+					Child:     row.Tags[childTag],
+					CallCount: uint64(count),
+				})
+			}
+		}
+	}
+	sortDependencies(deps)
+	return deps, nil
+}
+
+// spanDependencies counts the calls between services from the parent-child relationships of spans.
+// Calls within the same service are not considered dependencies.
+func (s *DependencyStore) spanDependencies(ctx context.Context, start, end time.Time) ([]model.DependencyLink, error) {
+	// annotation points duplicate the span point, so only the latter is counted
+	query := influxql.SelectCall(influxdb.Measurement, "COUNT", influxdb.DurationField).
+		Where(influxql.TimeRange(start, end), influxql.Eq(influxdb.AnnotationTag, "")).
+		GroupBy(influxdb.TraceIDTag, influxdb.SpanIDTag, influxdb.ParentSpanIDTag, influxdb.ServiceNameTag)
+	res, err := s.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	type spanKey struct {
+		traceID string
+		spanID  string
+	}
+	services := make(map[spanKey]string)
+	for _, result := range res.Results {
+		for _, row := range result.Series {
+			services[spanKey{row.Tags[influxdb.TraceIDTag], row.Tags[influxdb.SpanIDTag]}] = row.Tags[influxdb.ServiceNameTag]
+		}
+	}
+
+	links := make(map[string]*model.DependencyLink)
+	for _, result := range res.Results {
+		for _, row := range result.Series {
+			parentID, spanID := row.Tags[influxdb.ParentSpanIDTag], row.Tags[influxdb.SpanIDTag]
+			if parentID == "" || parentID == "0" || parentID == spanID {
+				continue
+			}
+			parent, ok := services[spanKey{row.Tags[influxdb.TraceIDTag], parentID}]
+			child := row.Tags[influxdb.ServiceNameTag]
+			if !ok || parent == child {
+				continue
+			}
+			for _, v := range row.Values {
+				count, err := intValue(v)
+				if err != nil {
+					return nil, err
+				}
+				key := parent + "&&&" + child
+				if _, ok := links[key]; !ok {
+					links[key] = &model.DependencyLink{Parent: parent, Child: child}
+				}
+				links[key].CallCount += uint64(count)
+			}
+		}
+	}
+
+	deps := make([]model.DependencyLink, 0, len(links))
+	for _, link := range links {
+		deps = append(deps, *link)
+	}
+	sortDependencies(deps)
+	return deps, nil
+}
+
+func (s *DependencyStore) query(ctx context.Context, query *influxql.Query) (*influxclient.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res, err := s.client.QuerySpans(query.String(), s.conf.Database)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Error(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// intValue returns the aggregate from a [time, value] row.
+func intValue(v []interface{}) (int64, error) {
+	if len(v) < 2 {
+		return 0, ErrIncorrectValueFormat
+	}
+	n, ok := v[1].(json.Number)
+	if !ok {
+		return 0, ErrIncorrectValueFormat
+	}
+	count, err := n.Int64()
+	if err != nil {
+		return 0, ErrIncorrectValueFormat
+	}
+	return count, nil
+}
+
+type dependenciesByServices []model.DependencyLink
+
+func (d dependenciesByServices) Len() int      { return len(d) }
+func (d dependenciesByServices) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d dependenciesByServices) Less(i, j int) bool {
+	if d[i].Parent != d[j].Parent {
+		return d[i].Parent < d[j].Parent
+	}
+	return d[i].Child < d[j].Child
+}
+
+func sortDependencies(deps []model.DependencyLink) {
+	sort.Sort(dependenciesByServices(deps))
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dependencystore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/influxdb/config"
+	"github.com/uber/jaeger/pkg/influxdb/mocks"
+	"github.com/uber/jaeger/storage/dependencystore"
+)
+
+type depStorageTest struct {
+	client  *mocks.Client
+	storage *DependencyStore
+}
+
+func withDepStore(fn func(s *depStorageTest)) {
+	client := &mocks.Client{}
+	s := &depStorageTest{
+		client:  client,
+		storage: NewDependencyStore(client, &config.Configuration{Database: "jaeger"}, zap.NewNop()),
+	}
+	fn(s)
+}
+
+var _ dependencystore.ContextReader = &DependencyStore{} // check API conformance
+var _ dependencystore.Writer = &DependencyStore{}        // check API conformance
+
+func TestWriteDependencies(t *testing.T) {
+	withDepStore(func(s *depStorageTest) {
+		ts := time.Unix(100, 0)
+		var written []*influxclient.Point
+		s.client.On("WritePoints", mock.AnythingOfType("[]*client.Point")).
+			Run(func(args mock.Arguments) { written = args.Get(0).([]*influxclient.Point) }).
+			Return(nil)
+
+		err := s.storage.WriteDependencies(ts, []model.DependencyLink{
+			{Parent: "hello", Child: "world", CallCount: 12},
+		})
+		require.NoError(t, err)
+		require.Len(t, written, 1)
+		assert.Equal(t, "dependencies", written[0].Name())
+		assert.Equal(t, map[string]string{"parent": "hello", "child": "world"}, written[0].Tags())
+		assert.Equal(t, ts, written[0].Time())
+		fields, err := written[0].Fields()
+		require.NoError(t, err)
+		assert.EqualValues(t, 12, fields["call_count"])
+	})
+}
+
+func TestWriteDependenciesError(t *testing.T) {
+	withDepStore(func(s *depStorageTest) {
+		s.client.On("WritePoints", mock.Anything).Return(errors.New("write error"))
+		err := s.storage.WriteDependencies(time.Now(), []model.DependencyLink{{Parent: "a", Child: "b"}})
+		assert.EqualError(t, err, "Failed to write dependencies: write error")
+	})
+}
+
+func response(rows ...models.Row) *influxclient.Response {
+	return &influxclient.Response{Results: []influxclient.Result{{Series: rows}}}
+}
+
+func countRow(tags map[string]string, count int) models.Row {
+	return models.Row{
+		Tags:    tags,
+		Columns: []string{"time", "value"},
+		Values:  [][]interface{}{{json.Number("0"), json.Number(fmt.Sprint(count))}},
+	}
+}
+
+func TestGetDependencies(t *testing.T) {
+	endTs := time.Unix(0, 1000)
+	aggregatedQuery := `SELECT SUM("call_count") FROM "dependencies" WHERE time >= 400 AND time <= 1000 GROUP BY "parent", "child"`
+	spansQuery := `SELECT COUNT("duration_ns") FROM "zipkin" WHERE time >= 400 AND time <= 1000 AND "annotation" = '' GROUP BY "trace_id", "id", "parent_id", "service_name"`
+	span := func(traceID, id, parentID, service string) models.Row {
+		return countRow(map[string]string{"trace_id": traceID, "id": id, "parent_id": parentID, "service_name": service}, 1)
+	}
+
+	testCases := []struct {
+		caption       string
+		aggregated    *influxclient.Response
+		aggregatedErr error
+		spans         *influxclient.Response
+		spansErr      error
+		expected      []model.DependencyLink
+		expectedError string
+	}{
+		{
+			caption: "pre-aggregated dependencies",
+			aggregated: response(
+				countRow(map[string]string{"parent": "world", "child": "hello"}, 3),
+				countRow(map[string]string{"parent": "hello", "child": "world"}, 1),
+			),
+			expected: []model.DependencyLink{
+				{Parent: "hello", Child: "world", CallCount: 1},
+				{Parent: "world", Child: "hello", CallCount: 3},
+			},
+		},
+		{
+			caption:    "computed from spans",
+			aggregated: response(),
+			spans: response(
+				span("1", "1", "0", "frontend"),
+				span("1", "2", "1", "backend"),
+				span("1", "3", "1", "backend"),
+				span("1", "4", "3", "backend"),
+				span("2", "1", "1", "frontend"),
+				span("2", "2", "1", "db"),
+				span("3", "7", "5", "orphan"),
+			),
+			expected: []model.DependencyLink{
+				{Parent: "frontend", Child: "backend", CallCount: 2},
+				{Parent: "frontend", Child: "db", CallCount: 1},
+			},
+		},
+		{
+			caption:       "aggregated query error",
+			aggregatedErr: errors.New("query error"),
+			expectedError: "Error reading dependencies from storage: query error",
+		},
+		{
+			caption:       "aggregated response error",
+			aggregated:    &influxclient.Response{Err: "database not found"},
+			expectedError: "Error reading dependencies from storage: database not found",
+		},
+		{
+			caption:       "malformed aggregate",
+			aggregated:    response(models.Row{Values: [][]interface{}{{"0", "x"}}}),
+			expectedError: "Error reading dependencies from storage: Malformed response object",
+		},
+		{
+			caption:       "spans query error",
+			aggregated:    response(),
+			spansErr:      errors.New("query error"),
+			expectedError: "Error computing dependencies from spans: query error",
+		},
+	}
+	for _, tc := range testCases {
+		testCase := tc
+		t.Run(testCase.caption, func(t *testing.T) {
+			withDepStore(func(s *depStorageTest) {
+				s.client.On("QuerySpans", aggregatedQuery, "jaeger").Return(testCase.aggregated, testCase.aggregatedErr)
+				s.client.On("QuerySpans", spansQuery, "jaeger").Return(testCase.spans, testCase.spansErr)
+
+				deps, err := s.storage.GetDependencies(endTs, 600*time.Nanosecond)
+				if testCase.expectedError != "" {
+					assert.EqualError(t, err, testCase.expectedError)
+					assert.Nil(t, deps)
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, testCase.expected, deps)
+				}
+			})
+		})
+	}
+}
+
+func TestGetDependenciesContextCanceled(t *testing.T) {
+	withDepStore(func(s *depStorageTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		deps, err := s.storage.GetDependenciesContext(ctx, time.Unix(2, 0), time.Second)
+		assert.EqualError(t, err, "Error reading dependencies from storage: context canceled")
+		assert.Nil(t, deps)
+		s.client.AssertNotCalled(t, "QuerySpans", mock.Anything, mock.Anything)
+	})
+}
@@ -1,18 +1,24 @@
 package spanstore
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 
+	influxclient "github.com/influxdata/influxdb/client/v2"
 	influx "github.com/influxdata/influxdb/models"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
 	"github.com/uber/jaeger/model"
 	"github.com/uber/jaeger/pkg/influxdb"
 	"github.com/uber/jaeger/pkg/influxdb/config"
+	"github.com/uber/jaeger/pkg/influxdb/influxql"
 	"github.com/uber/jaeger/storage/spanstore"
+	storageMetrics "github.com/uber/jaeger/storage/spanstore/metrics"
 )
 
 var (
@@ -38,25 +44,46 @@ var (
 	ErrUnknownFields = errors.New("Unknown fields in response object")
 )
 
-type SpanReader struct {
-	client influxdb.Client
-	conf   *config.Configuration
+const defaultNumTraces = 100
+
+type spanReaderMetrics struct {
+	readTrace      *storageMetrics.WriteMetrics
+	readTraces     *storageMetrics.WriteMetrics
+	queryTraceIDs  *storageMetrics.WriteMetrics
+	queryTagIndex  *storageMetrics.WriteMetrics
+	queryTagValues *storageMetrics.WriteMetrics
 }
 
-const (
-	GetTraceQueryTemplate = `SELECT * FROM "zipkin" WHERE "trace_id" = '%s'`
-)
+type SpanReader struct {
+	client  influxdb.Client
+	conf    *config.Configuration
+	logger  *zap.Logger
+	metrics spanReaderMetrics
+}
 
 func (s *SpanReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
-	query := fmt.Sprintf(GetTraceQueryTemplate, traceID.String())
-	res, err := s.client.QuerySpans(query, s.conf.Database)
+	return s.GetTraceContext(context.Background(), traceID)
+}
+
+// GetTraceContext is GetTrace bound to ctx. It returns spanstore.ErrTraceNotFound if the trace has no spans.
+func (s *SpanReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	query := influxql.Select(influxdb.Measurement, "*").
+		Where(influxql.Eq(influxdb.TraceIDTag, traceID.String()))
+	res, err := s.query(ctx, query, s.metrics.readTrace)
 	if err != nil {
 		return nil, err
 	}
 	if len(res.Results) != 1 || len(res.Results[0].Series) != 1 {
-		return &model.Trace{}, nil
+		return nil, spanstore.ErrTraceNotFound
 	}
-	return NewTrace(res.Results[0].Series)
+	trace, err := NewTrace(res.Results[0].Series)
+	if err != nil {
+		return nil, err
+	}
+	if len(trace.Spans) == 0 {
+		return nil, spanstore.ErrTraceNotFound
+	}
+	return trace, nil
 }
 
 func NewTrace(series []influx.Row) (*model.Trace, error) {
@@ -96,8 +123,13 @@ func formatTraceID(t *model.TraceID) string {
 }
 
 func (s *SpanReader) GetServices() ([]string, error) {
-	query := `SHOW TAG VALUES FROM "zipkin" WITH KEY = "service_name"`
-	res, err := s.client.QuerySpans(query, s.conf.Database)
+	return s.GetServicesContext(context.Background())
+}
+
+// GetServicesContext is GetServices bound to ctx
+func (s *SpanReader) GetServicesContext(ctx context.Context) ([]string, error) {
+	query := influxql.ShowTagValues(influxdb.OperationNamesMeasurement, influxdb.ServiceNameTag)
+	res, err := s.query(ctx, query, s.metrics.queryTagValues)
 	if err != nil {
 		return nil, err
 	}
@@ -115,8 +147,14 @@ func (s *SpanReader) GetServices() ([]string, error) {
 }
 
 func (s *SpanReader) GetOperations(service string) ([]string, error) {
-	query := fmt.Sprintf(`SHOW TAG VALUES FROM "zipkin" with key="name" WHERE "service_name" = '%s'`, service)
-	res, err := s.client.QuerySpans(query, s.conf.Database)
+	return s.GetOperationsContext(context.Background(), service)
+}
+
+// GetOperationsContext is GetOperations bound to ctx
+func (s *SpanReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	query := influxql.ShowTagValues(influxdb.OperationNamesMeasurement, influxdb.OperationTag).
+		Where(influxql.Eq(influxdb.ServiceNameTag, service))
+	res, err := s.query(ctx, query, s.metrics.queryTagValues)
 	if err != nil {
 		return nil, err
 	}
@@ -156,6 +194,11 @@ func validateQuery(p *spanstore.TraceQueryParameters) error {
 
 type Span struct {
 	*model.Span
+
+	// complete is set when the row carries the JSON-encoded span fields written by SpanWriter,
+	// in which case annotation rows for the same span are redundant.
+	complete    bool
+	processTags model.KeyValues
 }
 
 func NewSpan(tags map[string]string, fields []string, values []interface{}) (*Span, error) {
@@ -188,6 +231,12 @@ func NewSpan(tags map[string]string, fields []string, values []interface{}) (*Sp
 			return nil, err
 		}
 	}
+	if span.complete {
+		if span.Process != nil {
+			span.Process.Tags = span.processTags
+		}
+		return span, nil
+	}
 	if span.SpanID == span.ParentSpanID {
 		span.ParentSpanID = model.SpanID(0)
 	} else {
@@ -216,7 +265,7 @@ func (s *Span) AddField(column string, value interface{}) error {
 		if err != nil {
 			return err
 		}
-		s.StartTime = time.Unix(0, t)
+		s.StartTime = time.Unix(0, t).UTC()
 	case "annotation":
 		a, ok := value.(string)
 		if !ok {
@@ -290,9 +339,31 @@ func (s *Span) AddField(column string, value interface{}) error {
 		if !ok {
 			return ErrIncorrectValueFormat
 		}
-		s.Process = &model.Process{
-			ServiceName: v,
+		if s.Process == nil {
+			s.Process = &model.Process{}
+		}
+		s.Process.ServiceName = v
+	case "flags":
+		n, ok := value.(json.Number)
+		if !ok {
+			return ErrIncorrectValueFormat
+		}
+		f, err := n.Int64()
+		if err != nil {
+			return err
 		}
+		s.Flags = model.Flags(f)
+	case "tags":
+		s.complete = true
+		return unmarshalField(value, &s.Tags)
+	case "logs":
+		return unmarshalField(value, &s.Logs)
+	case "process_tags":
+		return unmarshalField(value, &s.processTags)
+	case "references":
+		return unmarshalField(value, &s.References)
+	case "warnings":
+		return unmarshalField(value, &s.Warnings)
 	case "trace_id":
 		v, ok := value.(string)
 		if !ok {
@@ -307,6 +378,14 @@ func (s *Span) AddField(column string, value interface{}) error {
 	return nil
 }
 
+func unmarshalField(value interface{}, v interface{}) error {
+	str, ok := value.(string)
+	if !ok {
+		return ErrIncorrectValueFormat
+	}
+	return json.Unmarshal([]byte(str), v)
+}
+
 func merge(span *Span, elems ...*Span) *Span {
 	for _, e := range elems {
 		for _, tag := range e.Tags {
@@ -319,9 +398,6 @@ func merge(span *Span, elems ...*Span) *Span {
 			span.Process = e.Process
 		} else if span.Process != nil && e.Process != nil {
 			span.Process.Tags = append(span.Process.Tags, e.Process.Tags...)
-			if span.Process.ServiceName != e.Process.ServiceName {
-				fmt.Printf("DIFFERent NAME %s\n", span.Process.ServiceName)
-			}
 		}
 	}
 	return span
@@ -330,6 +406,11 @@ func merge(span *Span, elems ...*Span) *Span {
 type Spans []*Span
 
 func (spans Spans) Reduce() *model.Span {
+	for _, s := range spans {
+		if s.complete {
+			return s.Span
+		}
+	}
 	switch len(spans) {
 	case 0:
 		return nil
@@ -340,134 +421,249 @@ func (spans Spans) Reduce() *model.Span {
 	}
 }
 
+// FindTraces returns the traces that have a span of the service matching the operation and duration bounds,
+// and for each of the query tags a span of the service carrying it, ordered newest first and paginated
+// by the offset and the number of traces of the query.
 func (s *SpanReader) FindTraces(q *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
-	if err := validateQuery(q); err != nil {
+	return s.FindTracesContext(context.Background(), q)
+}
+
+// FindTracesContext is FindTraces bound to ctx
+func (s *SpanReader) FindTracesContext(ctx context.Context, q *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	traceIDs, err := s.findPageTraceIDs(ctx, q)
+	if err != nil {
 		return nil, err
 	}
-	fields := []string{"time", "service_name", `"name"`, "annotation_key", "annotation", "endpoint_host", "id", "parent_id", "duration_ns"}
-	query := `SELECT %s FROM "zipkin" WHERE  time < %d AND time > %d `
-	query = fmt.Sprintf(query, strings.Join(fields, ","), q.StartTimeMax.UTC().UnixNano(), q.StartTimeMin.UTC().UnixNano())
-
-	//	query := `SELECT %s FROM "zipkin" WHERE "service_name" = '%s' AND time < %d AND time > %d `
-	//query = fmt.Sprintf(query, strings.Join(fields, ","), q.ServiceName, q.StartTimeMax.UTC().UnixNano(), q.StartTimeMin.UTC().UnixNano())
+	// webui seems to hang forever (stack) if this isn't nil
+	if len(traceIDs) == 0 {
+		return nil, nil
+	}
+	return s.loadTraces(ctx, traceIDs)
+}
 
-	if q.OperationName != "" {
-		query += fmt.Sprintf(`AND "name" = '%s' `, q.OperationName)
+// FindTraceIDs returns the IDs of the traces FindTraces returns, in the same order, without loading them
+func (s *SpanReader) FindTraceIDs(ctx context.Context, q *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	tagValues, err := s.findPageTraceIDs(ctx, q)
+	if err != nil {
+		return nil, err
 	}
-	tags := []string{}
-	for k, v := range q.Tags {
-		if k == "" || v == "" {
-			continue
+	traceIDs := make([]model.TraceID, 0, len(tagValues))
+	for _, tagValue := range tagValues {
+		traceID, err := model.TraceIDFromString(tagValue)
+		if err != nil {
+			return nil, err
 		}
-		t := fmt.Sprintf(`("annotation_key" = '%s' AND "annotation" = '%s')`, k, v)
-		tags = append(tags, t)
+		traceIDs = append(traceIDs, traceID)
 	}
+	return traceIDs, nil
+}
 
-	switch len(tags) {
-	case 1:
-		query += fmt.Sprintf(" AND %s", tags[0])
-	case 2:
-		query += fmt.Sprintf(" AND (%s)", strings.Join(tags, " OR "))
+// findPageTraceIDs returns the trace ID tag values of the page of traces selected by the query
+func (s *SpanReader) findPageTraceIDs(ctx context.Context, q *spanstore.TraceQueryParameters) ([]string, error) {
+	if err := validateQuery(q); err != nil {
+		return nil, err
 	}
-
-	if q.DurationMin != 0 {
-		query += fmt.Sprintf(` AND "duration" >= %d `, q.DurationMin.Nanoseconds())
+	numTraces := q.NumTraces
+	if numTraces == 0 {
+		numTraces = defaultNumTraces
 	}
-
-	if q.DurationMax != 0 {
-		query += fmt.Sprintf(` AND "duration" <= %d `, q.DurationMax.Nanoseconds())
+	traceIDs, err := s.findTraceIDs(ctx, q)
+	if err != nil {
+		return nil, err
 	}
+	if q.Offset >= len(traceIDs) {
+		return nil, nil
+	}
+	traceIDs = traceIDs[q.Offset:]
+	if len(traceIDs) > numTraces {
+		traceIDs = traceIDs[:numTraces]
+	}
+	return traceIDs, nil
+}
 
-	//query += ` GROUP BY "service_name", "name", "trace_id" `
-	query += ` GROUP BY "trace_id" `
-
-	if q.NumTraces > 0 {
-		query += fmt.Sprintf(" SLIMIT %d", q.NumTraces)
+// findTraceIDs intersects the traces matching the span conditions with the traces matching each tag.
+// Tags are matched against the annotation points, which cover span tags, process tags and log fields.
+func (s *SpanReader) findTraceIDs(ctx context.Context, q *spanstore.TraceQueryParameters) ([]string, error) {
+	base := []influxql.Expr{influxql.TimeRange(q.StartTimeMin, q.StartTimeMax)}
+	if q.ServiceName != "" {
+		base = append(base, influxql.Eq(influxdb.ServiceNameTag, q.ServiceName))
 	}
 
-	fmt.Printf("\n\n*** query %s***\n\n", query)
-	res, err := s.client.QuerySpans(query, s.conf.Database)
+	spansQuery := influxql.SelectCall(influxdb.Measurement, "LAST", influxdb.DurationField).
+		Where(base...).
+		Where(influxql.Eq(influxdb.AnnotationTag, ""))
+	if q.OperationName != "" {
+		spansQuery.Where(influxql.Eq(influxdb.OperationTag, q.OperationName))
+	}
+	if q.DurationMin != 0 {
+		spansQuery.Where(influxql.Gte(influxdb.DurationField, q.DurationMin.Nanoseconds()))
+	}
+	if q.DurationMax != 0 {
+		spansQuery.Where(influxql.Lte(influxdb.DurationField, q.DurationMax.Nanoseconds()))
+	}
+	candidates, err := s.queryTraceIDs(ctx, spansQuery.GroupBy(influxdb.TraceIDTag), s.metrics.queryTraceIDs)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("RES\n\n%#+v\n\n\n\n******\n", res)
-	traces := []*model.Trace{}
-	for _, result := range res.Results {
-		trace, err := NewTrace(result.Series)
+
+	for k, v := range q.Tags {
+		if len(candidates) == 0 {
+			break
+		}
+		if k == "" || v == "" {
+			continue
+		}
+		tagQuery := influxql.SelectCall(influxdb.Measurement, "LAST", influxdb.DurationField).
+			Where(base...).
+			Where(influxql.Eq(influxdb.AnnotationKeyTag, k), influxql.Eq(influxdb.AnnotationTag, v)).
+			GroupBy(influxdb.TraceIDTag)
+		matches, err := s.queryTraceIDs(ctx, tagQuery, s.metrics.queryTagIndex)
 		if err != nil {
 			return nil, err
 		}
-		if len(trace.Spans) > 0 {
-			traces = append(traces, trace)
+		for traceID := range candidates {
+			if _, ok := matches[traceID]; !ok {
+				delete(candidates, traceID)
+			}
 		}
 	}
 
-	// webui seems to hang forever (stack) if this isn't nil
-	if len(traces) == 0 {
-		return nil, nil
+	traceIDs := make(traceIDsByTime, 0, len(candidates))
+	for traceID, ts := range candidates {
+		traceIDs = append(traceIDs, traceIDTime{traceID: traceID, ts: ts})
 	}
-	b, _ := json.MarshalIndent(traces, "", "    ")
-	fmt.Printf("\n\n%s\n\n", string(b))
-	return traces, nil
-}
-
-func (s *SpanReader) WriteSpan(span *model.Span) error {
-	return nil
-}
-
-func NewSpanReader(client influxdb.Client, conf *config.Configuration) *SpanReader {
-	return &SpanReader{
-		client: client,
-		conf:   conf,
+	sort.Sort(traceIDs)
+	ids := make([]string, len(traceIDs))
+	for i, t := range traceIDs {
+		ids[i] = t.traceID
 	}
+	return ids, nil
 }
 
-// GetDependencies loads service dependencies from influx.
-func (s *SpanReader) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
-	end := endTs.UTC().UnixNano()
-	start := endTs.Add(-lookback).UTC().UnixNano()
-
-	query := fmt.Sprintf(`SELECT COUNT("duration_ns") FROM "zipkin" WHERE  time > %d AND time < %d AND annotation='' GROUP BY "id","parent_id", "service_name"`, start, end)
-	fmt.Printf("Query: %s endTs.String(): %s, lookback.String(): %s\n", query, endTs.String(), lookback.String())
-	res, err := s.client.QuerySpans(query, s.conf.Database)
-	fmt.Printf("Response: %#+v\n", res)
+// queryTraceIDs runs a query grouped by trace ID and returns the time of the latest point for each trace.
+func (s *SpanReader) queryTraceIDs(
+	ctx context.Context,
+	query *influxql.Query,
+	queryMetrics *storageMetrics.WriteMetrics,
+) (map[string]int64, error) {
+	res, err := s.query(ctx, query, queryMetrics)
 	if err != nil {
 		return nil, err
 	}
-
-	services := make(map[string]string)
-
+	traceIDs := make(map[string]int64)
 	for _, result := range res.Results {
-		for _, series := range result.Series {
-			id := series.Tags["id"]
-			srv := series.Tags["service_name"]
-			services[id] = srv
-		}
-	}
-
-	deps := make([]model.DependencyLink, 0)
-	for _, result := range res.Results {
-		for _, series := range result.Series {
-			srv := series.Tags["service_name"]
-			pid := series.Tags["parent_id"]
-			psrv := services[pid]
-			for _, r := range series.Values {
-				raw, ok := r[1].(json.Number)
+		for _, row := range result.Series {
+			traceID := row.Tags[influxdb.TraceIDTag]
+			for _, v := range row.Values {
+				if len(v) == 0 {
+					return nil, ErrIncorrectValueFormat
+				}
+				n, ok := v[0].(json.Number)
 				if !ok {
 					return nil, ErrIncorrectValueFormat
 				}
-				count, err := raw.Int64()
+				ts, err := n.Int64()
 				if err != nil {
-					return nil, ErrIncorrectValueFormat
+					return nil, err
 				}
-				d := model.DependencyLink{
-					Parent:    psrv,
-					Child:     srv,
-					CallCount: uint64(count),
+				if ts > traceIDs[traceID] {
+					traceIDs[traceID] = ts
 				}
-				deps = append(deps, d)
 			}
 		}
 	}
-	return deps, nil
+	return traceIDs, nil
+}
+
+// loadTraces reads the complete traces in a single query and returns them in the order of traceIDs.
+func (s *SpanReader) loadTraces(ctx context.Context, traceIDs []string) ([]*model.Trace, error) {
+	ids := make([]influxql.Expr, len(traceIDs))
+	for i, traceID := range traceIDs {
+		ids[i] = influxql.Eq(influxdb.TraceIDTag, traceID)
+	}
+	query := influxql.Select(influxdb.Measurement, "*").
+		Where(influxql.Or(ids...)).
+		GroupBy(influxdb.TraceIDTag)
+	res, err := s.query(ctx, query, s.metrics.readTraces)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.Trace, len(traceIDs))
+	for _, result := range res.Results {
+		for _, row := range result.Series {
+			trace, err := NewTrace([]influx.Row{row})
+			if err != nil {
+				return nil, err
+			}
+			byID[row.Tags[influxdb.TraceIDTag]] = trace
+		}
+	}
+	traces := make([]*model.Trace, 0, len(traceIDs))
+	for _, traceID := range traceIDs {
+		if trace, ok := byID[traceID]; ok && len(trace.Spans) > 0 {
+			traces = append(traces, trace)
+		}
+	}
+	return traces, nil
+}
+
+// query executes the query against the configured database unless ctx is already done, since the InfluxDB
+// client cannot abandon a query in flight, and reports metrics and logs about it.
+func (s *SpanReader) query(
+	ctx context.Context,
+	query *influxql.Query,
+	queryMetrics *storageMetrics.WriteMetrics,
+) (*influxclient.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := s.client.QuerySpans(query.String(), s.conf.Database)
+	if err == nil {
+		err = res.Error()
+	}
+	queryMetrics.Emit(err, time.Since(start))
+	if err != nil {
+		s.logger.Error("Failed to exec query", zap.String("query", query.String()), zap.Error(err))
+		return nil, err
+	}
+	return res, nil
+}
+
+type traceIDTime struct {
+	traceID string
+	ts      int64
+}
+
+// traceIDsByTime orders trace IDs by their latest matching span, newest first.
+type traceIDsByTime []traceIDTime
+
+func (t traceIDsByTime) Len() int      { return len(t) }
+func (t traceIDsByTime) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t traceIDsByTime) Less(i, j int) bool {
+	if t[i].ts != t[j].ts {
+		return t[i].ts > t[j].ts
+	}
+	return t[i].traceID < t[j].traceID
+}
+
+// NewSpanReader returns a spanstore.Reader for InfluxDB that reports metrics for every read operation.
+func NewSpanReader(client influxdb.Client, conf *config.Configuration, logger *zap.Logger, metricsFactory metrics.Factory) spanstore.Reader {
+	return storageMetrics.NewReadMetricsDecorator(newSpanReader(client, conf, logger, metricsFactory), metricsFactory)
+}
+
+func newSpanReader(client influxdb.Client, conf *config.Configuration, logger *zap.Logger, metricsFactory metrics.Factory) *SpanReader {
+	readFactory := metricsFactory.Namespace("Read", nil)
+	return &SpanReader{
+		client: client,
+		conf:   conf,
+		logger: logger,
+		metrics: spanReaderMetrics{
+			readTrace:      storageMetrics.NewWriteMetrics(readFactory, "ReadTrace"),
+			readTraces:     storageMetrics.NewWriteMetrics(readFactory, "ReadTraces"),
+			queryTraceIDs:  storageMetrics.NewWriteMetrics(readFactory, "TraceIDs"),
+			queryTagIndex:  storageMetrics.NewWriteMetrics(readFactory, "TagIndex"),
+			queryTagValues: storageMetrics.NewWriteMetrics(readFactory, "TagValues"),
+		},
+	}
 }
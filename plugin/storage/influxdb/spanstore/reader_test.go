@@ -0,0 +1,503 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/influxdb"
+	"github.com/uber/jaeger/pkg/influxdb/config"
+	"github.com/uber/jaeger/pkg/influxdb/mocks"
+	"github.com/uber/jaeger/pkg/testutils"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+type spanReaderTest struct {
+	client         *mocks.Client
+	logBuffer      *testutils.Buffer
+	metricsFactory *metrics.LocalFactory
+	reader         *SpanReader
+}
+
+func withSpanReader(fn func(r *spanReaderTest)) {
+	client := &mocks.Client{}
+	logger, logBuffer := testutils.NewLogger()
+	metricsFactory := metrics.NewLocalFactory(0)
+	fn(&spanReaderTest{
+		client:         client,
+		logBuffer:      logBuffer,
+		metricsFactory: metricsFactory,
+		reader:         newSpanReader(client, &config.Configuration{Database: "jaeger"}, logger, metricsFactory),
+	})
+}
+
+var _ spanstore.ContextReader = &SpanReader{} // check API conformance
+
+func TestNewSpanReader(t *testing.T) {
+	client := &mocks.Client{}
+	client.On("QuerySpans", `SHOW TAG VALUES FROM "operation_names" WITH KEY = "service_name"`, "jaeger").
+		Return(&influxclient.Response{}, nil)
+	metricsFactory := metrics.NewLocalFactory(0)
+	reader := NewSpanReader(client, &config.Configuration{Database: "jaeger"}, zap.NewNop(), metricsFactory)
+	_, err := reader.GetServices()
+	require.NoError(t, err)
+
+	counters, _ := metricsFactory.Snapshot()
+	assert.EqualValues(t, 1, counters["GetServices.attempts"])
+	assert.EqualValues(t, 1, counters["Read.TagValues.attempts"])
+}
+
+// pointsToRow renders points the way InfluxDB returns them for a SELECT * without GROUP BY:
+// a single series whose columns are the union of time, tag keys and field keys.
+func pointsToRow(t *testing.T, points []*influxclient.Point) models.Row {
+	columnSet := map[string]struct{}{}
+	for _, p := range points {
+		for k := range p.Tags() {
+			columnSet[k] = struct{}{}
+		}
+		fields, err := p.Fields()
+		require.NoError(t, err)
+		for k := range fields {
+			columnSet[k] = struct{}{}
+		}
+	}
+	columns := []string{}
+	for c := range columnSet {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+	columns = append([]string{"time"}, columns...)
+
+	row := models.Row{Name: influxdb.Measurement, Columns: columns}
+	for _, p := range points {
+		fields, err := p.Fields()
+		require.NoError(t, err)
+		values := make([]interface{}, len(columns))
+		values[0] = json.Number(fmt.Sprint(p.UnixNano()))
+		for i, c := range columns[1:] {
+			if v, ok := p.Tags()[c]; ok {
+				values[i+1] = v
+			} else if v, ok := fields[c]; ok {
+				if n, ok := v.(int64); ok {
+					v = json.Number(fmt.Sprint(n))
+				}
+				values[i+1] = v
+			}
+		}
+		row.Values = append(row.Values, values)
+	}
+	return row
+}
+
+func TestGetTraceRoundTrip(t *testing.T) {
+	traceID := model.TraceID{High: 1, Low: 0xff}
+	root := &model.Span{
+		TraceID:       traceID,
+		SpanID:        model.SpanID(1),
+		OperationName: "root",
+		References:    []model.SpanRef{},
+		StartTime:     time.Unix(10, 0).UTC(),
+		Duration:      time.Second,
+		Tags:          model.KeyValues{model.String("http.method", "GET"), model.Int64("http.status_code", 200)},
+		Logs:          []model.Log{{Timestamp: time.Unix(10, 5).UTC(), Fields: []model.KeyValue{model.String("event", "x")}}},
+		Process:       model.NewProcess("frontend", []model.KeyValue{model.String("hostname", "h1")}),
+	}
+	child := &model.Span{
+		TraceID:       traceID,
+		SpanID:        model.SpanID(2),
+		ParentSpanID:  model.SpanID(1),
+		OperationName: "child",
+		References:    []model.SpanRef{{RefType: model.ChildOf, TraceID: traceID, SpanID: model.SpanID(1)}},
+		Flags:         model.Flags(3),
+		StartTime:     time.Unix(10, 100).UTC(),
+		Duration:      time.Millisecond,
+		Tags:          model.KeyValues{},
+		Process:       model.NewProcess("backend", []model.KeyValue{}),
+		Warnings:      []string{"clock skew"},
+	}
+	var points []*influxclient.Point
+	for _, span := range []*model.Span{root, child} {
+		spanPoints, err := influxdb.SpanPoints(span)
+		require.NoError(t, err)
+		points = append(points, spanPoints...)
+	}
+
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", `SELECT * FROM "zipkin" WHERE "trace_id" = '100000000000000ff'`, "jaeger").
+			Return(&influxclient.Response{Results: []influxclient.Result{{Series: []models.Row{pointsToRow(t, points)}}}}, nil)
+
+		trace, err := r.reader.GetTrace(traceID)
+		require.NoError(t, err)
+		model.SortTrace(trace)
+		expected := &model.Trace{Spans: []*model.Span{root, child}}
+		model.SortTrace(expected)
+		assert.Equal(t, expected, trace)
+	})
+}
+
+func TestGetTraceNotFound(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", `SELECT * FROM "zipkin" WHERE "trace_id" = '1'`, "jaeger").
+			Return(&influxclient.Response{Results: []influxclient.Result{{}}}, nil)
+
+		trace, err := r.reader.GetTrace(model.TraceID{Low: 1})
+		assert.Equal(t, spanstore.ErrTraceNotFound, err)
+		assert.Nil(t, trace)
+	})
+}
+
+func TestGetOperationsQuotesService(t *testing.T) {
+	testCases := []struct {
+		service       string
+		expectedQuery string
+	}{
+		{
+			service:       "frontend",
+			expectedQuery: `SHOW TAG VALUES FROM "operation_names" WITH KEY = "name" WHERE "service_name" = 'frontend'`,
+		},
+		{
+			service:       `x' OR "service_name" =~ /.*/ OR 'a'='a`,
+			expectedQuery: `SHOW TAG VALUES FROM "operation_names" WITH KEY = "name" WHERE "service_name" = 'x\' OR "service_name" =~ /.*/ OR \'a\'=\'a'`,
+		},
+		{
+			service:       `back\slash'`,
+			expectedQuery: `SHOW TAG VALUES FROM "operation_names" WITH KEY = "name" WHERE "service_name" = 'back\\slash\''`,
+		},
+	}
+	for _, testCase := range testCases {
+		withSpanReader(func(r *spanReaderTest) {
+			r.client.On("QuerySpans", testCase.expectedQuery, "jaeger").Return(&influxclient.Response{
+				Results: []influxclient.Result{{Series: []models.Row{{
+					Columns: []string{"key", "value"},
+					Values:  [][]interface{}{{"name", "get"}},
+				}}}},
+			}, nil)
+			operations, err := r.reader.GetOperations(testCase.service)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"get"}, operations)
+		})
+	}
+}
+
+func TestGetServices(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", `SHOW TAG VALUES FROM "operation_names" WITH KEY = "service_name"`, "jaeger").Return(&influxclient.Response{
+			Results: []influxclient.Result{{Series: []models.Row{{
+				Columns: []string{"key", "value"},
+				Values:  [][]interface{}{{"service_name", "frontend"}, {"service_name", "backend"}},
+			}}}},
+		}, nil)
+		services, err := r.reader.GetServices()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"frontend", "backend"}, services)
+	})
+}
+
+// traceIDRows renders the response of a LAST(...) query grouped by trace ID, one series per trace.
+func traceIDRows(latest map[string]int64) *influxclient.Response {
+	var rows []models.Row
+	for traceID, ts := range latest {
+		rows = append(rows, models.Row{
+			Name:    influxdb.Measurement,
+			Tags:    map[string]string{influxdb.TraceIDTag: traceID},
+			Columns: []string{"time", "last"},
+			Values:  [][]interface{}{{json.Number(fmt.Sprint(ts)), json.Number("1000")}},
+		})
+	}
+	return &influxclient.Response{Results: []influxclient.Result{{Series: rows}}}
+}
+
+func testSpan(traceID model.TraceID, service string) *model.Span {
+	return &model.Span{
+		TraceID:       traceID,
+		SpanID:        model.SpanID(1),
+		OperationName: "op",
+		References:    []model.SpanRef{},
+		StartTime:     time.Unix(1, 500).UTC(),
+		Duration:      time.Microsecond,
+		Tags:          model.KeyValues{},
+		Process:       model.NewProcess(service, []model.KeyValue{}),
+	}
+}
+
+// traceRows renders the response of a SELECT * grouped by trace ID, one series per trace.
+func traceRows(t *testing.T, spans ...*model.Span) *influxclient.Response {
+	var rows []models.Row
+	for _, span := range spans {
+		points, err := influxdb.SpanPoints(span)
+		require.NoError(t, err)
+		row := pointsToRow(t, points)
+		row.Tags = map[string]string{influxdb.TraceIDTag: span.TraceID.String()}
+		rows = append(rows, row)
+	}
+	return &influxclient.Response{Results: []influxclient.Result{{Series: rows}}}
+}
+
+func TestFindTraces(t *testing.T) {
+	const (
+		timeRange = `time >= 1000000000 AND time <= 2000000000 AND "service_name" = 'svc'`
+		spanQuery = `SELECT LAST("duration_ns") FROM "zipkin" WHERE ` + timeRange +
+			` AND "annotation" = '' AND "name" = 'op' AND "duration_ns" >= 1000 AND "duration_ns" <= 5000 GROUP BY "trace_id"`
+		httpQuery = `SELECT LAST("duration_ns") FROM "zipkin" WHERE ` + timeRange +
+			` AND "annotation_key" = 'http.method' AND "annotation" = 'GET' GROUP BY "trace_id"`
+		errorQuery = `SELECT LAST("duration_ns") FROM "zipkin" WHERE ` + timeRange +
+			` AND "annotation_key" = 'error' AND "annotation" = 'true' GROUP BY "trace_id"`
+		loadQuery = `SELECT * FROM "zipkin" WHERE ("trace_id" = '3' OR "trace_id" = '1') GROUP BY "trace_id"`
+	)
+	query := &spanstore.TraceQueryParameters{
+		ServiceName:   "svc",
+		OperationName: "op",
+		Tags:          map[string]string{"http.method": "GET", "error": "true"},
+		StartTimeMin:  time.Unix(1, 0),
+		StartTimeMax:  time.Unix(2, 0),
+		DurationMin:   time.Microsecond,
+		DurationMax:   5 * time.Microsecond,
+	}
+	trace1 := testSpan(model.TraceID{Low: 1}, "svc")
+	trace3 := testSpan(model.TraceID{Low: 3}, "svc")
+
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", spanQuery, "jaeger").
+			Return(traceIDRows(map[string]int64{"1": 1100, "2": 1200, "3": 1300, "4": 1400}), nil)
+		// a trace must carry every tag, not just one of them
+		r.client.On("QuerySpans", httpQuery, "jaeger").
+			Return(traceIDRows(map[string]int64{"1": 1100, "2": 1200, "3": 1300}), nil)
+		r.client.On("QuerySpans", errorQuery, "jaeger").
+			Return(traceIDRows(map[string]int64{"1": 1100, "3": 1300, "4": 1400, "5": 1500}), nil)
+		r.client.On("QuerySpans", loadQuery, "jaeger").
+			Return(traceRows(t, trace1, trace3), nil)
+
+		traces, err := r.reader.FindTraces(query)
+		require.NoError(t, err)
+		require.Len(t, traces, 2)
+
+		counters, _ := r.metricsFactory.Snapshot()
+		assert.EqualValues(t, 1, counters["Read.TraceIDs.inserts"])
+		assert.EqualValues(t, 2, counters["Read.TagIndex.inserts"])
+		assert.EqualValues(t, 1, counters["Read.ReadTraces.inserts"])
+		assert.Equal(t, "", r.logBuffer.String())
+		// newest first
+		assert.Equal(t, []*model.Span{trace3}, traces[0].Spans)
+		assert.Equal(t, []*model.Span{trace1}, traces[1].Spans)
+	})
+}
+
+func TestFindTracesNumTraces(t *testing.T) {
+	const (
+		spanQuery = `SELECT LAST("duration_ns") FROM "zipkin" WHERE time >= 1000000000 AND time <= 2000000000` +
+			` AND "service_name" = 'svc' AND "annotation" = '' GROUP BY "trace_id"`
+		loadQuery = `SELECT * FROM "zipkin" WHERE "trace_id" = '2' GROUP BY "trace_id"`
+	)
+	trace2 := testSpan(model.TraceID{Low: 2}, "svc")
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", spanQuery, "jaeger").
+			Return(traceIDRows(map[string]int64{"1": 1100, "2": 1200}), nil)
+		r.client.On("QuerySpans", loadQuery, "jaeger").
+			Return(traceRows(t, trace2), nil)
+
+		traces, err := r.reader.FindTraces(&spanstore.TraceQueryParameters{
+			ServiceName:  "svc",
+			StartTimeMin: time.Unix(1, 0),
+			StartTimeMax: time.Unix(2, 0),
+			NumTraces:    1,
+		})
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		assert.Equal(t, []*model.Span{trace2}, traces[0].Spans)
+	})
+}
+
+func TestFindTracesOffset(t *testing.T) {
+	const (
+		spanQuery = `SELECT LAST("duration_ns") FROM "zipkin" WHERE time >= 1000000000 AND time <= 2000000000` +
+			` AND "service_name" = 'svc' AND "annotation" = '' GROUP BY "trace_id"`
+		loadQuery = `SELECT * FROM "zipkin" WHERE "trace_id" = '2' GROUP BY "trace_id"`
+	)
+	trace2 := testSpan(model.TraceID{Low: 2}, "svc")
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", spanQuery, "jaeger").
+			Return(traceIDRows(map[string]int64{"1": 1100, "2": 1200, "3": 1300}), nil)
+		r.client.On("QuerySpans", loadQuery, "jaeger").
+			Return(traceRows(t, trace2), nil)
+
+		query := &spanstore.TraceQueryParameters{
+			ServiceName:  "svc",
+			StartTimeMin: time.Unix(1, 0),
+			StartTimeMax: time.Unix(2, 0),
+			NumTraces:    1,
+			Offset:       1,
+		}
+		traces, err := r.reader.FindTraces(query)
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		assert.Equal(t, []*model.Span{trace2}, traces[0].Spans)
+
+		query.Offset = 3
+		traces, err = r.reader.FindTraces(query)
+		require.NoError(t, err)
+		assert.Nil(t, traces)
+	})
+}
+
+func TestFindTraceIDs(t *testing.T) {
+	const spanQuery = `SELECT LAST("duration_ns") FROM "zipkin" WHERE time >= 1000000000 AND time <= 2000000000` +
+		` AND "service_name" = 'svc' AND "annotation" = '' GROUP BY "trace_id"`
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", spanQuery, "jaeger").
+			Return(traceIDRows(map[string]int64{"1": 1100, "2": 1200, "3": 1300}), nil)
+
+		traceIDs, err := r.reader.FindTraceIDs(context.Background(), &spanstore.TraceQueryParameters{
+			ServiceName:  "svc",
+			StartTimeMin: time.Unix(1, 0),
+			StartTimeMax: time.Unix(2, 0),
+			NumTraces:    2,
+			Offset:       1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []model.TraceID{{Low: 2}, {Low: 1}}, traceIDs)
+		// the traces are not loaded
+		r.client.AssertNumberOfCalls(t, "QuerySpans", 1)
+	})
+}
+
+func TestFindTracesNoMatches(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		r.client.On("QuerySpans", mock.AnythingOfType("string"), "jaeger").
+			Return(traceIDRows(map[string]int64{}), nil).Once()
+
+		traces, err := r.reader.FindTraces(&spanstore.TraceQueryParameters{
+			ServiceName:  "svc",
+			Tags:         map[string]string{"error": "true"},
+			StartTimeMin: time.Unix(1, 0),
+			StartTimeMax: time.Unix(2, 0),
+		})
+		require.NoError(t, err)
+		assert.Nil(t, traces)
+		r.client.AssertNumberOfCalls(t, "QuerySpans", 1)
+	})
+}
+
+func TestFindTracesContextCanceled(t *testing.T) {
+	withSpanReader(func(r *spanReaderTest) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.client.On("QuerySpans", mock.AnythingOfType("string"), "jaeger").
+			Run(func(mock.Arguments) { cancel() }).
+			Return(traceIDRows(map[string]int64{"1": 1}), nil).Once()
+
+		traces, err := r.reader.FindTracesContext(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  "svc",
+			Tags:         map[string]string{"error": "true"},
+			StartTimeMin: time.Unix(1, 0),
+			StartTimeMax: time.Unix(2, 0),
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Nil(t, traces)
+		r.client.AssertNumberOfCalls(t, "QuerySpans", 1)
+	})
+}
+
+func TestFindTracesErrors(t *testing.T) {
+	testCases := []struct {
+		caption       string
+		query         *spanstore.TraceQueryParameters
+		queryResponse *influxclient.Response
+		queryError    error
+		expectedError string
+		expectedLog   string
+	}{
+		{
+			caption:       "nil query",
+			expectedError: ErrMalformedRequestObject.Error(),
+		},
+		{
+			caption:       "tags without service",
+			query:         &spanstore.TraceQueryParameters{Tags: map[string]string{"k": "v"}},
+			expectedError: ErrServiceNameNotSet.Error(),
+		},
+		{
+			caption: "query error",
+			query: &spanstore.TraceQueryParameters{
+				StartTimeMin: time.Unix(1, 0),
+				StartTimeMax: time.Unix(2, 0),
+			},
+			queryError:    errors.New("connection refused"),
+			expectedError: "connection refused",
+			expectedLog:   `"msg":"Failed to exec query","query":"SELECT LAST(\"duration_ns\") FROM \"zipkin\"`,
+		},
+		{
+			caption: "response error",
+			query: &spanstore.TraceQueryParameters{
+				StartTimeMin: time.Unix(1, 0),
+				StartTimeMax: time.Unix(2, 0),
+			},
+			queryResponse: &influxclient.Response{Err: "database not found: jaeger"},
+			expectedError: "database not found: jaeger",
+			expectedLog:   `"error":"database not found: jaeger"`,
+		},
+		{
+			caption: "malformed time",
+			query: &spanstore.TraceQueryParameters{
+				StartTimeMin: time.Unix(1, 0),
+				StartTimeMax: time.Unix(2, 0),
+			},
+			queryResponse: &influxclient.Response{Results: []influxclient.Result{{Series: []models.Row{{
+				Tags:    map[string]string{influxdb.TraceIDTag: "1"},
+				Columns: []string{"time", "last"},
+				Values:  [][]interface{}{{"yesterday", json.Number("1")}},
+			}}}}},
+			expectedError: ErrIncorrectValueFormat.Error(),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.caption, func(t *testing.T) {
+			withSpanReader(func(r *spanReaderTest) {
+				r.client.On("QuerySpans", mock.AnythingOfType("string"), "jaeger").
+					Return(testCase.queryResponse, testCase.queryError)
+				traces, err := r.reader.FindTraces(testCase.query)
+				assert.EqualError(t, err, testCase.expectedError)
+				assert.Nil(t, traces)
+				counters, _ := r.metricsFactory.Snapshot()
+				if testCase.expectedLog != "" {
+					assert.Contains(t, r.logBuffer.String(), testCase.expectedLog)
+					assert.EqualValues(t, 1, counters["Read.TraceIDs.errors"])
+				} else {
+					assert.Equal(t, "", r.logBuffer.String())
+					assert.EqualValues(t, 0, counters["Read.TraceIDs.errors"])
+				}
+			})
+		})
+	}
+}
@@ -1,10 +1,143 @@
 package spanstore
 
-import "github.com/uber/jaeger/model"
+import (
+	"context"
+	"sync/atomic"
+	"time"
 
+	influxclient "github.com/influxdata/influxdb/client/v2"
+	"github.com/pkg/errors"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/cache"
+	"github.com/uber/jaeger/pkg/influxdb"
+	storageMetrics "github.com/uber/jaeger/storage/spanstore/metrics"
+)
+
+var errWriterClosed = errors.New("span writer is closed")
+
+// SpanWriter writes spans into the InfluxDB span measurement, and their services and operations into
+// the operation names measurement. Each span is written with its annotations as a single batch of points.
 type SpanWriter struct {
+	client                influxdb.Client
+	logger                *zap.Logger
+	spansMetrics          *storageMetrics.WriteMetrics
+	operationNamesMetrics *storageMetrics.WriteMetrics
+	writeCacheTTL         time.Duration
+	operationNames        cache.Cache
+	closed                int32
+}
+
+// NewSpanWriter returns a SpanWriter that writes through the given client. An operation is indexed again
+// once writeCacheTTL has passed since it was last indexed, or for every span if writeCacheTTL is zero.
+func NewSpanWriter(
+	client influxdb.Client,
+	writeCacheTTL time.Duration,
+	logger *zap.Logger,
+	metricsFactory metrics.Factory,
+) *SpanWriter {
+	return &SpanWriter{
+		client:                client,
+		logger:                logger,
+		spansMetrics:          storageMetrics.NewWriteMetrics(metricsFactory, "Spans"),
+		operationNamesMetrics: storageMetrics.NewWriteMetrics(metricsFactory, "OperationNames"),
+		writeCacheTTL:         writeCacheTTL,
+		operationNames: cache.NewLRUWithOptions(
+			10000,
+			&cache.Options{
+				TTL:             writeCacheTTL,
+				InitialCapacity: 1000,
+			}),
+	}
 }
 
+// WriteSpan writes the span and its annotation points to InfluxDB
 func (s *SpanWriter) WriteSpan(span *model.Span) error {
+	return s.WriteSpanContext(context.Background(), span)
+}
+
+// WriteSpanContext is WriteSpan bound to ctx. The write is skipped if ctx is already done.
+func (s *SpanWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&s.closed) != 0 {
+		return errWriterClosed
+	}
+	spans := []*model.Span{span}
+	if err := s.write(spans); err != nil {
+		return s.logError(span, err, "Failed to insert span")
+	}
+	if err := s.writeOperationNames(spans); err != nil {
+		return s.logError(span, err, "Failed to index operation name")
+	}
 	return nil
 }
+
+func (s *SpanWriter) write(spans []*model.Span) error {
+	start := time.Now()
+	err := s.client.WriteSpans(spans)
+	s.spansMetrics.Emit(err, time.Since(start))
+	return err
+}
+
+// writeOperationNames indexes the operations of the spans that are not in the write cache. Operations
+// are only added to the cache once they are written, so that failed writes are retried with later spans.
+func (s *SpanWriter) writeOperationNames(spans []*model.Span) error {
+	var points []*influxclient.Point
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, span := range spans {
+		if span.Process == nil {
+			continue
+		}
+		key := span.Process.ServiceName + "\x00" + span.OperationName
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if s.writeCacheTTL != 0 && s.operationNames.Get(key) != nil {
+			continue
+		}
+		point, err := influxdb.OperationNamePoint(span)
+		if err != nil {
+			return err
+		}
+		if point != nil {
+			points = append(points, point)
+			keys = append(keys, key)
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	start := time.Now()
+	err := s.client.WritePoints(points)
+	s.operationNamesMetrics.Emit(err, time.Since(start))
+	if err != nil {
+		return err
+	}
+	if s.writeCacheTTL != 0 {
+		for _, key := range keys {
+			s.operationNames.Put(key, key)
+		}
+	}
+	return nil
+}
+
+// Close makes the writer reject the spans written afterwards
+func (s *SpanWriter) Close() error {
+	atomic.StoreInt32(&s.closed, 1)
+	return nil
+}
+
+func (s *SpanWriter) logError(span *model.Span, err error, msg string) error {
+	s.logger.
+		With(zap.String("trace_id", span.TraceID.String())).
+		With(zap.String("span_id", span.SpanID.String())).
+		With(zap.Error(err)).
+		Error(msg)
+	return errors.Wrap(err, msg)
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	influxclient "github.com/influxdata/influxdb/client/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/influxdb"
+	"github.com/uber/jaeger/pkg/influxdb/mocks"
+	"github.com/uber/jaeger/pkg/testutils"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+func TestSpanWriterWriteSpan(t *testing.T) {
+	testCases := []struct {
+		caption       string
+		writeError    error
+		expectedError string
+		expectedLogs  []string
+	}{
+		{caption: "write succeeds"},
+		{
+			caption:       "write fails",
+			writeError:    errors.New("write error"),
+			expectedError: "Failed to insert span: write error",
+			expectedLogs: []string{
+				`"msg":"Failed to insert span"`,
+				`"trace_id":"1"`,
+				`"span_id":"2"`,
+				`"error":"write error"`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		testCase := tc
+		t.Run(testCase.caption, func(t *testing.T) {
+			span := &model.Span{
+				TraceID:       model.TraceID{Low: 1},
+				SpanID:        model.SpanID(2),
+				OperationName: "op",
+				Process:       model.NewProcess("svc", nil),
+			}
+			client := &mocks.Client{}
+			client.On("WriteSpans", []*model.Span{span}).Return(testCase.writeError)
+			if testCase.writeError == nil {
+				client.On("WritePoints", mock.Anything).Return(nil)
+			}
+
+			logger, logBuffer := testutils.NewLogger()
+			metricsFactory := metrics.NewLocalFactory(0)
+
+			var writer spanstore.Writer = NewSpanWriter(client, 0, logger, metricsFactory)
+			err := writer.WriteSpan(span)
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "", logBuffer.String())
+			} else {
+				assert.EqualError(t, err, testCase.expectedError)
+				for _, expectedLog := range testCase.expectedLogs {
+					assert.Contains(t, logBuffer.String(), expectedLog)
+				}
+			}
+			client.AssertExpectations(t)
+
+			counters, _ := metricsFactory.Snapshot()
+			assert.EqualValues(t, 1, counters["Spans.attempts"])
+		})
+	}
+}
+
+func TestSpanWriterWriteSpanContextCanceled(t *testing.T) {
+	client := &mocks.Client{}
+	var writer spanstore.ContextWriter = NewSpanWriter(client, 0, zap.NewNop(), metrics.NullFactory)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := writer.WriteSpanContext(ctx, &model.Span{})
+	require.Equal(t, context.Canceled, err)
+	client.AssertNotCalled(t, "WriteSpans", mock.Anything)
+}
+
+func TestSpanWriterRejectsSpansAfterClose(t *testing.T) {
+	client := &mocks.Client{}
+	writer := NewSpanWriter(client, 0, zap.NewNop(), metrics.NullFactory)
+	require.NoError(t, writer.Close())
+	err := writer.WriteSpan(&model.Span{})
+	assert.Equal(t, errWriterClosed, err)
+	client.AssertNotCalled(t, "WriteSpans", mock.Anything)
+}
+
+func operationNames(points []*influxclient.Point) []string {
+	var names []string
+	for _, point := range points {
+		tags := point.Tags()
+		names = append(names, tags[influxdb.ServiceNameTag]+":"+tags[influxdb.OperationTag])
+	}
+	return names
+}
+
+func TestSpanWriterIndexesOperationNames(t *testing.T) {
+	span := func(service, operation string) *model.Span {
+		return &model.Span{OperationName: operation, Process: model.NewProcess(service, nil)}
+	}
+	testCases := []struct {
+		caption       string
+		writeCacheTTL time.Duration
+		expected      [][]string
+	}{
+		{
+			caption:       "with write cache",
+			writeCacheTTL: time.Hour,
+			expected:      [][]string{{"svc:get"}, {"svc:put"}, {"other:get"}},
+		},
+		{
+			caption:  "without write cache",
+			expected: [][]string{{"svc:get"}, {"svc:put"}, {"svc:get"}, {"svc:get"}, {"other:get"}},
+		},
+	}
+	for _, tc := range testCases {
+		testCase := tc
+		t.Run(testCase.caption, func(t *testing.T) {
+			client := &mocks.Client{}
+			client.On("WriteSpans", mock.Anything).Return(nil)
+			var written [][]string
+			client.On("WritePoints", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				written = append(written, operationNames(args.Get(0).([]*influxclient.Point)))
+			})
+			metricsFactory := metrics.NewLocalFactory(0)
+			writer := NewSpanWriter(client, testCase.writeCacheTTL, zap.NewNop(), metricsFactory)
+
+			for _, s := range []*model.Span{
+				span("svc", "get"), span("svc", "put"), span("svc", "get"),
+				span("svc", "get"), span("other", "get"),
+			} {
+				require.NoError(t, writer.WriteSpan(s))
+			}
+			assert.Equal(t, testCase.expected, written)
+
+			counters, _ := metricsFactory.Snapshot()
+			assert.EqualValues(t, len(testCase.expected), counters["OperationNames.attempts"])
+		})
+	}
+}
+
+func TestSpanWriterRetriesFailedOperationNames(t *testing.T) {
+	span := &model.Span{OperationName: "get", Process: model.NewProcess("svc", nil)}
+	client := &mocks.Client{}
+	client.On("WriteSpans", mock.Anything).Return(nil)
+	client.On("WritePoints", mock.Anything).Return(errors.New("index error")).Once()
+	client.On("WritePoints", mock.Anything).Return(nil).Once()
+	logger, logBuffer := testutils.NewLogger()
+	writer := NewSpanWriter(client, time.Hour, logger, metrics.NullFactory)
+
+	err := writer.WriteSpan(span)
+	assert.EqualError(t, err, "Failed to index operation name: index error")
+	assert.Contains(t, logBuffer.String(), `"msg":"Failed to index operation name"`)
+	// the operation was not cached, so it is indexed with the next span
+	require.NoError(t, writer.WriteSpan(span))
+	require.NoError(t, writer.WriteSpan(span))
+	client.AssertNumberOfCalls(t, "WritePoints", 2)
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/uber/jaeger/pkg/es"
 	"github.com/uber/jaeger/pkg/testutils"
 	"github.com/uber/jaeger/plugin/storage/es/dependencystore"
+	"github.com/uber/jaeger/plugin/storage/es/samplingstore"
 	"github.com/uber/jaeger/plugin/storage/es/spanstore"
 )
 
@@ -86,6 +87,7 @@ func (s *ESStorageIntegration) initSpanstore() {
 	client := es.WrapESClient(s.client)
 	s.spanWriter = spanstore.NewSpanWriter(client, s.logger, metrics.NullFactory)
 	s.spanReader = spanstore.NewSpanReader(client, s.logger, 72*time.Hour, metrics.NullFactory)
+	s.samplingStore = samplingstore.NewSamplingStore(client, s.logger, metrics.NullFactory)
 }
 
 func (s *ESStorageIntegration) esRefresh() error {
@@ -113,4 +115,5 @@ func TestAll(t *testing.T) {
 	s := &ESStorageIntegration{}
 	require.NoError(t, s.initializeES())
 	s.IntegrationTestAll(t)
+	s.IntegrationTestSamplingStore(t)
 }
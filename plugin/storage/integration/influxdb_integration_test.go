@@ -0,0 +1,119 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/uber/jaeger/pkg/influxdb"
+	"github.com/uber/jaeger/pkg/influxdb/config"
+	"github.com/uber/jaeger/pkg/testutils"
+	"github.com/uber/jaeger/plugin/storage/influxdb/dependencystore"
+	"github.com/uber/jaeger/plugin/storage/influxdb/spanstore"
+)
+
+const (
+	influxDBURL      = "http://" + host + ":8086"
+	influxDBDatabase = "jaeger_integration"
+)
+
+type InfluxDBStorageIntegration struct {
+	client influxdb.Client
+	conf   *config.Configuration
+	StorageIntegration
+}
+
+func (s *InfluxDBStorageIntegration) initializeInfluxDB() error {
+	s.conf = &config.Configuration{
+		Server:         influxDBURL,
+		Database:       influxDBDatabase,
+		ConnectionType: "http",
+	}
+	client, err := s.conf.NewClient()
+	if err != nil {
+		return err
+	}
+	logger, _ := testutils.NewLogger()
+
+	s.client = client
+	s.logger = logger
+
+	dependencyStore := dependencystore.NewDependencyStore(client, s.conf, logger)
+	s.dependencyReader = dependencyStore
+	s.dependencyWriter = dependencyStore
+	s.spanWriter = spanstore.NewSpanWriter(client, 0, logger, metrics.NullFactory)
+	s.spanReader = spanstore.NewSpanReader(client, s.conf, logger, metrics.NullFactory)
+	s.cleanUp = s.influxDBCleanUp
+	s.refresh = s.influxDBRefresh
+	return s.cleanUp()
+}
+
+// influxDBCleanUp recreates the database, dropping all spans and dependencies.
+func (s *InfluxDBStorageIntegration) influxDBCleanUp() error {
+	for _, query := range []string{
+		`DROP DATABASE "` + influxDBDatabase + `"`,
+		`CREATE DATABASE "` + influxDBDatabase + `"`,
+	} {
+		res, err := s.client.QuerySpans(query, "")
+		if err != nil {
+			return err
+		}
+		if err := res.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// influxDBRefresh is a no-op, points written over HTTP are immediately visible to queries.
+func (s *InfluxDBStorageIntegration) influxDBRefresh() error {
+	return nil
+}
+
+func influxDBHealthCheck() error {
+	for i := 0; i < 200; i++ {
+		if resp, err := http.Get(influxDBURL + "/ping"); err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return errors.New("influxdb is not ready")
+}
+
+func TestInfluxDBStorage(t *testing.T) {
+	if os.Getenv("INFLUXDB_INTEGRATION_TEST") == "" {
+		t.Skip("Set INFLUXDB_INTEGRATION_TEST env variable to run an integration test on InfluxDB backend")
+	}
+	if err := influxDBHealthCheck(); err != nil {
+		t.Fatal(err)
+	}
+	s := &InfluxDBStorageIntegration{}
+	require.NoError(t, s.initializeInfluxDB())
+	s.IntegrationTestAll(t)
+}
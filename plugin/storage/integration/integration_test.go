@@ -35,6 +35,7 @@ import (
 
 	"github.com/uber/jaeger/model"
 	"github.com/uber/jaeger/storage/dependencystore"
+	"github.com/uber/jaeger/storage/samplingstore"
 	"github.com/uber/jaeger/storage/spanstore"
 )
 
@@ -49,6 +50,7 @@ type StorageIntegration struct {
 	spanReader       spanstore.Reader
 	dependencyWriter dependencystore.Writer
 	dependencyReader dependencystore.Reader
+	samplingStore    samplingstore.Store
 
 	// cleanUp() should ensure that the storage backend is clean before another test.
 	// called either before or after each test, and should be idempotent
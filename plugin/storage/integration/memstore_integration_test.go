@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/uber/jaeger/pkg/testutils"
+	samplingMemory "github.com/uber/jaeger/storage/samplingstore/memory"
+	"github.com/uber/jaeger/storage/spanstore/memory"
+)
+
+type MemStorageIntegrationTestSuite struct {
+	StorageIntegration
+}
+
+func (s *MemStorageIntegrationTestSuite) initialize() error {
+	s.logger, _ = testutils.NewLogger()
+	s.cleanUp = s.memCleanUp
+	s.refresh = s.memRefresh
+	return s.cleanUp()
+}
+
+// memCleanUp replaces the stores with empty ones
+func (s *MemStorageIntegrationTestSuite) memCleanUp() error {
+	store := memory.NewStore()
+	s.spanWriter = store
+	s.spanReader = store
+	s.samplingStore = samplingMemory.NewStore()
+	return nil
+}
+
+func (s *MemStorageIntegrationTestSuite) memRefresh() error {
+	return nil
+}
+
+// The memory store computes dependencies from the stored spans and cannot store
+// pre-aggregated ones, so the dependency tests are not run against it.
+func TestMemoryStorage(t *testing.T) {
+	s := &MemStorageIntegrationTestSuite{}
+	if err := s.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	s.IntegrationTestGetServices(t)
+	s.IntegrationTestGetOperations(t)
+	s.IntegrationTestGetTrace(t)
+	s.IntegrationTestFindTraces(t)
+	s.IntegrationTestSamplingStore(t)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package integration
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+)
+
+// === SamplingStore Integration Tests ===
+
+type throughputByOperation []*model.Throughput
+
+func (t throughputByOperation) Len() int           { return len(t) }
+func (t throughputByOperation) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t throughputByOperation) Less(i, j int) bool { return t[i].Operation < t[j].Operation }
+
+func (s *StorageIntegration) IntegrationTestSamplingStore(t *testing.T) {
+	t.Log("Testing SamplingStore ...")
+	require.NoError(t, s.cleanUp())
+	s.testSamplingStoreThroughput(t)
+	require.NoError(t, s.cleanUp())
+	s.testSamplingStoreProbabilitiesAndQPS(t)
+	assert.NoError(t, s.cleanUp())
+}
+
+func (s *StorageIntegration) testSamplingStoreThroughput(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	expected := []*model.Throughput{
+		{Service: "svc", Operation: "GET", Count: 10, Probabilities: map[string]struct{}{"0.1": {}, "0.2": {}}},
+		{Service: "svc", Operation: "PUT", Count: 1, Probabilities: map[string]struct{}{"0.5": {}}},
+	}
+	require.NoError(t, s.samplingStore.InsertThroughput(expected[:1]))
+	require.NoError(t, s.samplingStore.InsertThroughput(expected[1:]))
+	require.NoError(t, s.refresh())
+	end := time.Now().Add(time.Minute)
+
+	var actual []*model.Throughput
+	for i := 0; i < iterations; i++ {
+		s.logger.Info(fmt.Sprintf(waitForBackendComment, i+1, iterations))
+		var err error
+		actual, err = s.samplingStore.GetThroughput(start, end)
+		require.NoError(t, err)
+		if len(actual) == len(expected) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond) // Will wait up to 3 seconds at worst.
+	}
+	sort.Sort(throughputByOperation(actual))
+	assert.Equal(t, expected, actual)
+
+	actual, err := s.samplingStore.GetThroughput(start.Add(-time.Hour), start)
+	require.NoError(t, err)
+	assert.Empty(t, actual)
+}
+
+func (s *StorageIntegration) testSamplingStoreProbabilitiesAndQPS(t *testing.T) {
+	probabilities, err := s.samplingStore.GetLatestProbabilities()
+	require.NoError(t, err)
+	assert.Empty(t, probabilities)
+
+	start := time.Now().Add(-time.Minute)
+	require.NoError(t, s.samplingStore.InsertProbabilitiesAndQPS("host1",
+		model.ServiceOperationProbabilities{"svc": {"GET": 0.1}},
+		model.ServiceOperationQPS{"svc": {"GET": 10}}))
+	// make sure the second insert is newer
+	time.Sleep(10 * time.Millisecond)
+	latest := model.ServiceOperationProbabilities{"svc": {"GET": 0.2, "PUT": 0.3}}
+	require.NoError(t, s.samplingStore.InsertProbabilitiesAndQPS("host2", latest,
+		model.ServiceOperationQPS{"svc": {"GET": 20, "PUT": 3}}))
+	require.NoError(t, s.refresh())
+	end := time.Now().Add(time.Minute)
+
+	expected := map[string][]model.ServiceOperationData{
+		"host1": {{"svc": {"GET": {Probability: 0.1, QPS: 10}}}},
+		"host2": {{"svc": {"GET": {Probability: 0.2, QPS: 20}, "PUT": {Probability: 0.3, QPS: 3}}}},
+	}
+	var actual map[string][]model.ServiceOperationData
+	for i := 0; i < iterations; i++ {
+		s.logger.Info(fmt.Sprintf(waitForBackendComment, i+1, iterations))
+		actual, err = s.samplingStore.GetProbabilitiesAndQPS(start, end)
+		require.NoError(t, err)
+		if len(actual) == len(expected) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond) // Will wait up to 3 seconds at worst.
+	}
+	assert.Equal(t, expected, actual)
+
+	probabilities, err = s.samplingStore.GetLatestProbabilities()
+	require.NoError(t, err)
+	assert.Equal(t, latest, probabilities)
+}
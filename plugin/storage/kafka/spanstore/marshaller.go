@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/uber/jaeger/model"
+	jConverter "github.com/uber/jaeger/model/converter/json"
+	tConverter "github.com/uber/jaeger/model/converter/thrift/jaeger"
+	jModel "github.com/uber/jaeger/model/json"
+	"github.com/uber/jaeger/thrift-gen/jaeger"
+)
+
+const (
+	// EncodingJSON encodes each span as a JSON span with an embedded process
+	EncodingJSON = "json"
+	// EncodingThrift encodes each span as a jaeger.thrift Batch holding the span and its process
+	EncodingThrift = "thrift"
+)
+
+// Marshaller encodes a span into the payload of a Kafka message
+type Marshaller interface {
+	Marshal(span *model.Span) ([]byte, error)
+}
+
+// Unmarshaller decodes a span from the payload of a Kafka message
+type Unmarshaller interface {
+	Unmarshal(payload []byte) (*model.Span, error)
+}
+
+// NewMarshaller returns the Marshaller for the given encoding
+func NewMarshaller(encoding string) (Marshaller, error) {
+	switch encoding {
+	case EncodingJSON:
+		return jsonMarshaller{}, nil
+	case EncodingThrift:
+		return thriftMarshaller{}, nil
+	}
+	return nil, fmt.Errorf("unsupported span encoding %q", encoding)
+}
+
+// NewUnmarshaller returns the Unmarshaller for the given encoding
+func NewUnmarshaller(encoding string) (Unmarshaller, error) {
+	switch encoding {
+	case EncodingJSON:
+		return jsonMarshaller{}, nil
+	case EncodingThrift:
+		return thriftMarshaller{}, nil
+	}
+	return nil, fmt.Errorf("unsupported span encoding %q", encoding)
+}
+
+type jsonMarshaller struct{}
+
+func (jsonMarshaller) Marshal(span *model.Span) ([]byte, error) {
+	return json.Marshal(jConverter.FromDomainEmbedProcess(span))
+}
+
+func (jsonMarshaller) Unmarshal(payload []byte) (*model.Span, error) {
+	var span jModel.Span
+	if err := json.Unmarshal(payload, &span); err != nil {
+		return nil, err
+	}
+	return jConverter.SpanToDomain(&span)
+}
+
+type thriftMarshaller struct{}
+
+func (thriftMarshaller) Marshal(span *model.Span) ([]byte, error) {
+	batch := &jaeger.Batch{
+		Process: tConverter.FromDomainProcess(span.Process),
+		Spans:   []*jaeger.Span{tConverter.FromDomainSpan(span)},
+	}
+	return thrift.NewTSerializer().Write(batch)
+}
+
+func (thriftMarshaller) Unmarshal(payload []byte) (*model.Span, error) {
+	batch := &jaeger.Batch{}
+	if err := thrift.NewTDeserializer().Read(batch, payload); err != nil {
+		return nil, err
+	}
+	if len(batch.Spans) != 1 {
+		return nil, fmt.Errorf("expected a batch with one span, got %d spans", len(batch.Spans))
+	}
+	return tConverter.ToDomainSpan(batch.Spans[0], batch.Process), nil
+}
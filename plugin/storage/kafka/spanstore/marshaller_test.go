@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/model"
+)
+
+func makeSpan() *model.Span {
+	return &model.Span{
+		TraceID:       model.TraceID{High: 1, Low: 2},
+		SpanID:        model.SpanID(3),
+		ParentSpanID:  model.SpanID(4),
+		OperationName: "op",
+		References: []model.SpanRef{
+			{RefType: model.ChildOf, TraceID: model.TraceID{High: 1, Low: 2}, SpanID: model.SpanID(4)},
+		},
+		Flags:     model.Flags(1),
+		StartTime: time.Unix(1485467191, 639875000).UTC(),
+		Duration:  22938 * time.Microsecond,
+		Tags: model.KeyValues{
+			model.String("http.method", "GET"),
+			model.Int64("http.status_code", 200),
+			model.Bool("error", false),
+		},
+		Logs: []model.Log{
+			{
+				Timestamp: time.Unix(1485467191, 640000000).UTC(),
+				Fields:    model.KeyValues{model.String("event", "retry")},
+			},
+		},
+		Process: model.NewProcess("api", model.KeyValues{model.String("hostname", "api246")}),
+	}
+}
+
+func TestMarshallerRoundTrip(t *testing.T) {
+	for _, encoding := range []string{EncodingJSON, EncodingThrift} {
+		t.Run(encoding, func(t *testing.T) {
+			marshaller, err := NewMarshaller(encoding)
+			require.NoError(t, err)
+			unmarshaller, err := NewUnmarshaller(encoding)
+			require.NoError(t, err)
+
+			span := makeSpan()
+			payload, err := marshaller.Marshal(span)
+			require.NoError(t, err)
+			decoded, err := unmarshaller.Unmarshal(payload)
+			require.NoError(t, err)
+
+			span.NormalizeTimestamps()
+			decoded.NormalizeTimestamps()
+			assert.Equal(t, span, decoded)
+		})
+	}
+}
+
+func TestUnsupportedEncoding(t *testing.T) {
+	_, err := NewMarshaller("protobuf")
+	assert.EqualError(t, err, `unsupported span encoding "protobuf"`)
+	_, err = NewUnmarshaller("protobuf")
+	assert.EqualError(t, err, `unsupported span encoding "protobuf"`)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	_, err := jsonMarshaller{}.Unmarshal([]byte("{"))
+	assert.Error(t, err)
+	_, err = jsonMarshaller{}.Unmarshal([]byte(`{"traceID": "x"}`))
+	assert.Error(t, err)
+
+	_, err = thriftMarshaller{}.Unmarshal([]byte("{"))
+	assert.Error(t, err)
+	payload, err := thriftMarshaller{}.Marshal(makeSpan())
+	require.NoError(t, err)
+	_, err = thriftMarshaller{}.Unmarshal(payload[:len(payload)/2])
+	assert.Error(t, err)
+}
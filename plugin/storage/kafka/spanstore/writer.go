@@ -0,0 +1,103 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	storageMetrics "github.com/uber/jaeger/storage/spanstore/metrics"
+)
+
+// SpanWriter publishes spans to a Kafka topic. Spans are keyed by their trace ID,
+// so that all spans of a trace land on the same partition.
+type SpanWriter struct {
+	producer     sarama.SyncProducer
+	marshaller   Marshaller
+	topic        string
+	logger       *zap.Logger
+	spansMetrics *storageMetrics.WriteMetrics
+}
+
+// NewSpanWriter returns a SpanWriter that publishes spans to the topic through the given producer
+func NewSpanWriter(
+	producer sarama.SyncProducer,
+	marshaller Marshaller,
+	topic string,
+	logger *zap.Logger,
+	metricsFactory metrics.Factory,
+) *SpanWriter {
+	return &SpanWriter{
+		producer:     producer,
+		marshaller:   marshaller,
+		topic:        topic,
+		logger:       logger,
+		spansMetrics: storageMetrics.NewWriteMetrics(metricsFactory, "Spans"),
+	}
+}
+
+// WriteSpan publishes the span to Kafka and waits for the brokers to acknowledge it
+func (w *SpanWriter) WriteSpan(span *model.Span) error {
+	return w.WriteSpanContext(context.Background(), span)
+}
+
+// WriteSpanContext is WriteSpan bound to ctx. The span is not published if ctx is already done,
+// but a publish in flight still waits for the brokers since the producer cannot be interrupted.
+func (w *SpanWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	payload, err := w.marshaller.Marshal(span)
+	if err != nil {
+		return w.logError(span, err, "Failed to marshal span")
+	}
+	start := time.Now()
+	_, _, err = w.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: w.topic,
+		Key:   sarama.StringEncoder(span.TraceID.String()),
+		Value: sarama.ByteEncoder(payload),
+	})
+	w.spansMetrics.Emit(err, time.Since(start))
+	if err != nil {
+		return w.logError(span, err, "Failed to publish span")
+	}
+	return nil
+}
+
+// Close closes the underlying producer
+func (w *SpanWriter) Close() error {
+	return w.producer.Close()
+}
+
+func (w *SpanWriter) logError(span *model.Span, err error, msg string) error {
+	w.logger.
+		With(zap.String("trace_id", span.TraceID.String())).
+		With(zap.String("span_id", span.SpanID.String())).
+		With(zap.Error(err)).
+		Error(msg)
+	return errors.Wrap(err, msg)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/testutils"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+// recordingProducer keeps the messages sent through the wrapped producer
+type recordingProducer struct {
+	sarama.SyncProducer
+	messages []*sarama.ProducerMessage
+}
+
+func (p *recordingProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.messages = append(p.messages, msg)
+	return p.SyncProducer.SendMessage(msg)
+}
+
+type failingMarshaller struct{}
+
+func (failingMarshaller) Marshal(span *model.Span) ([]byte, error) {
+	return nil, errors.New("marshal error")
+}
+
+func TestSpanWriterWriteSpan(t *testing.T) {
+	span := makeSpan()
+	producer := &recordingProducer{SyncProducer: mocks.NewSyncProducer(t, nil)}
+	producer.SyncProducer.(*mocks.SyncProducer).ExpectSendMessageWithCheckerFunctionAndSucceed(func(val []byte) error {
+		decoded, err := jsonMarshaller{}.Unmarshal(val)
+		if err != nil {
+			return err
+		}
+		if decoded.SpanID != span.SpanID {
+			return errors.New("unexpected span")
+		}
+		return nil
+	})
+	logger, logBuffer := testutils.NewLogger()
+	metricsFactory := metrics.NewLocalFactory(0)
+
+	var writer spanstore.Writer = NewSpanWriter(producer, jsonMarshaller{}, "jaeger-spans", logger, metricsFactory)
+	require.NoError(t, writer.WriteSpan(span))
+	assert.Equal(t, "", logBuffer.String())
+
+	require.Len(t, producer.messages, 1)
+	assert.Equal(t, "jaeger-spans", producer.messages[0].Topic)
+	assert.Equal(t, sarama.StringEncoder(span.TraceID.String()), producer.messages[0].Key)
+
+	counters, _ := metricsFactory.Snapshot()
+	assert.EqualValues(t, 1, counters["Spans.inserts"])
+	assert.NoError(t, writer.(*SpanWriter).Close())
+}
+
+func TestSpanWriterWriteSpanContextCanceled(t *testing.T) {
+	producer := &recordingProducer{SyncProducer: mocks.NewSyncProducer(t, nil)}
+	var writer spanstore.ContextWriter = NewSpanWriter(producer, jsonMarshaller{}, "jaeger-spans", zap.NewNop(), metrics.NullFactory)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, writer.WriteSpanContext(ctx, makeSpan()))
+	assert.Empty(t, producer.messages)
+	assert.NoError(t, writer.(*SpanWriter).Close())
+}
+
+func TestSpanWriterWriteSpanErrors(t *testing.T) {
+	testCases := []struct {
+		caption       string
+		marshaller    Marshaller
+		publishError  error
+		expectedError string
+		expectedLogs  []string
+	}{
+		{
+			caption:       "marshal fails",
+			marshaller:    failingMarshaller{},
+			expectedError: "Failed to marshal span: marshal error",
+			expectedLogs:  []string{`"msg":"Failed to marshal span"`, `"error":"marshal error"`},
+		},
+		{
+			caption:       "publish fails",
+			marshaller:    jsonMarshaller{},
+			publishError:  sarama.ErrOutOfBrokers,
+			expectedError: "Failed to publish span: " + sarama.ErrOutOfBrokers.Error(),
+			expectedLogs:  []string{`"msg":"Failed to publish span"`, `"trace_id":"10000000000000002"`, `"span_id":"3"`},
+		},
+	}
+	for _, tc := range testCases {
+		testCase := tc
+		t.Run(testCase.caption, func(t *testing.T) {
+			producer := mocks.NewSyncProducer(t, nil)
+			if testCase.publishError != nil {
+				producer.ExpectSendMessageAndFail(testCase.publishError)
+			}
+			logger, logBuffer := testutils.NewLogger()
+
+			writer := NewSpanWriter(producer, testCase.marshaller, "jaeger-spans", logger, metrics.NullFactory)
+			err := writer.WriteSpan(makeSpan())
+			assert.EqualError(t, err, testCase.expectedError)
+			for _, expectedLog := range testCase.expectedLogs {
+				assert.Contains(t, logBuffer.String(), expectedLog)
+			}
+			assert.NoError(t, writer.Close())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dependencystore
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/jaeger/model"
+)
+
+// NewContextReader returns reader itself if it accepts contexts or is nil, and otherwise adapts it to ContextReader.
+// The calls of an adapted reader fail early when the context is already done, but cannot be canceled
+// once they have started.
+func NewContextReader(reader Reader) ContextReader {
+	if reader == nil {
+		return nil
+	}
+	if r, ok := reader.(ContextReader); ok {
+		return r
+	}
+	return contextReader{Reader: reader}
+}
+
+type contextReader struct {
+	Reader
+}
+
+func (r contextReader) GetDependenciesContext(
+	ctx context.Context,
+	endTs time.Time,
+	lookback time.Duration,
+) ([]model.DependencyLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.GetDependencies(endTs, lookback)
+}
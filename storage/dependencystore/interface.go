@@ -21,6 +21,7 @@
 package dependencystore
 
 import (
+	"context"
 	"time"
 
 	"github.com/uber/jaeger/model"
@@ -35,3 +36,9 @@ type Writer interface {
 type Reader interface {
 	GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error)
 }
+
+// ContextReader is a Reader whose reads can be canceled and traced through a context.
+type ContextReader interface {
+	Reader
+	GetDependenciesContext(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mocks
+
+import context "context"
+import dependencystore "github.com/uber/jaeger/storage/dependencystore"
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/uber/jaeger/model"
+import time "time"
+
+// ContextReader is an autogenerated mock type for the ContextReader type
+type ContextReader struct {
+	mock.Mock
+}
+
+// GetDependencies provides a mock function with given fields: endTs, lookback
+func (_m *ContextReader) GetDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	ret := _m.Called(endTs, lookback)
+
+	var r0 []model.DependencyLink
+	if rf, ok := ret.Get(0).(func(time.Time, time.Duration) []model.DependencyLink); ok {
+		r0 = rf(endTs, lookback)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DependencyLink)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, time.Duration) error); ok {
+		r1 = rf(endTs, lookback)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDependenciesContext provides a mock function with given fields: ctx, endTs, lookback
+func (_m *ContextReader) GetDependenciesContext(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	ret := _m.Called(ctx, endTs, lookback)
+
+	var r0 []model.DependencyLink
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration) []model.DependencyLink); ok {
+		r0 = rf(ctx, endTs, lookback)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.DependencyLink)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Duration) error); ok {
+		r1 = rf(ctx, endTs, lookback)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+var _ dependencystore.ContextReader = (*ContextReader)(nil)
@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package samplingstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+)
+
+// NewContextStore returns store itself if it accepts contexts or is nil, and otherwise adapts it to ContextStore.
+// The calls of an adapted store fail early when the context is already done, but cannot be canceled
+// once they have started.
+func NewContextStore(store Store) ContextStore {
+	if store == nil {
+		return nil
+	}
+	if s, ok := store.(ContextStore); ok {
+		return s
+	}
+	return contextStore{Store: store}
+}
+
+type contextStore struct {
+	Store
+}
+
+func (s contextStore) InsertThroughputContext(ctx context.Context, throughput []*model.Throughput) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.InsertThroughput(throughput)
+}
+
+func (s contextStore) InsertProbabilitiesAndQPSContext(
+	ctx context.Context,
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.InsertProbabilitiesAndQPS(hostname, probabilities, qps)
+}
+
+func (s contextStore) GetThroughputContext(ctx context.Context, start, end time.Time) ([]*model.Throughput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.GetThroughput(start, end)
+}
+
+func (s contextStore) GetProbabilitiesAndQPSContext(
+	ctx context.Context,
+	start, end time.Time,
+) (map[string][]model.ServiceOperationData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.GetProbabilitiesAndQPS(start, end)
+}
+
+func (s contextStore) GetLatestProbabilitiesContext(ctx context.Context) (model.ServiceOperationProbabilities, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.GetLatestProbabilities()
+}
@@ -21,6 +21,7 @@
 package samplingstore
 
 import (
+	"context"
 	"time"
 
 	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
@@ -43,3 +44,28 @@ type Store interface {
 	// GetLatestProbabilities retrieves the latest sampling probabilities.
 	GetLatestProbabilities() (model.ServiceOperationProbabilities, error)
 }
+
+// ContextStore is a Store whose reads and writes can be canceled and traced through a context.
+type ContextStore interface {
+	Store
+
+	// InsertThroughputContext is InsertThroughput bound to ctx.
+	InsertThroughputContext(ctx context.Context, throughput []*model.Throughput) error
+
+	// InsertProbabilitiesAndQPSContext is InsertProbabilitiesAndQPS bound to ctx.
+	InsertProbabilitiesAndQPSContext(
+		ctx context.Context,
+		hostname string,
+		probabilities model.ServiceOperationProbabilities,
+		qps model.ServiceOperationQPS,
+	) error
+
+	// GetThroughputContext is GetThroughput bound to ctx.
+	GetThroughputContext(ctx context.Context, start, end time.Time) ([]*model.Throughput, error)
+
+	// GetProbabilitiesAndQPSContext is GetProbabilitiesAndQPS bound to ctx.
+	GetProbabilitiesAndQPSContext(ctx context.Context, start, end time.Time) (map[string][]model.ServiceOperationData, error)
+
+	// GetLatestProbabilitiesContext is GetLatestProbabilities bound to ctx.
+	GetLatestProbabilitiesContext(ctx context.Context) (model.ServiceOperationProbabilities, error)
+}
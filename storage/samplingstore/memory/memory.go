@@ -0,0 +1,212 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+)
+
+// DefaultRetention is how long the store keeps the sampling data by default, which is far longer than
+// the adaptive sampling processor looks back.
+const DefaultRetention = time.Hour
+
+type storedThroughput struct {
+	timestamp  time.Time
+	throughput []*model.Throughput
+}
+
+type storedProbabilitiesAndQPS struct {
+	timestamp     time.Time
+	hostname      string
+	probabilities model.ServiceOperationProbabilities
+	qps           model.ServiceOperationQPS
+}
+
+// Store is an in-memory store of sampling data. Entries older than the retention are discarded when
+// new entries are inserted, so that the store does not grow without bound.
+type Store struct {
+	sync.RWMutex
+	throughput          []*storedThroughput
+	probabilitiesAndQPS []*storedProbabilitiesAndQPS
+	retention           time.Duration
+	now                 func() time.Time
+}
+
+// NewStore creates an in-memory sampling store that keeps the sampling data for DefaultRetention.
+func NewStore() *Store {
+	return NewStoreWithRetention(DefaultRetention)
+}
+
+// NewStoreWithRetention creates an in-memory sampling store that keeps the sampling data for the given duration.
+func NewStoreWithRetention(retention time.Duration) *Store {
+	return &Store{retention: retention, now: time.Now}
+}
+
+// InsertThroughput implements samplingstore.Writer#InsertThroughput.
+func (s *Store) InsertThroughput(throughput []*model.Throughput) error {
+	s.Lock()
+	defer s.Unlock()
+	now := s.now()
+	expired := 0
+	for expired < len(s.throughput) && s.isExpired(s.throughput[expired].timestamp, now) {
+		expired++
+	}
+	s.throughput = append(s.throughput[expired:], &storedThroughput{
+		timestamp:  now,
+		throughput: throughput,
+	})
+	return nil
+}
+
+// InsertThroughputContext implements samplingstore.ContextStore#InsertThroughputContext.
+func (s *Store) InsertThroughputContext(ctx context.Context, throughput []*model.Throughput) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.InsertThroughput(throughput)
+}
+
+// InsertProbabilitiesAndQPS implements samplingstore.Writer#InsertProbabilitiesAndQPS.
+func (s *Store) InsertProbabilitiesAndQPS(
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
+) error {
+	s.Lock()
+	defer s.Unlock()
+	now := s.now()
+	expired := 0
+	for expired < len(s.probabilitiesAndQPS) && s.isExpired(s.probabilitiesAndQPS[expired].timestamp, now) {
+		expired++
+	}
+	s.probabilitiesAndQPS = append(s.probabilitiesAndQPS[expired:], &storedProbabilitiesAndQPS{
+		timestamp:     now,
+		hostname:      hostname,
+		probabilities: probabilities,
+		qps:           qps,
+	})
+	return nil
+}
+
+// InsertProbabilitiesAndQPSContext implements samplingstore.ContextStore#InsertProbabilitiesAndQPSContext.
+func (s *Store) InsertProbabilitiesAndQPSContext(
+	ctx context.Context,
+	hostname string,
+	probabilities model.ServiceOperationProbabilities,
+	qps model.ServiceOperationQPS,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.InsertProbabilitiesAndQPS(hostname, probabilities, qps)
+}
+
+// GetThroughput implements samplingstore.Reader#GetThroughput.
+func (s *Store) GetThroughput(start, end time.Time) ([]*model.Throughput, error) {
+	s.RLock()
+	defer s.RUnlock()
+	var throughput []*model.Throughput
+	for _, t := range s.throughput {
+		if inRange(t.timestamp, start, end) {
+			throughput = append(throughput, t.throughput...)
+		}
+	}
+	return throughput, nil
+}
+
+// GetThroughputContext implements samplingstore.ContextStore#GetThroughputContext.
+func (s *Store) GetThroughputContext(ctx context.Context, start, end time.Time) ([]*model.Throughput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.GetThroughput(start, end)
+}
+
+// GetProbabilitiesAndQPS implements samplingstore.Reader#GetProbabilitiesAndQPS.
+func (s *Store) GetProbabilitiesAndQPS(start, end time.Time) (map[string][]model.ServiceOperationData, error) {
+	s.RLock()
+	defer s.RUnlock()
+	hostProbabilitiesAndQPS := make(map[string][]model.ServiceOperationData)
+	for _, p := range s.probabilitiesAndQPS {
+		if inRange(p.timestamp, start, end) {
+			hostProbabilitiesAndQPS[p.hostname] = append(hostProbabilitiesAndQPS[p.hostname], toServiceOperationData(p.probabilities, p.qps))
+		}
+	}
+	return hostProbabilitiesAndQPS, nil
+}
+
+// GetProbabilitiesAndQPSContext implements samplingstore.ContextStore#GetProbabilitiesAndQPSContext.
+func (s *Store) GetProbabilitiesAndQPSContext(
+	ctx context.Context,
+	start, end time.Time,
+) (map[string][]model.ServiceOperationData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.GetProbabilitiesAndQPS(start, end)
+}
+
+// GetLatestProbabilities implements samplingstore.Reader#GetLatestProbabilities.
+func (s *Store) GetLatestProbabilities() (model.ServiceOperationProbabilities, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if len(s.probabilitiesAndQPS) == 0 {
+		return model.ServiceOperationProbabilities{}, nil
+	}
+	return s.probabilitiesAndQPS[len(s.probabilitiesAndQPS)-1].probabilities, nil
+}
+
+// GetLatestProbabilitiesContext implements samplingstore.ContextStore#GetLatestProbabilitiesContext.
+func (s *Store) GetLatestProbabilitiesContext(ctx context.Context) (model.ServiceOperationProbabilities, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.GetLatestProbabilities()
+}
+
+// isExpired returns true if an entry inserted at ts is older than the retention. The entries are
+// inserted in time order, so the expired ones are always at the start of the slices.
+func (s *Store) isExpired(ts, now time.Time) bool {
+	return now.Sub(ts) > s.retention
+}
+
+// inRange matches the Cassandra store, which excludes the start and includes the end of the range.
+func inRange(ts, start, end time.Time) bool {
+	return ts.After(start) && !ts.After(end)
+}
+
+func toServiceOperationData(probabilities model.ServiceOperationProbabilities, qps model.ServiceOperationQPS) model.ServiceOperationData {
+	data := make(model.ServiceOperationData, len(probabilities))
+	for service, operations := range probabilities {
+		data[service] = make(map[string]*model.ProbabilityAndQPS, len(operations))
+		for operation, probability := range operations {
+			data[service][operation] = &model.ProbabilityAndQPS{
+				Probability: probability,
+				QPS:         qps[service][operation],
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger/cmd/collector/app/sampling/model"
+	"github.com/uber/jaeger/storage/samplingstore"
+)
+
+var _ samplingstore.ContextStore = &Store{} // check API conformance
+
+func withStore(fn func(store *Store, now *time.Time)) {
+	now := time.Unix(1000, 0)
+	store := NewStore()
+	store.now = func() time.Time { return now }
+	fn(store, &now)
+}
+
+func TestThroughput(t *testing.T) {
+	withStore(func(store *Store, now *time.Time) {
+		first := []*model.Throughput{{Service: "svc", Operation: "GET", Count: 1, Probabilities: map[string]struct{}{"0.1": {}}}}
+		second := []*model.Throughput{{Service: "svc", Operation: "PUT", Count: 2, Probabilities: map[string]struct{}{}}}
+		require.NoError(t, store.InsertThroughput(first))
+		*now = now.Add(time.Minute)
+		require.NoError(t, store.InsertThroughput(second))
+
+		start := time.Unix(1000, 0)
+		throughput, err := store.GetThroughput(start.Add(-time.Second), start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, append(first, second...), throughput)
+
+		// the start of the range is exclusive
+		throughput, err = store.GetThroughput(start, start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, second, throughput)
+
+		throughput, err = store.GetThroughput(start.Add(time.Hour), start.Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, throughput)
+	})
+}
+
+func TestProbabilitiesAndQPS(t *testing.T) {
+	withStore(func(store *Store, now *time.Time) {
+		probabilities, err := store.GetLatestProbabilities()
+		require.NoError(t, err)
+		assert.Empty(t, probabilities)
+
+		require.NoError(t, store.InsertProbabilitiesAndQPS("host1",
+			model.ServiceOperationProbabilities{"svc": {"GET": 0.1}},
+			model.ServiceOperationQPS{"svc": {"GET": 10}}))
+		*now = now.Add(time.Minute)
+		require.NoError(t, store.InsertProbabilitiesAndQPS("host2",
+			model.ServiceOperationProbabilities{"svc": {"GET": 0.2, "PUT": 0.3}},
+			model.ServiceOperationQPS{"svc": {"GET": 20}}))
+
+		probabilities, err = store.GetLatestProbabilities()
+		require.NoError(t, err)
+		assert.Equal(t, model.ServiceOperationProbabilities{"svc": {"GET": 0.2, "PUT": 0.3}}, probabilities)
+
+		start := time.Unix(1000, 0)
+		data, err := store.GetProbabilitiesAndQPS(start.Add(-time.Second), start.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]model.ServiceOperationData{
+			"host1": {{"svc": {"GET": {Probability: 0.1, QPS: 10}}}},
+			"host2": {{"svc": {"GET": {Probability: 0.2, QPS: 20}, "PUT": {Probability: 0.3, QPS: 0}}}},
+		}, data)
+
+		data, err = store.GetProbabilitiesAndQPS(start, start.Add(time.Second))
+		require.NoError(t, err)
+		assert.Empty(t, data)
+	})
+}
+
+func TestContext(t *testing.T) {
+	withStore(func(store *Store, now *time.Time) {
+		ctx := context.Background()
+		throughput := []*model.Throughput{{Service: "svc", Operation: "GET", Count: 1}}
+		probabilities := model.ServiceOperationProbabilities{"svc": {"GET": 0.5}}
+		require.NoError(t, store.InsertThroughputContext(ctx, throughput))
+		require.NoError(t, store.InsertProbabilitiesAndQPSContext(ctx, "host", probabilities, model.ServiceOperationQPS{}))
+		*now = now.Add(time.Minute)
+
+		start := time.Unix(0, 0)
+		actualThroughput, err := store.GetThroughputContext(ctx, start, *now)
+		require.NoError(t, err)
+		assert.Equal(t, throughput, actualThroughput)
+		hostData, err := store.GetProbabilitiesAndQPSContext(ctx, start, *now)
+		require.NoError(t, err)
+		assert.Len(t, hostData["host"], 1)
+		latest, err := store.GetLatestProbabilitiesContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, probabilities, latest)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Equal(t, context.Canceled, store.InsertThroughputContext(ctx, throughput))
+		assert.Equal(t, context.Canceled, store.InsertProbabilitiesAndQPSContext(ctx, "host", probabilities, nil))
+		_, err = store.GetThroughputContext(ctx, start, *now)
+		assert.Equal(t, context.Canceled, err)
+		_, err = store.GetProbabilitiesAndQPSContext(ctx, start, *now)
+		assert.Equal(t, context.Canceled, err)
+		_, err = store.GetLatestProbabilitiesContext(ctx)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestRetention(t *testing.T) {
+	withStore(func(store *Store, now *time.Time) {
+		store.retention = time.Minute
+		start := *now
+		for i := 0; i < 3; i++ {
+			require.NoError(t, store.InsertThroughput([]*model.Throughput{{Service: "svc", Count: int64(i)}}))
+			require.NoError(t, store.InsertProbabilitiesAndQPS("host",
+				model.ServiceOperationProbabilities{"svc": {"GET": float64(i) / 10}},
+				model.ServiceOperationQPS{}))
+			*now = now.Add(45 * time.Second)
+		}
+		// the first entries are 90 seconds old when the last ones are inserted
+		assert.Len(t, store.throughput, 2)
+		assert.Len(t, store.probabilitiesAndQPS, 2)
+
+		throughput, err := store.GetThroughput(start.Add(-time.Second), *now)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Throughput{{Service: "svc", Count: 1}, {Service: "svc", Count: 2}}, throughput)
+
+		*now = now.Add(time.Hour)
+		require.NoError(t, store.InsertProbabilitiesAndQPS("host",
+			model.ServiceOperationProbabilities{"svc": {"GET": 0.5}},
+			model.ServiceOperationQPS{}))
+		assert.Len(t, store.probabilitiesAndQPS, 1)
+		probabilities, err := store.GetLatestProbabilities()
+		require.NoError(t, err)
+		assert.Equal(t, model.ServiceOperationProbabilities{"svc": {"GET": 0.5}}, probabilities)
+	})
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mocks
+
+import context "context"
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/uber/jaeger/cmd/collector/app/sampling/model"
+import samplingstore "github.com/uber/jaeger/storage/samplingstore"
+import time "time"
+
+// ContextStore is an autogenerated mock type for the ContextStore type
+type ContextStore struct {
+	mock.Mock
+}
+
+// GetLatestProbabilities provides a mock function with given fields:
+func (_m *ContextStore) GetLatestProbabilities() (model.ServiceOperationProbabilities, error) {
+	ret := _m.Called()
+
+	var r0 model.ServiceOperationProbabilities
+	if rf, ok := ret.Get(0).(func() model.ServiceOperationProbabilities); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(model.ServiceOperationProbabilities)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestProbabilitiesContext provides a mock function with given fields: ctx
+func (_m *ContextStore) GetLatestProbabilitiesContext(ctx context.Context) (model.ServiceOperationProbabilities, error) {
+	ret := _m.Called(ctx)
+
+	var r0 model.ServiceOperationProbabilities
+	if rf, ok := ret.Get(0).(func(context.Context) model.ServiceOperationProbabilities); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(model.ServiceOperationProbabilities)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProbabilitiesAndQPS provides a mock function with given fields: start, end
+func (_m *ContextStore) GetProbabilitiesAndQPS(start time.Time, end time.Time) (map[string][]model.ServiceOperationData, error) {
+	ret := _m.Called(start, end)
+
+	var r0 map[string][]model.ServiceOperationData
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time) map[string][]model.ServiceOperationData); ok {
+		r0 = rf(start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]model.ServiceOperationData)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time) error); ok {
+		r1 = rf(start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProbabilitiesAndQPSContext provides a mock function with given fields: ctx, start, end
+func (_m *ContextStore) GetProbabilitiesAndQPSContext(ctx context.Context, start time.Time, end time.Time) (map[string][]model.ServiceOperationData, error) {
+	ret := _m.Called(ctx, start, end)
+
+	var r0 map[string][]model.ServiceOperationData
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) map[string][]model.ServiceOperationData); ok {
+		r0 = rf(ctx, start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]model.ServiceOperationData)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetThroughput provides a mock function with given fields: start, end
+func (_m *ContextStore) GetThroughput(start time.Time, end time.Time) ([]*model.Throughput, error) {
+	ret := _m.Called(start, end)
+
+	var r0 []*model.Throughput
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time) []*model.Throughput); ok {
+		r0 = rf(start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Throughput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time) error); ok {
+		r1 = rf(start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetThroughputContext provides a mock function with given fields: ctx, start, end
+func (_m *ContextStore) GetThroughputContext(ctx context.Context, start time.Time, end time.Time) ([]*model.Throughput, error) {
+	ret := _m.Called(ctx, start, end)
+
+	var r0 []*model.Throughput
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) []*model.Throughput); ok {
+		r0 = rf(ctx, start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Throughput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertProbabilitiesAndQPS provides a mock function with given fields: hostname, probabilities, qps
+func (_m *ContextStore) InsertProbabilitiesAndQPS(hostname string, probabilities model.ServiceOperationProbabilities, qps model.ServiceOperationQPS) error {
+	ret := _m.Called(hostname, probabilities, qps)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, model.ServiceOperationProbabilities, model.ServiceOperationQPS) error); ok {
+		r0 = rf(hostname, probabilities, qps)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertProbabilitiesAndQPSContext provides a mock function with given fields: ctx, hostname, probabilities, qps
+func (_m *ContextStore) InsertProbabilitiesAndQPSContext(ctx context.Context, hostname string, probabilities model.ServiceOperationProbabilities, qps model.ServiceOperationQPS) error {
+	ret := _m.Called(ctx, hostname, probabilities, qps)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.ServiceOperationProbabilities, model.ServiceOperationQPS) error); ok {
+		r0 = rf(ctx, hostname, probabilities, qps)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertThroughput provides a mock function with given fields: throughput
+func (_m *ContextStore) InsertThroughput(throughput []*model.Throughput) error {
+	ret := _m.Called(throughput)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]*model.Throughput) error); ok {
+		r0 = rf(throughput)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertThroughputContext provides a mock function with given fields: ctx, throughput
+func (_m *ContextStore) InsertThroughputContext(ctx context.Context, throughput []*model.Throughput) error {
+	ret := _m.Called(ctx, throughput)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*model.Throughput) error); ok {
+		r0 = rf(ctx, throughput)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ samplingstore.ContextStore = (*ContextStore)(nil)
@@ -0,0 +1,116 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"context"
+
+	"github.com/uber/jaeger/model"
+)
+
+// NewContextReader returns reader itself if it accepts contexts or is nil, and otherwise adapts it to ContextReader.
+// The calls of an adapted reader fail early when the context is already done, but cannot be canceled
+// once they have started.
+func NewContextReader(reader Reader) ContextReader {
+	if reader == nil {
+		return nil
+	}
+	if r, ok := reader.(ContextReader); ok {
+		return r
+	}
+	return contextReader{Reader: reader}
+}
+
+type contextReader struct {
+	Reader
+}
+
+func (r contextReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.GetTrace(traceID)
+}
+
+func (r contextReader) GetServicesContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.GetServices()
+}
+
+func (r contextReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.GetOperations(service)
+}
+
+func (r contextReader) FindTracesContext(ctx context.Context, query *TraceQueryParameters) ([]*model.Trace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.FindTraces(query)
+}
+
+// FindTraceIDs has to load the traces, since a Reader cannot look up trace IDs alone
+func (r contextReader) FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error) {
+	traces, err := r.FindTracesContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return TraceIDs(traces), nil
+}
+
+// TraceIDs returns the IDs of the traces in order, leaving out the traces without spans
+func TraceIDs(traces []*model.Trace) []model.TraceID {
+	var traceIDs []model.TraceID
+	for _, trace := range traces {
+		if len(trace.Spans) > 0 {
+			traceIDs = append(traceIDs, trace.Spans[0].TraceID)
+		}
+	}
+	return traceIDs
+}
+
+// NewContextWriter returns writer itself if it accepts contexts or is nil, and otherwise adapts it to ContextWriter.
+// The writes of an adapted writer fail early when the context is already done, but cannot be canceled
+// once they have started.
+func NewContextWriter(writer Writer) ContextWriter {
+	if writer == nil {
+		return nil
+	}
+	if w, ok := writer.(ContextWriter); ok {
+		return w
+	}
+	return contextWriter{Writer: writer}
+}
+
+type contextWriter struct {
+	Writer
+}
+
+func (w contextWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return w.WriteSpan(span)
+}
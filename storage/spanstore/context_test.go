@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger/model"
+	. "github.com/uber/jaeger/storage/spanstore"
+	"github.com/uber/jaeger/storage/spanstore/mocks"
+)
+
+func TestNewContextReader(t *testing.T) {
+	contextReader := &mocks.ContextReader{}
+	assert.True(t, NewContextReader(contextReader) == contextReader, "a ContextReader is returned as is")
+	assert.Nil(t, NewContextReader(nil))
+
+	reader := &mocks.Reader{}
+	r := NewContextReader(reader)
+	ctx, cancel := context.WithCancel(context.Background())
+	trace := &model.Trace{}
+	query := &TraceQueryParameters{ServiceName: "svc"}
+	reader.On("GetTrace", model.TraceID{Low: 1}).Return(trace, nil).Once()
+	reader.On("GetServices").Return([]string{"svc"}, nil).Once()
+	reader.On("GetOperations", "svc").Return([]string{"op"}, nil).Once()
+	reader.On("FindTraces", query).Return([]*model.Trace{trace}, nil).Once()
+	found := &model.Trace{Spans: []*model.Span{{TraceID: model.TraceID{Low: 2}}}}
+	reader.On("FindTraces", query).Return([]*model.Trace{trace, found}, nil).Once()
+
+	actualTrace, err := r.GetTraceContext(ctx, model.TraceID{Low: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, trace, actualTrace)
+	services, err := r.GetServicesContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, services)
+	operations, err := r.GetOperationsContext(ctx, "svc")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"op"}, operations)
+	traces, err := r.FindTracesContext(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Trace{trace}, traces)
+	traceIDs, err := r.FindTraceIDs(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.TraceID{{Low: 2}}, traceIDs, "traces without spans are left out")
+
+	// the reader is not called once the context is done
+	cancel()
+	_, err = r.GetTraceContext(ctx, model.TraceID{Low: 1})
+	assert.Equal(t, context.Canceled, err)
+	_, err = r.GetServicesContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	_, err = r.GetOperationsContext(ctx, "svc")
+	assert.Equal(t, context.Canceled, err)
+	_, err = r.FindTracesContext(ctx, query)
+	assert.Equal(t, context.Canceled, err)
+	_, err = r.FindTraceIDs(ctx, query)
+	assert.Equal(t, context.Canceled, err)
+	reader.AssertExpectations(t)
+}
+
+func TestNewContextWriter(t *testing.T) {
+	contextWriter := &mocks.ContextWriter{}
+	assert.True(t, NewContextWriter(contextWriter) == contextWriter, "a ContextWriter is returned as is")
+	assert.Nil(t, NewContextWriter(nil))
+
+	writer := &mocks.Writer{}
+	w := NewContextWriter(writer)
+	ctx, cancel := context.WithCancel(context.Background())
+	span := &model.Span{}
+	writer.On("WriteSpan", span).Return(nil).Once()
+	assert.NoError(t, w.WriteSpanContext(ctx, span))
+
+	cancel()
+	assert.Equal(t, context.Canceled, w.WriteSpanContext(ctx, span))
+	writer.AssertExpectations(t)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/multierror"
+)
+
+// FederatedReader is a span Reader that queries several underlying span Readers and merges their results.
+// The results of the span readers that fail are left out, the query only fails if all of them fail.
+type FederatedReader struct {
+	spanReaders []ContextReader
+	logger      *zap.Logger
+}
+
+// NewFederatedReader creates a FederatedReader that logs the failures of the span readers
+func NewFederatedReader(logger *zap.Logger, spanReaders ...Reader) *FederatedReader {
+	contextReaders := make([]ContextReader, len(spanReaders))
+	for i, reader := range spanReaders {
+		contextReaders[i] = NewContextReader(reader)
+	}
+	return &FederatedReader{
+		spanReaders: contextReaders,
+		logger:      logger,
+	}
+}
+
+// forEachReader calls f concurrently for every span reader and waits for all of them to return. The failed
+// calls are logged, and their errors are wrapped into a single error returned along with whether all of them failed.
+func (r *FederatedReader) forEachReader(f func(i int, reader ContextReader) error) (allFailed bool, err error) {
+	errs := make([]error, len(r.spanReaders))
+	var wg sync.WaitGroup
+	wg.Add(len(r.spanReaders))
+	for i, reader := range r.spanReaders {
+		go func(i int, reader ContextReader) {
+			defer wg.Done()
+			errs[i] = f(i, reader)
+		}(i, reader)
+	}
+	wg.Wait()
+	var errors []error
+	for i, err := range errs {
+		if err != nil {
+			r.logger.Error("Span reader failed, its results are left out", zap.Int("reader", i), zap.Error(err))
+			errors = append(errors, err)
+		}
+	}
+	return len(errors) == len(r.spanReaders), multierror.Wrap(errors)
+}
+
+// GetTrace returns the spans of the trace found in any of the span readers. It returns ErrTraceNotFound
+// only if none of the span readers has the trace and none of them failed.
+func (r *FederatedReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
+	return r.GetTraceContext(context.Background(), traceID)
+}
+
+// GetTraceContext is GetTrace bound to ctx, which is passed to all span readers
+func (r *FederatedReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	traces := make([]*model.Trace, len(r.spanReaders))
+	_, err := r.forEachReader(func(i int, reader ContextReader) error {
+		trace, err := reader.GetTraceContext(ctx, traceID)
+		if err == ErrTraceNotFound {
+			return nil
+		}
+		traces[i] = trace
+		return err
+	})
+	var found []*model.Trace
+	for _, trace := range traces {
+		if trace != nil {
+			found = append(found, trace)
+		}
+	}
+	if len(found) == 0 {
+		if err != nil {
+			// the failed span readers may have the trace
+			return nil, err
+		}
+		return nil, ErrTraceNotFound
+	}
+	return r.mergeTraces(found), nil
+}
+
+// GetServices returns the sorted union of the services of all span readers
+func (r *FederatedReader) GetServices() ([]string, error) {
+	return r.GetServicesContext(context.Background())
+}
+
+// GetServicesContext is GetServices bound to ctx, which is passed to all span readers
+func (r *FederatedReader) GetServicesContext(ctx context.Context) ([]string, error) {
+	return r.mergeStrings(func(reader ContextReader) ([]string, error) {
+		return reader.GetServicesContext(ctx)
+	})
+}
+
+// GetOperations returns the sorted union of the operations of the service in all span readers
+func (r *FederatedReader) GetOperations(service string) ([]string, error) {
+	return r.GetOperationsContext(context.Background(), service)
+}
+
+// GetOperationsContext is GetOperations bound to ctx, which is passed to all span readers
+func (r *FederatedReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	return r.mergeStrings(func(reader ContextReader) ([]string, error) {
+		return reader.GetOperationsContext(ctx, service)
+	})
+}
+
+func (r *FederatedReader) mergeStrings(get func(reader ContextReader) ([]string, error)) ([]string, error) {
+	results := make([][]string, len(r.spanReaders))
+	allFailed, err := r.forEachReader(func(i int, reader ContextReader) error {
+		var err error
+		results[i], err = get(reader)
+		return err
+	})
+	if allFailed {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	merged := []string{}
+	for _, result := range results {
+		for _, s := range result {
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				merged = append(merged, s)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged, nil
+}
+
+// FindTraces returns the union of the traces found by all span readers, in the order the span readers
+// were given, merging the spans of traces found by several of them. The first query.Offset traces of
+// the union are skipped and at most query.NumTraces traces are returned when it is set.
+func (r *FederatedReader) FindTraces(query *TraceQueryParameters) ([]*model.Trace, error) {
+	return r.FindTracesContext(context.Background(), query)
+}
+
+// FindTracesContext is FindTraces bound to ctx, which is passed to all span readers
+func (r *FederatedReader) FindTracesContext(ctx context.Context, query *TraceQueryParameters) ([]*model.Trace, error) {
+	readerQuery := r.readerQuery(query)
+	results := make([][]*model.Trace, len(r.spanReaders))
+	allFailed, err := r.forEachReader(func(i int, reader ContextReader) error {
+		var err error
+		results[i], err = reader.FindTracesContext(ctx, readerQuery)
+		return err
+	})
+	if allFailed {
+		return nil, err
+	}
+	var traceIDs []model.TraceID
+	tracesByID := make(map[model.TraceID][]*model.Trace)
+	for _, traces := range results {
+		for _, trace := range traces {
+			if len(trace.Spans) == 0 {
+				continue
+			}
+			traceID := trace.Spans[0].TraceID
+			if _, ok := tracesByID[traceID]; !ok {
+				traceIDs = append(traceIDs, traceID)
+			}
+			tracesByID[traceID] = append(tracesByID[traceID], trace)
+		}
+	}
+	traceIDs = paginate(traceIDs, query)
+	merged := make([]*model.Trace, 0, len(traceIDs))
+	for _, traceID := range traceIDs {
+		merged = append(merged, r.mergeTraces(tracesByID[traceID]))
+	}
+	return merged, nil
+}
+
+// FindTraceIDs returns the IDs of the traces FindTraces returns, in the same order
+func (r *FederatedReader) FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error) {
+	readerQuery := r.readerQuery(query)
+	results := make([][]model.TraceID, len(r.spanReaders))
+	allFailed, err := r.forEachReader(func(i int, reader ContextReader) error {
+		var err error
+		results[i], err = reader.FindTraceIDs(ctx, readerQuery)
+		return err
+	})
+	if allFailed {
+		return nil, err
+	}
+	var traceIDs []model.TraceID
+	seen := make(map[model.TraceID]struct{})
+	for _, result := range results {
+		for _, traceID := range result {
+			if _, ok := seen[traceID]; !ok {
+				seen[traceID] = struct{}{}
+				traceIDs = append(traceIDs, traceID)
+			}
+		}
+	}
+	return paginate(traceIDs, query), nil
+}
+
+// readerQuery returns the query the span readers are asked. The offset only applies to the merged
+// results, so every span reader is asked for the traces preceding it as well.
+func (r *FederatedReader) readerQuery(query *TraceQueryParameters) *TraceQueryParameters {
+	readerQuery := *query
+	readerQuery.Offset = 0
+	if query.NumTraces > 0 {
+		readerQuery.NumTraces = query.NumTraces + query.Offset
+	}
+	return &readerQuery
+}
+
+// paginate skips the first query.Offset trace IDs and keeps at most query.NumTraces of them if it is set
+func paginate(traceIDs []model.TraceID, query *TraceQueryParameters) []model.TraceID {
+	if query.Offset >= len(traceIDs) {
+		return nil
+	}
+	traceIDs = traceIDs[query.Offset:]
+	if query.NumTraces > 0 && len(traceIDs) > query.NumTraces {
+		traceIDs = traceIDs[:query.NumTraces]
+	}
+	return traceIDs
+}
+
+// mergeTraces combines the spans of the same trace returned by several span readers. Spans stored
+// identically in more than one backend, e.g. while writing to both during a migration, are kept once.
+func (r *FederatedReader) mergeTraces(traces []*model.Trace) *model.Trace {
+	if len(traces) == 1 {
+		return traces[0]
+	}
+	merged := &model.Trace{}
+	seen := make(map[uint64]struct{})
+	for _, trace := range traces {
+		for _, span := range trace.Spans {
+			hash, err := model.HashCode(span)
+			if err == nil {
+				if _, ok := seen[hash]; ok {
+					continue
+				}
+				seen[hash] = struct{}{}
+			}
+			merged.Spans = append(merged.Spans, span)
+		}
+		merged.Warnings = append(merged.Warnings, trace.Warnings...)
+	}
+	return merged
+}
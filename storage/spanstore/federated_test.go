@@ -0,0 +1,283 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spanstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	. "github.com/uber/jaeger/storage/spanstore"
+	"github.com/uber/jaeger/storage/spanstore/mocks"
+)
+
+var errReaderDown = errors.New("reader is down")
+
+func makeFederatedSpan(traceID, spanID uint64, kind string) *model.Span {
+	span := &model.Span{
+		TraceID:       model.TraceID{Low: traceID},
+		SpanID:        model.SpanID(spanID),
+		OperationName: "op",
+		Process:       model.NewProcess("svc", nil),
+	}
+	if kind != "" {
+		span.Tags = model.KeyValues{model.String("span.kind", kind)}
+	}
+	return span
+}
+
+func withFederatedReader(f func(r *FederatedReader, first, second *mocks.Reader)) {
+	first, second := &mocks.Reader{}, &mocks.Reader{}
+	f(NewFederatedReader(zap.NewNop(), first, second), first, second)
+}
+
+func TestFederatedReaderGetServices(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		first.On("GetServices").Return([]string{"b", "a"}, nil)
+		second.On("GetServices").Return([]string{"c", "b"}, nil)
+		services, err := r.GetServices()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, services)
+	})
+}
+
+func TestFederatedReaderGetOperations(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		first.On("GetOperations", "svc").Return([]string{"get"}, nil)
+		second.On("GetOperations", "svc").Return([]string{}, nil)
+		operations, err := r.GetOperations("svc")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"get"}, operations)
+
+		first.On("GetOperations", "partial").Return([]string{"put"}, nil)
+		second.On("GetOperations", "partial").Return(nil, errReaderDown)
+		operations, err = r.GetOperations("partial")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"put"}, operations)
+
+		first.On("GetOperations", "broken").Return(nil, errReaderDown)
+		second.On("GetOperations", "broken").Return(nil, errReaderDown)
+		operations, err = r.GetOperations("broken")
+		assert.EqualError(t, err, "[reader is down, reader is down]")
+		assert.Nil(t, operations)
+	})
+}
+
+func TestFederatedReaderGetTrace(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		traceID := model.TraceID{Low: 1}
+		first.On("GetTrace", traceID).Return(&model.Trace{Spans: []*model.Span{
+			makeFederatedSpan(1, 1, ""),
+			makeFederatedSpan(1, 2, "client"),
+		}}, nil)
+		second.On("GetTrace", traceID).Return(&model.Trace{Spans: []*model.Span{
+			makeFederatedSpan(1, 1, ""),
+			makeFederatedSpan(1, 2, "server"),
+		}}, nil)
+		trace, err := r.GetTrace(traceID)
+		require.NoError(t, err)
+		require.Len(t, trace.Spans, 3, "the span stored in both readers is returned once")
+		assert.Equal(t, model.SpanID(1), trace.Spans[0].SpanID)
+		assert.Equal(t, model.SpanID(2), trace.Spans[1].SpanID)
+		assert.Equal(t, model.SpanID(2), trace.Spans[2].SpanID, "spans that differ are kept as they are")
+	})
+}
+
+func TestFederatedReaderGetTraceFromOneReader(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		traceID := model.TraceID{Low: 1}
+		expected := &model.Trace{Spans: []*model.Span{makeFederatedSpan(1, 1, "")}}
+		first.On("GetTrace", traceID).Return(nil, ErrTraceNotFound)
+		second.On("GetTrace", traceID).Return(expected, nil)
+		trace, err := r.GetTrace(traceID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, trace)
+	})
+}
+
+func TestFederatedReaderGetTraceNotFound(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		traceID := model.TraceID{Low: 1}
+		first.On("GetTrace", traceID).Return(nil, ErrTraceNotFound)
+		second.On("GetTrace", traceID).Return(nil, ErrTraceNotFound)
+		trace, err := r.GetTrace(traceID)
+		assert.Equal(t, ErrTraceNotFound, err)
+		assert.Nil(t, trace)
+	})
+}
+
+func TestFederatedReaderGetTraceFailure(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		traceID := model.TraceID{Low: 1}
+		first.On("GetTrace", traceID).Return(nil, ErrTraceNotFound)
+		second.On("GetTrace", traceID).Return(nil, errReaderDown)
+		trace, err := r.GetTrace(traceID)
+		assert.Equal(t, errReaderDown, err)
+		assert.Nil(t, trace)
+	})
+}
+
+func TestFederatedReaderGetTracePartialFailure(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		traceID := model.TraceID{Low: 1}
+		expected := &model.Trace{Spans: []*model.Span{makeFederatedSpan(1, 1, "")}}
+		first.On("GetTrace", traceID).Return(nil, errReaderDown)
+		second.On("GetTrace", traceID).Return(expected, nil)
+		trace, err := r.GetTrace(traceID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, trace)
+	})
+}
+
+func TestFederatedReaderFindTraces(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		query := &TraceQueryParameters{ServiceName: "svc", NumTraces: 3}
+		first.On("FindTraces", query).Return([]*model.Trace{
+			{Spans: []*model.Span{makeFederatedSpan(1, 1, "")}},
+			{Spans: []*model.Span{makeFederatedSpan(2, 1, "")}},
+		}, nil)
+		second.On("FindTraces", query).Return([]*model.Trace{
+			{Spans: []*model.Span{makeFederatedSpan(2, 1, ""), makeFederatedSpan(2, 2, "")}},
+			{},
+			{Spans: []*model.Span{makeFederatedSpan(3, 1, "")}},
+			{Spans: []*model.Span{makeFederatedSpan(4, 1, "")}},
+		}, nil)
+		traces, err := r.FindTraces(query)
+		require.NoError(t, err)
+		require.Len(t, traces, 3)
+		assert.Equal(t, model.TraceID{Low: 1}, traces[0].Spans[0].TraceID)
+		assert.Equal(t, model.TraceID{Low: 2}, traces[1].Spans[0].TraceID)
+		assert.Len(t, traces[1].Spans, 2)
+		assert.Equal(t, model.TraceID{Low: 3}, traces[2].Spans[0].TraceID)
+	})
+}
+
+func TestFederatedReaderFindTracesOffset(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		query := &TraceQueryParameters{ServiceName: "svc", NumTraces: 2, Offset: 1}
+		readerQuery := &TraceQueryParameters{ServiceName: "svc", NumTraces: 3}
+		first.On("FindTraces", readerQuery).Return([]*model.Trace{
+			{Spans: []*model.Span{makeFederatedSpan(1, 1, "")}},
+			{Spans: []*model.Span{makeFederatedSpan(2, 1, "")}},
+		}, nil)
+		second.On("FindTraces", readerQuery).Return([]*model.Trace{
+			{Spans: []*model.Span{makeFederatedSpan(2, 2, "")}},
+			{Spans: []*model.Span{makeFederatedSpan(3, 1, "")}},
+			{Spans: []*model.Span{makeFederatedSpan(4, 1, "")}},
+		}, nil)
+		traces, err := r.FindTraces(query)
+		require.NoError(t, err)
+		require.Len(t, traces, 2)
+		assert.Equal(t, model.TraceID{Low: 2}, traces[0].Spans[0].TraceID)
+		assert.Len(t, traces[0].Spans, 2)
+		assert.Equal(t, model.TraceID{Low: 3}, traces[1].Spans[0].TraceID)
+
+		query.Offset = 4
+		readerQuery.NumTraces = 6
+		traces, err = r.FindTraces(query)
+		require.NoError(t, err)
+		assert.Empty(t, traces)
+	})
+}
+
+func TestFederatedReaderFindTracesPartialFailure(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		query := &TraceQueryParameters{ServiceName: "svc"}
+		first.On("FindTraces", query).Return(nil, errReaderDown)
+		second.On("FindTraces", query).Return([]*model.Trace{
+			{Spans: []*model.Span{makeFederatedSpan(1, 1, "")}},
+		}, nil)
+		traces, err := r.FindTraces(query)
+		require.NoError(t, err)
+		require.Len(t, traces, 1)
+		assert.Equal(t, model.TraceID{Low: 1}, traces[0].Spans[0].TraceID)
+	})
+}
+
+func TestFederatedReaderFindTracesFailure(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		query := &TraceQueryParameters{ServiceName: "svc"}
+		first.On("FindTraces", query).Return(nil, errReaderDown)
+		second.On("FindTraces", query).Return(nil, errReaderDown)
+		traces, err := r.FindTraces(query)
+		assert.EqualError(t, err, "[reader is down, reader is down]")
+		assert.Nil(t, traces)
+	})
+}
+
+func TestFederatedReaderFindTraceIDs(t *testing.T) {
+	first, second := &mocks.ContextReader{}, &mocks.Reader{}
+	r := NewFederatedReader(zap.NewNop(), first, second)
+	ctx := context.Background()
+	query := &TraceQueryParameters{ServiceName: "svc", NumTraces: 2, Offset: 1}
+	readerQuery := &TraceQueryParameters{ServiceName: "svc", NumTraces: 3}
+	first.On("FindTraceIDs", ctx, readerQuery).Return([]model.TraceID{{Low: 1}, {Low: 2}}, nil)
+	second.On("FindTraces", readerQuery).Return([]*model.Trace{
+		{Spans: []*model.Span{makeFederatedSpan(2, 2, "")}},
+		{Spans: []*model.Span{makeFederatedSpan(3, 1, "")}},
+		{Spans: []*model.Span{makeFederatedSpan(4, 1, "")}},
+	}, nil)
+	traceIDs, err := r.FindTraceIDs(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, []model.TraceID{{Low: 2}, {Low: 3}}, traceIDs)
+
+	query.Offset = 4
+	readerQuery.NumTraces = 6
+	traceIDs, err = r.FindTraceIDs(ctx, query)
+	require.NoError(t, err)
+	assert.Empty(t, traceIDs)
+}
+
+func TestFederatedReaderFindTraceIDsFailure(t *testing.T) {
+	withFederatedReader(func(r *FederatedReader, first, second *mocks.Reader) {
+		query := &TraceQueryParameters{ServiceName: "svc"}
+		first.On("FindTraces", query).Return(nil, errReaderDown)
+		second.On("FindTraces", query).Return(nil, errReaderDown)
+		traceIDs, err := r.FindTraceIDs(context.Background(), query)
+		assert.EqualError(t, err, "[reader is down, reader is down]")
+		assert.Nil(t, traceIDs)
+	})
+}
+
+func TestFederatedReaderContext(t *testing.T) {
+	first, second := &mocks.ContextReader{}, &mocks.Reader{}
+	r := NewFederatedReader(zap.NewNop(), first, second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	traceID := model.TraceID{Low: 1}
+	first.On("GetTraceContext", ctx, traceID).Return(&model.Trace{Spans: []*model.Span{makeFederatedSpan(1, 1, "")}}, nil)
+	second.On("GetTrace", traceID).Return(nil, ErrTraceNotFound)
+	first.On("GetServicesContext", ctx).Return([]string{"a"}, nil)
+	second.On("GetServices").Return([]string{"b"}, nil)
+
+	trace, err := r.GetTraceContext(ctx, traceID)
+	require.NoError(t, err)
+	assert.Len(t, trace.Spans, 1)
+	services, err := r.GetServicesContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, services)
+	first.AssertExpectations(t)
+}
@@ -21,6 +21,7 @@
 package spanstore
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -32,6 +33,12 @@ type Writer interface {
 	WriteSpan(span *model.Span) error
 }
 
+// ContextWriter is a Writer whose writes can be canceled and traced through a context.
+type ContextWriter interface {
+	Writer
+	WriteSpanContext(ctx context.Context, span *model.Span) error
+}
+
 var (
 	// ErrTraceNotFound is returned by Reader's GetTrace if no data is found for given trace ID.
 	ErrTraceNotFound = errors.New("trace not found")
@@ -45,7 +52,20 @@ type Reader interface {
 	FindTraces(query *TraceQueryParameters) ([]*model.Trace, error)
 }
 
+// ContextReader is a Reader whose reads can be canceled and traced through a context.
+type ContextReader interface {
+	Reader
+	GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error)
+	GetServicesContext(ctx context.Context) ([]string, error)
+	GetOperationsContext(ctx context.Context, service string) ([]string, error)
+	FindTracesContext(ctx context.Context, query *TraceQueryParameters) ([]*model.Trace, error)
+	// FindTraceIDs returns the IDs of the traces FindTraces would return, in the same order,
+	// without loading the traces when the storage can avoid it.
+	FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error)
+}
+
 // TraceQueryParameters contains parameters of a trace query.
+// The matching traces are paginated by skipping the first Offset ones and returning at most NumTraces.
 type TraceQueryParameters struct {
 	ServiceName   string
 	OperationName string
@@ -55,4 +75,5 @@ type TraceQueryParameters struct {
 	DurationMin   time.Duration
 	DurationMax   time.Duration
 	NumTraces     int
+	Offset        int
 }
@@ -21,35 +21,69 @@
 package memory
 
 import (
-	"errors"
+	"container/list"
+	"context"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/uber/jaeger-lib/metrics"
+
 	"github.com/uber/jaeger/model"
 	"github.com/uber/jaeger/model/adjuster"
+	"github.com/uber/jaeger/pkg/memory/config"
 	"github.com/uber/jaeger/storage/spanstore"
 )
 
-var errTraceNotFound = errors.New("Trace was not found")
+const defaultNumTraces = 100
 
-// Store is an unbounded in-memory store of traces
+// Store is an in-memory store of traces, optionally bounded by the number of traces and spans
 type Store struct {
-	// TODO: make this a bounded memory store
 	sync.RWMutex
-	traces     map[model.TraceID]*model.Trace
-	services   map[string]struct{}
-	operations map[string]map[string]struct{}
+	traces map[model.TraceID]*model.Trace
+	// services and operations count the stored spans of each service and operation,
+	// so that they can be removed along with their last trace
+	services   map[string]int
+	operations map[string]map[string]int
 	deduper    adjuster.Adjuster
+	config     config.Configuration
+	// writeOrder holds trace IDs from the least to the most recently written
+	writeOrder *list.List
+	elements   map[model.TraceID]*list.Element
+	spanCount  int
+	metrics    storeMetrics
+}
+
+type storeMetrics struct {
+	// Traces is the number of traces in the store
+	Traces metrics.Gauge `metric:"traces"`
+	// Spans is the number of spans in the store
+	Spans metrics.Gauge `metric:"spans"`
+	// EvictedTraces counts the traces evicted to stay within the limits
+	EvictedTraces metrics.Counter `metric:"evicted-traces"`
+	// EvictedSpans counts the spans of evicted traces
+	EvictedSpans metrics.Counter `metric:"evicted-spans"`
 }
 
-// NewStore creates an in-memory store
+// NewStore creates an unbounded in-memory store
 func NewStore() *Store {
-	return &Store{
+	return NewBoundedStore(config.Configuration{}, metrics.NullFactory)
+}
+
+// NewBoundedStore creates an in-memory store that evicts the least recently written traces
+// when it holds more traces or spans than allowed by the configuration
+func NewBoundedStore(cfg config.Configuration, metricsFactory metrics.Factory) *Store {
+	m := &Store{
 		traces:     map[model.TraceID]*model.Trace{},
-		services:   map[string]struct{}{},
-		operations: map[string]map[string]struct{}{},
+		services:   map[string]int{},
+		operations: map[string]map[string]int{},
 		deduper:    adjuster.SpanIDDeduper(),
+		config:     cfg,
+		writeOrder: list.New(),
+		elements:   map[model.TraceID]*list.Element{},
 	}
+	metrics.Init(&m.metrics, metricsFactory.Namespace("memory-store", nil), nil)
+	return m
 }
 
 // GetDependencies returns dependencies between services
@@ -90,6 +124,18 @@ func (m *Store) GetDependencies(endTs time.Time, lookback time.Duration) ([]mode
 	return retMe, nil
 }
 
+// GetDependenciesContext is GetDependencies bound to ctx
+func (m *Store) GetDependenciesContext(
+	ctx context.Context,
+	endTs time.Time,
+	lookback time.Duration,
+) ([]model.DependencyLink, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetDependencies(endTs, lookback)
+}
+
 func (m *Store) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
 	for _, s := range trace.Spans {
 		if s.SpanID == spanID {
@@ -113,30 +159,86 @@ func (m *Store) WriteSpan(span *model.Span) error {
 	m.Lock()
 	defer m.Unlock()
 	if _, ok := m.operations[span.Process.ServiceName]; !ok {
-		m.operations[span.Process.ServiceName] = map[string]struct{}{}
+		m.operations[span.Process.ServiceName] = map[string]int{}
 	}
-	m.operations[span.Process.ServiceName][span.OperationName] = struct{}{}
-	m.services[span.Process.ServiceName] = struct{}{}
+	m.operations[span.Process.ServiceName][span.OperationName]++
+	m.services[span.Process.ServiceName]++
 	if _, ok := m.traces[span.TraceID]; !ok {
 		m.traces[span.TraceID] = &model.Trace{}
+		m.elements[span.TraceID] = m.writeOrder.PushBack(span.TraceID)
+	} else {
+		m.writeOrder.MoveToBack(m.elements[span.TraceID])
 	}
 	m.traces[span.TraceID].Spans = append(m.traces[span.TraceID].Spans, span)
+	m.spanCount++
+	m.evict()
 
+	m.metrics.Traces.Update(int64(len(m.traces)))
+	m.metrics.Spans.Update(int64(m.spanCount))
 	return nil
 }
 
+// WriteSpanContext is WriteSpan bound to ctx
+func (m *Store) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.WriteSpan(span)
+}
+
+// evict removes the least recently written traces until the store is within its limits.
+// A single trace with more spans than allowed is evicted as well.
+func (m *Store) evict() {
+	for (m.config.MaxTraces > 0 && len(m.traces) > m.config.MaxTraces) ||
+		(m.config.MaxSpans > 0 && m.spanCount > m.config.MaxSpans) {
+		traceID := m.writeOrder.Remove(m.writeOrder.Front()).(model.TraceID)
+		trace := m.traces[traceID]
+		delete(m.traces, traceID)
+		delete(m.elements, traceID)
+		for _, span := range trace.Spans {
+			m.removeSpan(span)
+		}
+		m.metrics.EvictedTraces.Inc(1)
+		m.metrics.EvictedSpans.Inc(int64(len(trace.Spans)))
+	}
+}
+
+// removeSpan forgets the service and operation of the span if no other span has them.
+func (m *Store) removeSpan(span *model.Span) {
+	m.spanCount--
+	service := span.Process.ServiceName
+	if m.services[service]--; m.services[service] == 0 {
+		delete(m.services, service)
+	}
+	operations := m.operations[service]
+	if operations[span.OperationName]--; operations[span.OperationName] == 0 {
+		delete(operations, span.OperationName)
+	}
+	if len(operations) == 0 {
+		delete(m.operations, service)
+	}
+}
+
 // GetTrace gets a trace
 func (m *Store) GetTrace(traceID model.TraceID) (*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
 	retMe := m.traces[traceID]
 	if retMe == nil {
-		return nil, errTraceNotFound
+		return nil, spanstore.ErrTraceNotFound
 	}
 	return retMe, nil
 }
 
-// GetServices returns a list of all known services
+// GetTraceContext is GetTrace bound to ctx
+func (m *Store) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetTrace(traceID)
+}
+
+// GetServices returns a sorted list of all known services
 func (m *Store) GetServices() ([]string, error) {
 	m.RLock()
 	defer m.RUnlock()
@@ -144,10 +246,19 @@ func (m *Store) GetServices() ([]string, error) {
 	for k := range m.services {
 		retMe = append(retMe, k)
 	}
+	sort.Strings(retMe)
 	return retMe, nil
 }
 
-// GetOperations returns the operations of a given service
+// GetServicesContext is GetServices bound to ctx
+func (m *Store) GetServicesContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetServices()
+}
+
+// GetOperations returns the sorted operations of a given service
 func (m *Store) GetOperations(service string) ([]string, error) {
 	m.RLock()
 	defer m.RUnlock()
@@ -156,75 +267,142 @@ func (m *Store) GetOperations(service string) ([]string, error) {
 		for ops := range operations {
 			retMe = append(retMe, ops)
 		}
+		sort.Strings(retMe)
 		return retMe, nil
 	}
 	return []string{}, nil
 }
 
-// FindTraces returns all traces in the query parameters are satisfied by a trace's span
+// GetOperationsContext is GetOperations bound to ctx
+func (m *Store) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetOperations(service)
+}
+
+// FindTraces returns the traces that have a span of the service matching the operation, duration and
+// start time conditions, and for each of the query tags a span of the service within the start time range
+// carrying it in its tags, process tags or log fields. The traces are ordered by their most recent matching
+// span, newest first, and paginated by query.Offset and query.NumTraces.
 func (m *Store) FindTraces(query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
-	var retMe []*model.Trace
+	numTraces := query.NumTraces
+	if numTraces == 0 {
+		numTraces = defaultNumTraces
+	}
+	var matches tracesByStartTime
 	for _, trace := range m.traces {
-		if len(retMe) >= query.NumTraces {
-			return retMe, nil
-		}
-		if m.validTrace(trace, query) {
-			retMe = append(retMe, trace)
+		if latest, ok := m.validTrace(trace, query); ok {
+			matches = append(matches, traceMatch{trace: trace, latest: latest})
 		}
 	}
+	sort.Sort(matches)
+	if query.Offset >= len(matches) {
+		matches = nil
+	} else if query.Offset > 0 {
+		matches = matches[query.Offset:]
+	}
+	if len(matches) > numTraces {
+		matches = matches[:numTraces]
+	}
+	var retMe []*model.Trace
+	for _, match := range matches {
+		retMe = append(retMe, match.trace)
+	}
 	return retMe, nil
 }
 
-func (m *Store) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) bool {
+// FindTracesContext is FindTraces bound to ctx
+func (m *Store) FindTracesContext(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.FindTraces(query)
+}
+
+// FindTraceIDs returns the IDs of the traces FindTraces returns
+func (m *Store) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	traces, err := m.FindTracesContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return spanstore.TraceIDs(traces), nil
+}
+
+type traceMatch struct {
+	trace  *model.Trace
+	latest time.Time
+}
+
+// tracesByStartTime orders traces by their most recent matching span, newest first
+type tracesByStartTime []traceMatch
+
+func (t tracesByStartTime) Len() int           { return len(t) }
+func (t tracesByStartTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t tracesByStartTime) Less(i, j int) bool { return t[i].latest.After(t[j].latest) }
+
+// validTrace checks whether the trace satisfies the query, and returns the start time of its most recent matching span
+func (m *Store) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	unmatchedTags := make(map[string]string, len(query.Tags))
+	for k, v := range query.Tags {
+		unmatchedTags[k] = v
+	}
 	for _, span := range trace.Spans {
+		if !m.validSpanService(span, query) {
+			continue
+		}
+		for _, keyValue := range m.flattenTags(span) {
+			// (NB): we cannot use the KeyValue.Find function because there can be multiple tags with the same key
+			if v, ok := unmatchedTags[keyValue.Key]; ok && keyValue.AsString() == v {
+				delete(unmatchedTags, keyValue.Key)
+			}
+		}
 		if m.validSpan(span, query) {
-			return true
+			found = true
+			if span.StartTime.After(latest) {
+				latest = span.StartTime
+			}
 		}
 	}
-	return false
+	return latest, found && len(unmatchedTags) == 0
 }
 
-func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
+// validSpanService checks the conditions that apply to all spans considered by a query
+func (m *Store) validSpanService(span *model.Span, query *spanstore.TraceQueryParameters) bool {
 	if query.ServiceName != span.Process.ServiceName {
 		return false
 	}
-	if query.OperationName != "" && query.OperationName != span.OperationName {
+	if !query.StartTimeMin.IsZero() && span.StartTime.Before(query.StartTimeMin) {
 		return false
 	}
-	if query.DurationMin != 0 && span.Duration < query.DurationMin {
+	if !query.StartTimeMax.IsZero() && span.StartTime.After(query.StartTimeMax) {
 		return false
 	}
-	if query.DurationMax != 0 && span.Duration > query.DurationMax {
+	return true
+}
+
+// validSpan checks the conditions that a single span of the trace must satisfy
+func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
+	if query.OperationName != "" && query.OperationName != span.OperationName {
 		return false
 	}
-	if !query.StartTimeMin.IsZero() && span.StartTime.Before(query.StartTimeMin) {
+	if query.DurationMin != 0 && span.Duration < query.DurationMin {
 		return false
 	}
-	if !query.StartTimeMax.IsZero() && span.StartTime.After(query.StartTimeMax) {
+	if query.DurationMax != 0 && span.Duration > query.DurationMax {
 		return false
 	}
-	spanKVs := m.flattenTags(span)
-	for queryK, queryV := range query.Tags {
-		keyValueFoundAndMatches := false
-		// (NB): we cannot find the KeyValue.Find function because there can be multiple tags with the same key
-		for _, keyValue := range spanKVs {
-			if keyValue.Key == queryK && keyValue.AsString() == queryV {
-				keyValueFoundAndMatches = true
-				break
-			}
-		}
-		if !keyValueFoundAndMatches {
-			return false
-		}
-	}
 	return true
 }
 
 // TODO: this is a good candidate function to have on a span
 func (m *Store) flattenTags(span *model.Span) model.KeyValues {
-	retMe := span.Tags
+	retMe := make(model.KeyValues, 0, len(span.Tags)+len(span.Process.Tags))
+	retMe = append(retMe, span.Tags...)
 	retMe = append(retMe, span.Process.Tags...)
 	for _, l := range span.Logs {
 		retMe = append(retMe, l.Fields...)
@@ -21,12 +21,18 @@
 package memory
 
 import (
+	"context"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/memory/config"
+	"github.com/uber/jaeger/storage/dependencystore"
 	"github.com/uber/jaeger/storage/spanstore"
 )
 
@@ -146,6 +152,58 @@ func withMemoryStore(f func(store *Store)) {
 	f(NewStore())
 }
 
+func TestStoreContext(t *testing.T) {
+	withPopulatedMemoryStore(func(store *Store) {
+		var _ spanstore.ContextReader = store
+		var _ spanstore.ContextWriter = store
+		var _ dependencystore.ContextReader = store
+
+		ctx := context.Background()
+		trace, err := store.GetTraceContext(ctx, testingSpan.TraceID)
+		require.NoError(t, err)
+		assert.Equal(t, testingSpan, trace.Spans[0])
+		services, err := store.GetServicesContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{testingSpan.Process.ServiceName}, services)
+		operations, err := store.GetOperationsContext(ctx, testingSpan.Process.ServiceName)
+		require.NoError(t, err)
+		assert.Equal(t, []string{testingSpan.OperationName}, operations)
+		traces, err := store.FindTracesContext(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  testingSpan.Process.ServiceName,
+			StartTimeMin: testingSpan.StartTime.Add(-time.Hour),
+			StartTimeMax: testingSpan.StartTime.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Len(t, traces, 1)
+		traceIDs, err := store.FindTraceIDs(ctx, &spanstore.TraceQueryParameters{
+			ServiceName:  testingSpan.Process.ServiceName,
+			StartTimeMin: testingSpan.StartTime.Add(-time.Hour),
+			StartTimeMax: testingSpan.StartTime.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []model.TraceID{testingSpan.TraceID}, traceIDs)
+		assert.NoError(t, store.WriteSpanContext(ctx, childSpan1))
+		_, err = store.GetDependenciesContext(ctx, time.Now(), time.Hour)
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = store.GetTraceContext(ctx, testingSpan.TraceID)
+		assert.Equal(t, context.Canceled, err)
+		_, err = store.GetServicesContext(ctx)
+		assert.Equal(t, context.Canceled, err)
+		_, err = store.GetOperationsContext(ctx, testingSpan.Process.ServiceName)
+		assert.Equal(t, context.Canceled, err)
+		_, err = store.FindTracesContext(ctx, &spanstore.TraceQueryParameters{})
+		assert.Equal(t, context.Canceled, err)
+		_, err = store.FindTraceIDs(ctx, &spanstore.TraceQueryParameters{})
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, context.Canceled, store.WriteSpanContext(ctx, childSpan2))
+		_, err = store.GetDependenciesContext(ctx, time.Now(), time.Hour)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
 func TestStoreGetEmptyDependencies(t *testing.T) {
 	withMemoryStore(func(store *Store) {
 		links, err := store.GetDependencies(time.Now(), time.Hour)
@@ -193,7 +251,7 @@ func TestStoreGetTraceSuccess(t *testing.T) {
 func TestStoreGetTraceFailure(t *testing.T) {
 	withPopulatedMemoryStore(func(store *Store) {
 		trace, err := store.GetTrace(model.TraceID{})
-		assert.EqualError(t, err, errTraceNotFound.Error())
+		assert.Equal(t, spanstore.ErrTraceNotFound, err)
 		assert.Nil(t, trace)
 	})
 }
@@ -310,3 +368,184 @@ func TestStoreGetTrace(t *testing.T) {
 		})
 	}
 }
+
+func makeSpan(traceID uint64, spanID uint64, service, operation string) *model.Span {
+	return &model.Span{
+		TraceID:       model.TraceID{Low: traceID},
+		SpanID:        model.SpanID(spanID),
+		Process:       &model.Process{ServiceName: service},
+		OperationName: operation,
+		StartTime:     time.Unix(300, 0),
+	}
+}
+
+func TestBoundedStoreEvictsLeastRecentlyWrittenTraces(t *testing.T) {
+	metricsFactory := metrics.NewLocalFactory(0)
+	store := NewBoundedStore(config.Configuration{MaxTraces: 2}, metricsFactory)
+	require.NoError(t, store.WriteSpan(makeSpan(1, 1, "frontend", "get")))
+	require.NoError(t, store.WriteSpan(makeSpan(2, 1, "backend", "query")))
+	// trace 1 becomes the most recently written
+	require.NoError(t, store.WriteSpan(makeSpan(1, 2, "frontend", "get")))
+	require.NoError(t, store.WriteSpan(makeSpan(3, 1, "frontend", "post")))
+
+	_, err := store.GetTrace(model.TraceID{Low: 2})
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+	trace, err := store.GetTrace(model.TraceID{Low: 1})
+	require.NoError(t, err)
+	assert.Len(t, trace.Spans, 2)
+	_, err = store.GetTrace(model.TraceID{Low: 3})
+	assert.NoError(t, err)
+
+	services, err := store.GetServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend"}, services)
+	operations, err := store.GetOperations("backend")
+	require.NoError(t, err)
+	assert.Empty(t, operations)
+
+	counters, gauges := metricsFactory.Snapshot()
+	assert.EqualValues(t, 1, counters["memory-store.evicted-traces"])
+	assert.EqualValues(t, 1, counters["memory-store.evicted-spans"])
+	assert.EqualValues(t, 2, gauges["memory-store.traces"])
+	assert.EqualValues(t, 3, gauges["memory-store.spans"])
+}
+
+func TestBoundedStoreEvictsBySpanCount(t *testing.T) {
+	metricsFactory := metrics.NewLocalFactory(0)
+	store := NewBoundedStore(config.Configuration{MaxSpans: 3}, metricsFactory)
+	require.NoError(t, store.WriteSpan(makeSpan(1, 1, "frontend", "get")))
+	require.NoError(t, store.WriteSpan(makeSpan(1, 2, "frontend", "post")))
+	require.NoError(t, store.WriteSpan(makeSpan(2, 1, "frontend", "get")))
+	require.NoError(t, store.WriteSpan(makeSpan(2, 2, "frontend", "put")))
+
+	_, err := store.GetTrace(model.TraceID{Low: 1})
+	assert.Equal(t, spanstore.ErrTraceNotFound, err)
+	operations, err := store.GetOperations("frontend")
+	require.NoError(t, err)
+	sort.Strings(operations)
+	assert.Equal(t, []string{"get", "put"}, operations)
+
+	// a trace larger than the limit cannot be kept
+	for i := uint64(1); i <= 4; i++ {
+		require.NoError(t, store.WriteSpan(makeSpan(3, i, "backend", "query")))
+	}
+	services, err := store.GetServices()
+	require.NoError(t, err)
+	assert.Empty(t, services)
+
+	counters, gauges := metricsFactory.Snapshot()
+	assert.EqualValues(t, 3, counters["memory-store.evicted-traces"])
+	assert.EqualValues(t, 8, counters["memory-store.evicted-spans"])
+	assert.EqualValues(t, 0, gauges["memory-store.traces"])
+	assert.EqualValues(t, 0, gauges["memory-store.spans"])
+}
+
+func TestStoreFindTracesTagsAcrossSpans(t *testing.T) {
+	withMemoryStore(func(store *Store) {
+		root := makeSpan(1, 1, "frontend", "get")
+		root.Duration = time.Second
+		root.Tags = model.KeyValues{model.String("http.method", "GET")}
+		child := makeSpan(1, 2, "frontend", "query")
+		child.Duration = time.Millisecond
+		child.Process.Tags = model.KeyValues{model.String("hostname", "h1")}
+		child.Logs = []model.Log{{Fields: model.KeyValues{model.Bool("error", true)}}}
+		other := makeSpan(1, 3, "backend", "query")
+		other.Tags = model.KeyValues{model.String("db", "users")}
+		for _, span := range []*model.Span{root, child, other} {
+			require.NoError(t, store.WriteSpan(span))
+		}
+
+		testCases := []struct {
+			caption string
+			query   spanstore.TraceQueryParameters
+			found   bool
+		}{
+			{
+				caption: "tags in span tags, process tags and logs of different spans",
+				query:   spanstore.TraceQueryParameters{Tags: map[string]string{"http.method": "GET", "hostname": "h1", "error": "true"}},
+				found:   true,
+			},
+			{
+				caption: "tag of another service",
+				query:   spanstore.TraceQueryParameters{Tags: map[string]string{"http.method": "GET", "db": "users"}},
+			},
+			{
+				caption: "tag value mismatch",
+				query:   spanstore.TraceQueryParameters{Tags: map[string]string{"error": "false"}},
+			},
+			{
+				caption: "duration of any span",
+				query:   spanstore.TraceQueryParameters{DurationMax: 2 * time.Millisecond},
+				found:   true,
+			},
+			{
+				caption: "operation and duration must be satisfied by the same span",
+				query:   spanstore.TraceQueryParameters{OperationName: "get", DurationMax: 2 * time.Millisecond},
+			},
+			{
+				caption: "operation, duration and tags on other spans",
+				query: spanstore.TraceQueryParameters{
+					OperationName: "query",
+					DurationMin:   time.Millisecond,
+					DurationMax:   time.Millisecond,
+					Tags:          map[string]string{"http.method": "GET"},
+				},
+				found: true,
+			},
+			{
+				caption: "tags outside of the start time range",
+				query: spanstore.TraceQueryParameters{
+					StartTimeMin: time.Unix(400, 0),
+					Tags:         map[string]string{"http.method": "GET"},
+				},
+			},
+		}
+		for _, testCase := range testCases {
+			query := testCase.query
+			query.ServiceName = "frontend"
+			traces, err := store.FindTraces(&query)
+			require.NoError(t, err)
+			if testCase.found {
+				assert.Len(t, traces, 1, testCase.caption)
+			} else {
+				assert.Empty(t, traces, testCase.caption)
+			}
+		}
+	})
+}
+
+func TestStoreFindTracesOrderAndLimit(t *testing.T) {
+	withMemoryStore(func(store *Store) {
+		for i := uint64(1); i <= 150; i++ {
+			span := makeSpan(i, 1, "frontend", "get")
+			span.StartTime = time.Unix(int64(i), 0)
+			require.NoError(t, store.WriteSpan(span))
+		}
+
+		traces, err := store.FindTraces(&spanstore.TraceQueryParameters{ServiceName: "frontend", NumTraces: 3})
+		require.NoError(t, err)
+		require.Len(t, traces, 3)
+		for i, trace := range traces {
+			assert.Equal(t, model.TraceID{Low: uint64(150 - i)}, trace.Spans[0].TraceID)
+		}
+
+		traces, err = store.FindTraces(&spanstore.TraceQueryParameters{ServiceName: "frontend"})
+		require.NoError(t, err)
+		assert.Len(t, traces, defaultNumTraces)
+
+		traces, err = store.FindTraces(&spanstore.TraceQueryParameters{ServiceName: "frontend", NumTraces: 3, Offset: 3})
+		require.NoError(t, err)
+		require.Len(t, traces, 3)
+		for i, trace := range traces {
+			assert.Equal(t, model.TraceID{Low: uint64(147 - i)}, trace.Spans[0].TraceID)
+		}
+
+		traces, err = store.FindTraces(&spanstore.TraceQueryParameters{ServiceName: "frontend", Offset: 140})
+		require.NoError(t, err)
+		assert.Len(t, traces, 10)
+
+		traces, err = store.FindTraces(&spanstore.TraceQueryParameters{ServiceName: "frontend", Offset: 150})
+		require.NoError(t, err)
+		assert.Empty(t, traces)
+	})
+}
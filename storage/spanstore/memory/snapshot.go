@@ -0,0 +1,153 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+)
+
+// SaveSnapshot writes all spans of the store to the file, replacing it atomically.
+// Spans are gob encoded, like in model.Span.Hash, so that no field is lost, and
+// are written from the least to the most recently written trace.
+func (m *Store) SaveSnapshot(path string) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create snapshot file")
+	}
+	w := bufio.NewWriter(file)
+	if err := m.writeSpans(w); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "Failed to write snapshot")
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "Failed to write snapshot")
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "Failed to write snapshot")
+	}
+	return errors.Wrap(os.Rename(tmpPath, path), "Failed to replace snapshot file")
+}
+
+func (m *Store) writeSpans(w io.Writer) error {
+	m.RLock()
+	defer m.RUnlock()
+	enc := gob.NewEncoder(w)
+	for e := m.writeOrder.Front(); e != nil; e = e.Next() {
+		for _, span := range m.traces[e.Value.(model.TraceID)].Spans {
+			if err := enc.Encode(span); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadSnapshot writes the spans saved by SaveSnapshot into the store.
+// A missing file is not an error, as no snapshot has been taken yet.
+func (m *Store) LoadSnapshot(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to open snapshot file")
+	}
+	defer file.Close()
+	dec := gob.NewDecoder(bufio.NewReader(file))
+	for {
+		var span model.Span
+		if err := dec.Decode(&span); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "Failed to read snapshot")
+		}
+		if span.Process == nil {
+			span.Process = &model.Process{}
+		}
+		if err := m.WriteSpan(&span); err != nil {
+			return err
+		}
+	}
+}
+
+// Snapshotter periodically saves snapshots of a store to a file
+type Snapshotter struct {
+	store  *Store
+	path   string
+	logger *zap.Logger
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewSnapshotter starts saving snapshots of the store to the file at the given interval.
+// If the interval is not positive, only the final snapshot is saved when closing.
+func NewSnapshotter(store *Store, path string, interval time.Duration, logger *zap.Logger) *Snapshotter {
+	s := &Snapshotter{
+		store:  store,
+		path:   path,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run(interval)
+	return s
+}
+
+func (s *Snapshotter) run(interval time.Duration) {
+	defer close(s.done)
+	if interval <= 0 {
+		<-s.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.store.SaveSnapshot(s.path); err != nil {
+				s.logger.Error("Failed to save snapshot", zap.String("path", s.path), zap.Error(err))
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close stops the periodic snapshots and saves a final one
+func (s *Snapshotter) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.store.SaveSnapshot(s.path)
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/uber/jaeger/model"
+	"github.com/uber/jaeger/pkg/memory/config"
+	"github.com/uber/jaeger/storage/spanstore"
+)
+
+func withSnapshotDir(t *testing.T, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "memory-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	f(dir)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	withSnapshotDir(t, func(dir string) {
+		path := filepath.Join(dir, "snapshot")
+		store := NewStore()
+		span := &model.Span{
+			TraceID:       model.TraceID{High: 1, Low: 2},
+			SpanID:        model.SpanID(3),
+			ParentSpanID:  model.SpanID(4),
+			OperationName: "get",
+			References:    []model.SpanRef{{RefType: model.FollowsFrom, TraceID: model.TraceID{Low: 2}, SpanID: model.SpanID(4)}},
+			Flags:         model.Flags(1),
+			StartTime:     time.Unix(300, 123456789).UTC(),
+			Duration:      1500 * time.Nanosecond,
+			Tags:          model.KeyValues{model.String("a", "b"), model.Int64("c", 5), model.Binary("d", []byte{1, 2})},
+			Logs:          []model.Log{{Timestamp: time.Unix(300, 5).UTC(), Fields: model.KeyValues{model.Bool("e", true)}}},
+			Process:       model.NewProcess("frontend", model.KeyValues{model.Float64("f", 1.5)}),
+			Warnings:      []string{"clock skew"},
+		}
+		require.NoError(t, store.WriteSpan(span))
+		require.NoError(t, store.WriteSpan(makeSpan(5, 1, "backend", "query")))
+		require.NoError(t, store.SaveSnapshot(path))
+
+		restored := NewStore()
+		require.NoError(t, restored.LoadSnapshot(path))
+		trace, err := restored.GetTrace(span.TraceID)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Span{span}, trace.Spans)
+		services, err := restored.GetServices()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backend", "frontend"}, services)
+	})
+}
+
+func TestSnapshotPreservesWriteOrder(t *testing.T) {
+	withSnapshotDir(t, func(dir string) {
+		path := filepath.Join(dir, "snapshot")
+		store := NewStore()
+		require.NoError(t, store.WriteSpan(makeSpan(1, 1, "frontend", "get")))
+		require.NoError(t, store.WriteSpan(makeSpan(2, 1, "frontend", "get")))
+		require.NoError(t, store.WriteSpan(makeSpan(1, 2, "frontend", "get")))
+		require.NoError(t, store.SaveSnapshot(path))
+
+		// trace 2 is the least recently written, so it is evicted when the limit is lowered
+		restored := NewBoundedStore(config.Configuration{MaxTraces: 1}, metrics.NullFactory)
+		require.NoError(t, restored.LoadSnapshot(path))
+		_, err := restored.GetTrace(model.TraceID{Low: 2})
+		assert.Equal(t, spanstore.ErrTraceNotFound, err)
+		trace, err := restored.GetTrace(model.TraceID{Low: 1})
+		require.NoError(t, err)
+		assert.Len(t, trace.Spans, 2)
+	})
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	withSnapshotDir(t, func(dir string) {
+		store := NewStore()
+		assert.NoError(t, store.LoadSnapshot(filepath.Join(dir, "missing")))
+
+		corrupted := filepath.Join(dir, "corrupted")
+		require.NoError(t, ioutil.WriteFile(corrupted, []byte("not a snapshot"), 0644))
+		assert.Error(t, store.LoadSnapshot(corrupted))
+
+		assert.Error(t, store.SaveSnapshot(filepath.Join(dir, "missing", "snapshot")))
+	})
+}
+
+func TestSnapshotter(t *testing.T) {
+	withSnapshotDir(t, func(dir string) {
+		path := filepath.Join(dir, "snapshot")
+		store := NewStore()
+		require.NoError(t, store.WriteSpan(makeSpan(1, 1, "frontend", "get")))
+		snapshotter := NewSnapshotter(store, path, time.Millisecond, zap.NewNop())
+
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(path); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		_, err := os.Stat(path)
+		require.NoError(t, err, "a periodic snapshot must be saved")
+
+		require.NoError(t, store.WriteSpan(makeSpan(2, 1, "backend", "get")))
+		require.NoError(t, snapshotter.Close())
+		restored := NewStore()
+		require.NoError(t, restored.LoadSnapshot(path))
+		services, err := restored.GetServices()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backend", "frontend"}, services)
+	})
+}
+
+func TestSnapshotterOnlyOnClose(t *testing.T) {
+	withSnapshotDir(t, func(dir string) {
+		path := filepath.Join(dir, "snapshot")
+		snapshotter := NewSnapshotter(NewStore(), path, 0, zap.NewNop())
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+		require.NoError(t, snapshotter.Close())
+		_, err = os.Stat(path)
+		assert.NoError(t, err)
+	})
+}
@@ -21,6 +21,7 @@
 package metrics
 
 import (
+	"context"
 	"time"
 
 	"github.com/uber/jaeger-lib/metrics"
@@ -31,8 +32,9 @@ import (
 
 // ReadMetricsDecorator wraps a spanstore.Reader and collects metrics around each read operation.
 type ReadMetricsDecorator struct {
-	spanReader           spanstore.Reader
+	spanReader           spanstore.ContextReader
 	findTracesMetrics    *queryMetrics
+	findTraceIDsMetrics  *queryMetrics
 	getTraceMetrics      *queryMetrics
 	getServicesMetrics   *queryMetrics
 	getOperationsMetrics *queryMetrics
@@ -62,8 +64,9 @@ func (q *queryMetrics) emit(err error, latency time.Duration, responses int) {
 // NewReadMetricsDecorator returns a new ReadMetricsDecorator.
 func NewReadMetricsDecorator(spanReader spanstore.Reader, metricsFactory metrics.Factory) *ReadMetricsDecorator {
 	return &ReadMetricsDecorator{
-		spanReader:           spanReader,
+		spanReader:           spanstore.NewContextReader(spanReader),
 		findTracesMetrics:    buildQueryMetrics("FindTraces", metricsFactory),
+		findTraceIDsMetrics:  buildQueryMetrics("FindTraceIDs", metricsFactory),
 		getTraceMetrics:      buildQueryMetrics("GetTrace", metricsFactory),
 		getServicesMetrics:   buildQueryMetrics("GetServices", metricsFactory),
 		getOperationsMetrics: buildQueryMetrics("GetOperations", metricsFactory),
@@ -79,32 +82,98 @@ func buildQueryMetrics(namespace string, metricsFactory metrics.Factory) *queryM
 
 // FindTraces implements spanstore.Reader#FindTraces
 func (m *ReadMetricsDecorator) FindTraces(traceQuery *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	return m.FindTracesContext(context.Background(), traceQuery)
+}
+
+// FindTracesContext implements spanstore.ContextReader#FindTracesContext
+func (m *ReadMetricsDecorator) FindTracesContext(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]*model.Trace, error) {
 	start := time.Now()
-	retMe, err := m.spanReader.FindTraces(traceQuery)
+	retMe, err := m.spanReader.FindTracesContext(ctx, traceQuery)
 	m.findTracesMetrics.emit(err, time.Since(start), len(retMe))
 	return retMe, err
 }
 
+// FindTraceIDs implements spanstore.ContextReader#FindTraceIDs
+func (m *ReadMetricsDecorator) FindTraceIDs(
+	ctx context.Context,
+	traceQuery *spanstore.TraceQueryParameters,
+) ([]model.TraceID, error) {
+	start := time.Now()
+	retMe, err := m.spanReader.FindTraceIDs(ctx, traceQuery)
+	m.findTraceIDsMetrics.emit(err, time.Since(start), len(retMe))
+	return retMe, err
+}
+
 // GetTrace implements spanstore.Reader#GetTrace
 func (m *ReadMetricsDecorator) GetTrace(traceID model.TraceID) (*model.Trace, error) {
+	return m.GetTraceContext(context.Background(), traceID)
+}
+
+// GetTraceContext implements spanstore.ContextReader#GetTraceContext
+func (m *ReadMetricsDecorator) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
 	start := time.Now()
-	retMe, err := m.spanReader.GetTrace(traceID)
+	retMe, err := m.spanReader.GetTraceContext(ctx, traceID)
 	m.getTraceMetrics.emit(err, time.Since(start), 1)
 	return retMe, err
 }
 
 // GetServices implements spanstore.Reader#GetServices
 func (m *ReadMetricsDecorator) GetServices() ([]string, error) {
+	return m.GetServicesContext(context.Background())
+}
+
+// GetServicesContext implements spanstore.ContextReader#GetServicesContext
+func (m *ReadMetricsDecorator) GetServicesContext(ctx context.Context) ([]string, error) {
 	start := time.Now()
-	retMe, err := m.spanReader.GetServices()
+	retMe, err := m.spanReader.GetServicesContext(ctx)
 	m.getServicesMetrics.emit(err, time.Since(start), len(retMe))
 	return retMe, err
 }
 
 // GetOperations implements spanstore.Reader#GetOperations
 func (m *ReadMetricsDecorator) GetOperations(service string) ([]string, error) {
+	return m.GetOperationsContext(context.Background(), service)
+}
+
+// GetOperationsContext implements spanstore.ContextReader#GetOperationsContext
+func (m *ReadMetricsDecorator) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
 	start := time.Now()
-	retMe, err := m.spanReader.GetOperations(service)
+	retMe, err := m.spanReader.GetOperationsContext(ctx, service)
 	m.getOperationsMetrics.emit(err, time.Since(start), len(retMe))
 	return retMe, err
 }
+
+// WriteMetricsDecorator wraps a spanstore.Writer and collects metrics around each write operation.
+type WriteMetricsDecorator struct {
+	spanWriter       spanstore.ContextWriter
+	writeSpanMetrics *WriteMetrics
+}
+
+// NewWriteMetricsDecorator returns a new WriteMetricsDecorator.
+func NewWriteMetricsDecorator(spanWriter spanstore.Writer, metricsFactory metrics.Factory) *WriteMetricsDecorator {
+	return &WriteMetricsDecorator{
+		spanWriter:       spanstore.NewContextWriter(spanWriter),
+		writeSpanMetrics: NewWriteMetrics(metricsFactory, "WriteSpan"),
+	}
+}
+
+// WriteSpan implements spanstore.Writer#WriteSpan
+func (m *WriteMetricsDecorator) WriteSpan(span *model.Span) error {
+	return m.WriteSpanContext(context.Background(), span)
+}
+
+// WriteSpanContext implements spanstore.ContextWriter#WriteSpanContext
+func (m *WriteMetricsDecorator) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	start := time.Now()
+	err := m.spanWriter.WriteSpanContext(ctx, span)
+	m.writeSpanMetrics.Emit(err, time.Since(start))
+	return err
+}
+
+// Close closes the underlying span writer if it is an io.Closer
+func (m *WriteMetricsDecorator) Close() error {
+	return spanstore.CloseWriter(m.spanWriter)
+}
@@ -21,6 +21,7 @@
 package metrics_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -74,6 +75,46 @@ func TestSuccessfulUnderlyingCalls(t *testing.T) {
 	checkExpectedExistingAndNonExistentCounters(t, counters, expecteds, gauges, existingKeys, nonExistentKeys)
 }
 
+func TestContextPassedToUnderlyingCalls(t *testing.T) {
+	mf := metrics.NewLocalFactory(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockReader := mocks.ContextReader{}
+	mrs := NewReadMetricsDecorator(&mockReader, mf)
+	mockReader.On("GetServicesContext", ctx).Return([]string{}, nil)
+	mrs.GetServicesContext(ctx)
+	mockReader.On("GetOperationsContext", ctx, "something").Return([]string{}, nil)
+	mrs.GetOperationsContext(ctx, "something")
+	mockReader.On("GetTraceContext", ctx, model.TraceID{}).Return(&model.Trace{}, nil)
+	mrs.GetTraceContext(ctx, model.TraceID{})
+	mockReader.On("FindTracesContext", ctx, &spanstore.TraceQueryParameters{}).Return(nil, errors.New("Failure"))
+	mrs.FindTracesContext(ctx, &spanstore.TraceQueryParameters{})
+	mockReader.On("FindTraceIDs", ctx, &spanstore.TraceQueryParameters{}).Return([]model.TraceID{{Low: 1}}, nil)
+	mrs.FindTraceIDs(ctx, &spanstore.TraceQueryParameters{})
+
+	mockWriter := mocks.ContextWriter{}
+	mws := NewWriteMetricsDecorator(&mockWriter, mf)
+	mockWriter.On("WriteSpanContext", ctx, &model.Span{}).Return(nil)
+	mws.WriteSpanContext(ctx, &model.Span{})
+
+	mockReader.AssertExpectations(t)
+	mockWriter.AssertExpectations(t)
+	counters, _ := mf.Snapshot()
+	expecteds := map[string]int64{
+		"GetServices.successes":   1,
+		"GetOperations.successes": 1,
+		"GetTrace.successes":      1,
+		"FindTraces.errors":       1,
+		"FindTraceIDs.successes":  1,
+		"WriteSpan.attempts":      1,
+		"WriteSpan.inserts":       1,
+	}
+	for k, v := range expecteds {
+		assert.EqualValues(t, v, counters[k], k)
+	}
+}
+
 func checkExpectedExistingAndNonExistentCounters(t *testing.T, actualCounters, expectedCounters, actualGauges map[string]int64, existingKeys, nonExistentKeys []string) {
 	for k, v := range expectedCounters {
 		assert.EqualValues(t, v, actualCounters[k], k)
@@ -131,3 +172,27 @@ func TestFailingUnderlyingCalls(t *testing.T) {
 
 	checkExpectedExistingAndNonExistentCounters(t, counters, expecteds, gauges, existingKeys, nonExistentKeys)
 }
+
+func TestWriteMetricsDecorator(t *testing.T) {
+	mf := metrics.NewLocalFactory(0)
+
+	mockWriter := mocks.Writer{}
+	mws := NewWriteMetricsDecorator(&mockWriter, mf)
+	okSpan, badSpan := &model.Span{SpanID: 1}, &model.Span{SpanID: 2}
+	mockWriter.On("WriteSpan", okSpan).Return(nil)
+	mockWriter.On("WriteSpan", badSpan).Return(errors.New("Failure"))
+	assert.NoError(t, mws.WriteSpan(okSpan))
+	assert.EqualError(t, mws.WriteSpan(badSpan), "Failure")
+
+	counters, gauges := mf.Snapshot()
+	expecteds := map[string]int64{
+		"WriteSpan.attempts": 2,
+		"WriteSpan.inserts":  1,
+		"WriteSpan.errors":   1,
+	}
+	existingKeys := []string{
+		"WriteSpan.latency-ok.P50",
+		"WriteSpan.latency-err.P50",
+	}
+	checkExpectedExistingAndNonExistentCounters(t, counters, expecteds, gauges, existingKeys, nil)
+}
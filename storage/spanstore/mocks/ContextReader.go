@@ -0,0 +1,240 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mocks
+
+import context "context"
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/uber/jaeger/model"
+import spanstore "github.com/uber/jaeger/storage/spanstore"
+
+// ContextReader is an autogenerated mock type for the ContextReader type
+type ContextReader struct {
+	mock.Mock
+}
+
+// FindTraceIDs provides a mock function with given fields: ctx, query
+func (_m *ContextReader) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []model.TraceID
+	if rf, ok := ret.Get(0).(func(context.Context, *spanstore.TraceQueryParameters) []model.TraceID); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.TraceID)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *spanstore.TraceQueryParameters) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindTraces provides a mock function with given fields: query
+func (_m *ContextReader) FindTraces(query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	ret := _m.Called(query)
+
+	var r0 []*model.Trace
+	if rf, ok := ret.Get(0).(func(*spanstore.TraceQueryParameters) []*model.Trace); ok {
+		r0 = rf(query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Trace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*spanstore.TraceQueryParameters) error); ok {
+		r1 = rf(query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindTracesContext provides a mock function with given fields: ctx, query
+func (_m *ContextReader) FindTracesContext(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []*model.Trace
+	if rf, ok := ret.Get(0).(func(context.Context, *spanstore.TraceQueryParameters) []*model.Trace); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Trace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *spanstore.TraceQueryParameters) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOperations provides a mock function with given fields: service
+func (_m *ContextReader) GetOperations(service string) ([]string, error) {
+	ret := _m.Called(service)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(service)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(service)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOperationsContext provides a mock function with given fields: ctx, service
+func (_m *ContextReader) GetOperationsContext(ctx context.Context, service string) ([]string, error) {
+	ret := _m.Called(ctx, service)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, service)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, service)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServices provides a mock function with given fields:
+func (_m *ContextReader) GetServices() ([]string, error) {
+	ret := _m.Called()
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServicesContext provides a mock function with given fields: ctx
+func (_m *ContextReader) GetServicesContext(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTrace provides a mock function with given fields: traceID
+func (_m *ContextReader) GetTrace(traceID model.TraceID) (*model.Trace, error) {
+	ret := _m.Called(traceID)
+
+	var r0 *model.Trace
+	if rf, ok := ret.Get(0).(func(model.TraceID) *model.Trace); ok {
+		r0 = rf(traceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Trace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(model.TraceID) error); ok {
+		r1 = rf(traceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTraceContext provides a mock function with given fields: ctx, traceID
+func (_m *ContextReader) GetTraceContext(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	ret := _m.Called(ctx, traceID)
+
+	var r0 *model.Trace
+	if rf, ok := ret.Get(0).(func(context.Context, model.TraceID) *model.Trace); ok {
+		r0 = rf(ctx, traceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Trace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.TraceID) error); ok {
+		r1 = rf(ctx, traceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+var _ spanstore.ContextReader = (*ContextReader)(nil)
@@ -0,0 +1,61 @@
+// Copyright (c) 2017 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mocks
+
+import context "context"
+import mock "github.com/stretchr/testify/mock"
+import model "github.com/uber/jaeger/model"
+import spanstore "github.com/uber/jaeger/storage/spanstore"
+
+// ContextWriter is an autogenerated mock type for the ContextWriter type
+type ContextWriter struct {
+	mock.Mock
+}
+
+// WriteSpan provides a mock function with given fields: span
+func (_m *ContextWriter) WriteSpan(span *model.Span) error {
+	ret := _m.Called(span)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.Span) error); ok {
+		r0 = rf(span)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WriteSpanContext provides a mock function with given fields: ctx, span
+func (_m *ContextWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	ret := _m.Called(ctx, span)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Span) error); ok {
+		r0 = rf(ctx, span)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ spanstore.ContextWriter = (*ContextWriter)(nil)
@@ -21,29 +21,115 @@
 package spanstore
 
 import (
+	"context"
+	"io"
+	"strconv"
+
 	"github.com/uber/jaeger/model"
 	"github.com/uber/jaeger/pkg/multierror"
 )
 
 // MultiplexWriter is a span Writer that tries to save spans into several underlying span Writers
 type MultiplexWriter struct {
-	spanWriters []Writer
+	names       []string
+	spanWriters []ContextWriter
 }
 
-// NewMultiplexWriter creates a MultiplexWriter
+// NewMultiplexWriter creates a MultiplexWriter whose span writers are named after their position
 func NewMultiplexWriter(spanWriters ...Writer) *MultiplexWriter {
+	names := make([]string, len(spanWriters))
+	for i := range spanWriters {
+		names[i] = strconv.Itoa(i)
+	}
+	return NewNamedMultiplexWriter(names, spanWriters)
+}
+
+// NewNamedMultiplexWriter creates a MultiplexWriter whose span writers are identified by the names at the
+// same positions, for instance their storage types, so that WriteSpanTo can address them across restarts
+// even if the order of the span writers changes.
+func NewNamedMultiplexWriter(names []string, spanWriters []Writer) *MultiplexWriter {
+	contextWriters := make([]ContextWriter, len(spanWriters))
+	for i, writer := range spanWriters {
+		contextWriters[i] = NewContextWriter(writer)
+	}
 	return &MultiplexWriter{
-		spanWriters: spanWriters,
+		names:       names,
+		spanWriters: contextWriters,
 	}
 }
 
 // WriteSpan calls WriteSpan on each span writer. It will sum up failures, it is not transactional
 func (c *MultiplexWriter) WriteSpan(span *model.Span) error {
+	return c.WriteSpanContext(context.Background(), span)
+}
+
+// WriteSpanContext is WriteSpan bound to ctx, which is passed to each span writer
+func (c *MultiplexWriter) WriteSpanContext(ctx context.Context, span *model.Span) error {
+	_, err := c.WriteSpanTo(ctx, span, nil)
+	return err
+}
+
+// WriteSpanTo calls WriteSpanContext on the span writers with the given names, or on all of them if names
+// is nil or if any of the names is not the name of a span writer, since the span writers may have been
+// reconfigured since the names were recorded. It returns the names of the span writers that failed along
+// with their errors, so that only these can be retried.
+func (c *MultiplexWriter) WriteSpanTo(ctx context.Context, span *model.Span, names []string) ([]string, error) {
+	indices := c.indices(names)
+	var failed []string
+	var errors []error
+	for _, i := range indices {
+		if err := c.spanWriters[i].WriteSpanContext(ctx, span); err != nil {
+			failed = append(failed, c.names[i])
+			errors = append(errors, err)
+		}
+	}
+	return failed, multierror.Wrap(errors)
+}
+
+func (c *MultiplexWriter) indices(names []string) []int {
+	all := make([]int, len(c.spanWriters))
+	for i := range all {
+		all[i] = i
+	}
+	if names == nil {
+		return all
+	}
+	indices := make([]int, 0, len(names))
+	for _, name := range names {
+		index := -1
+		for i := range c.names {
+			if c.names[i] == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return all
+		}
+		indices = append(indices, index)
+	}
+	return indices
+}
+
+// Close closes each span writer that is an io.Closer
+func (c *MultiplexWriter) Close() error {
 	var errors []error
 	for _, writer := range c.spanWriters {
-		if err := writer.WriteSpan(span); err != nil {
+		if err := CloseWriter(writer); err != nil {
 			errors = append(errors, err)
 		}
 	}
 	return multierror.Wrap(errors)
 }
+
+// CloseWriter closes the span writer if it is an io.Closer, for instance to flush the spans it buffers.
+// Writers adapted by NewContextWriter are unwrapped first.
+func CloseWriter(writer Writer) error {
+	if w, ok := writer.(contextWriter); ok {
+		writer = w.Writer
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
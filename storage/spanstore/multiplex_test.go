@@ -21,6 +21,7 @@
 package spanstore_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/uber/jaeger/model"
 	. "github.com/uber/jaeger/storage/spanstore"
+	"github.com/uber/jaeger/storage/spanstore/mocks"
 )
 
 var errIWillAlwaysFail = errors.New("ErrProneWriteSpanStore will always fail")
@@ -59,3 +61,72 @@ func TestCompositeWriteSpanStoreFirstFailure(t *testing.T) {
 	c := NewMultiplexWriter(&errProneWriteSpanStore{}, &noopWriteSpanStore{})
 	assert.Equal(t, errIWillAlwaysFail, c.WriteSpan(nil))
 }
+
+func TestCompositeWriteSpanStoreContext(t *testing.T) {
+	writer := &mocks.ContextWriter{}
+	c := NewMultiplexWriter(writer, &noopWriteSpanStore{})
+	ctx, cancel := context.WithCancel(context.Background())
+	span := &model.Span{}
+	writer.On("WriteSpanContext", ctx, span).Return(nil).Once()
+	assert.NoError(t, c.WriteSpanContext(ctx, span))
+	writer.AssertExpectations(t)
+
+	cancel()
+	writer.On("WriteSpanContext", ctx, span).Return(ctx.Err()).Once()
+	assert.EqualError(t, c.WriteSpanContext(ctx, span), "[context canceled, context canceled]")
+}
+
+type closingWriteSpanStore struct {
+	noopWriteSpanStore
+	err    error
+	closed bool
+}
+
+func (c *closingWriteSpanStore) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestCompositeWriteSpanStoreClose(t *testing.T) {
+	first := &closingWriteSpanStore{err: errIWillAlwaysFail}
+	second := &closingWriteSpanStore{}
+	c := NewMultiplexWriter(first, &noopWriteSpanStore{}, second)
+	assert.Equal(t, errIWillAlwaysFail, c.Close())
+	assert.True(t, first.closed)
+	assert.True(t, second.closed)
+}
+
+func TestCompositeWriteSpanStoreWriteSpanTo(t *testing.T) {
+	first, second := &mocks.Writer{}, &mocks.Writer{}
+	c := NewNamedMultiplexWriter(
+		[]string{"first", "failing", "second"},
+		[]Writer{first, &errProneWriteSpanStore{}, second},
+	)
+	span := &model.Span{}
+	first.On("WriteSpan", span).Return(nil).Once()
+	second.On("WriteSpan", span).Return(errIWillAlwaysFail).Once()
+	failed, err := c.WriteSpanTo(context.Background(), span, nil)
+	assert.Equal(t, []string{"failing", "second"}, failed)
+	assert.EqualError(t, err, fmt.Sprintf("[%s, %s]", errIWillAlwaysFail, errIWillAlwaysFail))
+
+	second.On("WriteSpan", span).Return(nil).Once()
+	failed, err = c.WriteSpanTo(context.Background(), span, []string{"second"})
+	assert.Empty(t, failed)
+	assert.NoError(t, err)
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+}
+
+func TestCompositeWriteSpanStoreWriteSpanToUnknownWriter(t *testing.T) {
+	first, second := &mocks.Writer{}, &mocks.Writer{}
+	c := NewNamedMultiplexWriter([]string{"first", "second"}, []Writer{first, second})
+	span := &model.Span{}
+	first.On("WriteSpan", span).Return(nil).Once()
+	second.On("WriteSpan", span).Return(nil).Once()
+	// the span is written with all span writers rather than with none
+	failed, err := c.WriteSpanTo(context.Background(), span, []string{"second", "removed"})
+	assert.Empty(t, failed)
+	assert.NoError(t, err)
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+}
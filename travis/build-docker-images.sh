@@ -24,7 +24,7 @@ export DOCKER_NAMESPACE=jaegertracing
 export DOCKER_TAG=${COMMIT:?'missing COMMIT env var'}
 make docker
 
-for component in agent cassandra-schema collector query
+for component in agent cassandra-schema collector ingester query
 do
   export REPO="jaegertracing/jaeger-${component}"
   bash ./travis/upload-to-docker.sh
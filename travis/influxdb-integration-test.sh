@@ -0,0 +1,10 @@
+#!/bin/bash
+
+set -e
+
+docker pull influxdb:1.3
+export CID=$(docker run -d -p 8086:8086 influxdb:1.3)
+export INFLUXDB_INTEGRATION_TEST=test
+make influxdb-integration-test
+unset INFLUXDB_INTEGRATION_TEST
+docker kill $CID